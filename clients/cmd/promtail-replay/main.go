@@ -0,0 +1,170 @@
+// Command promtail-replay reads a capture file produced by
+// `promtail -dry-run -dry-run-capture-file=...` and pushes its entries
+// through the normal Promtail client code, for load testing and migration
+// rehearsal against a new Loki instance.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+func main() {
+	var (
+		file          string
+		url           string
+		tenantID      string
+		shiftToNow    bool
+		speed         float64
+		multiply      int
+		progressEvery int
+		maxLineSize   int
+		batchSize     int
+	)
+
+	flag.StringVar(&file, "file", "", "Capture file to replay (newline-delimited JSON produced by promtail -dry-run-capture-file).")
+	flag.StringVar(&url, "url", "", "Loki push URL to replay entries to, e.g. http://localhost:3100/loki/api/v1/push.")
+	flag.StringVar(&tenantID, "tenant-id", "", "Override the tenant ID recorded in the capture file for every entry.")
+	flag.BoolVar(&shiftToNow, "shift-to-now", false, "Shift every entry's timestamp so that the first entry is emitted at the current time.")
+	flag.Float64Var(&speed, "speed", 1, "Playback speed multiplier. 2 replays twice as fast as it was captured, 0.5 half as fast. 0 disables inter-entry waiting entirely.")
+	flag.IntVar(&multiply, "multiply", 1, "Multiply ingest volume by duplicating every stream this many times, each copy tagged with a __replay_copy__ label.")
+	flag.IntVar(&progressEvery, "progress-every", 10000, "Log a progress line every this many entries sent.")
+	flag.IntVar(&maxLineSize, "client.max-line-size", 0, "Maximum line size to send to Loki. 0 means no limit.")
+	flag.IntVar(&batchSize, "client.batch-size-bytes", client.BatchSize, "Maximum batch size to accrue before sending.")
+	flag.Parse()
+
+	logger := util_log.Logger
+
+	if file == "" || url == "" {
+		fmt.Fprintln(os.Stderr, "both -file and -url are required")
+		os.Exit(1)
+	}
+	if multiply < 1 {
+		multiply = 1
+	}
+
+	cfg := client.Config{}
+	if err := cfg.URL.Set(url); err != nil {
+		level.Error(logger).Log("msg", "invalid url", "error", err)
+		os.Exit(1)
+	}
+	cfg.BatchWait = client.BatchWait
+	cfg.BatchSize = batchSize
+	cfg.TenantID = tenantID
+
+	metrics := client.NewMetrics(nil)
+	c, err := client.New(metrics, cfg, 0, maxLineSize, false, logger)
+	if err != nil {
+		level.Error(logger).Log("msg", "could not create client", "error", err)
+		os.Exit(1)
+	}
+	defer c.Stop()
+
+	if err := replay(logger, c, file, tenantID, shiftToNow, speed, multiply, progressEvery); err != nil {
+		level.Error(logger).Log("msg", "replay failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func replay(logger log.Logger, c client.Client, file, tenantOverride string, shiftToNow bool, speed float64, multiply, progressEvery int) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("could not open capture file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var (
+		count       int
+		firstTs     time.Time
+		firstWallTs time.Time
+		shiftDelta  time.Duration
+	)
+
+	for scanner.Scan() {
+		var ce client.CaptureEntry
+		if err := json.Unmarshal(scanner.Bytes(), &ce); err != nil {
+			level.Warn(logger).Log("msg", "skipping malformed capture line", "error", err)
+			continue
+		}
+
+		if firstTs.IsZero() {
+			firstTs = ce.Timestamp
+			firstWallTs = time.Now()
+			if shiftToNow {
+				shiftDelta = firstWallTs.Sub(firstTs)
+			}
+		} else if speed > 0 {
+			// Wait until it's time to emit this entry, scaled by speed.
+			target := firstWallTs.Add(time.Duration(float64(ce.Timestamp.Sub(firstTs)) / speed))
+			if d := time.Until(target); d > 0 {
+				time.Sleep(d)
+			}
+		}
+
+		lbs, err := syntax.ParseLabels(ce.Labels)
+		if err != nil {
+			level.Warn(logger).Log("msg", "skipping entry with unparsable labels", "labels", ce.Labels, "error", err)
+			continue
+		}
+		labelSet := make(model.LabelSet, len(lbs)+1)
+		for _, l := range lbs {
+			labelSet[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+		}
+
+		tenant := ce.Tenant
+		if tenantOverride != "" {
+			tenant = tenantOverride
+		}
+		if tenant != "" {
+			labelSet[client.ReservedLabelTenantID] = model.LabelValue(tenant)
+		}
+
+		ts := ce.Timestamp.Add(shiftDelta)
+
+		for i := 0; i < multiply; i++ {
+			shardLabels := labelSet
+			if i > 0 {
+				shardLabels = labelSet.Clone()
+				shardLabels["__replay_copy__"] = model.LabelValue(fmt.Sprintf("%d", i))
+			}
+			c.Chan() <- api.Entry{
+				Labels: shardLabels,
+				Entry: logproto.Entry{
+					Timestamp: ts,
+					Line:      ce.Line,
+				},
+			}
+		}
+
+		count += multiply
+		if progressEvery > 0 && count%progressEvery == 0 {
+			level.Info(logger).Log("msg", "replay progress", "entries_sent", count)
+		}
+	}
+
+	if err := scanner.Err(); err != nil && err != io.EOF {
+		return fmt.Errorf("error reading capture file: %w", err)
+	}
+
+	level.Info(logger).Log("msg", "replay complete", "entries_sent", count)
+	return nil
+}
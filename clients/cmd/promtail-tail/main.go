@@ -0,0 +1,329 @@
+// Command promtail-tail is a one-shot "tail and exit" debugging tool: point
+// it at a promtail config file, a docker scrape job, and a running
+// container, and it prints what promtail would ship for that container,
+// with the job's relabel_configs and pipeline_stages applied, then exits
+// with a summary. It's meant for answering "what would promtail do with
+// this config" without standing up the full agent, so unlike the real
+// docker target it never writes to a positions file.
+//
+// It deliberately doesn't reuse targets/docker.Target: that type is tuned
+// for a long-running agent (adaptive per-line buffering, reconnect
+// backoff, pause labels, Swarm/compose label enrichment) and, notably,
+// ignores relabel_configs' keep/drop decision, forwarding every entry
+// regardless. This command wants exactly that decision, so it applies
+// relabel_configs itself against a minimal label set: the same
+// __meta_docker_container_* labels service discovery would attach, plus
+// __meta_docker_container_log_stream. Compose/Swarm labels and
+// level-detection aren't available here.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+
+	"github.com/grafana/loki/v3/clients/pkg/logentry/stages"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client"
+	promtail_config "github.com/grafana/loki/v3/clients/pkg/promtail/config"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/util/cfg"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+// The __meta_docker_container_* labels discovery/moby attaches to a
+// container, and __meta_docker_container_log_stream, which
+// targets/docker.Target adds itself once it starts reading. Mirrored here
+// as literals, the same way positions.go hardcodes "journal-" rather than
+// importing an unexported constant from another package.
+const (
+	metaLabelContainerID    = model.MetaLabelPrefix + "docker_container_id"
+	metaLabelContainerName  = model.MetaLabelPrefix + "docker_container_name"
+	metaLabelContainerImage = model.MetaLabelPrefix + "docker_container_image"
+	metaLabelLogStream      = model.MetaLabelPrefix + "docker_container_log_stream"
+)
+
+// Config wraps promtail's own config so -config.file loads relabel_configs
+// and pipeline_stages the same way the real agent would.
+type Config struct {
+	promtail_config.Config `yaml:",inline"`
+	configFile             string
+}
+
+func (c *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFile, "config.file", "", "Promtail config file to load the target job's relabel_configs and pipeline_stages from.")
+	c.Config.RegisterFlags(f)
+}
+
+// Clone takes advantage of pass-by-value semantics to return a distinct
+// *Config, the same way clients/cmd/promtail's Config.Clone does.
+func (c *Config) Clone() flagext.Registerer {
+	return func(c Config) *Config { return &c }(*c)
+}
+
+func main() {
+	var (
+		jobName      string
+		containerRef string
+		host         string
+		tailDuration time.Duration
+		tailLines    int
+	)
+	flag.StringVar(&jobName, "job", "", "job_name of the scrape_configs entry to load relabel_configs/pipeline_stages from. Required if the config has more than one docker_sd_configs job.")
+	flag.StringVar(&containerRef, "container", "", "Name or ID of the container to tail.")
+	flag.StringVar(&host, "host", "", "Docker daemon address, e.g. unix:///var/run/docker.sock. Defaults to the job's first docker_sd_configs host, or the environment (DOCKER_HOST) if that's empty too.")
+	flag.DurationVar(&tailDuration, "duration", 0, "Stop and print the summary after this long. At least one of -duration or -lines is required.")
+	flag.IntVar(&tailLines, "lines", 0, "Stop and print the summary after reading this many lines. At least one of -duration or -lines is required.")
+
+	var config Config
+	args := os.Args[1:]
+	if err := cfg.DefaultUnmarshal(&config, args, flag.CommandLine); err != nil {
+		fmt.Println("Unable to parse config:", err)
+		os.Exit(1)
+	}
+
+	if containerRef == "" {
+		fmt.Fprintln(os.Stderr, "promtail-tail: -container is required")
+		os.Exit(1)
+	}
+	if tailDuration <= 0 && tailLines <= 0 {
+		fmt.Fprintln(os.Stderr, "promtail-tail: at least one of -duration or -lines is required")
+		os.Exit(1)
+	}
+
+	job, err := findJob(config.ScrapeConfig, jobName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "promtail-tail:", err)
+		os.Exit(1)
+	}
+
+	if host == "" && len(job.DockerSDConfigs) > 0 {
+		host = job.DockerSDConfigs[0].Host
+	}
+
+	logger := util_log.Logger
+	summary, err := tail(logger, config.Config, job, host, containerRef, tailDuration, tailLines)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "promtail-tail:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf(
+		"\nsummary: lines_read=%d dropped_by_relabel=%d label_sets_produced=%d\n",
+		summary.linesRead, summary.droppedByRelabel, len(summary.labelSets),
+	)
+}
+
+// findJob returns the scrape_configs entry to tail from. With jobName set,
+// it must match a docker_sd_configs job by name. Left empty, exactly one
+// docker_sd_configs job must exist in the config to pick unambiguously.
+func findJob(scrapeConfigs []scrapeconfig.Config, jobName string) (scrapeconfig.Config, error) {
+	var dockerJobs []scrapeconfig.Config
+	for _, sc := range scrapeConfigs {
+		if len(sc.DockerSDConfigs) == 0 {
+			continue
+		}
+		if jobName != "" && sc.JobName != jobName {
+			continue
+		}
+		dockerJobs = append(dockerJobs, sc)
+	}
+	switch len(dockerJobs) {
+	case 0:
+		if jobName != "" {
+			return scrapeconfig.Config{}, fmt.Errorf("no docker_sd_configs job named %q found", jobName)
+		}
+		return scrapeconfig.Config{}, errors.New("no docker_sd_configs job found in config")
+	case 1:
+		return dockerJobs[0], nil
+	default:
+		return scrapeconfig.Config{}, fmt.Errorf("multiple docker_sd_configs jobs found, pick one with -job")
+	}
+}
+
+// tailSummary is the final report printed after the bounded read loop ends.
+type tailSummary struct {
+	linesRead        int
+	droppedByRelabel int
+	labelSets        map[string]struct{}
+}
+
+// tail connects to the docker daemon at host, reads containerRef's logs,
+// applies job's relabel_configs and pipeline_stages the way the real
+// docker target would, and forwards surviving entries to a dry-run logger.
+// It stops after duration elapses or maxLines entries have been read,
+// whichever comes first (a zero value disables that bound).
+func tail(logger log.Logger, cfg promtail_config.Config, job scrapeconfig.Config, host, containerRef string, duration time.Duration, maxLines int) (*tailSummary, error) {
+	ctx := context.Background()
+	if duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, duration)
+		defer cancel()
+	}
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	dockerOpts := []dockerclient.Opt{dockerclient.WithAPIVersionNegotiation()}
+	if host != "" {
+		dockerOpts = append(dockerOpts, dockerclient.WithHost(host))
+	}
+	cli, err := dockerclient.NewClientWithOpts(dockerOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating docker client: %w", err)
+	}
+	defer cli.Close()
+
+	info, err := cli.ContainerInspect(ctx, containerRef)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting container %s: %w", containerRef, err)
+	}
+
+	rc, err := cli.ContainerLogs(ctx, info.ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Tail:       "0",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading logs for container %s: %w", containerRef, err)
+	}
+	defer rc.Close()
+
+	metrics := client.NewMetrics(nil)
+	dryRun, err := client.NewLogger(metrics, logger, cfg.ClientConfigs...)
+	if err != nil {
+		return nil, fmt.Errorf("creating dry-run client: %w", err)
+	}
+	defer dryRun.Stop()
+
+	// This is a one-shot CLI invocation, not a long-running agent, so there's
+	// no DefaultRegisterer worth sharing metrics through; give the pipeline
+	// its own registry the same way config.Validate does.
+	pipeline, err := stages.NewPipeline(logger, job.PipelineStages, &job.JobName, prometheus.NewRegistry())
+	if err != nil {
+		return nil, fmt.Errorf("creating pipeline for job %s: %w", job.JobName, err)
+	}
+	entryHandler := pipeline.Wrap(dryRun)
+	// Stop, and so drain, the pipeline before dryRun.Stop (deferred above,
+	// so it runs after this) closes the channel the pipeline forwards into.
+	defer entryHandler.Stop()
+
+	discoveryLabels := labelSetFor(info)
+
+	summary := &tailSummary{labelSets: map[string]struct{}{}}
+
+	type rawLine struct {
+		stream string
+		text   string
+	}
+	lineCh := make(chan rawLine)
+
+	var wg sync.WaitGroup
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer stdoutW.Close()
+		defer stderrW.Close()
+		if info.Config.Tty {
+			_, _ = io.Copy(stdoutW, rc)
+			return
+		}
+		_, _ = stdcopy.StdCopy(stdoutW, stderrW, rc)
+	}()
+	scanInto := func(r io.Reader, stream string) {
+		defer wg.Done()
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			select {
+			case lineCh <- rawLine{stream: stream, text: scanner.Text()}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+	wg.Add(2)
+	go scanInto(stdoutR, "stdout")
+	go scanInto(stderrR, "stderr")
+	go func() {
+		wg.Wait()
+		close(lineCh)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return summary, nil
+		case rl, ok := <-lineCh:
+			if !ok {
+				return summary, nil
+			}
+			summary.linesRead++
+
+			lb := labels.NewBuilder(discoveryLabels)
+			lb.Set(metaLabelLogStream, rl.stream)
+
+			processed, keep := relabel.Process(lb.Labels(), job.RelabelConfigs...)
+			if !keep {
+				summary.droppedByRelabel++
+			} else {
+				out := processed.Map()
+				for k := range out {
+					if strings.HasPrefix(k, model.ReservedLabelPrefix) {
+						delete(out, k)
+					}
+				}
+				summary.labelSets[labels.FromMap(out).String()] = struct{}{}
+
+				lset := make(model.LabelSet, len(out))
+				for k, v := range out {
+					lset[model.LabelName(k)] = model.LabelValue(v)
+				}
+				entryHandler.Chan() <- api.Entry{
+					Labels: lset,
+					Entry: logproto.Entry{
+						Timestamp: time.Now(),
+						Line:      rl.text,
+					},
+				}
+			}
+
+			if maxLines > 0 && summary.linesRead >= maxLines {
+				stop()
+			}
+		}
+	}
+}
+
+// labelSetFor builds the __meta_docker_container_* discovery labels
+// discovery/moby would attach to info's container, as a starting point for
+// relabel_configs to act on. Compose/Swarm labels aren't included: this
+// tool only inspects the container itself, not its orchestrator.
+func labelSetFor(info dockertypes.ContainerJSON) labels.Labels {
+	lb := labels.NewBuilder(labels.EmptyLabels())
+	lb.Set(metaLabelContainerID, info.ID)
+	lb.Set(metaLabelContainerName, strings.TrimPrefix(info.Name, "/"))
+	lb.Set(metaLabelContainerImage, info.Config.Image)
+	return lb.Labels()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/moby"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client"
+	promtail_config "github.com/grafana/loki/v3/clients/pkg/promtail/config"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+)
+
+// testPromtailConfig returns a minimal promtail config with a single,
+// never-actually-dialed client: client.NewLogger requires at least one
+// client config to build its --dry-run-style summary output from, even
+// though it never sends anything.
+func testPromtailConfig(t *testing.T) promtail_config.Config {
+	t.Helper()
+	u, err := url.Parse("http://127.0.0.1:0/loki/api/v1/push")
+	require.NoError(t, err)
+	return promtail_config.Config{
+		ClientConfigs: []client.Config{{URL: flagext.URLValue{URL: u}}},
+	}
+}
+
+// fakeDockerDaemon stands up the same kind of httptest server the docker
+// target's own tests use in ../../pkg/promtail/targets/docker: it serves
+// container info on inspect and a handful of pre-framed stdcopy log lines
+// on /logs. tail() doesn't need the daemon to keep the connection open
+// past that: it bounds itself on -lines/-duration regardless.
+func fakeDockerDaemon(t *testing.T, lines []string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/logs") {
+			sw := stdcopy.NewStdWriter(w, stdcopy.Stdout)
+			for _, line := range lines {
+				_, err := sw.Write([]byte(line + "\n"))
+				require.NoError(t, err)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		info := types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:   "deadbeef",
+				Name: "/app",
+			},
+			Mounts:          []types.MountPoint{},
+			Config:          &container.Config{Image: "app:latest"},
+			NetworkSettings: &types.NetworkSettings{},
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(info))
+	}))
+}
+
+func Test_tail_ForwardsLinesAndReportsLabelSets(t *testing.T) {
+	ts := fakeDockerDaemon(t, []string{"hello", "world"})
+	defer ts.Close()
+
+	logger := log.NewNopLogger()
+	job := scrapeconfig.Config{JobName: "docker"}
+
+	summary, err := tail(logger, testPromtailConfig(t), job, ts.URL, "app", 2*time.Second, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, summary.linesRead)
+	require.Equal(t, 0, summary.droppedByRelabel)
+	require.Len(t, summary.labelSets, 1)
+}
+
+func Test_tail_CountsLinesDroppedByRelabel(t *testing.T) {
+	ts := fakeDockerDaemon(t, []string{"hello", "world"})
+	defer ts.Close()
+
+	logger := log.NewNopLogger()
+	job := scrapeconfig.Config{
+		JobName: "docker",
+		RelabelConfigs: []*relabel.Config{{
+			SourceLabels: model.LabelNames{"__meta_docker_container_name"},
+			Regex:        relabel.MustNewRegexp("app"),
+			Action:       relabel.Drop,
+		}},
+	}
+
+	summary, err := tail(logger, testPromtailConfig(t), job, ts.URL, "app", 2*time.Second, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, summary.linesRead)
+	require.Equal(t, 2, summary.droppedByRelabel)
+	require.Empty(t, summary.labelSets)
+}
+
+func Test_findJob(t *testing.T) {
+	nonDocker := scrapeconfig.Config{JobName: "syslog"}
+	docker1 := scrapeconfig.Config{JobName: "docker1", DockerSDConfigs: []*moby.DockerSDConfig{{}}}
+	docker2 := scrapeconfig.Config{JobName: "docker2", DockerSDConfigs: []*moby.DockerSDConfig{{}}}
+
+	_, err := findJob([]scrapeconfig.Config{nonDocker}, "")
+	require.Error(t, err, "no docker job should error")
+
+	got, err := findJob([]scrapeconfig.Config{nonDocker, docker1}, "")
+	require.NoError(t, err)
+	require.Equal(t, "docker1", got.JobName)
+
+	_, err = findJob([]scrapeconfig.Config{docker1, docker2}, "")
+	require.Error(t, err, "ambiguous docker jobs without -job should error")
+
+	got, err = findJob([]scrapeconfig.Config{docker1, docker2}, "docker2")
+	require.NoError(t, err)
+	require.Equal(t, "docker2", got.JobName)
+}
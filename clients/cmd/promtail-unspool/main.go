@@ -0,0 +1,79 @@
+// Command promtail-unspool reads a spool directory written by a promtail
+// client with spool.enabled (see clients/pkg/promtail/client.SpoolConfig)
+// and pushes its entries through the normal Promtail client code, for
+// physically transferring logs collected at an air-gapped site to a real
+// Loki instance.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+func main() {
+	var (
+		dir         string
+		url         string
+		stateFile   string
+		tenantID    string
+		speed       float64
+		maxLineSize int
+		batchSize   int
+	)
+
+	flag.StringVar(&dir, "directory", "", "Spool directory to read (as written by a client with spool.enabled).")
+	flag.StringVar(&url, "url", "", "Loki push URL to unspool entries to, e.g. http://localhost:3100/loki/api/v1/push.")
+	flag.StringVar(&stateFile, "state-file", "", "File tracking which spool files have already been pushed, so re-running only sends new ones. Defaults to <directory>/.unspool-state.json.")
+	flag.StringVar(&tenantID, "tenant-id", "", "Override the tenant ID recorded in the spool files for every entry.")
+	flag.Float64Var(&speed, "speed", 0, "Playback speed multiplier. 1 replays at the rate entries were originally spooled, 2 twice as fast. 0 (the default) disables inter-entry waiting entirely.")
+	flag.IntVar(&maxLineSize, "client.max-line-size", 0, "Maximum line size to send to Loki. 0 means no limit.")
+	flag.IntVar(&batchSize, "client.batch-size-bytes", client.BatchSize, "Maximum batch size to accrue before sending.")
+	flag.Parse()
+
+	logger := util_log.Logger
+
+	if dir == "" || url == "" {
+		fmt.Fprintln(os.Stderr, "both -directory and -url are required")
+		os.Exit(1)
+	}
+	if stateFile == "" {
+		stateFile = dir + "/.unspool-state.json"
+	}
+
+	cfg := client.Config{}
+	if err := cfg.URL.Set(url); err != nil {
+		level.Error(logger).Log("msg", "invalid url", "error", err)
+		os.Exit(1)
+	}
+	cfg.BatchWait = client.BatchWait
+	cfg.BatchSize = batchSize
+	cfg.TenantID = tenantID
+
+	metrics := client.NewMetrics(nil)
+	c, err := client.New(metrics, cfg, 0, maxLineSize, false, logger)
+	if err != nil {
+		level.Error(logger).Log("msg", "could not create client", "error", err)
+		os.Exit(1)
+	}
+	defer c.Stop()
+
+	count, err := client.Unspool(logger, c, client.UnspoolOptions{
+		Directory:      dir,
+		StateFile:      stateFile,
+		TenantOverride: tenantID,
+		Speed:          speed,
+	})
+	if err != nil {
+		level.Error(logger).Log("msg", "unspool failed", "entries_sent", count, "error", err)
+		os.Exit(1)
+	}
+
+	level.Info(logger).Log("msg", "unspool complete", "entries_sent", count)
+}
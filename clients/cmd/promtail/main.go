@@ -1,11 +1,13 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"reflect"
 	"sync"
+	"time"
 
 	// embed time zone data
 	_ "time/tzdata"
@@ -44,7 +46,12 @@ type Config struct {
 	printConfig            bool
 	logConfig              bool
 	dryRun                 bool
+	dryRunCaptureFile      string
 	checkSyntax            bool
+	checkConfig            bool
+	checkConnectivity      bool
+	checkConfigJSON        bool
+	checkConfigTimeout     time.Duration
 	configFile             string
 	configExpandEnv        bool
 	inspect                bool
@@ -56,7 +63,12 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.logConfig, "log-config-reverse-order", false, "Dump the entire Loki config object at Info log "+
 		"level with the order reversed, reversing the order makes viewing the entries easier in Grafana.")
 	f.BoolVar(&c.dryRun, "dry-run", false, "Start Promtail but print entries instead of sending them to Loki.")
+	f.StringVar(&c.dryRunCaptureFile, "dry-run-capture-file", "", "When set alongside -dry-run, write entries as newline-delimited JSON to this file instead of printing them, so they can later be replayed with promtail-replay.")
 	f.BoolVar(&c.checkSyntax, "check-syntax", false, "Validate the config file of its syntax")
+	f.BoolVar(&c.checkConfig, "check-config", false, "Validate the config file beyond syntax: clients, scrape configs, relabel rules and pipeline stages, then exit. Combine with -check-connectivity to also test-resolve docker hosts and client push URLs.")
+	f.BoolVar(&c.checkConnectivity, "check-connectivity", false, "When set alongside -check-config, also test-resolve docker_sd_configs hosts and dial client push URLs.")
+	f.BoolVar(&c.checkConfigJSON, "check-config-json", false, "When set alongside -check-config, print problems found as a JSON array instead of plain text, for CI annotation.")
+	f.DurationVar(&c.checkConfigTimeout, "check-config-timeout", 5*time.Second, "Timeout for each probe made by -check-config -check-connectivity.")
 	f.BoolVar(&c.inspect, "inspect", false, "Allows for detailed inspection of pipeline stages")
 	f.StringVar(&c.configFile, "config.file", "", "yaml file to load")
 	f.BoolVar(&c.configExpandEnv, "config.expand-env", false, "Expands ${var} in config according to the values of the environment variables.")
@@ -71,6 +83,39 @@ func (c *Config) Clone() flagext.Registerer {
 	}(*c)
 }
 
+// runCheckConfig runs the same config the real promtail startup path would
+// use through promtail_config.Check, so that -check-config catches the
+// mistakes (bad relabel regex, duplicate client names, an unwritable
+// positions path) that otherwise only surface as a crash loop after
+// deploy. It prints every problem found to w and returns the process exit
+// code: 0 if none were found, 1 otherwise.
+func runCheckConfig(config *Config, w *os.File) int {
+	config.Config.Setup(util_log.Logger)
+
+	problems := promtail_config.Check(&config.Config, promtail_config.CheckOptions{
+		CheckConnectivity: config.checkConnectivity,
+		Timeout:           config.checkConfigTimeout,
+	})
+
+	if config.checkConfigJSON {
+		if err := json.NewEncoder(w).Encode(problems); err != nil {
+			fmt.Println("Unable to encode -check-config results:", err)
+			return 1
+		}
+	} else if len(problems) == 0 {
+		fmt.Fprintln(w, "Valid config! No problems found")
+	} else {
+		for _, p := range problems {
+			fmt.Fprintln(w, p)
+		}
+	}
+
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
+
 // wrap os.Exit so that deferred functions execute before the process exits
 func exit(code int) {
 	// flush all logs that may be buffered in memory
@@ -113,6 +158,10 @@ func main() {
 	// Use Stderr instead of files for the klog.
 	klog.SetOutput(os.Stderr)
 
+	if config.checkConfig {
+		exit(runCheckConfig(&config, os.Stdout))
+	}
+
 	if config.inspect {
 		stages.Inspect = true
 	}
@@ -164,7 +213,7 @@ func main() {
 		}
 		return &config.Config, nil
 	}
-	p, err := promtail.New(config.Config, newConfigFunc, clientMetrics, config.dryRun)
+	p, err := promtail.New(config.Config, newConfigFunc, clientMetrics, config.dryRun, promtail.WithDryRunCaptureFile(config.dryRunCaptureFile))
 	if err != nil {
 		level.Error(util_log.Logger).Log("msg", "error creating promtail", "error", err)
 		exit(1)
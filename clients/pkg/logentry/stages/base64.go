@@ -0,0 +1,117 @@
+package stages
+
+import (
+	"encoding/base64"
+	"reflect"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+// Config Errors
+const (
+	ErrEmptyBase64StageConfig = "empty base64 stage configuration"
+	ErrEmptyBase64Source      = "empty source in base64 stage"
+	ErrEmptyBase64Target      = "empty target in base64 stage"
+)
+
+// Base64Config configures a base64Stage. It's shared by the base64_decode
+// and base64_encode stages, since they only differ in which direction they
+// convert.
+type Base64Config struct {
+	Source string `mapstructure:"source"`
+	Target string `mapstructure:"target"`
+}
+
+func validateBase64Config(c *Base64Config) error {
+	if c == nil {
+		return errors.New(ErrEmptyBase64StageConfig)
+	}
+	if c.Source == "" {
+		return errors.New(ErrEmptyBase64Source)
+	}
+	if c.Target == "" {
+		return errors.New(ErrEmptyBase64Target)
+	}
+	return nil
+}
+
+func parseBase64Config(config interface{}) (*Base64Config, error) {
+	cfg := &Base64Config{}
+	if err := mapstructure.Decode(config, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, validateBase64Config(cfg)
+}
+
+// base64Stage copies cfg.Source into cfg.Target through convert, logging a
+// warning and storing the empty string in cfg.Target if convert fails. name
+// distinguishes base64_decode from base64_encode for Name() and log lines.
+type base64Stage struct {
+	cfg     *Base64Config
+	convert func(string) (string, error)
+	name    string
+	logger  log.Logger
+}
+
+func newBase64DecodeStage(logger log.Logger, config interface{}) (Stage, error) {
+	return newBase64Stage(logger, config, StageTypeBase64Decode, func(s string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(s)
+		return string(decoded), err
+	})
+}
+
+func newBase64EncodeStage(logger log.Logger, config interface{}) (Stage, error) {
+	return newBase64Stage(logger, config, StageTypeBase64Encode, func(s string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(s)), nil
+	})
+}
+
+func newBase64Stage(logger log.Logger, config interface{}, name string, convert func(string) (string, error)) (Stage, error) {
+	cfg, err := parseBase64Config(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return toStage(&base64Stage{
+		cfg:     cfg,
+		convert: convert,
+		name:    name,
+		logger:  log.With(logger, "component", "stage", "type", name),
+	}), nil
+}
+
+// Process implements Stage
+func (b *base64Stage) Process(_ model.LabelSet, extracted map[string]interface{}, _ *time.Time, _ *string) {
+	v, ok := extracted[b.cfg.Source]
+	if !ok {
+		if Debug {
+			level.Debug(b.logger).Log("msg", "source does not exist in the set of extracted values", "source", b.cfg.Source)
+		}
+		return
+	}
+
+	value, err := getString(v)
+	if err != nil {
+		if Debug {
+			level.Debug(b.logger).Log("msg", "failed to convert source value to string", "source", b.cfg.Source, "err", err, "type", reflect.TypeOf(v))
+		}
+		return
+	}
+
+	result, err := b.convert(value)
+	if err != nil {
+		level.Warn(b.logger).Log("msg", "failed to convert source value", "source", b.cfg.Source, "err", err)
+		result = ""
+	}
+	extracted[b.cfg.Target] = result
+}
+
+// Name implements Stage
+func (b *base64Stage) Name() string {
+	return b.name
+}
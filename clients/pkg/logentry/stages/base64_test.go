@@ -0,0 +1,99 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+var testBase64DecodeYaml = `
+pipeline_stages:
+- json:
+    expressions:
+      encoded:
+- base64_decode:
+    source: encoded
+    target: decoded
+`
+
+var testBase64EncodeYaml = `
+pipeline_stages:
+- json:
+    expressions:
+      raw:
+- base64_encode:
+    source: raw
+    target: encoded
+`
+
+func TestPipeline_Base64Decode(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testBase64DecodeYaml), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `{"encoded":"aGVsbG8="}`, time.Now()))[0]
+	assert.Equal(t, "hello", out.Extracted["decoded"])
+}
+
+func TestPipeline_Base64Decode_InvalidInputStoresEmptyString(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testBase64DecodeYaml), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `{"encoded":"not-valid-base64!!"}`, time.Now()))[0]
+	assert.Equal(t, "", out.Extracted["decoded"])
+}
+
+func TestPipeline_Base64Encode(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testBase64EncodeYaml), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `{"raw":"hello"}`, time.Now()))[0]
+	assert.Equal(t, "aGVsbG8=", out.Extracted["encoded"])
+}
+
+func Test_validateBase64Config(t *testing.T) {
+	tests := map[string]struct {
+		config *Base64Config
+		err    error
+	}{
+		"empty config": {
+			nil,
+			errors.New(ErrEmptyBase64StageConfig),
+		},
+		"missing source": {
+			&Base64Config{Target: "out"},
+			errors.New(ErrEmptyBase64Source),
+		},
+		"missing target": {
+			&Base64Config{Source: "in"},
+			errors.New(ErrEmptyBase64Target),
+		},
+		"valid": {
+			&Base64Config{Source: "in", Target: "out"},
+			nil,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			err := validateBase64Config(tt.config)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
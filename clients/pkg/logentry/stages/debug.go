@@ -0,0 +1,83 @@
+//go:build promtail_debug
+
+package stages
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/prometheus/common/model"
+)
+
+// DebugConfig configures the debug stage. It only exists in builds tagged
+// promtail_debug; see newDebugStage.
+type DebugConfig struct {
+	// Prefix identifies which point in the pipeline this stage's log lines
+	// came from, since a pipeline can have more than one debug stage.
+	Prefix string `mapstructure:"prefix"`
+	// Level is the log level the entry's state is logged at: "debug"
+	// (the default), "info", "warn", or "error".
+	Level string `mapstructure:"level"`
+}
+
+// levelLoggers maps DebugConfig.Level to the go-kit log/level function it
+// selects.
+var levelLoggers = map[string]func(log.Logger) log.Logger{
+	"":      level.Debug,
+	"debug": level.Debug,
+	"info":  level.Info,
+	"warn":  level.Warn,
+	"error": level.Error,
+}
+
+// newDebugStage creates a stage that logs the current entry's line, label
+// set, extracted map, and timestamp, tagged with cfg.Prefix. It's meant to
+// be dropped in and out of a pipeline_stages list to see the state between
+// two stages that would otherwise be opaque, without the overhead of
+// logging on every entry in a production build: it's compiled in only when
+// promtail is built with `-tags promtail_debug`, so removing the tag
+// removes the stage (and its cost) entirely, same as this file.
+func newDebugStage(logger log.Logger, config interface{}) (Stage, error) {
+	cfg := &DebugConfig{}
+	if err := mapstructure.Decode(config, cfg); err != nil {
+		return nil, err
+	}
+
+	logFn, ok := levelLoggers[cfg.Level]
+	if !ok {
+		return nil, fmt.Errorf("unknown debug stage level %q", cfg.Level)
+	}
+
+	return toStage(&debugStage{
+		cfgs:   cfg,
+		logger: logger,
+		log:    logFn,
+	}), nil
+}
+
+// debugStage logs every entry that passes through it; see newDebugStage.
+type debugStage struct {
+	cfgs   *DebugConfig
+	logger log.Logger
+	log    func(log.Logger) log.Logger
+}
+
+// Process implements Stage.
+func (d *debugStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
+	d.log(d.logger).Log(
+		"msg", "pipeline debug",
+		"prefix", d.cfgs.Prefix,
+		"line", *entry,
+		"labels", labels.String(),
+		"extracted", fmt.Sprintf("%+v", extracted),
+		"timestamp", t.String(),
+	)
+}
+
+// Name implements Stage.
+func (d *debugStage) Name() string {
+	return StageTypeDebug
+}
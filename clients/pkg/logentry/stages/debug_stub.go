@@ -0,0 +1,15 @@
+//go:build !promtail_debug
+
+package stages
+
+import (
+	"errors"
+
+	"github.com/go-kit/log"
+)
+
+// newDebugStage is a stub used in the default build, where the debug stage's
+// per-entry logging overhead isn't compiled in at all; see debug.go.
+func newDebugStage(_ log.Logger, _ interface{}) (Stage, error) {
+	return nil, errors.New("the debug stage requires promtail to be built with -tags promtail_debug")
+}
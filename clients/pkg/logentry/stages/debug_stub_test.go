@@ -0,0 +1,15 @@
+//go:build !promtail_debug
+
+package stages
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newDebugStage_DisabledWithoutBuildTag(t *testing.T) {
+	_, err := newDebugStage(log.NewNopLogger(), map[string]interface{}{"prefix": "x"})
+	require.Error(t, err)
+}
@@ -0,0 +1,42 @@
+//go:build promtail_debug
+
+package stages
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+var testDebugYaml = `
+pipeline_stages:
+- debug:
+    prefix: after-json
+    level: info
+`
+
+func TestPipeline_Debug(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewLogfmtLogger(&buf)
+
+	pl, err := NewPipeline(logger, loadConfig(testDebugYaml), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, "hello", time.Unix(0, 0)))
+	require.Len(t, out, 1)
+	require.Equal(t, "hello", out[0].Line)
+
+	logged := buf.String()
+	require.Contains(t, logged, "level=info")
+	require.Contains(t, logged, "prefix=after-json")
+	require.Contains(t, logged, "line=hello")
+}
+
+func Test_newDebugStage_UnknownLevel(t *testing.T) {
+	_, err := newDebugStage(log.NewNopLogger(), map[string]interface{}{"level": "verbose"})
+	require.Error(t, err)
+}
@@ -0,0 +1,158 @@
+package stages
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+)
+
+// Config Errors
+const (
+	ErrEmptyDecompressStageConfig = "empty decompress stage configuration"
+	ErrEmptyDecompressSource      = "empty source in decompress stage"
+	ErrEmptyDecompressFormat      = "empty format in decompress stage"
+	ErrEmptyDecompressTarget      = "empty target in decompress stage"
+)
+
+// decompressSourceFile is the special Source value that decompresses the raw
+// log line itself, rather than an extracted field, for file targets tailing
+// already-compressed files (for example, `.gz` files read through zcat).
+const decompressSourceFile = "file"
+
+// DecompressConfig configures a decompressStage.
+type DecompressConfig struct {
+	// Source is the name of the extracted field holding base64-encoded
+	// compressed content, or the literal "file" to decompress the raw log
+	// line itself.
+	Source string `mapstructure:"source"`
+	// Target is the extracted field the decompressed content is written
+	// to. Required unless Source is "file", in which case the log line is
+	// replaced in place and Target is ignored.
+	Target string `mapstructure:"target"`
+	// Format selects the compression codec the source was compressed
+	// with: gzip, zstd, or lz4.
+	Format string `mapstructure:"format"`
+}
+
+func validateDecompressConfig(c *DecompressConfig) (compression.Codec, error) {
+	if c == nil {
+		return 0, errors.New(ErrEmptyDecompressStageConfig)
+	}
+	if c.Source == "" {
+		return 0, errors.New(ErrEmptyDecompressSource)
+	}
+	if c.Format == "" {
+		return 0, errors.New(ErrEmptyDecompressFormat)
+	}
+	if c.Source != decompressSourceFile && c.Target == "" {
+		return 0, errors.New(ErrEmptyDecompressTarget)
+	}
+	codec, err := compression.ParseCodec(c.Format)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid format in decompress stage")
+	}
+	return codec, nil
+}
+
+func parseDecompressConfig(config interface{}) (*DecompressConfig, compression.Codec, error) {
+	cfg := &DecompressConfig{}
+	if err := mapstructure.Decode(config, cfg); err != nil {
+		return nil, 0, err
+	}
+	codec, err := validateDecompressConfig(cfg)
+	return cfg, codec, err
+}
+
+// decompressStage decompresses cfg.Source using the codec selected by
+// cfg.Format and writes the result to cfg.Target, or, when cfg.Source is
+// "file", decompresses the raw log line in place. Source field values are
+// expected to be base64-encoded, since extracted values are always strings;
+// the log line itself is used as-is, since it's already raw bytes. A
+// decompression failure logs a warning and leaves the line (or extracted
+// values) exactly as they were, rather than dropping the entry.
+type decompressStage struct {
+	cfg    *DecompressConfig
+	codec  compression.Codec
+	logger log.Logger
+}
+
+func newDecompressStage(logger log.Logger, config interface{}) (Stage, error) {
+	cfg, codec, err := parseDecompressConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return toStage(&decompressStage{
+		cfg:    cfg,
+		codec:  codec,
+		logger: log.With(logger, "component", "stage", "type", StageTypeDecompress),
+	}), nil
+}
+
+// Process implements Stage
+func (d *decompressStage) Process(_ model.LabelSet, extracted map[string]interface{}, _ *time.Time, entry *string) {
+	if d.cfg.Source == decompressSourceFile {
+		decoded, err := d.decompress([]byte(*entry))
+		if err != nil {
+			level.Warn(d.logger).Log("msg", "failed to decompress log line, leaving it unchanged", "err", err)
+			return
+		}
+		*entry = string(decoded)
+		return
+	}
+
+	v, ok := extracted[d.cfg.Source]
+	if !ok {
+		if Debug {
+			level.Debug(d.logger).Log("msg", "source does not exist in the set of extracted values", "source", d.cfg.Source)
+		}
+		return
+	}
+
+	value, err := getString(v)
+	if err != nil {
+		if Debug {
+			level.Debug(d.logger).Log("msg", "failed to convert source value to string", "source", d.cfg.Source, "err", err, "type", reflect.TypeOf(v))
+		}
+		return
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		level.Warn(d.logger).Log("msg", "failed to base64-decode source value, leaving it unchanged", "source", d.cfg.Source, "err", err)
+		return
+	}
+
+	decoded, err := d.decompress(raw)
+	if err != nil {
+		level.Warn(d.logger).Log("msg", "failed to decompress source value, leaving it unchanged", "source", d.cfg.Source, "err", err)
+		return
+	}
+	extracted[d.cfg.Target] = string(decoded)
+}
+
+func (d *decompressStage) decompress(raw []byte) ([]byte, error) {
+	pool := compression.GetReaderPool(d.codec)
+	reader, err := pool.GetReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer pool.PutReader(reader)
+
+	return io.ReadAll(reader)
+}
+
+// Name implements Stage
+func (d *decompressStage) Name() string {
+	return StageTypeDecompress
+}
@@ -0,0 +1,154 @@
+package stages
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+var testDecompressFieldYaml = `
+pipeline_stages:
+- json:
+    expressions:
+      compressed:
+- decompress:
+    source: compressed
+    target: decoded
+    format: gzip
+`
+
+var testDecompressFileYaml = `
+pipeline_stages:
+- decompress:
+    source: file
+    format: gzip
+`
+
+func gzipBase64(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func gzipBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestPipeline_Decompress_Field(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testDecompressFieldYaml), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	encoded := gzipBase64(t, "hello")
+	out := processEntries(pl, newEntry(nil, nil, `{"compressed":"`+encoded+`"}`, time.Now()))[0]
+	assert.Equal(t, "hello", out.Extracted["decoded"])
+}
+
+func TestPipeline_Decompress_Field_InvalidInputLeavesTargetUnset(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testDecompressFieldYaml), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `{"compressed":"not-valid-base64!!"}`, time.Now()))[0]
+	_, ok := out.Extracted["decoded"]
+	assert.False(t, ok)
+}
+
+func TestPipeline_Decompress_File(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testDecompressFileYaml), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, string(gzipBytes(t, "hello world")), time.Now()))[0]
+	assert.Equal(t, "hello world", out.Line)
+}
+
+func TestPipeline_Decompress_File_InvalidInputLeavesLineUnchanged(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testDecompressFileYaml), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, "not gzip data", time.Now()))[0]
+	assert.Equal(t, "not gzip data", out.Line)
+}
+
+func Test_validateDecompressConfig(t *testing.T) {
+	tests := map[string]struct {
+		config *DecompressConfig
+		err    error
+	}{
+		"empty config": {
+			nil,
+			errors.New(ErrEmptyDecompressStageConfig),
+		},
+		"missing source": {
+			&DecompressConfig{Target: "out", Format: "gzip"},
+			errors.New(ErrEmptyDecompressSource),
+		},
+		"missing format": {
+			&DecompressConfig{Source: "in", Target: "out"},
+			errors.New(ErrEmptyDecompressFormat),
+		},
+		"missing target": {
+			&DecompressConfig{Source: "in", Format: "gzip"},
+			errors.New(ErrEmptyDecompressTarget),
+		},
+		"target not required when source is file": {
+			&DecompressConfig{Source: decompressSourceFile, Format: "gzip"},
+			nil,
+		},
+		"valid": {
+			&DecompressConfig{Source: "in", Target: "out", Format: "gzip"},
+			nil,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			_, err := validateDecompressConfig(tt.config)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_validateDecompressConfig_UnsupportedFormat(t *testing.T) {
+	_, err := validateDecompressConfig(&DecompressConfig{Source: "in", Target: "out", Format: "bzip2"})
+	require.Error(t, err)
+}
+
+func Test_validateDecompressConfig_SupportedFormats(t *testing.T) {
+	for _, format := range []string{"gzip", "zstd", "lz4"} {
+		codec, err := validateDecompressConfig(&DecompressConfig{Source: "in", Target: "out", Format: format})
+		require.NoError(t, err)
+		assert.NotEqual(t, compression.Codec(0), codec)
+	}
+}
@@ -3,7 +3,11 @@ package stages
 import (
 	"fmt"
 	"net"
+	"os"
+	"os/signal"
 	"reflect"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/go-kit/log"
@@ -11,6 +15,7 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"github.com/oschwald/geoip2-golang"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 )
 
@@ -74,7 +79,25 @@ func validateGeoIPConfig(c *GeoIPConfig) error {
 	return nil
 }
 
-func newGeoIPStage(logger log.Logger, configs interface{}) (Stage, error) {
+func getGeoIPLookupErrorsMetric(registerer prometheus.Registerer) *prometheus.CounterVec {
+	lookupErrors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "pipeline",
+		Name:      "geoip_lookup_errors_total",
+		Help:      "A count of geoip lookups that failed, either because the IP wasn't found in the database or the record couldn't be parsed.",
+	}, []string{"db_type"})
+	err := registerer.Register(lookupErrors)
+	if err != nil {
+		if existing, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			lookupErrors = existing.ExistingCollector.(*prometheus.CounterVec)
+		} else {
+			// Same behavior as MustRegister if the error is not for AlreadyRegistered
+			panic(err)
+		}
+	}
+	return lookupErrors
+}
+
+func newGeoIPStage(logger log.Logger, configs interface{}, registerer prometheus.Registerer) (Stage, error) {
 	cfgs := &GeoIPConfig{}
 	err := mapstructure.Decode(configs, cfgs)
 	if err != nil {
@@ -91,17 +114,28 @@ func newGeoIPStage(logger log.Logger, configs interface{}) (Stage, error) {
 		return nil, err
 	}
 
-	return &geoIPStage{
-		db:     db,
-		logger: logger,
-		cfgs:   cfgs,
-	}, nil
+	g := &geoIPStage{
+		db:           db,
+		logger:       logger,
+		cfgs:         cfgs,
+		lookupErrors: getGeoIPLookupErrorsMetric(registerer),
+		quit:         make(chan struct{}),
+	}
+	g.watchReload()
+	return g, nil
 }
 
 type geoIPStage struct {
 	logger log.Logger
-	db     *geoip2.Reader
 	cfgs   *GeoIPConfig
+
+	// dbMtx guards db, which is swapped out by reload without stopping Run's
+	// goroutine, so in-flight entries keep flowing through the same channels.
+	dbMtx sync.RWMutex
+	db    *geoip2.Reader
+
+	lookupErrors *prometheus.CounterVec
+	quit         chan struct{}
 }
 
 // Run implements Stage
@@ -124,8 +158,46 @@ func (g *geoIPStage) Name() string {
 }
 
 // Cleanup implements Stage.
-func (*geoIPStage) Cleanup() {
-	// no-op
+func (g *geoIPStage) Cleanup() {
+	close(g.quit)
+}
+
+// watchReload reopens cfgs.DB whenever the process receives SIGHUP, so an
+// updated .mmdb file can be picked up without restarting promtail. The
+// reader is swapped under dbMtx rather than tearing down Run's goroutine,
+// so entries already in flight aren't dropped.
+func (g *geoIPStage) watchReload() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-hup:
+				g.reload()
+			case <-g.quit:
+				return
+			}
+		}
+	}()
+}
+
+func (g *geoIPStage) reload() {
+	db, err := geoip2.Open(g.cfgs.DB)
+	if err != nil {
+		level.Error(g.logger).Log("msg", "failed to reload geoip database", "db", g.cfgs.DB, "err", err)
+		return
+	}
+
+	g.dbMtx.Lock()
+	old := g.db
+	g.db = db
+	g.dbMtx.Unlock()
+
+	if err := old.Close(); err != nil {
+		level.Error(g.logger).Log("msg", "error while closing previous geoip db", "err", err)
+	}
+	level.Info(g.logger).Log("msg", "reloaded geoip database", "db", g.cfgs.DB)
 }
 
 func (g *geoIPStage) process(labels model.LabelSet, extracted map[string]interface{}, _ *time.Time, _ *string) {
@@ -147,17 +219,24 @@ func (g *geoIPStage) process(labels model.LabelSet, extracted map[string]interfa
 		}
 		ip = net.ParseIP(value)
 	}
+
+	g.dbMtx.RLock()
+	db := g.db
+	g.dbMtx.RUnlock()
+
 	switch g.cfgs.DBType {
 	case "city":
-		record, err := g.db.City(ip)
+		record, err := db.City(ip)
 		if err != nil {
+			g.lookupErrors.WithLabelValues(g.cfgs.DBType).Inc()
 			level.Error(g.logger).Log("msg", "unable to get City record for the ip", "err", err, "ip", ip)
 			return
 		}
 		g.populateLabelsWithCityData(labels, record)
 	case "asn":
-		record, err := g.db.ASN(ip)
+		record, err := db.ASN(ip)
 		if err != nil {
+			g.lookupErrors.WithLabelValues(g.cfgs.DBType).Inc()
 			level.Error(g.logger).Log("msg", "unable to get ASN record for the ip", "err", err, "ip", ip)
 			return
 		}
@@ -168,6 +247,8 @@ func (g *geoIPStage) process(labels model.LabelSet, extracted map[string]interfa
 }
 
 func (g *geoIPStage) close() {
+	g.dbMtx.RLock()
+	defer g.dbMtx.RUnlock()
 	if err := g.db.Close(); err != nil {
 		level.Error(g.logger).Log("msg", "error while closing geoip db", "err", err)
 	}
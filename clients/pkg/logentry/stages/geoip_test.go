@@ -4,7 +4,10 @@ import (
 	"testing"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
 )
 
 func Test_ValidateConfigs(t *testing.T) {
@@ -53,3 +56,34 @@ func Test_ValidateConfigs(t *testing.T) {
 		}
 	}
 }
+
+func Test_GeoIPStage_ReloadWithMissingDBIsNoop(t *testing.T) {
+	g := &geoIPStage{
+		logger:       util_log.Logger,
+		cfgs:         &GeoIPConfig{DB: "/does/not/exist.mmdb"},
+		lookupErrors: getGeoIPLookupErrorsMetric(prometheus.NewRegistry()),
+		quit:         make(chan struct{}),
+	}
+	g.reload()
+	require.Nil(t, g.db, "a failed reload must leave the previous (nil) reader untouched")
+}
+
+func Test_GeoIPStage_Cleanup(t *testing.T) {
+	g := &geoIPStage{
+		logger:       util_log.Logger,
+		cfgs:         &GeoIPConfig{DB: "test"},
+		lookupErrors: getGeoIPLookupErrorsMetric(prometheus.NewRegistry()),
+		quit:         make(chan struct{}),
+	}
+	g.watchReload()
+	g.Cleanup()
+	_, ok := <-g.quit
+	require.False(t, ok, "Cleanup should close quit so watchReload's goroutine exits")
+}
+
+func Test_getGeoIPLookupErrorsMetric_ReusesExistingCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	first := getGeoIPLookupErrorsMetric(reg)
+	second := getGeoIPLookupErrorsMetric(reg)
+	require.Same(t, first, second)
+}
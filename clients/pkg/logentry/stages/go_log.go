@@ -0,0 +1,246 @@
+package stages
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/go-logfmt/logfmt"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+// Config Errors
+const ErrEmptyGoLogStageConfig = "go_log stage config cannot be empty"
+
+// GoLogConfig configures the go_log stage. Source, when set, reads the
+// line to parse from the extracted map instead of the log line itself.
+type GoLogConfig struct {
+	Source *string `mapstructure:"source"`
+}
+
+func parseGoLogConfig(config interface{}) (*GoLogConfig, error) {
+	cfg := &GoLogConfig{}
+	if err := mapstructure.Decode(config, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// goLogStage parses lines produced by Go's standard `log` package and by
+// `log/slog`'s text and JSON handlers.
+type goLogStage struct {
+	cfg    *GoLogConfig
+	logger log.Logger
+}
+
+// newGoLogStage creates a new go_log pipeline stage from a config.
+func newGoLogStage(logger log.Logger, config interface{}) (Stage, error) {
+	if config == nil {
+		return nil, errors.New(ErrEmptyGoLogStageConfig)
+	}
+	cfg, err := parseGoLogConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return toStage(&goLogStage{
+		cfg:    cfg,
+		logger: log.With(logger, "component", "stage", "type", "go_log"),
+	}), nil
+}
+
+// Process implements Stage
+func (g *goLogStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
+	input := entry
+
+	if g.cfg.Source != nil {
+		if _, ok := extracted[*g.cfg.Source]; !ok {
+			if Debug {
+				level.Debug(g.logger).Log("msg", "source does not exist in the set of extracted values", "source", *g.cfg.Source)
+			}
+			return
+		}
+
+		value, err := getString(extracted[*g.cfg.Source])
+		if err != nil {
+			if Debug {
+				level.Debug(g.logger).Log("msg", "failed to convert source value to string", "source", *g.cfg.Source, "err", err)
+			}
+			return
+		}
+		input = &value
+	}
+
+	if input == nil {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "cannot parse a nil entry")
+		}
+		return
+	}
+
+	parsed, ok := parseGoLogLine(*input)
+	if !ok {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "line did not match any known log/slog format", "line", *input)
+		}
+		return
+	}
+
+	if parsed.timestamp != nil {
+		*t = *parsed.timestamp
+	}
+	if parsed.level != "" {
+		labels[model.LabelName("level")] = model.LabelValue(parsed.level)
+	}
+	*entry = parsed.message
+	for k, v := range parsed.attrs {
+		extracted[k] = v
+	}
+}
+
+// Name implements Stage
+func (g *goLogStage) Name() string {
+	return StageTypeGoLog
+}
+
+// goLogLine is the result of successfully parsing a line emitted by the
+// standard `log` package or by `log/slog`.
+type goLogLine struct {
+	timestamp *time.Time
+	level     string
+	message   string
+	attrs     map[string]string
+}
+
+// goLogClassicPrefix matches the timestamp, level, and remainder of a
+// line from the standard `log` package with a level prepended, e.g.
+// `2024/01/01 12:00:00 INFO message key=value`.
+var goLogClassicPrefix = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?)\s+([A-Z]+)\s+(.*)$`)
+
+// parseGoLogLine tries, in turn, the slog JSON handler format, the slog
+// text handler format, and the classic `log` package format, and returns
+// the first one that matches.
+func parseGoLogLine(line string) (goLogLine, bool) {
+	if p, ok := parseSlogJSON(line); ok {
+		return p, true
+	}
+	if p, ok := parseSlogText(line); ok {
+		return p, true
+	}
+	return parseGoLogClassic(line)
+}
+
+// parseSlogJSON parses a line emitted by slog.JSONHandler.
+func parseSlogJSON(line string) (goLogLine, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") {
+		return goLogLine{}, false
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(trimmed), &fields); err != nil {
+		return goLogLine{}, false
+	}
+
+	msg, ok := fields["msg"].(string)
+	if !ok {
+		return goLogLine{}, false
+	}
+
+	p := goLogLine{message: msg, attrs: map[string]string{}}
+	if lvl, ok := fields["level"].(string); ok {
+		p.level = lvl
+	}
+	if ts, ok := fields["time"].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339, ts); err == nil {
+			p.timestamp = &parsed
+		}
+	}
+	for k, v := range fields {
+		if k == "msg" || k == "level" || k == "time" {
+			continue
+		}
+		if s, err := getString(v); err == nil {
+			p.attrs[k] = s
+		}
+	}
+	return p, true
+}
+
+// parseSlogText parses a line emitted by slog.TextHandler, which is
+// itself logfmt with reserved "time", "level", and "msg" keys.
+func parseSlogText(line string) (goLogLine, bool) {
+	fields := map[string]string{}
+
+	decoder := logfmt.NewDecoder(strings.NewReader(line))
+	for decoder.ScanRecord() {
+		for decoder.ScanKeyval() {
+			fields[string(decoder.Key())] = string(decoder.Value())
+		}
+	}
+	if decoder.Err() != nil {
+		return goLogLine{}, false
+	}
+
+	msg, ok := fields["msg"]
+	if !ok {
+		return goLogLine{}, false
+	}
+
+	p := goLogLine{message: msg, level: fields["level"], attrs: map[string]string{}}
+	if ts, ok := fields["time"]; ok {
+		if parsed, err := time.Parse(time.RFC3339, ts); err == nil {
+			p.timestamp = &parsed
+		}
+	}
+	for k, v := range fields {
+		if k == "msg" || k == "level" || k == "time" {
+			continue
+		}
+		p.attrs[k] = v
+	}
+	return p, true
+}
+
+// parseGoLogClassic parses the classic `log` package format with a level
+// and trailing key=value attributes appended, as described by goLogClassicPrefix.
+func parseGoLogClassic(line string) (goLogLine, bool) {
+	m := goLogClassicPrefix.FindStringSubmatch(line)
+	if m == nil {
+		return goLogLine{}, false
+	}
+
+	message, attrs := splitMessageAndAttrs(m[3])
+	p := goLogLine{level: m[2], message: message, attrs: attrs}
+	if ts, err := time.Parse("2006/01/02 15:04:05", m[1]); err == nil {
+		p.timestamp = &ts
+	} else if ts, err := time.Parse("2006/01/02 15:04:05.000000", m[1]); err == nil {
+		p.timestamp = &ts
+	}
+	return p, true
+}
+
+// splitMessageAndAttrs pulls trailing key=value tokens off of line, the
+// way slog's text handler appends attributes after the message, leaving
+// whatever precedes them as the message.
+func splitMessageAndAttrs(line string) (string, map[string]string) {
+	fields := strings.Fields(line)
+	attrs := map[string]string{}
+
+	i := len(fields)
+	for i > 0 {
+		k, v, ok := strings.Cut(fields[i-1], "=")
+		if !ok || k == "" {
+			break
+		}
+		attrs[k] = v
+		i--
+	}
+
+	return strings.TrimSpace(strings.Join(fields[:i], " ")), attrs
+}
@@ -0,0 +1,84 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+var testGoLogPipeline = `
+pipeline_stages:
+- go_log: {}
+`
+
+func TestPipeline_GoLog(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		entry           string
+		expectedLine    string
+		expectedLevel   model.LabelValue
+		expectedExtract map[string]interface{}
+	}{
+		"classic log package with a level and trailing attributes": {
+			entry:         "2024/01/01 12:00:00 INFO message key=value key2=value2",
+			expectedLine:  "message",
+			expectedLevel: "INFO",
+			expectedExtract: map[string]interface{}{
+				"key":  "value",
+				"key2": "value2",
+			},
+		},
+		"slog text handler": {
+			entry:         `time=2024-01-01T12:00:00.000Z level=WARN msg="disk almost full" free_pct=5`,
+			expectedLine:  "disk almost full",
+			expectedLevel: "WARN",
+			expectedExtract: map[string]interface{}{
+				"free_pct": "5",
+			},
+		},
+		"slog json handler": {
+			entry:         `{"time":"2024-01-01T12:00:00Z","level":"ERROR","msg":"request failed","status":"500"}`,
+			expectedLine:  "request failed",
+			expectedLevel: "ERROR",
+			expectedExtract: map[string]interface{}{
+				"status": "500",
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(util_log.Logger, loadConfig(testGoLogPipeline), nil, prometheus.DefaultRegisterer)
+			assert.NoError(t, err)
+
+			out := processEntries(pl, newEntry(nil, model.LabelSet{}, testData.entry, time.Now()))[0]
+			assert.Equal(t, testData.expectedLine, out.Line)
+			assert.Equal(t, testData.expectedLevel, out.Labels["level"])
+			for k, v := range testData.expectedExtract {
+				assert.Equal(t, v, out.Extracted[k])
+			}
+		})
+	}
+}
+
+func Test_parseGoLogLine(t *testing.T) {
+	p, ok := parseGoLogClassic("2024/01/01 12:00:00 INFO hello world foo=bar")
+	assert.True(t, ok)
+	assert.Equal(t, "INFO", p.level)
+	assert.Equal(t, "hello world", p.message)
+	assert.Equal(t, "bar", p.attrs["foo"])
+	assert.NotNil(t, p.timestamp)
+
+	_, ok = parseGoLogClassic("not a go log line")
+	assert.False(t, ok)
+}
@@ -0,0 +1,224 @@
+package stages
+
+import (
+	"net"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// Config errors
+const (
+	ErrEmptyIPAnonymizeStageConfig = "ip_anonymize stage config cannot be empty"
+	ErrInvalidIPv4OctetsToZero     = "ip_anonymize stage ipv4_octets_to_zero must be between 1 and 4, got %d"
+	ErrInvalidIPv6GroupsToZero     = "ip_anonymize stage ipv6_groups_to_zero must be between 1 and 8, got %d"
+)
+
+const (
+	defaultIPv4OctetsToZero = 1 // zero the last octet
+	defaultIPv6GroupsToZero = 5 // zero the last 80 bits
+)
+
+// IPAnonymizeConfig configures an ipAnonymizeStage. Source names the
+// extracted field holding the address to anonymize; if empty, the log line
+// itself is treated as the address.
+type IPAnonymizeConfig struct {
+	Source string `mapstructure:"source"`
+
+	// IPv4OctetsToZero is how many of an IPv4 address's 4 trailing octets
+	// to zero. Defaults to 1, zeroing only the last octet.
+	IPv4OctetsToZero int `mapstructure:"ipv4_octets_to_zero"`
+
+	// IPv6GroupsToZero is how many of an IPv6 address's 8 trailing 16-bit
+	// groups to zero. Defaults to 5, zeroing the last 80 bits.
+	IPv6GroupsToZero int `mapstructure:"ipv6_groups_to_zero"`
+}
+
+func validateIPAnonymizeConfig(c *IPAnonymizeConfig) error {
+	if c == nil {
+		return errors.New(ErrEmptyIPAnonymizeStageConfig)
+	}
+	if c.IPv4OctetsToZero == 0 {
+		c.IPv4OctetsToZero = defaultIPv4OctetsToZero
+	}
+	if c.IPv6GroupsToZero == 0 {
+		c.IPv6GroupsToZero = defaultIPv6GroupsToZero
+	}
+	if c.IPv4OctetsToZero < 1 || c.IPv4OctetsToZero > 4 {
+		return errors.Errorf(ErrInvalidIPv4OctetsToZero, c.IPv4OctetsToZero)
+	}
+	if c.IPv6GroupsToZero < 1 || c.IPv6GroupsToZero > 8 {
+		return errors.Errorf(ErrInvalidIPv6GroupsToZero, c.IPv6GroupsToZero)
+	}
+	return nil
+}
+
+func parseIPAnonymizeConfig(config interface{}) (*IPAnonymizeConfig, error) {
+	cfg := &IPAnonymizeConfig{}
+	if err := mapstructure.Decode(config, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, validateIPAnonymizeConfig(cfg)
+}
+
+// ipv4Segment is a dotted-decimal IPv4 address, reused both as its own
+// pattern and as the trailing segment of an IPv4-mapped/-compatible IPv6
+// address (e.g. ::ffff:192.0.2.1).
+const ipv4Segment = `(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)`
+
+// ipPatterns are tried in order against the whole value, so an address
+// found embedded in a larger string (e.g. an access log line) is
+// anonymized in place rather than requiring the whole value to be a bare
+// address. The IPv6 pattern runs first: without its dedicated
+// ipv4Segment-tail alternatives, an IPv4-mapped address like
+// ::ffff:192.0.2.1 would only have its leading "::ffff:192" matched as a
+// (technically valid, but wrong) short IPv6 address, leaving the rest of
+// the dotted-decimal tail behind in the output.
+var ipPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`::(?:ffff(?::0{1,4})?:)?` + ipv4Segment + `\b` +
+		`|(?:[0-9a-fA-F]{1,4}:){1,4}:` + ipv4Segment + `\b` +
+		`|(?:[0-9a-fA-F]{1,4}:){6}` + ipv4Segment + `\b` +
+		`|\b(?:[0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4}\b` +
+		`|\b(?:[0-9a-fA-F]{1,4}:){1,7}:` +
+		`|(?:[0-9a-fA-F]{1,4}:){1,6}:[0-9a-fA-F]{1,4}\b` +
+		`|(?:[0-9a-fA-F]{1,4}:){1,5}(?::[0-9a-fA-F]{1,4}){1,2}\b` +
+		`|(?:[0-9a-fA-F]{1,4}:){1,4}(?::[0-9a-fA-F]{1,4}){1,3}\b` +
+		`|(?:[0-9a-fA-F]{1,4}:){1,3}(?::[0-9a-fA-F]{1,4}){1,4}\b` +
+		`|(?:[0-9a-fA-F]{1,4}:){1,2}(?::[0-9a-fA-F]{1,4}){1,5}\b` +
+		`|[0-9a-fA-F]{1,4}:(?:(?::[0-9a-fA-F]{1,4}){1,6})\b` +
+		`|:(?:(?::[0-9a-fA-F]{1,4}){1,7}|:)\b`),
+	regexp.MustCompile(`\b` + ipv4Segment + `\b`),
+}
+
+func getIPsAnonymizedMetric(registerer prometheus.Registerer) *prometheus.CounterVec {
+	ipsAnonymized := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "pipeline",
+		Name:      "ips_anonymized_total",
+		Help:      "A count of IP addresses anonymized by the ip_anonymize pipeline stage, per source field name.",
+	}, []string{"source"})
+	err := registerer.Register(ipsAnonymized)
+	if err != nil {
+		if existing, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			ipsAnonymized = existing.ExistingCollector.(*prometheus.CounterVec)
+		} else {
+			// Same behavior as MustRegister if the error is not for AlreadyRegistered
+			panic(err)
+		}
+	}
+	return ipsAnonymized
+}
+
+// ipAnonymizeStage zeroes the trailing octets (IPv4) or 16-bit groups
+// (IPv6) of an address found in cfg.Source, or in the log line itself if
+// cfg.Source is empty. It's meant to satisfy GDPR-style requirements
+// around not persisting a client's full IP address.
+type ipAnonymizeStage struct {
+	cfg           *IPAnonymizeConfig
+	ipsAnonymized *prometheus.CounterVec
+	logger        log.Logger
+}
+
+func newIPAnonymizeStage(logger log.Logger, config interface{}, registerer prometheus.Registerer) (Stage, error) {
+	cfg, err := parseIPAnonymizeConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return toStage(&ipAnonymizeStage{
+		cfg:           cfg,
+		ipsAnonymized: getIPsAnonymizedMetric(registerer),
+		logger:        log.With(logger, "component", "stage", "type", StageTypeIPAnonymize),
+	}), nil
+}
+
+// Process implements Stage
+func (s *ipAnonymizeStage) Process(_ model.LabelSet, extracted map[string]interface{}, _ *time.Time, entry *string) {
+	sourceName := s.cfg.Source
+	if sourceName == "" {
+		sourceName = "line"
+	}
+
+	value := *entry
+	if s.cfg.Source != "" {
+		v, ok := extracted[s.cfg.Source]
+		if !ok {
+			if Debug {
+				level.Debug(s.logger).Log("msg", "source does not exist in the set of extracted values", "source", s.cfg.Source)
+			}
+			return
+		}
+
+		var err error
+		value, err = getString(v)
+		if err != nil {
+			if Debug {
+				level.Debug(s.logger).Log("msg", "failed to convert source value to string", "source", s.cfg.Source, "err", err, "type", reflect.TypeOf(v))
+			}
+			return
+		}
+	}
+
+	anonymized := s.anonymizeMatches(value, sourceName)
+	if anonymized == value {
+		if Debug {
+			level.Debug(s.logger).Log("msg", "no IP address found to anonymize", "source", sourceName)
+		}
+		return
+	}
+
+	if s.cfg.Source == "" {
+		*entry = anonymized
+	} else {
+		extracted[s.cfg.Source] = anonymized
+	}
+}
+
+// anonymizeMatches replaces every embedded IP address found in value,
+// counting each replacement against ipsAnonymized under sourceName.
+func (s *ipAnonymizeStage) anonymizeMatches(value, sourceName string) string {
+	for _, re := range ipPatterns {
+		value = re.ReplaceAllStringFunc(value, func(match string) string {
+			ip := net.ParseIP(match)
+			if ip == nil {
+				return match
+			}
+			s.ipsAnonymized.WithLabelValues(sourceName).Inc()
+			return s.anonymize(ip)
+		})
+	}
+	return value
+}
+
+// anonymize zeroes the configured number of trailing octets or 16-bit
+// groups of ip, depending on whether it's an IPv4 or IPv6 address.
+func (s *ipAnonymizeStage) anonymize(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		masked := make(net.IP, len(v4))
+		copy(masked, v4)
+		for i := 0; i < s.cfg.IPv4OctetsToZero; i++ {
+			masked[len(masked)-1-i] = 0
+		}
+		return masked.String()
+	}
+
+	v6 := ip.To16()
+	masked := make(net.IP, len(v6))
+	copy(masked, v6)
+	for i := 0; i < s.cfg.IPv6GroupsToZero; i++ {
+		masked[len(masked)-1-2*i] = 0
+		masked[len(masked)-2-2*i] = 0
+	}
+	return masked.String()
+}
+
+// Name implements Stage
+func (s *ipAnonymizeStage) Name() string {
+	return StageTypeIPAnonymize
+}
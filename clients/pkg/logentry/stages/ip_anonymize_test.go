@@ -0,0 +1,140 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+var testIPAnonymizeSourceYaml = `
+pipeline_stages:
+- json:
+    expressions:
+      client_ip:
+- ip_anonymize:
+    source: client_ip
+`
+
+var testIPAnonymizeLineYaml = `
+pipeline_stages:
+- ip_anonymize: {}
+`
+
+var testIPAnonymizeCustomOctetsYaml = `
+pipeline_stages:
+- json:
+    expressions:
+      client_ip:
+- ip_anonymize:
+    source: client_ip
+    ipv4_octets_to_zero: 2
+    ipv6_groups_to_zero: 8
+`
+
+func TestPipeline_IPAnonymize_IPv4Source(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testIPAnonymizeSourceYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `{"client_ip":"203.0.113.42"}`, time.Now()))[0]
+	assert.Equal(t, "203.0.113.0", out.Extracted["client_ip"])
+}
+
+func TestPipeline_IPAnonymize_IPv6Source(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testIPAnonymizeSourceYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `{"client_ip":"2001:db8:85a3:1234:5678:8a2e:370:7334"}`, time.Now()))[0]
+	assert.Equal(t, "2001:db8:85a3::", out.Extracted["client_ip"])
+}
+
+func TestPipeline_IPAnonymize_Line(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testIPAnonymizeLineYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, "203.0.113.42", time.Now()))[0]
+	assert.Equal(t, "203.0.113.0", out.Line)
+}
+
+func TestPipeline_IPAnonymize_EmbeddedInLine(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testIPAnonymizeLineYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `127.0.0.1 - - [10/Oct/2023:13:55:36 -0700] "GET / HTTP/1.1" 200 2326`, time.Now()))[0]
+	assert.Equal(t, `127.0.0.0 - - [10/Oct/2023:13:55:36 -0700] "GET / HTTP/1.1" 200 2326`, out.Line)
+}
+
+func TestPipeline_IPAnonymize_InvalidIPIsUnchanged(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testIPAnonymizeSourceYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `{"client_ip":"not-an-ip"}`, time.Now()))[0]
+	assert.Equal(t, "not-an-ip", out.Extracted["client_ip"])
+}
+
+func TestPipeline_IPAnonymize_CustomOctets(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testIPAnonymizeCustomOctetsYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `{"client_ip":"203.0.113.42"}`, time.Now()))[0]
+	assert.Equal(t, "203.0.0.0", out.Extracted["client_ip"])
+}
+
+func Test_validateIPAnonymizeConfig(t *testing.T) {
+	tests := map[string]struct {
+		config *IPAnonymizeConfig
+		err    error
+		want   *IPAnonymizeConfig
+	}{
+		"empty config": {
+			nil,
+			errors.New(ErrEmptyIPAnonymizeStageConfig),
+			nil,
+		},
+		"defaults applied": {
+			&IPAnonymizeConfig{Source: "client_ip"},
+			nil,
+			&IPAnonymizeConfig{Source: "client_ip", IPv4OctetsToZero: defaultIPv4OctetsToZero, IPv6GroupsToZero: defaultIPv6GroupsToZero},
+		},
+		"ipv4 octets out of range": {
+			&IPAnonymizeConfig{IPv4OctetsToZero: 5},
+			errors.Errorf(ErrInvalidIPv4OctetsToZero, 5),
+			nil,
+		},
+		"ipv6 groups out of range": {
+			&IPAnonymizeConfig{IPv6GroupsToZero: 9},
+			errors.Errorf(ErrInvalidIPv6GroupsToZero, 9),
+			nil,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			err := validateIPAnonymizeConfig(tt.config)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tt.config)
+		})
+	}
+}
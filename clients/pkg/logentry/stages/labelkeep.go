@@ -0,0 +1,78 @@
+package stages
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// ErrEmptyLabelKeepStageConfig error returned if config is empty
+	ErrEmptyLabelKeepStageConfig = "label_keep stage config cannot be empty"
+)
+
+// LabelKeepConfig is a list of label name regexes; any label whose name
+// doesn't fully match at least one of them is dropped.
+type LabelKeepConfig []string
+
+func validateLabelKeepConfig(c LabelKeepConfig) error {
+	if len(c) < 1 {
+		return errors.New(ErrEmptyLabelKeepStageConfig)
+	}
+	return nil
+}
+
+func newLabelKeepStage(configs interface{}) (Stage, error) {
+	cfgs := &LabelKeepConfig{}
+	err := mapstructure.Decode(configs, cfgs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateLabelKeepConfig(*cfgs); err != nil {
+		return nil, err
+	}
+
+	matchers := make([]*regexp.Regexp, 0, len(*cfgs))
+	for _, pattern := range *cfgs {
+		matcher, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid label_keep regex %q", pattern)
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	return toStage(&labelKeepStage{
+		matchers: matchers,
+	}), nil
+}
+
+type labelKeepStage struct {
+	matchers []*regexp.Regexp
+}
+
+// Process implements Stage
+func (l *labelKeepStage) Process(labels model.LabelSet, _ map[string]interface{}, _ *time.Time, _ *string) {
+	for label := range labels {
+		if !l.matchesAny(string(label)) {
+			delete(labels, label)
+		}
+	}
+}
+
+func (l *labelKeepStage) matchesAny(name string) bool {
+	for _, matcher := range l.matchers {
+		if matcher.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Name implements Stage
+func (l *labelKeepStage) Name() string {
+	return StageTypeLabelKeep
+}
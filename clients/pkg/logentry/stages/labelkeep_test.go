@@ -0,0 +1,107 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	ww "github.com/grafana/dskit/server"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+func Test_labelKeepStage_Process(t *testing.T) {
+	// Enable debug logging
+	cfg := &ww.Config{}
+	require.Nil(t, cfg.LogLevel.Set("debug"))
+	util_log.InitLogger(cfg, nil, false)
+	Debug = true
+
+	tests := []struct {
+		name           string
+		config         *LabelKeepConfig
+		inputLabels    model.LabelSet
+		expectedLabels model.LabelSet
+	}{
+		{
+			name:   "keep single label by exact match",
+			config: &LabelKeepConfig{"testLabel1"},
+			inputLabels: model.LabelSet{
+				"testLabel1": "testValue",
+				"testLabel2": "testValue",
+			},
+			expectedLabels: model.LabelSet{
+				"testLabel1": "testValue",
+			},
+		},
+		{
+			name:   "keep labels matching a regex",
+			config: &LabelKeepConfig{"kube_.*"},
+			inputLabels: model.LabelSet{
+				"kube_pod_name":       "testValue",
+				"kube_container_name": "testValue",
+				"host":                "testValue",
+			},
+			expectedLabels: model.LabelSet{
+				"kube_pod_name":       "testValue",
+				"kube_container_name": "testValue",
+			},
+		},
+		{
+			name:   "keep labels matching any of several regexes",
+			config: &LabelKeepConfig{"kube_.*", "^host$"},
+			inputLabels: model.LabelSet{
+				"kube_pod_name": "testValue",
+				"host":          "testValue",
+				"other":         "testValue",
+			},
+			expectedLabels: model.LabelSet{
+				"kube_pod_name": "testValue",
+				"host":          "testValue",
+			},
+		},
+		{
+			name:   "regex must match the whole label name, not just a substring",
+			config: &LabelKeepConfig{"host"},
+			inputLabels: model.LabelSet{
+				"host":     "testValue",
+				"hostname": "testValue",
+			},
+			expectedLabels: model.LabelSet{
+				"host": "testValue",
+			},
+		},
+		{
+			name:   "no labels match",
+			config: &LabelKeepConfig{"foobar"},
+			inputLabels: model.LabelSet{
+				"testLabel1": "testValue",
+				"testLabel2": "testValue",
+			},
+			expectedLabels: model.LabelSet{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			st, err := newLabelKeepStage(test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := processEntries(st, newEntry(nil, test.inputLabels, "", time.Now()))[0]
+			assert.Equal(t, test.expectedLabels, out.Labels)
+		})
+	}
+}
+
+func Test_labelKeepStage_InvalidRegex(t *testing.T) {
+	_, err := newLabelKeepStage(&LabelKeepConfig{"("})
+	require.Error(t, err)
+}
+
+func Test_labelKeepStage_EmptyConfig(t *testing.T) {
+	_, err := newLabelKeepStage(&LabelKeepConfig{})
+	require.EqualError(t, err, ErrEmptyLabelKeepStageConfig)
+}
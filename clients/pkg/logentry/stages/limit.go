@@ -3,6 +3,7 @@ package stages
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
@@ -17,12 +18,15 @@ import (
 )
 
 const (
-	ErrLimitStageInvalidRateOrBurst = "limit stage failed to parse rate or burst"
-	ErrLimitStageByLabelMustDrop    = "When ratelimiting by label, drop must be true"
-	MinReasonableMaxDistinctLabels  = 10000 // 80bytes per rate.Limiter ~ 1MiB memory
+	ErrLimitStageInvalidRateOrBurst     = "limit stage failed to parse rate or burst"
+	ErrLimitStageInvalidByteRateOrBurst = "limit stage failed to parse byte_rate or byte_burst"
+	ErrLimitStageByLabelMustDrop        = "When ratelimiting by label, drop must be true"
+	ErrLimitStageByteRateMustDrop       = "When ratelimiting by byte_rate, drop must be true"
+	MinReasonableMaxDistinctLabels      = 10000 // 80bytes per rate.Limiter ~ 1MiB memory
 )
 
 var ratelimitDropReason = "ratelimit_drop_stage"
+var byteRatelimitDropReason = "byte_ratelimit_drop_stage"
 
 type LimitConfig struct {
 	Rate              float64 `mapstructure:"rate"`
@@ -30,6 +34,14 @@ type LimitConfig struct {
 	Drop              bool    `mapstructure:"drop"`
 	ByLabelName       string  `mapstructure:"by_label_name"`
 	MaxDistinctLabels int     `mapstructure:"max_distinct_labels"`
+
+	// ByteRate and ByteBurst additionally cap the volume of log line
+	// bytes forwarded per second, on top of the entries/sec cap above.
+	// Unlike Rate/Burst, which spend one token per entry, these spend a
+	// number of tokens equal to the entry's line length. Both must be set
+	// together, and only apply when Drop is true.
+	ByteRate  float64 `mapstructure:"byte_rate"`
+	ByteBurst int     `mapstructure:"byte_burst"`
 }
 
 func newLimitStage(logger log.Logger, config interface{}, registerer prometheus.Registerer) (Stage, error) {
@@ -68,6 +80,10 @@ func newLimitStage(logger log.Logger, config interface{}, registerer prometheus.
 		r.rateLimiter = rate.NewLimiter(rate.Limit(cfg.Rate), cfg.Burst)
 	}
 
+	if cfg.ByteRate > 0 {
+		r.byteRateLimiter = rate.NewLimiter(rate.Limit(cfg.ByteRate), cfg.ByteBurst)
+	}
+
 	return r, nil
 }
 
@@ -79,6 +95,13 @@ func validateLimitConfig(cfg *LimitConfig) error {
 	if cfg.ByLabelName != "" && !cfg.Drop {
 		return errors.Errorf(ErrLimitStageByLabelMustDrop)
 	}
+
+	if (cfg.ByteRate > 0) != (cfg.ByteBurst > 0) {
+		return errors.Errorf(ErrLimitStageInvalidByteRateOrBurst)
+	}
+	if cfg.ByteRate > 0 && !cfg.Drop {
+		return errors.Errorf(ErrLimitStageByteRateMustDrop)
+	}
 	return nil
 }
 
@@ -88,6 +111,7 @@ type limitStage struct {
 	cfg                *LimitConfig
 	rateLimiter        *rate.Limiter
 	rateLimiterByLabel util.GenerationalMap[model.LabelValue, *rate.Limiter]
+	byteRateLimiter    *rate.Limiter
 	dropCount          *prometheus.CounterVec
 	dropCountByLabel   *prometheus.CounterVec
 	byLabelName        model.LabelName
@@ -98,7 +122,7 @@ func (m *limitStage) Run(in chan Entry) chan Entry {
 	go func() {
 		defer close(out)
 		for e := range in {
-			if !m.shouldThrottle(e.Labels) {
+			if !m.shouldThrottle(e.Labels, len(e.Line)) {
 				out <- e
 				continue
 			}
@@ -107,7 +131,12 @@ func (m *limitStage) Run(in chan Entry) chan Entry {
 	return out
 }
 
-func (m *limitStage) shouldThrottle(labels model.LabelSet) bool {
+func (m *limitStage) shouldThrottle(labels model.LabelSet, lineSize int) bool {
+	if m.byteRateLimiter != nil && !m.byteRateLimiter.AllowN(time.Now(), lineSize) {
+		m.dropCount.WithLabelValues(byteRatelimitDropReason).Inc()
+		return true
+	}
+
 	if m.cfg.ByLabelName != "" {
 		labelValue, ok := labels[model.LabelName(m.cfg.ByLabelName)]
 		if !ok {
@@ -58,6 +58,20 @@ var testNonAppLogLine = `
 }
 `
 
+var testLimitByteRateYaml = `
+pipeline_stages:
+- json:
+    expressions:
+      app:
+      msg:
+- limit:
+    rate: 1000
+    burst: 1000
+    drop: true
+    byte_rate: 1
+    byte_burst: 200
+`
+
 var plName = "testPipeline"
 
 // TestLimitWaitPipeline is used to verify we properly parse the yaml config and create a working pipeline
@@ -148,3 +162,32 @@ func TestLimitByLabelPipeline(t *testing.T) {
 	assert.True(t, hasTotal)
 	assert.True(t, hasByLabel)
 }
+
+// TestLimitByteRatePipeline verifies that a low byte_rate/byte_burst caps
+// forwarded volume even though the entries/sec rate is left wide open.
+func TestLimitByteRatePipeline(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testLimitByteRateYaml), &plName, registry)
+	require.NoError(t, err)
+
+	logs := make([]Entry, 0)
+	logCount := 5
+	for i := 0; i < logCount; i++ {
+		logs = append(logs, newEntry(nil, model.LabelSet{"app": "loki"}, testMatchLogLineApp1, time.Now()))
+	}
+	out := processEntries(pl, logs...)
+
+	// byte_burst only covers a single line, so only the first entry of
+	// the burst is forwarded before the byte limiter starts dropping.
+	assert.Len(t, out, 1)
+
+	var hasTotal bool
+	mfs, _ := registry.Gather()
+	for _, mf := range mfs {
+		if *mf.Name == "logentry_dropped_lines_total" {
+			hasTotal = true
+			assert.Equal(t, logCount-1, int(mf.Metric[0].Counter.GetValue()))
+		}
+	}
+	assert.True(t, hasTotal)
+}
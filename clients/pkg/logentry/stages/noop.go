@@ -0,0 +1,29 @@
+package stages
+
+type noopStage struct{}
+
+func newNoopStage(_ interface{}) (Stage, error) {
+	return &noopStage{}, nil
+}
+
+// Run implements Stage
+func (m *noopStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			out <- e
+		}
+	}()
+	return out
+}
+
+// Name implements Stage
+func (m *noopStage) Name() string {
+	return StageTypeNoop
+}
+
+// Cleanup implements Stage.
+func (*noopStage) Cleanup() {
+	// no-op
+}
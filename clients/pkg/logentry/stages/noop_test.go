@@ -0,0 +1,52 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+var testNoopPipeline = `
+pipeline_stages:
+- noop:
+`
+
+func TestPipeline_Noop(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testNoopPipeline), nil, prometheus.DefaultRegisterer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := processEntries(pl, newEntry(nil, nil, "sample text", time.Now()))[0]
+	assert.Equal(t, "sample text", out.Line)
+}
+
+func BenchmarkNoopStage(b *testing.B) {
+	stage, err := New(util_log.Logger, nil, StageTypeNoop, nil, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	labels := model.LabelSet{}
+	ts := time.Now()
+	extr := map[string]interface{}{}
+
+	in := make(chan Entry)
+	out := stage.Run(in)
+	go func() {
+		//nolint:revive
+		for range out {
+		}
+	}()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		in <- newEntry(extr, labels, "sample text", ts)
+	}
+	close(in)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/go-logfmt/logfmt"
 	json "github.com/json-iterator/go"
 	"github.com/mitchellh/mapstructure"
 	"github.com/prometheus/client_golang/prometheus"
@@ -18,6 +19,12 @@ import (
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
 )
 
+// Supported values for PackConfig.Encoding.
+const (
+	PackEncodingJSON   = "json"
+	PackEncodingLogfmt = "logfmt"
+)
+
 var (
 	reallyTrue  = true
 	reallyFalse = false
@@ -105,20 +112,54 @@ func (w Packed) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// marshalLogfmt encodes w as a logfmt envelope, the labels sorted for
+// deterministic output the same way MarshalJSON sorts them, with the entry
+// line under the _entry key so a downstream unpack stage configured for
+// logfmt can find it.
+func marshalLogfmt(w Packed) ([]byte, error) {
+	keys := make([]string, 0, len(w.Labels))
+	for k := range w.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	enc := logfmt.NewEncoder(&buf)
+	for _, k := range keys {
+		if err := enc.EncodeKeyval(k, w.Labels[k]); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.EncodeKeyval(logqlmodel.PackedEntryKey, w.Entry); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // PackConfig contains the configuration for a packStage
 type PackConfig struct {
 	Labels          []string `mapstrcuture:"labels"`
 	IngestTimestamp *bool    `mapstructure:"ingest_timestamp"`
+	// Encoding selects the envelope format the entry is packed into: "json"
+	// (the default, see Packed) or "logfmt", where the entry line is packed
+	// under the "_entry" key alongside the packed labels, mirroring the
+	// unpack stage's expectations for whichever format it's configured to
+	// read.
+	Encoding string `mapstructure:"encoding"`
 }
 
 // validatePackConfig validates the PackConfig for the packStage
-//
-//nolint:unparam // Always returns nil until someone adds more validation and can remove this.
 func validatePackConfig(cfg *PackConfig) error {
 	// Default the IngestTimestamp value to be true
 	if cfg.IngestTimestamp == nil {
 		cfg.IngestTimestamp = &reallyTrue
 	}
+	if cfg.Encoding == "" {
+		cfg.Encoding = PackEncodingJSON
+	}
+	if cfg.Encoding != PackEncodingJSON && cfg.Encoding != PackEncodingLogfmt {
+		return fmt.Errorf("invalid encoding '%s', must be '%s' or '%s'", cfg.Encoding, PackEncodingJSON, PackEncodingLogfmt)
+	}
 	return nil
 }
 
@@ -187,11 +228,19 @@ func (m *packStage) pack(e Entry) Entry {
 		Entry:  e.Line,
 	}
 
-	// Marshal to json
-	wl, err := json.Marshal(w)
+	var (
+		wl  []byte
+		err error
+	)
+	switch m.cfg.Encoding {
+	case PackEncodingLogfmt:
+		wl, err = marshalLogfmt(w)
+	default:
+		wl, err = json.Marshal(w)
+	}
 	if err != nil {
 		if Debug {
-			level.Debug(m.logger).Log("msg", "pack stage failed to marshal packed object to json, packing will be skipped", "err", err)
+			level.Debug(m.logger).Log("msg", fmt.Sprintf("pack stage failed to marshal packed object to %s, packing will be skipped", m.cfg.Encoding), "err", err)
 		}
 		return e
 	}
@@ -102,6 +102,44 @@ func TestPackPipeline(t *testing.T) {
 	assert.Equal(t, testRegexLogLine, w.Entry)
 }
 
+func Test_packStage_Run_LogfmtEncoding(t *testing.T) {
+	config := &PackConfig{
+		Labels:          []string{"foo", "bar"},
+		IngestTimestamp: &reallyFalse,
+		Encoding:        PackEncodingLogfmt,
+	}
+	require.NoError(t, validatePackConfig(config))
+
+	m, err := newPackStage(util_log.Logger, config, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	entry := Entry{
+		Extracted: map[string]interface{}{
+			"foo": "bar",
+			"bar": "baz",
+		},
+		Entry: api.Entry{
+			Labels: model.LabelSet{
+				"foo": "bar",
+				"bar": "baz",
+			},
+			Entry: logproto.Entry{
+				Timestamp: time.Unix(1, 0),
+				Line:      "test line 1",
+			},
+		},
+	}
+
+	out := processEntries(m, entry)
+	assert.Equal(t, model.LabelSet{}, out[0].Labels)
+	assert.Equal(t, `bar=baz foo=bar _entry="test line 1"`, out[0].Line)
+}
+
+func Test_validatePackConfig_InvalidEncoding(t *testing.T) {
+	cfg := &PackConfig{Encoding: "yaml"}
+	require.Error(t, validatePackConfig(cfg))
+}
+
 func Test_packStage_Run(t *testing.T) {
 	// Enable debug logging
 	cfg := &ww.Config{}
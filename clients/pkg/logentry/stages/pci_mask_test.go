@@ -0,0 +1,91 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+var testPCIMaskSourceYaml = `
+pipeline_stages:
+- json:
+    expressions:
+      message:
+- pci_mask:
+    source: message
+`
+
+var testPCIMaskLineYaml = `
+pipeline_stages:
+- pci_mask: {}
+`
+
+func TestPipeline_PCIMask_Visa_HyphenSeparated(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testPCIMaskLineYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, "card on file: 4111-1111-1111-1234", time.Now()))[0]
+	assert.Equal(t, "card on file: XXXX-XXXX-XXXX-1234", out.Line)
+}
+
+func TestPipeline_PCIMask_Mastercard_SpaceSeparated(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testPCIMaskLineYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, "charged 5500 0000 0000 0004", time.Now()))[0]
+	assert.Equal(t, "charged XXXX-XXXX-XXXX-0004", out.Line)
+}
+
+func TestPipeline_PCIMask_Amex(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testPCIMaskLineYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, "amex 3782 822463 10005", time.Now()))[0]
+	assert.Equal(t, "amex XXXX-XXXX-XXXX-0005", out.Line)
+}
+
+func TestPipeline_PCIMask_Discover(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testPCIMaskLineYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, "discover 6011-0000-0000-0004", time.Now()))[0]
+	assert.Equal(t, "discover XXXX-XXXX-XXXX-0004", out.Line)
+}
+
+func TestPipeline_PCIMask_Source(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testPCIMaskSourceYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `{"message":"card 4111-1111-1111-1234 declined"}`, time.Now()))[0]
+	assert.Equal(t, "card XXXX-XXXX-XXXX-1234 declined", out.Extracted["message"])
+}
+
+func TestPipeline_PCIMask_NoMatchIsUnchanged(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testPCIMaskLineYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, "no card numbers here", time.Now()))[0]
+	assert.Equal(t, "no card numbers here", out.Line)
+}
+
+func Test_maskCardNumber(t *testing.T) {
+	assert.Equal(t, "XXXX-XXXX-XXXX-1234", maskCardNumber("4111-1111-1111-1234"))
+	assert.Equal(t, "XXXX-XXXX-XXXX-1234", maskCardNumber("4111 1111 1111 1234"))
+}
@@ -15,6 +15,11 @@ import (
 	"github.com/prometheus/common/model"
 )
 
+// groupReferencePattern matches a $1-style or ${name}-style group reference
+// in a replace string, which is how we decide whether to expand it with
+// regexp.Regexp.ExpandString instead of the default per-group template.
+var groupReferencePattern = regexp.MustCompile(`\$(\w+|\{\w+\})`)
+
 // Config Errors
 const (
 	ErrEmptyReplaceStageConfig = "empty replace stage configuration"
@@ -26,6 +31,7 @@ type ReplaceConfig struct {
 	Expression string  `mapstructure:"expression"`
 	Source     *string `mapstructure:"source"`
 	Replace    string  `mapstructure:"replace"`
+	Count      *int    `mapstructure:"count"`
 }
 
 // validateReplaceConfig validates the config and return a regex
@@ -118,7 +124,15 @@ func (r *replaceStage) Process(_ model.LabelSet, extracted map[string]interface{
 
 	// Get string of matched captured groups. We will use this to extract all named captured groups
 	match := r.expression.FindStringSubmatch(*input)
-	matchAllIndex := r.expression.FindAllStringSubmatchIndex(*input, -1)
+
+	// Count limits how many occurrences of expression are replaced, the
+	// same as the count argument to FindAllStringSubmatchIndex: -1 (the
+	// default, when Count is unset) replaces every occurrence.
+	count := -1
+	if r.cfg.Count != nil {
+		count = *r.cfg.Count
+	}
+	matchAllIndex := r.expression.FindAllStringSubmatchIndex(*input, count)
 
 	if matchAllIndex == nil {
 		if Debug {
@@ -127,24 +141,37 @@ func (r *replaceStage) Process(_ model.LabelSet, extracted map[string]interface{
 		return
 	}
 
-	// All extracted values will be available for templating
-	td := r.getTemplateData(extracted)
+	var (
+		result      string
+		capturedMap map[string]string
+		err         error
+	)
 
-	// Initialize the template with the "replace" string defined by user
-	templ, err := template.New("pipeline_template").Funcs(functionMap).Parse(r.cfg.Replace)
-	if err != nil {
-		if Debug {
-			level.Debug(r.logger).Log("msg", "template initialization error", "err", err)
+	if groupReferencePattern.MatchString(r.cfg.Replace) {
+		// The replace string references capture groups by number ($1) or
+		// name (${name}), so expand it against each match as a whole,
+		// instead of substituting each captured group independently.
+		result = r.getReplacedEntryExpand(matchAllIndex, *input)
+	} else {
+		// All extracted values will be available for templating
+		td := r.getTemplateData(extracted)
+
+		// Initialize the template with the "replace" string defined by user
+		templ, tErr := template.New("pipeline_template").Funcs(functionMap).Parse(r.cfg.Replace)
+		if tErr != nil {
+			if Debug {
+				level.Debug(r.logger).Log("msg", "template initialization error", "err", tErr)
+			}
+			return
 		}
-		return
-	}
 
-	result, capturedMap, err := r.getReplacedEntry(matchAllIndex, *input, td, templ)
-	if err != nil {
-		if Debug {
-			level.Debug(r.logger).Log("msg", "failed to execute template on extracted value", "err", err)
+		result, capturedMap, err = r.getReplacedEntry(matchAllIndex, *input, td, templ)
+		if err != nil {
+			if Debug {
+				level.Debug(r.logger).Log("msg", "failed to execute template on extracted value", "err", err)
+			}
+			return
 		}
-		return
 	}
 
 	if r.cfg.Source != nil {
@@ -158,6 +185,8 @@ func (r *replaceStage) Process(_ model.LabelSet, extracted map[string]interface{
 		if i != 0 && name != "" {
 			if v, ok := capturedMap[match[i]]; ok {
 				extracted[name] = v
+			} else if i < len(match) {
+				extracted[name] = match[i]
 			}
 		}
 	}
@@ -178,6 +207,12 @@ func (r *replaceStage) getReplacedEntry(matchAllIndex [][]int, input string, td
 	// captured group. Here 0-19 is "11.11.11.11 - frank",  0-11 is "11.11.11.11" and
 	// 14-19 is "frank". So, we advance by 2 index to get the next match
 	for _, matchIndex := range matchAllIndex {
+		if len(matchIndex) == 2 {
+			// The expression has no capture groups, so there's nothing past
+			// the whole-match indices to loop over below. Substitute the
+			// whole match itself, the same way a single capture group would be.
+			matchIndex = append(matchIndex, matchIndex[0], matchIndex[1])
+		}
 		for i := 2; i < len(matchIndex); i += 2 {
 			if matchIndex[i] == -1 {
 				continue
@@ -200,6 +235,22 @@ func (r *replaceStage) getReplacedEntry(matchAllIndex [][]int, input string, td
 	return result + input[previousInputEndIndex:], capturedMap, nil
 }
 
+// getReplacedEntryExpand replaces each whole match in input with r.cfg.Replace
+// expanded against that match's capture groups, using the same $1, $2, and
+// ${name} syntax as regexp.Regexp.Expand. A literal $ in the replace string
+// must be escaped as $$.
+func (r *replaceStage) getReplacedEntryExpand(matchAllIndex [][]int, input string) string {
+	var result string
+	previousInputEndIndex := 0
+	for _, matchIndex := range matchAllIndex {
+		start, end := matchIndex[0], matchIndex[1]
+		expanded := r.expression.ExpandString(nil, r.cfg.Replace, input, matchIndex)
+		result += input[previousInputEndIndex:start] + string(expanded)
+		previousInputEndIndex = end
+	}
+	return result + input[previousInputEndIndex:]
+}
+
 func (r *replaceStage) getTemplateData(extracted map[string]interface{}) map[string]string {
 	td := make(map[string]string)
 	for k, v := range extracted {
@@ -76,9 +76,30 @@ pipeline_stages:
       replace: ''
 `
 
+var testReplaceYamlWithCount = `
+---
+pipeline_stages:
+  -
+    replace:
+      expression: 'o'
+      replace: '0'
+      count: 3
+`
+
+var testReplaceYamlWithGroupReference = `
+---
+pipeline_stages:
+  -
+    replace:
+      expression: '(?P<user>\w+)@(?P<host>\S+)'
+      replace: '$user at ${host}'
+`
+
 var testReplaceLogLine = `11.11.11.11 - frank [25/Jan/2000:14:00:01 -0500] "GET /1986.js HTTP/1.1" 200 932 "-" "Mozilla/5.0 (Windows; U; Windows NT 5.1; de; rv:1.9.1.7) Gecko/20091221 Firefox/3.5.7 GTB6"`
 var testReplaceLogJSONLine = `{"time":"2019-01-01T01:00:00.000000001Z", "level": "info", "msg": "11.11.11.11 - \"POST /loki/api/push/ HTTP/1.1\" 200 932 \"-\" \"Mozilla/5.0 (Windows; U; Windows NT 5.1; de; rv:1.9.1.7) Gecko/20091221 Firefox/3.5.7 GTB6\""}`
 var testReplaceLogLineAdjacentCaptureGroups = `abc`
+var testReplaceLogLineCount = `foo boo moo`
+var testReplaceLogLineGroupReference = `alice@example.com bob@test.org`
 
 func TestPipeline_Replace(t *testing.T) {
 	t.Parallel()
@@ -158,6 +179,21 @@ func TestPipeline_Replace(t *testing.T) {
 			map[string]interface{}{},
 			``,
 		},
+		"successfully run a pipeline replacing only the first count matches": {
+			testReplaceYamlWithCount,
+			testReplaceLogLineCount,
+			map[string]interface{}{},
+			`f00 b0o moo`,
+		},
+		"successfully run a pipeline with a replace value using group references": {
+			testReplaceYamlWithGroupReference,
+			testReplaceLogLineGroupReference,
+			map[string]interface{}{
+				"user": "alice",
+				"host": "example.com",
+			},
+			`alice at example.com bob at test.org`,
+		},
 	}
 
 	for testName, testData := range tests {
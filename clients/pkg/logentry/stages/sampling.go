@@ -1,6 +1,7 @@
 package stages
 
 import (
+	"hash/fnv"
 	"math"
 	"math/rand"
 	"time"
@@ -9,7 +10,10 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/uber/jaeger-client-go/utils"
+
+	"github.com/grafana/loki/v3/pkg/util"
 )
 
 const (
@@ -26,6 +30,18 @@ type SamplingConfig struct {
 	DropReason *string `mapstructure:"drop_counter_reason"`
 	//
 	SamplingRate float64 `mapstructure:"rate"`
+
+	// ByLabelName, when set, switches sampling from a random per-entry draw
+	// to a deterministic hash of the named label's value, so every entry
+	// sharing that value gets the same keep/drop outcome. Entries missing
+	// the label fall back to the behavior below.
+	ByLabelName string `mapstructure:"by_label"`
+
+	// Deterministic hashes the log line itself instead of drawing a random
+	// number, so repeated identical lines are sampled consistently. It's
+	// ignored when ByLabelName is set and present on the entry, since the
+	// label hash already makes the decision deterministic.
+	Deterministic bool `mapstructure:"deterministic"`
 }
 
 // validateSamplingConfig validates the SamplingConfig for the sampleStage
@@ -62,6 +78,7 @@ func newSamplingStage(logger log.Logger, config interface{}, registerer promethe
 		logger:           log.With(logger, "component", "stage", "type", "sampling"),
 		cfg:              cfg,
 		dropCount:        getDropCountMetric(registerer),
+		sampledCount:     getSampledCountMetric(registerer),
 		samplingBoundary: samplingBoundary,
 		source:           source,
 	}, nil
@@ -71,6 +88,7 @@ type samplingStage struct {
 	logger           log.Logger
 	cfg              *SamplingConfig
 	dropCount        *prometheus.CounterVec
+	sampledCount     *prometheus.CounterVec
 	samplingBoundary uint64
 	source           rand.Source
 }
@@ -80,7 +98,8 @@ func (m *samplingStage) Run(in chan Entry) chan Entry {
 	go func() {
 		defer close(out)
 		for e := range in {
-			if m.isSampled() {
+			if m.isSampled(e) {
+				m.sampledCount.WithLabelValues(*m.cfg.DropReason).Inc()
 				out <- e
 				continue
 			}
@@ -90,12 +109,34 @@ func (m *samplingStage) Run(in chan Entry) chan Entry {
 	return out
 }
 
+// isSampled decides whether e is kept. When ByLabelName names a label
+// present on e, or Deterministic is set, the decision is a hash of the
+// label value or line rather than a random draw, so the same key always
+// yields the same outcome; otherwise it falls back to the original
+// jaeger-derived random sampling below.
+func (m *samplingStage) isSampled(e Entry) bool {
+	if m.cfg.ByLabelName != "" {
+		if labelValue, ok := e.Labels[model.LabelName(m.cfg.ByLabelName)]; ok {
+			return m.samplingBoundary >= hashKey(string(labelValue))&maxRandomNumber
+		}
+	} else if m.cfg.Deterministic {
+		return m.samplingBoundary >= hashKey(e.Line)&maxRandomNumber
+	}
+
+	return m.samplingBoundary >= m.randomID()&maxRandomNumber
+}
+
+// hashKey returns the FNV-64a hash of key, used as a deterministic
+// replacement for randomID when a stable sampling decision is required.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(util.YoloBuf(key))
+	return h.Sum64()
+}
+
 // code from jaeger project.
 // github.com/uber/jaeger-client-go@v2.30.0+incompatible/sampler.go:144
 // func (s *ProbabilisticSampler) IsSampled(id TraceID, operation string) (bool, []Tag)
-func (m *samplingStage) isSampled() bool {
-	return m.samplingBoundary >= m.randomID()&maxRandomNumber
-}
 func (m *samplingStage) randomID() uint64 {
 	val := m.randomNumber()
 	for val == 0 {
@@ -116,3 +157,12 @@ func (m *samplingStage) Name() string {
 func (*samplingStage) Cleanup() {
 	// no-op
 }
+
+// getSampledCountMetric returns the shared counter of log lines kept by a
+// sampling stage, labeled the same way as getDropCountMetric so the two can
+// be compared side by side.
+func getSampledCountMetric(registerer prometheus.Registerer) *prometheus.CounterVec {
+	return util.RegisterCounterVec(registerer, "logentry", "sampled_lines_total",
+		"A count of all log lines kept by a sampling pipeline stage",
+		[]string{"reason"})
+}
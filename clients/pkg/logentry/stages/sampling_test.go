@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -38,6 +39,49 @@ func TestSamplingPipeline(t *testing.T) {
 
 }
 
+func TestSamplingStage_Deterministic(t *testing.T) {
+	logger := util_log.Logger
+	cfg := &SamplingConfig{SamplingRate: 0.5, Deterministic: true}
+	require.NoError(t, validateSamplingConfig(cfg))
+
+	stage, err := newSamplingStage(logger, cfg, prometheus.NewRegistry())
+	require.NoError(t, err)
+	s := stage.(*samplingStage)
+
+	e := newEntry(nil, nil, testMatchLogLineApp1, time.Now())
+	first := s.isSampled(e)
+	for i := 0; i < 100; i++ {
+		require.Equal(t, first, s.isSampled(e), "a deterministic decision must be stable across repeated calls with the same line")
+	}
+}
+
+func TestSamplingStage_ByLabel(t *testing.T) {
+	logger := util_log.Logger
+	cfg := &SamplingConfig{SamplingRate: 0.5, ByLabelName: "tenant"}
+	require.NoError(t, validateSamplingConfig(cfg))
+
+	stage, err := newSamplingStage(logger, cfg, prometheus.NewRegistry())
+	require.NoError(t, err)
+	s := stage.(*samplingStage)
+
+	tenantA := newEntry(nil, model.LabelSet{"tenant": "a"}, testMatchLogLineApp1, time.Now())
+	tenantAAgain := newEntry(nil, model.LabelSet{"tenant": "a"}, testMatchLogLineApp2, time.Now())
+	tenantB := newEntry(nil, model.LabelSet{"tenant": "b"}, testMatchLogLineApp1, time.Now())
+
+	decisionA := s.isSampled(tenantA)
+	assert.Equal(t, decisionA, s.isSampled(tenantAAgain), "entries sharing a label value must get the same sampling decision, regardless of line content")
+
+	// Different label values are free to land on either side; this only
+	// verifies the by-label path doesn't panic and falls through to a
+	// decision rather than erroring.
+	_ = s.isSampled(tenantB)
+
+	// An entry missing the configured label falls back to the stage's
+	// underlying random sampling instead of erroring.
+	unlabeled := newEntry(nil, nil, testMatchLogLineApp1, time.Now())
+	assert.NotPanics(t, func() { s.isSampled(unlabeled) })
+}
+
 func Test_validateSamplingConfig(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -37,10 +37,24 @@ const (
 	StageTypeMultiline       = "multiline"
 	StageTypePack            = "pack"
 	StageTypeLabelAllow      = "labelallow"
+	StageTypeLabelKeep       = "label_keep"
 	StageTypeStaticLabels    = "static_labels"
 	StageTypeDecolorize      = "decolorize"
 	StageTypeEventLogMessage = "eventlogmessage"
 	StageTypeGeoIP           = "geoip"
+	StageTypeGoLog           = "go_log"
+	StageTypeWindowsEvent    = "windowsevent"
+	StageTypeWASM            = "wasm"
+	StageTypeNoop            = "noop"
+	StageTypeBase64Decode    = "base64_decode"
+	StageTypeBase64Encode    = "base64_encode"
+	StageTypeIPAnonymize     = "ip_anonymize"
+	StageTypePCIMask         = "pci_mask"
+	StageTypeHash            = "hash"
+	StageTypeDecompress      = "decompress"
+	// StageTypeDebug only does anything in a build tagged promtail_debug;
+	// see newDebugStage.
+	StageTypeDebug = "debug"
 	// Deprecated. Renamed to `structured_metadata`. Will be removed after the migration.
 	StageTypeNonIndexedLabels   = "non_indexed_labels"
 	StageTypeStructuredMetadata = "structured_metadata"
@@ -196,6 +210,9 @@ func initCreators() {
 		StageTypeLabelAllow: func(params StageCreationParams) (Stage, error) {
 			return newLabelAllowStage(params.config)
 		},
+		StageTypeLabelKeep: func(params StageCreationParams) (Stage, error) {
+			return newLabelKeepStage(params.config)
+		},
 		StageTypeStaticLabels: func(params StageCreationParams) (Stage, error) {
 			return newStaticLabelsStage(params.logger, params.config)
 		},
@@ -206,7 +223,40 @@ func initCreators() {
 			return newEventLogMessageStage(params.logger, params.config)
 		},
 		StageTypeGeoIP: func(params StageCreationParams) (Stage, error) {
-			return newGeoIPStage(params.logger, params.config)
+			return newGeoIPStage(params.logger, params.config, params.registerer)
+		},
+		StageTypeGoLog: func(params StageCreationParams) (Stage, error) {
+			return newGoLogStage(params.logger, params.config)
+		},
+		StageTypeWindowsEvent: func(params StageCreationParams) (Stage, error) {
+			return newWindowsEventStage(params.logger, params.config)
+		},
+		StageTypeWASM: func(params StageCreationParams) (Stage, error) {
+			return newWASMStage(params.logger, params.config)
+		},
+		StageTypeNoop: func(params StageCreationParams) (Stage, error) {
+			return newNoopStage(params.config)
+		},
+		StageTypeBase64Decode: func(params StageCreationParams) (Stage, error) {
+			return newBase64DecodeStage(params.logger, params.config)
+		},
+		StageTypeBase64Encode: func(params StageCreationParams) (Stage, error) {
+			return newBase64EncodeStage(params.logger, params.config)
+		},
+		StageTypeIPAnonymize: func(params StageCreationParams) (Stage, error) {
+			return newIPAnonymizeStage(params.logger, params.config, params.registerer)
+		},
+		StageTypePCIMask: func(params StageCreationParams) (Stage, error) {
+			return newPCIMaskStage(params.logger, params.config, params.registerer)
+		},
+		StageTypeHash: func(params StageCreationParams) (Stage, error) {
+			return newHashStage(params.logger, params.config)
+		},
+		StageTypeDecompress: func(params StageCreationParams) (Stage, error) {
+			return newDecompressStage(params.logger, params.config)
+		},
+		StageTypeDebug: func(params StageCreationParams) (Stage, error) {
+			return newDebugStage(params.logger, params.config)
 		},
 		StageTypeNonIndexedLabels:   newStructuredMetadataStage,
 		StageTypeStructuredMetadata: newStructuredMetadataStage,
@@ -3,6 +3,7 @@ package stages
 import (
 	"github.com/go-kit/log"
 	"github.com/mitchellh/mapstructure"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/pkg/logproto"
@@ -19,14 +20,16 @@ func newStructuredMetadataStage(params StageCreationParams) (Stage, error) {
 		return nil, err
 	}
 	return &structuredMetadataStage{
-		cfgs:   *cfgs,
-		logger: params.logger,
+		cfgs:    *cfgs,
+		logger:  params.logger,
+		created: getStructuredMetadataCreatedMetric(params.registerer),
 	}, nil
 }
 
 type structuredMetadataStage struct {
-	cfgs   LabelsConfig
-	logger log.Logger
+	cfgs    LabelsConfig
+	logger  log.Logger
+	created prometheus.Counter
 }
 
 func (s *structuredMetadataStage) Name() string {
@@ -40,13 +43,38 @@ func (*structuredMetadataStage) Cleanup() {
 
 func (s *structuredMetadataStage) Run(in chan Entry) chan Entry {
 	return RunWith(in, func(e Entry) Entry {
+		before := len(e.StructuredMetadata)
 		processLabelsConfigs(s.logger, e.Extracted, s.cfgs, func(labelName model.LabelName, labelValue model.LabelValue) {
 			e.StructuredMetadata = append(e.StructuredMetadata, logproto.LabelAdapter{Name: string(labelName), Value: string(labelValue)})
 		})
-		return s.extractFromLabels(e)
+		e = s.extractFromLabels(e)
+		s.created.Add(float64(len(e.StructuredMetadata) - before))
+		return e
 	})
 }
 
+// getStructuredMetadataCreatedMetric returns a counter tracking how many
+// structured metadata entries the structured_metadata stage has promoted,
+// so operators moving high-cardinality fields off labels can confirm the
+// stage is doing what they expect without inspecting individual streams.
+func getStructuredMetadataCreatedMetric(registerer prometheus.Registerer) prometheus.Counter {
+	created := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "logentry",
+		Name:      "structured_metadata_created_total",
+		Help:      "A count of structured metadata entries created by the structured_metadata pipeline stage",
+	})
+	err := registerer.Register(created)
+	if err != nil {
+		if existing, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			created = existing.ExistingCollector.(prometheus.Counter)
+		} else {
+			// Same behavior as MustRegister if the error is not for AlreadyRegistered
+			panic(err)
+		}
+	}
+	return created
+}
+
 func (s *structuredMetadataStage) extractFromLabels(e Entry) Entry {
 	labels := e.Labels
 	foundLabels := []model.LabelName{}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
@@ -175,3 +176,22 @@ func Test_StructuredMetadataStage(t *testing.T) {
 		})
 	}
 }
+
+func Test_StructuredMetadataStage_CreatedMetric(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	plName := "test_pipeline"
+	pl, err := NewPipeline(util_log.Logger, loadConfig(pipelineStagesStructuredMetadataFromLogfmt), &plName, registry)
+	require.NoError(t, err)
+
+	processEntries(pl, newEntry(nil, nil, "app=loki component=ingester", time.Now()))
+
+	require.Equal(t, 1, testutil.CollectAndCount(registry, "logentry_structured_metadata_created_total"))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() == "logentry_structured_metadata_created_total" {
+			require.Equal(t, float64(1), f.GetMetric()[0].GetCounter().GetValue())
+		}
+	}
+}
@@ -32,6 +32,7 @@ const (
 
 	TimestampActionOnFailureSkip    = "skip"
 	TimestampActionOnFailureFudge   = "fudge"
+	TimestampActionOnFailureZero    = "zero"
 	TimestampActionOnFailureDefault = TimestampActionOnFailureFudge
 
 	// Maximum number of "streams" for which we keep the last known timestamp
@@ -39,7 +40,7 @@ const (
 )
 
 var (
-	TimestampActionOnFailureOptions = []string{TimestampActionOnFailureSkip, TimestampActionOnFailureFudge}
+	TimestampActionOnFailureOptions = []string{TimestampActionOnFailureSkip, TimestampActionOnFailureFudge, TimestampActionOnFailureZero}
 )
 
 // TimestampConfig configures timestamp extraction
@@ -207,7 +208,13 @@ func (ts *timestampStage) processActionOnFailure(labels model.LabelSet, t *time.
 	case TimestampActionOnFailureFudge:
 		ts.processActionOnFailureFudge(labels, t)
 	case TimestampActionOnFailureSkip:
-		// Nothing to do
+		// Nothing to do, entry keeps the timestamp it already has (typically
+		// the collection time promtail stamped it with).
+	case TimestampActionOnFailureZero:
+		// Set it to the Unix epoch instead, so a failed parse is obvious in
+		// the entry's timestamp rather than silently looking like it was
+		// collected on time.
+		*t = time.Unix(0, 0).UTC()
 	}
 }
 
@@ -0,0 +1,213 @@
+package stages
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Config errors
+const (
+	ErrEmptyWASMStageConfig  = "wasm stage config cannot be empty"
+	ErrWASMStagePathRequired = "wasm stage path is required"
+)
+
+const (
+	// defaultWASMTimeout bounds how long a single process_line call may run
+	// before the module instance is torn down and the line passes through
+	// unmodified.
+	defaultWASMTimeout = 100 * time.Millisecond
+
+	wasmProcessLineFunc = "process_line"
+	wasmAllocFunc       = "alloc"
+	wasmDeallocFunc     = "dealloc"
+)
+
+// WASMConfig configures the wasm stage.
+type WASMConfig struct {
+	// Path to a WASM module exporting a process_line(ptr, len) (new_ptr,
+	// new_len) function, plus alloc(size) ptr and dealloc(ptr, size) helpers
+	// used to pass the line into and out of the module's linear memory.
+	Path string `mapstructure:"path"`
+
+	// TimeoutMS caps how long a single process_line call is allowed to run.
+	// Defaults to 100ms.
+	TimeoutMS int `mapstructure:"timeout_ms"`
+}
+
+func validateWASMConfig(cfg *WASMConfig) error {
+	if cfg == nil {
+		return errors.New(ErrEmptyWASMStageConfig)
+	}
+	if cfg.Path == "" {
+		return errors.New(ErrWASMStagePathRequired)
+	}
+	return nil
+}
+
+func newWASMStage(logger log.Logger, config interface{}) (Stage, error) {
+	cfg := &WASMConfig{}
+	if err := mapstructure.Decode(config, cfg); err != nil {
+		return nil, err
+	}
+	if err := validateWASMConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	timeout := defaultWASMTimeout
+	if cfg.TimeoutMS > 0 {
+		timeout = time.Duration(cfg.TimeoutMS) * time.Millisecond
+	}
+
+	wasmBytes, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading wasm stage module")
+	}
+
+	ctx := context.Background()
+	// WithCloseOnContextDone makes wazero actually enforce a Call's context
+	// deadline against a running function, closing the module if it's still
+	// running once the deadline passes. Without it, timeout only bounds
+	// well-behaved modules that periodically return; a tight loop in a
+	// misbehaving one would run forever regardless of timeout.
+	runtimeCfg := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	runtime := wazero.NewRuntimeWithConfig(ctx, runtimeCfg)
+
+	compiled, err := runtime.CompileModule(ctx, wasmBytes)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, errors.Wrap(err, "compiling wasm stage module")
+	}
+
+	module, err := runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, errors.Wrap(err, "instantiating wasm stage module")
+	}
+
+	processLine := module.ExportedFunction(wasmProcessLineFunc)
+	if processLine == nil {
+		runtime.Close(ctx)
+		return nil, errors.Errorf("wasm module does not export %q", wasmProcessLineFunc)
+	}
+	alloc := module.ExportedFunction(wasmAllocFunc)
+	dealloc := module.ExportedFunction(wasmDeallocFunc)
+	if alloc == nil || dealloc == nil {
+		runtime.Close(ctx)
+		return nil, errors.Errorf("wasm module must export %q and %q", wasmAllocFunc, wasmDeallocFunc)
+	}
+
+	return &wasmStage{
+		logger:      logger,
+		cfg:         cfg,
+		timeout:     timeout,
+		runtime:     runtime,
+		module:      module,
+		processLine: processLine,
+		alloc:       alloc,
+		dealloc:     dealloc,
+	}, nil
+}
+
+// wasmStage runs each log line through a user-supplied WASM module. The
+// compiled module and its single instance are reused across entries; calls
+// into it are bounded by timeout so a misbehaving module can't stall the
+// pipeline.
+type wasmStage struct {
+	logger  log.Logger
+	cfg     *WASMConfig
+	timeout time.Duration
+
+	runtime     wazero.Runtime
+	module      api.Module
+	processLine api.Function
+	alloc       api.Function
+	dealloc     api.Function
+}
+
+// Run implements Stage.
+func (w *wasmStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		defer w.runtime.Close(context.Background())
+		for e := range in {
+			w.process(&e.Line)
+			out <- e
+		}
+	}()
+	return out
+}
+
+func (w *wasmStage) process(line *string) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+
+	in := []byte(*line)
+	mem := w.module.Memory()
+
+	inPtr, err := w.callAlloc(ctx, uint32(len(in)))
+	if err != nil {
+		level.Warn(w.logger).Log("msg", "wasm stage failed to allocate guest memory", "err", err)
+		return
+	}
+	defer w.callDealloc(ctx, inPtr, uint32(len(in)))
+
+	if !mem.Write(inPtr, in) {
+		level.Warn(w.logger).Log("msg", "wasm stage failed to write line into guest memory")
+		return
+	}
+
+	results, err := w.processLine.Call(ctx, uint64(inPtr), uint64(len(in)))
+	if err != nil {
+		level.Warn(w.logger).Log("msg", "wasm stage process_line call failed or timed out", "err", err, "timeout", w.timeout)
+		return
+	}
+	if len(results) != 2 {
+		level.Warn(w.logger).Log("msg", "wasm stage process_line returned an unexpected number of results", "got", len(results))
+		return
+	}
+
+	outPtr, outLen := uint32(results[0]), uint32(results[1])
+	defer w.callDealloc(ctx, outPtr, outLen)
+
+	out, ok := mem.Read(outPtr, outLen)
+	if !ok {
+		level.Warn(w.logger).Log("msg", "wasm stage failed to read transformed line from guest memory")
+		return
+	}
+
+	// Copy out of guest memory before it can be reused or freed.
+	*line = string(append([]byte(nil), out...))
+}
+
+func (w *wasmStage) callAlloc(ctx context.Context, size uint32) (uint32, error) {
+	results, err := w.alloc.Call(ctx, uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	return uint32(results[0]), nil
+}
+
+func (w *wasmStage) callDealloc(ctx context.Context, ptr, size uint32) {
+	if _, err := w.dealloc.Call(ctx, uint64(ptr), uint64(size)); err != nil {
+		level.Debug(w.logger).Log("msg", "wasm stage dealloc call failed", "err", err)
+	}
+}
+
+// Name implements Stage.
+func (w *wasmStage) Name() string {
+	return StageTypeWASM
+}
+
+// Cleanup implements Stage.
+func (*wasmStage) Cleanup() {
+	// no-op: the runtime is closed once Run's input channel is drained.
+}
@@ -0,0 +1,71 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+func Test_ValidateWASMConfig(t *testing.T) {
+	tests := []struct {
+		config    WASMConfig
+		wantError error
+	}{
+		{
+			WASMConfig{Path: "testdata/process_line.wasm"},
+			nil,
+		},
+		{
+			WASMConfig{Path: "testdata/process_line.wasm", TimeoutMS: 50},
+			nil,
+		},
+		{
+			WASMConfig{},
+			errors.New(ErrWASMStagePathRequired),
+		},
+	}
+	for _, tt := range tests {
+		err := validateWASMConfig(&tt.config)
+		if tt.wantError == nil {
+			require.NoError(t, err)
+			continue
+		}
+		require.EqualError(t, err, tt.wantError.Error())
+	}
+}
+
+func Test_ValidateWASMConfig_NilConfig(t *testing.T) {
+	err := validateWASMConfig(nil)
+	require.EqualError(t, err, ErrEmptyWASMStageConfig)
+}
+
+// testdata/process_line.wasm truncates its input to at most 8 bytes.
+func Test_WASMStage_TransformsLine(t *testing.T) {
+	stage, err := newWASMStage(util_log.Logger, &WASMConfig{Path: "testdata/process_line.wasm"})
+	require.NoError(t, err)
+
+	out := processEntries(stage, newEntry(nil, nil, "hello world this is long", time.Now()))[0]
+	assert.Equal(t, "hello wo", out.Line)
+}
+
+func Test_WASMStage_MissingAllocDeallocExports(t *testing.T) {
+	_, err := newWASMStage(util_log.Logger, &WASMConfig{Path: "testdata/no_alloc.wasm"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "must export")
+}
+
+// testdata/spin_line.wasm's process_line never returns, so the call is
+// expected to hit the configured timeout and leave the line unmodified.
+func Test_WASMStage_TimeoutLeavesLineUnmodified(t *testing.T) {
+	stage, err := newWASMStage(util_log.Logger, &WASMConfig{Path: "testdata/spin_line.wasm", TimeoutMS: 50})
+	require.NoError(t, err)
+
+	const line = "unchanged"
+	out := processEntries(stage, newEntry(nil, nil, line, time.Now()))[0]
+	assert.Equal(t, line, out.Line)
+}
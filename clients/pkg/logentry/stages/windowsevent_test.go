@@ -0,0 +1,62 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+var testWindowsEventPipeline = `
+pipeline_stages:
+- windowsevent: {}
+`
+
+const testWindowsEventXML = `<Event xmlns="http://schemas.microsoft.com/win/2004/08/events/event">
+  <System>
+    <Provider Name="Microsoft-Windows-Security-Auditing"/>
+    <EventID>4624</EventID>
+    <Level>4</Level>
+    <TimeCreated SystemTime="2024-01-01T12:00:00.000000Z"/>
+    <Keywords>0x8020000000000000</Keywords>
+  </System>
+  <RenderingInfo>
+    <Message>An account was successfully logged on.</Message>
+  </RenderingInfo>
+</Event>`
+
+func TestPipeline_WindowsEvent(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testWindowsEventPipeline), nil, prometheus.DefaultRegisterer)
+	assert.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, model.LabelSet{}, testWindowsEventXML, time.Now()))[0]
+
+	assert.Equal(t, testWindowsEventXML, out.Line)
+	assert.Equal(t, 4624, out.Extracted["EventID"])
+	assert.Equal(t, 4, out.Extracted["Level"])
+	assert.Equal(t, "Microsoft-Windows-Security-Auditing", out.Extracted["ProviderName"])
+	assert.Equal(t, "2024-01-01T12:00:00.000000Z", out.Extracted["TimeCreated"])
+	assert.Equal(t, "0x8020000000000000", out.Extracted["Keywords"])
+	assert.Equal(t, "An account was successfully logged on.", out.Extracted["Message"])
+
+	assert.Equal(t, model.LabelValue("information"), out.Labels["level"])
+	assert.Equal(t, model.LabelValue("Microsoft-Windows-Security-Auditing"), out.Labels["source"])
+}
+
+func Test_windowsEventLevelName(t *testing.T) {
+	assert.Equal(t, "critical", windowsEventLevelName(1))
+	assert.Equal(t, "error", windowsEventLevelName(2))
+	assert.Equal(t, "warning", windowsEventLevelName(3))
+	assert.Equal(t, "information", windowsEventLevelName(4))
+	assert.Equal(t, "verbose", windowsEventLevelName(5))
+	assert.Equal(t, "information", windowsEventLevelName(0))
+}
+
+func Test_newWindowsEventStage_EmptyConfigError(t *testing.T) {
+	_, err := newWindowsEventStage(util_log.Logger, nil)
+	assert.EqualError(t, err, ErrEmptyWindowsEventStageConfig)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
@@ -90,3 +91,134 @@ func AddLabelsMiddleware(additionalLabels model.LabelSet) EntryMiddleware {
 		})
 	})
 }
+
+// EntryHandlerMetrics holds the counters and histogram shared by every
+// EntryHandler wrapped with NewInstrumentedEntryHandler. Construct one per
+// registerer and reuse it across every target that wraps a handler, so
+// per-target series live on the same vectors instead of each registering
+// their own.
+type EntryHandlerMetrics struct {
+	entries  *prometheus.CounterVec
+	bytes    *prometheus.CounterVec
+	latency  *prometheus.CounterVec
+	lineSize *prometheus.HistogramVec
+}
+
+// NewEntryHandlerMetrics creates the metric vectors used by
+// NewInstrumentedEntryHandler. If reg is non-nil, the vectors are also
+// registered with it. lineSizeBuckets additionally tracks a histogram of
+// forwarded line sizes; pass nil to skip that histogram when the extra
+// series aren't needed.
+func NewEntryHandlerMetrics(reg prometheus.Registerer, lineSizeBuckets []float64) *EntryHandlerMetrics {
+	m := &EntryHandlerMetrics{
+		entries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Name:      "handler_entries_total",
+			Help:      "Number of entries forwarded through an instrumented EntryHandler.",
+		}, []string{"job", "target"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Name:      "handler_bytes_total",
+			Help:      "Number of bytes forwarded through an instrumented EntryHandler.",
+		}, []string{"job", "target"}),
+		latency: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Name:      "handler_blocked_seconds_total",
+			Help:      "Cumulative time an instrumented EntryHandler spent blocked handing entries to the handler it wraps.",
+		}, []string{"job", "target"}),
+	}
+	if lineSizeBuckets != nil {
+		m.lineSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "promtail",
+			Name:      "handler_line_size_bytes",
+			Help:      "Distribution of line sizes forwarded through an instrumented EntryHandler.",
+			Buckets:   lineSizeBuckets,
+		}, []string{"job", "target"})
+	}
+
+	if reg != nil {
+		reg.MustRegister(m.entries, m.bytes, m.latency)
+		if m.lineSize != nil {
+			reg.MustRegister(m.lineSize)
+		}
+	}
+	return m
+}
+
+// instrumentedEntryHandler wraps an EntryHandler with per-entry accounting
+// against a caller-supplied constant label set, typically the job name and
+// a key identifying the specific target (a container ID, a file path).
+type instrumentedEntryHandler struct {
+	next   EntryHandler
+	in     chan Entry
+	wg     sync.WaitGroup
+	once   sync.Once
+	labels prometheus.Labels
+
+	metrics *EntryHandlerMetrics
+}
+
+// NewInstrumentedEntryHandler wraps next so that every entry passed
+// through is counted, against constLabels, in metrics: entries forwarded,
+// bytes forwarded, and time spent blocked handing the entry to next. As
+// with other EntryMiddleware-style wrappers, the returned handler must be
+// Stopped independently from next; Stop drains the wrapper and
+// unregisters constLabels' series so short-lived targets (a container, a
+// tailed file) don't leave stale series behind after they go away.
+func NewInstrumentedEntryHandler(metrics *EntryHandlerMetrics, constLabels prometheus.Labels, next EntryHandler) InstrumentedEntryHandler {
+	h := &instrumentedEntryHandler{
+		next:    next,
+		in:      make(chan Entry),
+		labels:  constLabels,
+		metrics: metrics,
+	}
+	h.wg.Add(1)
+	go h.run()
+	return h
+}
+
+func (h *instrumentedEntryHandler) run() {
+	defer h.wg.Done()
+
+	nextChan := h.next.Chan()
+	entries := h.metrics.entries.With(h.labels)
+	bytes := h.metrics.bytes.With(h.labels)
+	latency := h.metrics.latency.With(h.labels)
+	var lineSize prometheus.Observer
+	if h.metrics.lineSize != nil {
+		lineSize = h.metrics.lineSize.With(h.labels)
+	}
+
+	for e := range h.in {
+		start := time.Now()
+		nextChan <- e
+		latency.Add(time.Since(start).Seconds())
+
+		entries.Inc()
+		bytes.Add(float64(len(e.Line)))
+		if lineSize != nil {
+			lineSize.Observe(float64(len(e.Line)))
+		}
+	}
+}
+
+func (h *instrumentedEntryHandler) Chan() chan<- Entry {
+	return h.in
+}
+
+func (h *instrumentedEntryHandler) Stop() {
+	h.once.Do(func() { close(h.in) })
+	h.wg.Wait()
+
+	h.metrics.entries.Delete(h.labels)
+	h.metrics.bytes.Delete(h.labels)
+	h.UnregisterLatencyMetric(h.labels)
+	if h.metrics.lineSize != nil {
+		h.metrics.lineSize.Delete(h.labels)
+	}
+}
+
+// UnregisterLatencyMetric implements InstrumentedEntryHandler.
+func (h *instrumentedEntryHandler) UnregisterLatencyMetric(labels prometheus.Labels) {
+	h.metrics.latency.Delete(labels)
+}
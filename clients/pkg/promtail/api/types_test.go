@@ -0,0 +1,112 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+// collectingHandler is a minimal EntryHandler that records every entry it
+// receives, for asserting what NewInstrumentedEntryHandler forwarded.
+type collectingHandler struct {
+	in      chan Entry
+	stopped chan struct{}
+	entries []Entry
+}
+
+func newCollectingHandler() *collectingHandler {
+	h := &collectingHandler{
+		in:      make(chan Entry),
+		stopped: make(chan struct{}),
+	}
+	go func() {
+		for e := range h.in {
+			h.entries = append(h.entries, e)
+		}
+	}()
+	return h
+}
+
+func (h *collectingHandler) Chan() chan<- Entry { return h.in }
+func (h *collectingHandler) Stop() {
+	close(h.stopped)
+}
+
+func Test_InstrumentedEntryHandler_LabelAttribution(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewEntryHandlerMetrics(reg, nil)
+	next := newCollectingHandler()
+
+	h := NewInstrumentedEntryHandler(metrics, prometheus.Labels{"job": "testjob", "target": "target-a"}, next)
+	h.Chan() <- Entry{Entry: logproto.Entry{Line: "hello"}}
+	h.Chan() <- Entry{Entry: logproto.Entry{Line: "world!"}}
+
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.entries.With(prometheus.Labels{"job": "testjob", "target": "target-a"})) == 2
+	}, time.Second, time.Millisecond, "expected 2 entries recorded against target-a")
+	require.Equal(t, float64(11), testutil.ToFloat64(metrics.bytes.With(prometheus.Labels{"job": "testjob", "target": "target-a"})))
+
+	h.Stop()
+	close(next.in)
+
+	otherNext := newCollectingHandler()
+	other := NewInstrumentedEntryHandler(metrics, prometheus.Labels{"job": "testjob", "target": "target-b"}, otherNext)
+	require.Equal(t, float64(0), testutil.ToFloat64(metrics.entries.With(prometheus.Labels{"job": "testjob", "target": "target-b"})))
+	other.Stop()
+	close(otherNext.in)
+}
+
+func Test_InstrumentedEntryHandler_StopDoesNotStopNext(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewEntryHandlerMetrics(reg, nil)
+	next := newCollectingHandler()
+
+	h := NewInstrumentedEntryHandler(metrics, prometheus.Labels{"job": "j", "target": "t"}, next)
+	h.Stop()
+
+	select {
+	case <-next.stopped:
+		t.Fatal("NewInstrumentedEntryHandler stopped next, but decorators must be stopped independently")
+	default:
+	}
+	close(next.in)
+}
+
+func Test_InstrumentedEntryHandler_StopUnregistersSeries(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	lineSizeBuckets := []float64{1, 10, 100}
+	metrics := NewEntryHandlerMetrics(reg, lineSizeBuckets)
+	next := newCollectingHandler()
+
+	labels := prometheus.Labels{"job": "j", "target": "t"}
+	h := NewInstrumentedEntryHandler(metrics, labels, next)
+	h.Chan() <- Entry{Entry: logproto.Entry{Line: "hello"}}
+	h.Stop()
+	close(next.in)
+
+	require.Equal(t, 0, testutil.CollectAndCount(reg, "promtail_handler_entries_total"))
+	require.Equal(t, 0, testutil.CollectAndCount(reg, "promtail_handler_bytes_total"))
+	require.Equal(t, 0, testutil.CollectAndCount(reg, "promtail_handler_blocked_seconds_total"))
+	require.Equal(t, 0, testutil.CollectAndCount(reg, "promtail_handler_line_size_bytes"))
+}
+
+func Benchmark_InstrumentedEntryHandler(b *testing.B) {
+	metrics := NewEntryHandlerMetrics(prometheus.NewRegistry(), nil)
+	next := newCollectingHandler()
+	defer close(next.in)
+
+	h := NewInstrumentedEntryHandler(metrics, prometheus.Labels{"job": "bench", "target": "bench"}, next)
+	defer h.Stop()
+
+	e := Entry{Entry: logproto.Entry{Line: "the quick brown fox jumps over the lazy dog"}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Chan() <- e
+	}
+}
@@ -2,6 +2,8 @@ package client
 
 import (
 	"fmt"
+	"hash/fnv"
+	"io"
 	"strconv"
 
 	"strings"
@@ -26,19 +28,59 @@ const (
 // and entries in a single batch request. In case of multi-tenant Promtail, log
 // streams for each tenant are stored in a dedicated batch.
 type batch struct {
-	streams   map[string]*logproto.Stream
+	// streams buckets batchStreams by the FNV-64a hash of their sorted
+	// label string, so a stream lookup on the hot append path is a single
+	// map access instead of a linear scan. Bucket values are slices, not
+	// single streams, because two distinct label sets can hash to the
+	// same key; add matches the bucket's entries against the full label
+	// string before appending, the same way the previous string-keyed map
+	// distinguished them.
+	streams   map[uint64][]*batchStream
 	bytes     int
 	createdAt time.Time
 
 	maxStreams int
+
+	// chargebackLabel, when non-empty, is the entry label chargeback is
+	// keyed by. chargeback accumulates bytes/lines per key as entries are
+	// added, so the totals are ready when the whole batch is later shipped
+	// or dropped.
+	chargebackLabel model.LabelName
+	chargeback      map[string]*chargebackCounts
+}
+
+// batchStream accumulates the entries of a single stream (one sorted label
+// set) within a batch.
+type batchStream struct {
+	labels  string
+	entries []logproto.Entry
+}
+
+// sizeBytes returns the raw, pre-compression size of every entry in the
+// stream, for use by splitStreams.
+func (s *batchStream) sizeBytes() int {
+	size := 0
+	for _, e := range s.entries {
+		size += protoEntrySize(e)
+	}
+	return size
+}
+
+// chargebackCounts accumulates the bytes and lines contributed by a single
+// chargeback key within a batch.
+type chargebackCounts struct {
+	bytes int
+	lines int
 }
 
-func newBatch(maxStreams int, entries ...api.Entry) *batch {
+func newBatch(maxStreams int, chargebackLabel model.LabelName, entries ...api.Entry) *batch {
 	b := &batch{
-		streams:    map[string]*logproto.Stream{},
-		bytes:      0,
-		createdAt:  time.Now(),
-		maxStreams: maxStreams,
+		streams:         map[uint64][]*batchStream{},
+		bytes:           0,
+		createdAt:       time.Now(),
+		maxStreams:      maxStreams,
+		chargebackLabel: chargebackLabel,
+		chargeback:      map[string]*chargebackCounts{},
 	}
 
 	// Add entries to the batch
@@ -54,11 +96,27 @@ func newBatch(maxStreams int, entries ...api.Entry) *batch {
 func (b *batch) add(entry api.Entry) error {
 	b.bytes += entrySize(entry)
 
+	if b.chargebackLabel != "" {
+		if key, ok := entry.Labels[b.chargebackLabel]; ok {
+			c, ok := b.chargeback[string(key)]
+			if !ok {
+				c = &chargebackCounts{}
+				b.chargeback[string(key)] = c
+			}
+			c.bytes += entrySize(entry)
+			c.lines++
+		}
+	}
+
 	// Append the entry to an already existing stream (if any)
 	labels := labelsMapToString(entry.Labels, ReservedLabelTenantID)
-	if stream, ok := b.streams[labels]; ok {
-		stream.Entries = append(stream.Entries, entry.Entry)
-		return nil
+	key := labelsHash(labels)
+	bucket := b.streams[key]
+	for _, stream := range bucket {
+		if stream.labels == labels {
+			stream.entries = append(stream.entries, entry.Entry)
+			return nil
+		}
 	}
 
 	streams := len(b.streams)
@@ -66,13 +124,21 @@ func (b *batch) add(entry api.Entry) error {
 		return fmt.Errorf(errMaxStreamsLimitExceeded, streams, b.maxStreams, labels)
 	}
 	// Add the entry as a new stream
-	b.streams[labels] = &logproto.Stream{
-		Labels:  labels,
-		Entries: []logproto.Entry{entry.Entry},
-	}
+	b.streams[key] = append(bucket, &batchStream{
+		labels:  labels,
+		entries: []logproto.Entry{entry.Entry},
+	})
 	return nil
 }
 
+// labelsHash returns the FNV-64a hash of a stream's sorted label string, as
+// produced by labelsMapToString, for use as a batch.streams key.
+func labelsHash(labels string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(labels))
+	return h.Sum64()
+}
+
 func labelsMapToString(ls model.LabelSet, without model.LabelName) string {
 	var b strings.Builder
 	totalSize := 2
@@ -105,6 +171,20 @@ func labelsMapToString(ls model.LabelSet, without model.LabelName) string {
 	return b.String()
 }
 
+// streamEntryCount returns how many entries the stream identified by
+// labels already holds in b, or 0 if the stream isn't present yet. Used by
+// client.ingest to enforce Config.MaxEntriesPerStreamPerBatch before an
+// entry is appended, rather than after.
+func (b *batch) streamEntryCount(labels string) int {
+	key := labelsHash(labels)
+	for _, stream := range b.streams[key] {
+		if stream.labels == labels {
+			return len(stream.entries)
+		}
+	}
+	return 0
+}
+
 // sizeBytes returns the current batch size in bytes
 func (b *batch) sizeBytes() int {
 	return b.bytes
@@ -135,19 +215,91 @@ func (b *batch) encode() ([]byte, int, error) {
 
 // creates push request and returns it, together with number of entries
 func (b *batch) createPushRequest() (*logproto.PushRequest, int) {
+	return pushRequestFor(b.allStreams())
+}
+
+// allStreams flattens every bucket of b.streams into a single slice.
+func (b *batch) allStreams() []*batchStream {
+	streams := make([]*batchStream, 0, len(b.streams))
+	for _, bucket := range b.streams {
+		streams = append(streams, bucket...)
+	}
+	return streams
+}
+
+// splitStreams partitions b's streams into groups whose raw, pre-compression
+// size stays at or under maxBytes, keeping every stream's entries together;
+// a single stream larger than maxBytes on its own still becomes its own
+// group; splitting an individual stream's entries isn't attempted, to keep
+// each group a self-contained, orderable push request. Used to send a very
+// large batch as a paced series of smaller requests instead of one huge one.
+func (b *batch) splitStreams(maxBytes int) [][]*batchStream {
+	var groups [][]*batchStream
+	var current []*batchStream
+	currentSize := 0
+
+	for _, stream := range b.allStreams() {
+		size := stream.sizeBytes()
+		if len(current) > 0 && currentSize+size > maxBytes {
+			groups = append(groups, current)
+			current = nil
+			currentSize = 0
+		}
+		current = append(current, stream)
+		currentSize += size
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// pushRequestFor builds a PushRequest out of an arbitrary subset of a
+// batch's streams, together with the number of entries it contains.
+func pushRequestFor(streams []*batchStream) (*logproto.PushRequest, int) {
 	req := logproto.PushRequest{
-		Streams: make([]logproto.Stream, 0, len(b.streams)),
+		Streams: make([]logproto.Stream, 0, len(streams)),
 	}
 
 	entriesCount := 0
-	for _, stream := range b.streams {
-		req.Streams = append(req.Streams, *stream)
-		entriesCount += len(stream.Entries)
+	for _, stream := range streams {
+		req.Streams = append(req.Streams, logproto.Stream{
+			Labels:  stream.labels,
+			Entries: stream.entries,
+		})
+		entriesCount += len(stream.entries)
 	}
 	return &req, entriesCount
 }
 
+// encodeStreamsTo marshals streams and writes them, Snappy-framed, directly
+// to w, so the compressed output never needs to be buffered in full before
+// being handed to a request body. Callers bound peak memory by keeping
+// streams to a bounded raw size (see batch.splitStreams) rather than by
+// this function, since the intermediate protobuf marshal still needs one
+// contiguous buffer of that size. It returns the number of encoded entries.
+func encodeStreamsTo(streams []*batchStream, w io.Writer) (int, error) {
+	req, entriesCount := pushRequestFor(streams)
+	buf, err := proto.Marshal(req)
+	if err != nil {
+		return 0, err
+	}
+
+	sw := snappy.NewBufferedWriter(w)
+	if _, err := sw.Write(buf); err != nil {
+		return 0, err
+	}
+	if err := sw.Close(); err != nil {
+		return 0, err
+	}
+	return entriesCount, nil
+}
+
 func entrySize(entry api.Entry) int {
+	return protoEntrySize(entry.Entry)
+}
+
+func protoEntrySize(entry logproto.Entry) int {
 	structuredMetadataSize := 0
 	for _, label := range entry.StructuredMetadata {
 		structuredMetadataSize += label.Size()
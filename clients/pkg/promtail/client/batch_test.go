@@ -24,7 +24,7 @@ func TestBatch_MaxStreams(t *testing.T) {
 		{Labels: model.LabelSet{"app": "app-4"}, Entry: logproto.Entry{Timestamp: time.Unix(6, 0).UTC(), Line: "line6"}},
 	}
 
-	b := newBatch(maxStream)
+	b := newBatch(maxStream, "")
 
 	errCount := 0
 	for _, entry := range inputEntries {
@@ -37,6 +37,22 @@ func TestBatch_MaxStreams(t *testing.T) {
 	assert.Equal(t, errCount, 2)
 }
 
+func TestBatch_streamEntryCount(t *testing.T) {
+	b := newBatch(0, "")
+
+	labels := labelsMapToString(model.LabelSet{"app": "app-1"}, "")
+	assert.Equal(t, 0, b.streamEntryCount(labels))
+
+	require.NoError(t, b.add(api.Entry{Labels: model.LabelSet{"app": "app-1"}, Entry: logproto.Entry{Timestamp: time.Unix(1, 0).UTC(), Line: "line1"}}))
+	assert.Equal(t, 1, b.streamEntryCount(labels))
+
+	require.NoError(t, b.add(api.Entry{Labels: model.LabelSet{"app": "app-1"}, Entry: logproto.Entry{Timestamp: time.Unix(2, 0).UTC(), Line: "line2"}}))
+	assert.Equal(t, 2, b.streamEntryCount(labels))
+
+	otherLabels := labelsMapToString(model.LabelSet{"app": "app-2"}, "")
+	assert.Equal(t, 0, b.streamEntryCount(otherLabels))
+}
+
 func TestBatch_add(t *testing.T) {
 	t.Parallel()
 
@@ -76,7 +92,7 @@ func TestBatch_add(t *testing.T) {
 		testData := testData
 
 		t.Run(testName, func(t *testing.T) {
-			b := newBatch(0)
+			b := newBatch(0, "")
 
 			for _, entry := range testData.inputEntries {
 				err := b.add(entry)
@@ -96,24 +112,24 @@ func TestBatch_encode(t *testing.T) {
 		expectedEntriesCount int
 	}{
 		"empty batch": {
-			inputBatch:           newBatch(0),
+			inputBatch:           newBatch(0, ""),
 			expectedEntriesCount: 0,
 		},
 		"single stream with single log entry": {
-			inputBatch: newBatch(0,
+			inputBatch: newBatch(0, "",
 				api.Entry{Labels: model.LabelSet{}, Entry: logEntries[0].Entry},
 			),
 			expectedEntriesCount: 1,
 		},
 		"single stream with multiple log entries": {
-			inputBatch: newBatch(0,
+			inputBatch: newBatch(0, "",
 				api.Entry{Labels: model.LabelSet{}, Entry: logEntries[0].Entry},
 				api.Entry{Labels: model.LabelSet{}, Entry: logEntries[1].Entry},
 			),
 			expectedEntriesCount: 2,
 		},
 		"multiple streams with multiple log entries": {
-			inputBatch: newBatch(0,
+			inputBatch: newBatch(0, "",
 				api.Entry{Labels: model.LabelSet{"type": "a"}, Entry: logEntries[0].Entry},
 				api.Entry{Labels: model.LabelSet{"type": "a"}, Entry: logEntries[1].Entry},
 				api.Entry{Labels: model.LabelSet{"type": "b"}, Entry: logEntries[2].Entry},
@@ -136,7 +152,7 @@ func TestBatch_encode(t *testing.T) {
 }
 
 func TestHashCollisions(t *testing.T) {
-	b := newBatch(0)
+	b := newBatch(0, "")
 
 	ls1 := model.LabelSet{"app": "l", "uniq0": "0", "uniq1": "1"}
 	ls2 := model.LabelSet{"app": "m", "uniq0": "1", "uniq1": "1"}
@@ -166,10 +182,44 @@ func TestHashCollisions(t *testing.T) {
 	}
 }
 
+func TestBatchStreams_HashKeyCollision(t *testing.T) {
+	b := newBatch(0, "")
+
+	labels := labelsMapToString(model.LabelSet{"app": "a"}, ReservedLabelTenantID)
+	key := labelsHash(labels)
+	// Pre-seed the bucket that "app=a" will hash into with an unrelated
+	// stream sharing the same key, simulating an FNV-64a collision.
+	b.streams[key] = []*batchStream{{labels: `{other="stream"}`, entries: []logproto.Entry{{Line: "existing"}}}}
+
+	require.NoError(t, b.add(api.Entry{Labels: model.LabelSet{"app": "a"}, Entry: logproto.Entry{Line: "new"}}))
+
+	require.Len(t, b.streams[key], 2, "a colliding hash bucket must keep distinct label sets as separate streams")
+}
+
 // store the result to a package level variable
 // so the compiler cannot eliminate the Benchmark itself.
 var result string
 
+func BenchmarkBatchAdd(b *testing.B) {
+	const numStreams = 100
+
+	entries := make([]api.Entry, numStreams)
+	for i := range entries {
+		entries[i] = api.Entry{
+			Labels: model.LabelSet{"stream": model.LabelValue(fmt.Sprintf("stream-%d", i))},
+			Entry:  logproto.Entry{Timestamp: time.Now(), Line: "line"},
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch := newBatch(0, "")
+		for _, entry := range entries {
+			_ = batch.add(entry)
+		}
+	}
+}
+
 func BenchmarkLabelsMapToString(b *testing.B) {
 	labelSet := make(model.LabelSet)
 	labelSet["label"] = "value"
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Enforcement modes for BudgetConfig.Mode.
+const (
+	BudgetModeWarn = "warn"
+	BudgetModeDrop = "drop"
+)
+
+// defaultBudgetWindow is how often a tenant's budget consumption counter
+// resets, used whenever BudgetConfig.Window is left at zero.
+const defaultBudgetWindow = 24 * time.Hour
+
+// budgetFileMode matches positionFileMode in the positions package: the
+// state file can carry tenant identifiers, so it's kept private.
+const budgetFileMode = 0600
+
+// defaultBudgetSyncPeriod is how often a budgetTracker with a StateFile
+// configured flushes its counters to disk.
+const defaultBudgetSyncPeriod = 10 * time.Second
+
+// budgetWarnThresholds are the consumption percentages that get their own
+// log line the first time a tenant crosses them within a window.
+var budgetWarnThresholds = []float64{80, 100}
+
+// BudgetConfig enforces a per-tenant byte budget over a rolling window, so
+// a platform team can cap what each tenant ships through this client
+// before Loki-side limits (or cost) are affected.
+type BudgetConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// PerTenant maps tenant ID to its byte budget for one Window. A tenant
+	// with no entry here isn't budgeted at all.
+	PerTenant map[string]uint64 `yaml:"per_tenant,omitempty"`
+
+	// Window is the rolling period each tenant's consumption counter
+	// resets over.
+	Window time.Duration `yaml:"window,omitempty"`
+
+	// Mode controls what happens once a tenant exceeds its budget: "warn"
+	// (the default) only logs and updates the consumption metric, while
+	// "drop" also stops shipping that tenant's entries until the window
+	// resets.
+	Mode string `yaml:"mode,omitempty"`
+
+	// StateFile persists consumption counters across restarts, so
+	// restarting this client mid-window doesn't hand every tenant a fresh
+	// budget early. Left empty, consumption isn't persisted and resets on
+	// every restart.
+	StateFile string `yaml:"state_file,omitempty"`
+
+	// AllowlistLabelName and AllowlistLabelValue exempt entries whose
+	// labels match from budget enforcement entirely: their bytes are still
+	// shipped and aren't counted against the budget. This is meant for a
+	// stream a tenant must never lose, e.g. an audit log, even once
+	// they're over budget.
+	AllowlistLabelName  string `yaml:"allowlist_label_name,omitempty"`
+	AllowlistLabelValue string `yaml:"allowlist_label_value,omitempty"`
+}
+
+// RegisterFlagsWithPrefix registers flags where every name is prefixed by
+// prefix.
+func (cfg *BudgetConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"client.budget.enabled", false, "Enforce a per-tenant ingestion byte budget over budget.window.")
+	f.DurationVar(&cfg.Window, prefix+"client.budget.window", defaultBudgetWindow, "Rolling period each tenant's budget consumption resets over.")
+	f.StringVar(&cfg.Mode, prefix+"client.budget.mode", BudgetModeWarn, "What to do once a tenant exceeds its budget: 'warn' logs only, 'drop' also stops shipping that tenant's entries.")
+	f.StringVar(&cfg.StateFile, prefix+"client.budget.state-file", "", "File to persist budget consumption counters in, so restarts don't reset them early. Left empty, consumption isn't persisted.")
+	f.StringVar(&cfg.AllowlistLabelName, prefix+"client.budget.allowlist-label-name", "", "Label name that exempts matching entries from budget enforcement.")
+	f.StringVar(&cfg.AllowlistLabelValue, prefix+"client.budget.allowlist-label-value", "", "Label value, on allowlist-label-name, that exempts matching entries from budget enforcement.")
+}
+
+func (cfg BudgetConfig) window() time.Duration {
+	if cfg.Window <= 0 {
+		return defaultBudgetWindow
+	}
+	return cfg.Window
+}
+
+func (cfg BudgetConfig) mode() string {
+	if cfg.Mode == "" {
+		return BudgetModeWarn
+	}
+	return cfg.Mode
+}
+
+// allowlisted reports whether labels bypass budget enforcement entirely.
+func (cfg BudgetConfig) allowlisted(labels model.LabelSet) bool {
+	if cfg.AllowlistLabelName == "" {
+		return false
+	}
+	return string(labels[model.LabelName(cfg.AllowlistLabelName)]) == cfg.AllowlistLabelValue
+}
+
+// budgetFile is the on-disk (YAML) format of BudgetConfig.StateFile.
+type budgetFile struct {
+	WindowStart time.Time         `yaml:"window_start"`
+	Consumed    map[string]uint64 `yaml:"consumed"`
+}
+
+// budgetTracker tracks each tenant's byte consumption over the current
+// window, persisting it to BudgetConfig.StateFile (if set) so a restart
+// mid-window resumes rather than resets.
+type budgetTracker struct {
+	logger log.Logger
+	cfg    BudgetConfig
+
+	mtx         sync.Mutex
+	windowStart time.Time
+	consumed    map[string]uint64
+	// warnedAt is the highest budgetWarnThresholds entry already logged
+	// for a tenant within the current window.
+	warnedAt map[string]float64
+}
+
+// newBudgetTracker creates a budgetTracker, loading any persisted state
+// from cfg.StateFile.
+func newBudgetTracker(logger log.Logger, cfg BudgetConfig) *budgetTracker {
+	t := &budgetTracker{
+		logger:   logger,
+		cfg:      cfg,
+		consumed: map[string]uint64{},
+		warnedAt: map[string]float64{},
+	}
+	t.load()
+	return t
+}
+
+// load populates windowStart and consumed from cfg.StateFile, if set. It
+// leaves windowStart at its zero value on any failure to read or parse the
+// file, including it not existing yet: rolloverLocked treats a zero
+// windowStart as "start a window now" and starts one from the timestamp of
+// the first add() call, rather than from time.Now() here at construction.
+func (t *budgetTracker) load() {
+	if t.cfg.StateFile == "" {
+		return
+	}
+
+	buf, err := os.ReadFile(filepath.Clean(t.cfg.StateFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			level.Warn(t.logger).Log("msg", "could not read budget state file, starting a fresh window", "err", err)
+		}
+		return
+	}
+
+	var f budgetFile
+	if err := yaml.Unmarshal(buf, &f); err != nil {
+		level.Warn(t.logger).Log("msg", "could not parse budget state file, starting a fresh window", "err", err)
+		return
+	}
+
+	t.windowStart = f.WindowStart
+	t.consumed = f.Consumed
+	if t.consumed == nil {
+		t.consumed = map[string]uint64{}
+	}
+}
+
+// rolloverLocked resets consumption once the current window has elapsed.
+// Callers must hold t.mtx, except during construction.
+func (t *budgetTracker) rolloverLocked(now time.Time) {
+	if t.windowStart.IsZero() || now.Sub(t.windowStart) >= t.cfg.window() {
+		t.windowStart = now
+		t.consumed = map[string]uint64{}
+		t.warnedAt = map[string]float64{}
+	}
+}
+
+// add records bytes shipped for tenantID, rolling the window over first if
+// it has elapsed, and reports the tenant's consumption as a fraction of its
+// budget (0 if the tenant isn't budgeted) and whether it's now over.
+func (t *budgetTracker) add(tenantID string, bytes int, now time.Time) (fraction float64, overBudget bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.rolloverLocked(now)
+
+	limit, budgeted := t.cfg.PerTenant[tenantID]
+	if !budgeted || limit == 0 {
+		return 0, false
+	}
+
+	t.consumed[tenantID] += uint64(bytes)
+	consumed := t.consumed[tenantID]
+	fraction = float64(consumed) / float64(limit)
+
+	pct := fraction * 100
+	for _, threshold := range budgetWarnThresholds {
+		if pct >= threshold && t.warnedAt[tenantID] < threshold {
+			t.warnedAt[tenantID] = threshold
+			level.Warn(t.logger).Log(
+				"msg", fmt.Sprintf("tenant crossed %.0f%% of its ingestion budget", threshold),
+				"tenant", tenantID, "consumed_bytes", consumed, "budget_bytes", limit, "mode", t.cfg.mode(),
+			)
+		}
+	}
+
+	return fraction, consumed > limit
+}
+
+// save persists the current window and consumption counters to
+// cfg.StateFile. It's a no-op if no StateFile is configured.
+func (t *budgetTracker) save() error {
+	if t.cfg.StateFile == "" {
+		return nil
+	}
+
+	t.mtx.Lock()
+	f := budgetFile{
+		WindowStart: t.windowStart,
+		Consumed:    make(map[string]uint64, len(t.consumed)),
+	}
+	for k, v := range t.consumed {
+		f.Consumed[k] = v
+	}
+	t.mtx.Unlock()
+
+	buf, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	return writeBudgetFile(filepath.Clean(t.cfg.StateFile), buf)
+}
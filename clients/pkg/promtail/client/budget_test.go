@@ -0,0 +1,158 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/utils"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+func Test_budgetTracker_WindowRollover(t *testing.T) {
+	cfg := BudgetConfig{PerTenant: map[string]uint64{"tenant-a": 10}, Window: time.Hour}
+	tr := newBudgetTracker(log.NewNopLogger(), cfg)
+
+	start := time.Unix(0, 0)
+	_, overBudget := tr.add("tenant-a", 8, start)
+	require.False(t, overBudget)
+
+	_, overBudget = tr.add("tenant-a", 5, start.Add(time.Minute))
+	require.True(t, overBudget, "13 bytes consumed against a 10 byte budget should be over")
+
+	// Once the window has elapsed, consumption starts fresh.
+	fraction, overBudget := tr.add("tenant-a", 1, start.Add(2*time.Hour))
+	require.False(t, overBudget)
+	require.InDelta(t, 0.1, fraction, 0.0001)
+}
+
+func Test_budgetTracker_UnbudgetedTenant(t *testing.T) {
+	cfg := BudgetConfig{PerTenant: map[string]uint64{"tenant-a": 10}}
+	tr := newBudgetTracker(log.NewNopLogger(), cfg)
+
+	fraction, overBudget := tr.add("tenant-b", 1000, time.Unix(0, 0))
+	require.False(t, overBudget)
+	require.Zero(t, fraction)
+}
+
+func Test_budgetTracker_PersistenceAcrossRestart(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "budget.yaml")
+	cfg := BudgetConfig{PerTenant: map[string]uint64{"tenant-a": 80}, Window: time.Hour, StateFile: stateFile}
+
+	tr := newBudgetTracker(log.NewNopLogger(), cfg)
+	now := time.Unix(0, 0)
+	_, overBudget := tr.add("tenant-a", 60, now)
+	require.False(t, overBudget)
+	require.NoError(t, tr.save())
+
+	// A fresh tracker pointed at the same state file resumes rather than
+	// resets: 30 more bytes tips tenant-a over its 80 byte budget.
+	restarted := newBudgetTracker(log.NewNopLogger(), cfg)
+	_, overBudget = restarted.add("tenant-a", 30, now.Add(time.Minute))
+	require.True(t, overBudget)
+}
+
+func Test_budgetTracker_MissingStateFileStartsFresh(t *testing.T) {
+	cfg := BudgetConfig{PerTenant: map[string]uint64{"tenant-a": 10}, StateFile: filepath.Join(t.TempDir(), "does-not-exist.yaml")}
+	tr := newBudgetTracker(log.NewNopLogger(), cfg)
+
+	fraction, overBudget := tr.add("tenant-a", 1, time.Unix(0, 0))
+	require.False(t, overBudget)
+	require.InDelta(t, 0.1, fraction, 0.0001)
+}
+
+func Test_BudgetConfig_Allowlisted(t *testing.T) {
+	cfg := BudgetConfig{AllowlistLabelName: "stream", AllowlistLabelValue: "audit"}
+
+	require.True(t, cfg.allowlisted(model.LabelSet{"stream": "audit"}))
+	require.False(t, cfg.allowlisted(model.LabelSet{"stream": "app"}))
+	require.False(t, cfg.allowlisted(model.LabelSet{}))
+
+	require.False(t, BudgetConfig{}.allowlisted(model.LabelSet{"stream": "audit"}))
+}
+
+// Test_Budget_DropModeExceedsBudget pushes entries for a tenant past its
+// budget through a real client in drop mode, and verifies the exceeding
+// entry never reaches the server while an allowlisted stream still does.
+func Test_Budget_DropModeExceedsBudget(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	receivedReqsChan := make(chan utils.RemoteWriteRequest, 10)
+	server := utils.NewRemoteWriteServer(receivedReqsChan, 200)
+	require.NotNil(t, server)
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+
+	cfg := Config{
+		URL:           serverURL,
+		BatchWait:     10 * time.Millisecond,
+		BatchSize:     1024 * 1024,
+		Client:        config.HTTPClientConfig{},
+		BackoffConfig: backoff.Config{MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 1},
+		Timeout:       time.Second,
+		Budget: BudgetConfig{
+			Enabled:             true,
+			PerTenant:           map[string]uint64{"tenant-a": 5},
+			Mode:                BudgetModeDrop,
+			AllowlistLabelName:  "stream",
+			AllowlistLabelValue: "audit",
+		},
+	}
+
+	m := NewMetrics(reg)
+	c, err := New(m, cfg, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+
+	c.Chan() <- api.Entry{
+		Labels: model.LabelSet{"__tenant_id__": "tenant-a"},
+		Entry:  logproto.Entry{Timestamp: time.Unix(1, 0).UTC(), Line: "short"},
+	}
+	c.Chan() <- api.Entry{
+		Labels: model.LabelSet{"__tenant_id__": "tenant-a"},
+		Entry:  logproto.Entry{Timestamp: time.Unix(2, 0).UTC(), Line: "over budget now"},
+	}
+	c.Chan() <- api.Entry{
+		Labels: model.LabelSet{"__tenant_id__": "tenant-a", "stream": "audit"},
+		Entry:  logproto.Entry{Timestamp: time.Unix(3, 0).UTC(), Line: "audited even so"},
+	}
+
+	c.Stop()
+
+	require.Len(t, receivedReqsChan, 1)
+	req := <-receivedReqsChan
+	var lines []string
+	for _, s := range req.Request.Streams {
+		for _, e := range s.Entries {
+			lines = append(lines, e.Line)
+		}
+	}
+	require.ElementsMatch(t, []string{"short", "audited even so"}, lines)
+}
+
+func Test_budgetTracker_Save_NoStateFileIsNoOp(t *testing.T) {
+	tr := newBudgetTracker(log.NewNopLogger(), BudgetConfig{})
+	require.NoError(t, tr.save())
+}
+
+func Test_budgetTracker_SaveWritesReadableFile(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "budget.yaml")
+	tr := newBudgetTracker(log.NewNopLogger(), BudgetConfig{PerTenant: map[string]uint64{"tenant-a": 10}, StateFile: stateFile})
+	tr.add("tenant-a", 3, time.Unix(0, 0))
+	require.NoError(t, tr.save())
+
+	_, err := os.Stat(stateFile)
+	require.NoError(t, err)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+)
+
+// CaptureEntry is a single log entry recorded by NewCaptureLogger, in a
+// newline-delimited JSON format that can later be replayed with
+// promtail-replay.
+type CaptureEntry struct {
+	Labels    string    `json:"labels"`
+	Timestamp time.Time `json:"timestamp"`
+	Line      string    `json:"line"`
+	Tenant    string    `json:"tenant,omitempty"`
+}
+
+type captureLogger struct {
+	entries chan api.Entry
+	file    *os.File
+	writer  *bufio.Writer
+
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewCaptureLogger creates a Client that, instead of sending entries to
+// Loki, records them as newline-delimited JSON to path. The resulting file
+// can be replayed against a real Loki instance with promtail-replay, which
+// makes it useful for load testing and migration rehearsal.
+func NewCaptureLogger(path string) (Client, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create capture file: %w", err)
+	}
+
+	c := &captureLogger{
+		entries: make(chan api.Entry),
+		file:    f,
+		writer:  bufio.NewWriter(f),
+	}
+	c.wg.Add(1)
+	go c.run()
+	return c, nil
+}
+
+func (c *captureLogger) run() {
+	defer c.wg.Done()
+	enc := json.NewEncoder(c.writer)
+	for e := range c.entries {
+		ce := CaptureEntry{
+			Labels:    e.Labels.String(),
+			Timestamp: e.Timestamp,
+			Line:      e.Line,
+			Tenant:    string(e.Labels[ReservedLabelTenantID]),
+		}
+		if err := enc.Encode(ce); err != nil {
+			fmt.Fprintf(os.Stderr, "capture logger: could not encode entry: %v\n", err)
+		}
+	}
+	c.writer.Flush()
+	c.file.Close()
+}
+
+func (c *captureLogger) Chan() chan<- api.Entry {
+	return c.entries
+}
+
+func (c *captureLogger) Stop() {
+	c.once.Do(func() { close(c.entries) })
+	c.wg.Wait()
+}
+
+func (c *captureLogger) StopNow() { c.Stop() }
+
+func (c *captureLogger) Name() string { return "capture" }
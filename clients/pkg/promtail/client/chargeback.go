@@ -0,0 +1,147 @@
+package client
+
+import (
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// ChargebackKeyLabel is the Prometheus label name used to carry the
+// aggregation key on the chargeback metrics below.
+const ChargebackKeyLabel = "key"
+
+// defaultChargebackMaxIdle is how long a chargeback key can go without a
+// shipped or dropped entry before its series and running totals are
+// expired, so that keys belonging to containers that have gone away don't
+// accumulate forever.
+const defaultChargebackMaxIdle = time.Hour
+
+// ChargebackConfig configures per-label shipped/dropped byte and line
+// accounting, so log volume can be attributed back to its source (e.g. a
+// container, or a lower-cardinality grouping like a Compose project) before
+// Loki-side tenant mixing.
+type ChargebackConfig struct {
+	Enabled   bool          `yaml:"enabled,omitempty"`
+	LabelName string        `yaml:"label_name,omitempty"`
+	MaxIdle   time.Duration `yaml:"max_idle,omitempty"`
+}
+
+// RegisterFlagsWithPrefix registers flags where every name is prefixed by
+// prefix.
+func (cfg *ChargebackConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"client.chargeback.enabled", false, "Track shipped/dropped bytes and lines per chargeback.label-name value.")
+	f.StringVar(&cfg.LabelName, prefix+"client.chargeback.label-name", "container", "Entry label used to group chargeback accounting. Choose a low-cardinality label, such as a Compose project, to bound the number of series.")
+	f.DurationVar(&cfg.MaxIdle, prefix+"client.chargeback.max-idle", defaultChargebackMaxIdle, "How long a chargeback key can go without traffic before its accounting is expired.")
+}
+
+func (cfg ChargebackConfig) maxIdle() time.Duration {
+	if cfg.MaxIdle <= 0 {
+		return defaultChargebackMaxIdle
+	}
+	return cfg.MaxIdle
+}
+
+// chargebackKey returns the aggregation key for entry, and whether entry
+// carries the configured label at all.
+func chargebackKey(cfg ChargebackConfig, labels model.LabelSet) (string, bool) {
+	v, ok := labels[model.LabelName(cfg.LabelName)]
+	if !ok {
+		return "", false
+	}
+	return string(v), true
+}
+
+// ChargebackTotals holds running totals of shipped and dropped volume for a
+// single chargeback key, summed over the lifetime of the process.
+type ChargebackTotals struct {
+	ShippedBytes uint64 `json:"shippedBytes"`
+	ShippedLines uint64 `json:"shippedLines"`
+	DroppedBytes uint64 `json:"droppedBytes"`
+	DroppedLines uint64 `json:"droppedLines"`
+}
+
+type chargebackEntry struct {
+	totals   ChargebackTotals
+	lastSeen time.Time
+}
+
+// chargebackTracker maintains in-memory running totals per chargeback key,
+// independent of Prometheus, so they can be exported as plain JSON without
+// having to run PromQL over the CounterVecs below.
+type chargebackTracker struct {
+	mtx     sync.Mutex
+	entries map[string]*chargebackEntry
+}
+
+func newChargebackTracker() *chargebackTracker {
+	return &chargebackTracker{entries: map[string]*chargebackEntry{}}
+}
+
+func (t *chargebackTracker) add(key string, bytes, lines int, shipped bool, now time.Time, maxIdle time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.pruneLocked(now, maxIdle)
+
+	e, ok := t.entries[key]
+	if !ok {
+		e = &chargebackEntry{}
+		t.entries[key] = e
+	}
+	e.lastSeen = now
+	if shipped {
+		e.totals.ShippedBytes += uint64(bytes)
+		e.totals.ShippedLines += uint64(lines)
+	} else {
+		e.totals.DroppedBytes += uint64(bytes)
+		e.totals.DroppedLines += uint64(lines)
+	}
+}
+
+// pruneLocked removes keys that haven't seen traffic within maxIdle. The
+// caller must hold t.mtx.
+func (t *chargebackTracker) pruneLocked(now time.Time, maxIdle time.Duration) {
+	for key, e := range t.entries {
+		if now.Sub(e.lastSeen) > maxIdle {
+			delete(t.entries, key)
+		}
+	}
+}
+
+func (t *chargebackTracker) snapshot() map[string]ChargebackTotals {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	out := make(map[string]ChargebackTotals, len(t.entries))
+	for key, e := range t.entries {
+		out[key] = e.totals
+	}
+	return out
+}
+
+// recordChargeback attributes bytes/lines to key, updating both the
+// lifetime totals and the exported CounterVecs. It's a no-op if chargeback
+// accounting isn't enabled.
+func (m *Metrics) recordChargeback(cfg ChargebackConfig, key string, bytes, lines int, shipped bool) {
+	if !cfg.Enabled {
+		return
+	}
+
+	m.chargeback.add(key, bytes, lines, shipped, time.Now(), cfg.maxIdle())
+
+	if shipped {
+		m.chargebackShippedBytes.WithLabelValues(key).Add(float64(bytes))
+		m.chargebackShippedLines.WithLabelValues(key).Add(float64(lines))
+	} else {
+		m.chargebackDroppedBytes.WithLabelValues(key).Add(float64(bytes))
+		m.chargebackDroppedLines.WithLabelValues(key).Add(float64(lines))
+	}
+}
+
+// ChargebackSnapshot returns the current lifetime shipped/dropped totals per
+// chargeback key.
+func (m *Metrics) ChargebackSnapshot() map[string]ChargebackTotals {
+	return m.chargeback.snapshot()
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/utils"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+func Test_chargebackTracker(t *testing.T) {
+	tr := newChargebackTracker()
+	now := time.Unix(0, 0)
+
+	tr.add("app-a", 10, 1, true, now, time.Hour)
+	tr.add("app-a", 5, 1, false, now, time.Hour)
+	tr.add("app-b", 3, 1, true, now, time.Hour)
+
+	snap := tr.snapshot()
+	require.Equal(t, ChargebackTotals{ShippedBytes: 10, ShippedLines: 1, DroppedBytes: 5, DroppedLines: 1}, snap["app-a"])
+	require.Equal(t, ChargebackTotals{ShippedBytes: 3, ShippedLines: 1}, snap["app-b"])
+
+	// app-a goes idle past its TTL: the next add for a different key prunes it.
+	tr.add("app-c", 1, 1, true, now.Add(2*time.Hour), time.Hour)
+	snap = tr.snapshot()
+	require.NotContains(t, snap, "app-a")
+	require.NotContains(t, snap, "app-b")
+	require.Contains(t, snap, "app-c")
+}
+
+// Test_Chargeback_TargetToClientAttribution pushes entries carrying a
+// chargeback label through a real client, one of which is dropped for
+// exceeding max_line_size, and verifies both entries are attributed to
+// their originating key end to end.
+func Test_Chargeback_TargetToClientAttribution(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	receivedReqsChan := make(chan utils.RemoteWriteRequest, 10)
+	server := utils.NewRemoteWriteServer(receivedReqsChan, 200)
+	require.NotNil(t, server)
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+
+	cfg := Config{
+		URL:           serverURL,
+		BatchWait:     10 * time.Millisecond,
+		BatchSize:     1024 * 1024,
+		Client:        config.HTTPClientConfig{},
+		BackoffConfig: backoff.Config{MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 1},
+		Timeout:       time.Second,
+		Chargeback:    ChargebackConfig{Enabled: true, LabelName: "container"},
+	}
+
+	m := NewMetrics(reg)
+	c, err := New(m, cfg, 0, 10, false, log.NewNopLogger())
+	require.NoError(t, err)
+
+	c.Chan() <- api.Entry{
+		Labels: model.LabelSet{"container": "app-a"},
+		Entry:  logproto.Entry{Timestamp: time.Unix(1, 0).UTC(), Line: "short"},
+	}
+	c.Chan() <- api.Entry{
+		Labels: model.LabelSet{"container": "app-a"},
+		Entry:  logproto.Entry{Timestamp: time.Unix(2, 0).UTC(), Line: "this line is far too long to fit"},
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(receivedReqsChan) < 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	c.Stop()
+
+	snap := m.ChargebackSnapshot()
+	require.Equal(t, uint64(1), snap["app-a"].ShippedLines)
+	require.Equal(t, uint64(len("short")), snap["app-a"].ShippedBytes)
+	require.Equal(t, uint64(1), snap["app-a"].DroppedLines)
+	require.Equal(t, uint64(len("this line is far too long to fit")), snap["app-a"].DroppedBytes)
+}
@@ -8,9 +8,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-kit/log"
@@ -21,6 +23,7 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/util/goroutinelabels"
 
 	lokiutil "github.com/grafana/loki/v3/pkg/util"
 	"github.com/grafana/loki/v3/pkg/util/build"
@@ -30,6 +33,11 @@ const (
 	contentType  = "application/x-protobuf"
 	maxErrMsgLen = 1024
 
+	// contentEncodingSnappyFramed marks a request body as Snappy-framed
+	// (streamed) rather than the single Snappy block Loki's push API
+	// otherwise expects; see StreamingConfig.
+	contentEncodingSnappyFramed = "x-snappy-framed"
+
 	// Label reserved to override the tenant ID while processing
 	// pipeline stages
 	ReservedLabelTenantID = "__tenant_id__"
@@ -40,12 +48,17 @@ const (
 	TenantLabel  = "tenant"
 	ReasonLabel  = "reason"
 
-	ReasonGeneric       = "ingester_error"
-	ReasonRateLimited   = "rate_limited"
-	ReasonStreamLimited = "stream_limited"
-	ReasonLineTooLong   = "line_too_long"
+	ReasonGeneric        = "ingester_error"
+	ReasonRateLimited    = "rate_limited"
+	ReasonStreamLimited  = "stream_limited"
+	ReasonLineTooLong    = "line_too_long"
+	ReasonBudgetExceeded = "budget_exceeded"
 )
 
+// Reasons lists every drop reason a client without budget enforcement can
+// report. ReasonBudgetExceeded is added in initBatchMetrics only for
+// clients with budgeting enabled, so a client that never budgets doesn't
+// export a permanently-zero budget_exceeded series.
 var Reasons = []string{ReasonGeneric, ReasonRateLimited, ReasonStreamLimited, ReasonLineTooLong}
 
 var UserAgent = fmt.Sprintf("promtail/%s", build.Version)
@@ -60,9 +73,64 @@ type Metrics struct {
 	mutatedBytes                 *prometheus.CounterVec
 	requestDuration              *prometheus.HistogramVec
 	batchRetries                 *prometheus.CounterVec
+	shardedStreams               *prometheus.GaugeVec
+	trackedShardedStreams        *prometheus.GaugeVec
+	collectedShardedStreams      *prometheus.CounterVec
+	reorderBufferOverflows       *prometheus.CounterVec
+	rewrittenStreams             *prometheus.CounterVec
+	streamEntriesPerBatchSpills  *prometheus.CounterVec
 	countersWithHost             []*prometheus.CounterVec
 	countersWithHostTenant       []*prometheus.CounterVec
 	countersWithHostTenantReason []*prometheus.CounterVec
+
+	// sentTotal and droppedTotal track entry counts across all hosts and
+	// tenants, independent of Prometheus label cardinality, so that
+	// PushSuccessRate can be computed cheaply for health checks.
+	sentTotal    atomic.Uint64
+	droppedTotal atomic.Uint64
+
+	// pushHealth tracks, per host, whether a push has landed recently, for
+	// PushHealthSnapshot.
+	pushHealth *pushHealthTracker
+
+	// chargeback* track shipped/dropped bytes and lines per
+	// ChargebackConfig.LabelName value, for attributing log volume back to
+	// its source. chargeback holds the same totals independent of
+	// Prometheus, so they can be read back as plain JSON.
+	chargeback             *chargebackTracker
+	chargebackShippedBytes *prometheus.CounterVec
+	chargebackShippedLines *prometheus.CounterVec
+	chargebackDroppedBytes *prometheus.CounterVec
+	chargebackDroppedLines *prometheus.CounterVec
+
+	// budgetConsumptionPercent tracks each tenant's ingestion budget
+	// consumption, as a percentage of BudgetConfig.PerTenant, over the
+	// current window.
+	budgetConsumptionPercent *prometheus.GaugeVec
+
+	// sendLagSeconds and readLagSeconds together split end-to-end
+	// freshness lag into its read and ship halves: sendLagSeconds observes
+	// (send_time - entry_timestamp) for every entry in a batch just
+	// accepted by the server, while readLagSeconds gauges
+	// (now - entry_timestamp) for the most recently read entry, before it
+	// has even reached a batch. negativeLagTotal counts the clock-skew
+	// cases where either would have gone negative.
+	sendLagSeconds   *prometheus.HistogramVec
+	readLagSeconds   *prometheus.GaugeVec
+	negativeLagTotal *prometheus.CounterVec
+}
+
+// PushSuccessRate returns the fraction, in [0, 1], of log entries that have
+// been successfully pushed to Loki since startup. It returns 1 when no
+// entries have been processed yet.
+func (m *Metrics) PushSuccessRate() float64 {
+	sent := m.sentTotal.Load()
+	dropped := m.droppedTotal.Load()
+	total := sent + dropped
+	if total == 0 {
+		return 1
+	}
+	return float64(sent) / float64(total)
 }
 
 func NewMetrics(reg prometheus.Registerer) *Metrics {
@@ -113,13 +181,85 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 		Name:      "batch_retries_total",
 		Help:      "Number of times batches has had to be retried.",
 	}, []string{HostLabel, TenantLabel})
+	m.shardedStreams = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "promtail",
+		Name:      "sharded_streams",
+		Help:      "Number of streams currently being sharded because their client-observed rate exceeds shard_streams.desired_rate.",
+	}, []string{HostLabel})
+	m.trackedShardedStreams = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "promtail",
+		Name:      "shard_streams_tracked_streams",
+		Help:      "Number of streams currently tracked for client-side sharding, including streams below desired_rate.",
+	}, []string{HostLabel})
+	m.collectedShardedStreams = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "shard_streams_collected_streams_total",
+		Help:      "Number of streams whose client-side sharding state was garbage collected after going idle for shard_streams.stream_idle_ttl.",
+	}, []string{HostLabel})
+	m.reorderBufferOverflows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "reorder_buffer_overflows_total",
+		Help:      "Number of times a stream's reorder buffer exceeded reorder_buffer.max_entries and was flushed early, out of its normal timestamp-ordered schedule.",
+	}, []string{HostLabel})
+	m.rewrittenStreams = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "rewritten_streams_total",
+		Help:      "Number of streams whose label set was changed by this client's external_labels or drop_labels before sending.",
+	}, []string{HostLabel})
+	m.streamEntriesPerBatchSpills = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "stream_entries_per_batch_spills_total",
+		Help:      "Number of times a stream hit client.max-entries-per-stream-per-batch and its remaining entries spilled into a new batch.",
+	}, []string{HostLabel, TenantLabel})
+	m.chargebackShippedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "chargeback_shipped_bytes_total",
+		Help:      "Number of bytes successfully shipped to Loki, by chargeback key.",
+	}, []string{ChargebackKeyLabel})
+	m.chargebackShippedLines = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "chargeback_shipped_lines_total",
+		Help:      "Number of log lines successfully shipped to Loki, by chargeback key.",
+	}, []string{ChargebackKeyLabel})
+	m.chargebackDroppedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "chargeback_dropped_bytes_total",
+		Help:      "Number of bytes dropped before reaching Loki, across all drop reasons, by chargeback key.",
+	}, []string{ChargebackKeyLabel})
+	m.chargebackDroppedLines = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "chargeback_dropped_lines_total",
+		Help:      "Number of log lines dropped before reaching Loki, across all drop reasons, by chargeback key.",
+	}, []string{ChargebackKeyLabel})
+	m.chargeback = newChargebackTracker()
+	m.pushHealth = newPushHealthTracker()
+	m.budgetConsumptionPercent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "promtail",
+		Name:      "budget_consumption_percent",
+		Help:      "Percentage of a tenant's ingestion budget consumed in the current window, for tenants with a budget.per_tenant entry.",
+	}, []string{HostLabel, TenantLabel})
+	m.sendLagSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "promtail",
+		Name:      "send_lag_seconds",
+		Help:      "Time between an entry's own timestamp and the moment its batch was accepted by the server, per host and tenant. Negative values, from clock skew between the log source and promtail, are clamped to zero; see negative_lag_total.",
+	}, []string{HostLabel, TenantLabel})
+	m.readLagSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "promtail",
+		Name:      "read_lag_seconds",
+		Help:      "How far behind the clock the most recently read entry's own timestamp is, per host and tenant. Comparing it against send_lag_seconds shows whether lag is accumulating while waiting to be read, or while waiting to be shipped.",
+	}, []string{HostLabel, TenantLabel})
+	m.negativeLagTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "negative_lag_total",
+		Help:      "Number of times send_lag_seconds or read_lag_seconds would have gone negative because of clock skew between the log source and promtail, and was clamped to zero instead.",
+	}, []string{HostLabel, TenantLabel})
 
 	m.countersWithHost = []*prometheus.CounterVec{
-		m.encodedBytes, m.sentBytes, m.sentEntries,
+		m.encodedBytes, m.sentBytes, m.sentEntries, m.reorderBufferOverflows, m.collectedShardedStreams, m.rewrittenStreams,
 	}
 
 	m.countersWithHostTenant = []*prometheus.CounterVec{
-		m.batchRetries,
+		m.batchRetries, m.negativeLagTotal, m.streamEntriesPerBatchSpills,
 	}
 
 	m.countersWithHostTenantReason = []*prometheus.CounterVec{
@@ -136,6 +276,20 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 		m.mutatedBytes = mustRegisterOrGet(reg, m.mutatedBytes).(*prometheus.CounterVec)
 		m.requestDuration = mustRegisterOrGet(reg, m.requestDuration).(*prometheus.HistogramVec)
 		m.batchRetries = mustRegisterOrGet(reg, m.batchRetries).(*prometheus.CounterVec)
+		m.shardedStreams = mustRegisterOrGet(reg, m.shardedStreams).(*prometheus.GaugeVec)
+		m.trackedShardedStreams = mustRegisterOrGet(reg, m.trackedShardedStreams).(*prometheus.GaugeVec)
+		m.collectedShardedStreams = mustRegisterOrGet(reg, m.collectedShardedStreams).(*prometheus.CounterVec)
+		m.reorderBufferOverflows = mustRegisterOrGet(reg, m.reorderBufferOverflows).(*prometheus.CounterVec)
+		m.rewrittenStreams = mustRegisterOrGet(reg, m.rewrittenStreams).(*prometheus.CounterVec)
+		m.streamEntriesPerBatchSpills = mustRegisterOrGet(reg, m.streamEntriesPerBatchSpills).(*prometheus.CounterVec)
+		m.chargebackShippedBytes = mustRegisterOrGet(reg, m.chargebackShippedBytes).(*prometheus.CounterVec)
+		m.chargebackShippedLines = mustRegisterOrGet(reg, m.chargebackShippedLines).(*prometheus.CounterVec)
+		m.chargebackDroppedBytes = mustRegisterOrGet(reg, m.chargebackDroppedBytes).(*prometheus.CounterVec)
+		m.chargebackDroppedLines = mustRegisterOrGet(reg, m.chargebackDroppedLines).(*prometheus.CounterVec)
+		m.budgetConsumptionPercent = mustRegisterOrGet(reg, m.budgetConsumptionPercent).(*prometheus.GaugeVec)
+		m.sendLagSeconds = mustRegisterOrGet(reg, m.sendLagSeconds).(*prometheus.HistogramVec)
+		m.readLagSeconds = mustRegisterOrGet(reg, m.readLagSeconds).(*prometheus.GaugeVec)
+		m.negativeLagTotal = mustRegisterOrGet(reg, m.negativeLagTotal).(*prometheus.CounterVec)
 	}
 
 	return &m
@@ -172,6 +326,8 @@ type client struct {
 	wg   sync.WaitGroup
 
 	externalLabels model.LabelSet
+	labelConflicts *conflictWarner
+	dropLabels     []model.LabelName
 
 	// ctx is used in any upstream calls from the `client`.
 	ctx                 context.Context
@@ -179,13 +335,26 @@ type client struct {
 	maxStreams          int
 	maxLineSize         int
 	maxLineSizeTruncate bool
+
+	// maxEntriesPerStreamPerBatch mirrors cfg.MaxEntriesPerStreamPerBatch;
+	// kept as its own field, the same way maxStreams/maxLineSize are, so
+	// ingest's hot path doesn't have to dereference cfg for it.
+	maxEntriesPerStreamPerBatch int
+
+	sharder   *streamSharder
+	reorderer *entryReorderer
+	budget    *budgetTracker
 }
 
 // Tripperware can wrap a roundtripper.
 type Tripperware func(http.RoundTripper) http.RoundTripper
 
-// New makes a new Client.
+// New makes a new Client. If cfg.Spool.Enabled, the returned Client writes
+// batches to a local spool directory instead of pushing them to cfg.URL.
 func New(metrics *Metrics, cfg Config, maxStreams, maxLineSize int, maxLineSizeTruncate bool, logger log.Logger) (Client, error) {
+	if cfg.Spool.Enabled {
+		return NewSpool(cfg, logger)
+	}
 	return newClient(metrics, cfg, maxStreams, maxLineSize, maxLineSizeTruncate, logger)
 }
 
@@ -203,27 +372,49 @@ func newClient(metrics *Metrics, cfg Config, maxStreams, maxLineSize int, maxLin
 	c := &client{
 		logger:  log.With(logger, "component", "client", "host", cfg.URL.Host),
 		cfg:     cfg,
-		entries: make(chan api.Entry),
+		entries: make(chan api.Entry, cfg.ChannelBufferSize),
 		metrics: metrics,
 		name:    asSha256(cfg),
 
 		externalLabels:      cfg.ExternalLabels.LabelSet,
+		dropLabels:          asLabelNames(cfg.DropLabels),
 		ctx:                 ctx,
 		cancel:              cancel,
 		maxStreams:          maxStreams,
 		maxLineSize:         maxLineSize,
 		maxLineSizeTruncate: maxLineSizeTruncate,
+
+		maxEntriesPerStreamPerBatch: cfg.MaxEntriesPerStreamPerBatch,
 	}
 	if cfg.Name != "" {
 		c.name = cfg.Name
 	}
+	c.labelConflicts = newConflictWarner(c.logger, c.name, cfg.ExternalLabelsOverride)
+
+	if cfg.ShardStreams.Enabled {
+		c.sharder = newStreamSharder(cfg.ShardStreams, cfg.URL.Host, metrics, c.logger)
+	}
+
+	if cfg.ReorderBuffer.Enabled {
+		c.reorderer = newEntryReorderer(cfg.ReorderBuffer, cfg.URL.Host, metrics)
+	}
+
+	if cfg.Budget.Enabled {
+		c.budget = newBudgetTracker(c.logger, cfg.Budget)
+	}
 
 	err := cfg.Client.Validate()
 	if err != nil {
 		return nil, err
 	}
 
-	c.client, err = config.NewClientFromConfig(cfg.Client, "promtail", config.WithHTTP2Disabled())
+	clientOpts := []config.HTTPClientOption{config.WithHTTP2Disabled()}
+	if cfg.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: cfg.DialTimeout}
+		clientOpts = append(clientOpts, config.WithDialContextFunc(dialer.DialContext))
+	}
+
+	c.client, err = config.NewClientFromConfig(cfg.Client, "promtail", clientOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -258,8 +449,12 @@ func NewWithTripperware(metrics *Metrics, cfg Config, maxStreams, maxLineSize in
 func (c *client) initBatchMetrics(tenantID string) {
 	// Initialize counters to 0 so the metrics are exported before the first
 	// occurrence of incrementing to avoid missing metrics.
+	reasons := Reasons
+	if c.cfg.Budget.Enabled {
+		reasons = append(append([]string{}, Reasons...), ReasonBudgetExceeded)
+	}
 	for _, counter := range c.metrics.countersWithHostTenantReason {
-		for _, reason := range Reasons {
+		for _, reason := range reasons {
 			counter.WithLabelValues(c.cfg.URL.Host, tenantID, reason).Add(0)
 		}
 	}
@@ -270,6 +465,9 @@ func (c *client) initBatchMetrics(tenantID string) {
 }
 
 func (c *client) run() {
+	_, resetLabels := goroutinelabels.Apply(c.ctx, "component", "promtail_client", "client", c.name, "host", c.cfg.URL.Host)
+	defer resetLabels()
+
 	batches := map[string]*batch{}
 
 	// Given the client handles multiple batches (1 per tenant) and each batch
@@ -286,13 +484,56 @@ func (c *client) run() {
 
 	maxWaitCheck := time.NewTicker(maxWaitCheckFrequency)
 
+	// reorderTickC only fires when a reorder buffer is configured; a nil
+	// channel in a select simply never becomes ready.
+	var reorderTickC <-chan time.Time
+	if c.reorderer != nil {
+		reorderTick := time.NewTicker(reorderTickFrequency)
+		defer reorderTick.Stop()
+		reorderTickC = reorderTick.C
+	}
+
+	// sharderGCTickC only fires when stream sharding is configured; a nil
+	// channel in a select simply never becomes ready.
+	var sharderGCTickC <-chan time.Time
+	if c.sharder != nil {
+		sharderGCTick := time.NewTicker(sharderGCTickFrequency)
+		defer sharderGCTick.Stop()
+		sharderGCTickC = sharderGCTick.C
+	}
+
+	// budgetSaveTickC only fires when a budget state file is configured; a
+	// nil channel in a select simply never becomes ready.
+	var budgetSaveTickC <-chan time.Time
+	if c.budget != nil && c.cfg.Budget.StateFile != "" {
+		budgetSaveTick := time.NewTicker(defaultBudgetSyncPeriod)
+		defer budgetSaveTick.Stop()
+		budgetSaveTickC = budgetSaveTick.C
+	}
+
 	defer func() {
 		maxWaitCheck.Stop()
+
+		// Drain anything still parked in the reorder buffer before flushing
+		// batches below, so an entry that hasn't hit MaxDelay yet isn't
+		// silently dropped just because c.entries closed first.
+		if c.reorderer != nil {
+			for _, e := range c.reorderer.releaseAll() {
+				c.ingest(batches, e)
+			}
+		}
+
 		// Send all pending batches
 		for tenantID, batch := range batches {
 			c.sendBatch(tenantID, batch)
 		}
 
+		if c.budget != nil {
+			if err := c.budget.save(); err != nil {
+				level.Warn(c.logger).Log("msg", "could not persist budget state file", "err", err)
+			}
+		}
+
 		c.wg.Done()
 	}()
 
@@ -303,50 +544,29 @@ func (c *client) run() {
 				return
 			}
 
-			e, tenantID := c.processEntry(e)
-
-			// Either drop or mutate the log entry because its length is greater than maxLineSize. maxLineSize == 0 means disabled.
-			if c.maxLineSize != 0 && len(e.Line) > c.maxLineSize {
-				if !c.maxLineSizeTruncate {
-					c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonLineTooLong).Inc()
-					c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonLineTooLong).Add(float64(len(e.Line)))
-					break
+			if c.reorderer == nil {
+				if !c.ingest(batches, e) {
+					return
 				}
-
-				c.metrics.mutatedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonLineTooLong).Inc()
-				c.metrics.mutatedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonLineTooLong).Add(float64(len(e.Line) - c.maxLineSize))
-				e.Line = e.Line[:c.maxLineSize]
-			}
-
-			batch, ok := batches[tenantID]
-
-			// If the batch doesn't exist yet, we create a new one with the entry
-			if !ok {
-				batches[tenantID] = newBatch(c.maxStreams, e)
-				c.initBatchMetrics(tenantID)
 				break
 			}
 
-			// If adding the entry to the batch will increase the size over the max
-			// size allowed, we do send the current batch and then create a new one
-			if batch.sizeBytesAfter(e) > c.cfg.BatchSize {
-				c.sendBatch(tenantID, batch)
-
-				batches[tenantID] = newBatch(c.maxStreams, e)
-				break
+			for _, re := range c.reorderer.add(e) {
+				if !c.ingest(batches, re) {
+					return
+				}
 			}
-
-			// The max size of the batch isn't reached, so we can add the entry
-			err := batch.add(e)
-			if err != nil {
-				level.Error(c.logger).Log("msg", "batch add err", "tenant", tenantID, "error", err)
-				reason := ReasonGeneric
-				if err.Error() == errMaxStreamsLimitExceeded {
-					reason = ReasonStreamLimited
+		case <-sharderGCTickC:
+			c.sharder.gc()
+		case <-budgetSaveTickC:
+			if err := c.budget.save(); err != nil {
+				level.Warn(c.logger).Log("msg", "could not persist budget state file", "err", err)
+			}
+		case <-reorderTickC:
+			for _, e := range c.reorderer.releaseExpired() {
+				if !c.ingest(batches, e) {
+					return
 				}
-				c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, reason).Add(float64(len(e.Line)))
-				c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, reason).Inc()
-				return
 			}
 		case <-maxWaitCheck.C:
 			// Send all batches whose max wait time has been reached
@@ -362,6 +582,106 @@ func (c *client) run() {
 	}
 }
 
+// ingest processes a single entry into batches: applying external labels,
+// sharding and the max line size policy, then adding it to (or starting) its
+// tenant's batch. It returns false if a fatal batch error means run should
+// stop.
+func (c *client) ingest(batches map[string]*batch, e api.Entry) bool {
+	e, tenantID := c.processEntry(e)
+
+	now := time.Now()
+	if e.Timestamp.After(now) {
+		c.metrics.negativeLagTotal.WithLabelValues(c.cfg.URL.Host, tenantID).Inc()
+	}
+	c.metrics.readLagSeconds.WithLabelValues(c.cfg.URL.Host, tenantID).Set(c.lagSeconds(now, e.Timestamp))
+
+	if c.budget != nil && !c.cfg.Budget.allowlisted(e.Labels) {
+		fraction, overBudget := c.budget.add(tenantID, len(e.Line), time.Now())
+		c.metrics.budgetConsumptionPercent.WithLabelValues(c.cfg.URL.Host, tenantID).Set(fraction * 100)
+
+		if overBudget && c.cfg.Budget.mode() == BudgetModeDrop {
+			c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonBudgetExceeded).Inc()
+			c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonBudgetExceeded).Add(float64(len(e.Line)))
+			c.metrics.droppedTotal.Add(1)
+			if key, ok := chargebackKey(c.cfg.Chargeback, e.Labels); ok {
+				c.metrics.recordChargeback(c.cfg.Chargeback, key, len(e.Line), 1, false)
+			}
+			return true
+		}
+	}
+
+	// Either drop or mutate the log entry because its length is greater than maxLineSize. maxLineSize == 0 means disabled.
+	if c.maxLineSize != 0 && len(e.Line) > c.maxLineSize {
+		if !c.maxLineSizeTruncate {
+			c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonLineTooLong).Inc()
+			c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonLineTooLong).Add(float64(len(e.Line)))
+			c.metrics.droppedTotal.Add(1)
+			if key, ok := chargebackKey(c.cfg.Chargeback, e.Labels); ok {
+				c.metrics.recordChargeback(c.cfg.Chargeback, key, len(e.Line), 1, false)
+			}
+			return true
+		}
+
+		c.metrics.mutatedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonLineTooLong).Inc()
+		c.metrics.mutatedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonLineTooLong).Add(float64(len(e.Line) - c.maxLineSize))
+		e.Line = e.Line[:c.maxLineSize]
+	}
+
+	batch, ok := batches[tenantID]
+
+	// If the batch doesn't exist yet, we create a new one with the entry
+	if !ok {
+		batches[tenantID] = newBatch(c.maxStreams, c.chargebackLabel(), e)
+		c.initBatchMetrics(tenantID)
+		return true
+	}
+
+	// If adding the entry to the batch will increase the size over the max
+	// size allowed, we do send the current batch and then create a new one
+	if batch.sizeBytesAfter(e) > c.cfg.BatchSize {
+		c.sendBatch(tenantID, batch)
+
+		batches[tenantID] = newBatch(c.maxStreams, c.chargebackLabel(), e)
+		return true
+	}
+
+	// If the entry's stream already holds maxEntriesPerStreamPerBatch
+	// entries in this batch, send the batch now and start the entry's
+	// stream fresh in a new one, so a single hot stream can't fill an
+	// entire push request and risk the whole thing being rejected by
+	// Loki's per-stream rate limit. Order is preserved because the
+	// current batch, including every earlier entry of this stream, is
+	// sent before the new one is created.
+	if c.maxEntriesPerStreamPerBatch > 0 {
+		labels := labelsMapToString(e.Labels, ReservedLabelTenantID)
+		if batch.streamEntryCount(labels) >= c.maxEntriesPerStreamPerBatch {
+			c.metrics.streamEntriesPerBatchSpills.WithLabelValues(c.cfg.URL.Host, tenantID).Inc()
+			c.sendBatch(tenantID, batch)
+
+			batches[tenantID] = newBatch(c.maxStreams, c.chargebackLabel(), e)
+			return true
+		}
+	}
+
+	// The max size of the batch isn't reached, so we can add the entry
+	err := batch.add(e)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "batch add err", "tenant", tenantID, "error", err)
+		reason := ReasonGeneric
+		if err.Error() == errMaxStreamsLimitExceeded {
+			reason = ReasonStreamLimited
+		}
+		c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, reason).Add(float64(len(e.Line)))
+		c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, reason).Inc()
+		c.metrics.droppedTotal.Add(1)
+		if key, ok := chargebackKey(c.cfg.Chargeback, e.Labels); ok {
+			c.metrics.recordChargeback(c.cfg.Chargeback, key, len(e.Line), 1, false)
+		}
+		return false
+	}
+	return true
+}
+
 func (c *client) Chan() chan<- api.Entry {
 	return c.entries
 }
@@ -379,6 +699,11 @@ func batchIsRateLimited(status int) bool {
 }
 
 func (c *client) sendBatch(tenantID string, batch *batch) {
+	if c.cfg.Streaming.Enabled {
+		c.sendBatchStreaming(tenantID, batch)
+		return
+	}
+
 	buf, entriesCount, err := batch.encode()
 	if err != nil {
 		level.Error(c.logger).Log("msg", "error encoding batch", "error", err)
@@ -401,12 +726,19 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 			level.Warn(c.logger).Log("msg", "dropping batch due to rate limiting applied at ingester")
 			c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonRateLimited).Add(bufBytes)
 			c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonRateLimited).Add(float64(entriesCount))
+			c.metrics.droppedTotal.Add(uint64(entriesCount))
+			c.metrics.recordPush(c.cfg.URL.Host, false, time.Now())
+			c.recordBatchChargeback(batch, false)
 			return
 		}
 
 		if err == nil {
 			c.metrics.sentBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
 			c.metrics.sentEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
+			c.metrics.sentTotal.Add(uint64(entriesCount))
+			c.metrics.recordPush(c.cfg.URL.Host, true, time.Now())
+			c.recordBatchChargeback(batch, true)
+			c.recordSendLag(tenantID, batch.allStreams(), time.Now())
 
 			return
 		}
@@ -436,17 +768,86 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 		}
 		c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, dropReason).Add(bufBytes)
 		c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, dropReason).Add(float64(entriesCount))
+		c.metrics.droppedTotal.Add(uint64(entriesCount))
+		c.metrics.recordPush(c.cfg.URL.Host, false, time.Now())
+		c.recordBatchChargeback(batch, false)
+	}
+}
+
+// chargebackLabel returns the entry label chargeback accounting is keyed by,
+// or "" if chargeback accounting is disabled.
+func (c *client) chargebackLabel() model.LabelName {
+	if !c.cfg.Chargeback.Enabled {
+		return ""
+	}
+	return model.LabelName(c.cfg.Chargeback.LabelName)
+}
+
+// recordBatchChargeback attributes the whole batch's per-key bytes/lines,
+// tallied as entries were added to it, as either shipped or dropped.
+func (c *client) recordBatchChargeback(b *batch, shipped bool) {
+	for key, counts := range b.chargeback {
+		c.metrics.recordChargeback(c.cfg.Chargeback, key, counts.bytes, counts.lines, shipped)
+	}
+}
+
+// lagSeconds returns max(0, now-t).Seconds(), the way both sendLagSeconds
+// and readLagSeconds want their inputs clamped. It doesn't itself account
+// for clock skew: an entry is only ever read once, so negativeLagTotal is
+// incremented where readLagSeconds is set, not here, to avoid counting the
+// same skewed entry twice as it's later observed again by sendLagSeconds.
+func (c *client) lagSeconds(now, t time.Time) float64 {
+	lag := now.Sub(t)
+	if lag < 0 {
+		return 0
+	}
+	return lag.Seconds()
+}
+
+// recordSendLag observes sendLagSeconds for every entry in streams, whose
+// batch (or batch chunk) was just accepted by the server.
+func (c *client) recordSendLag(tenantID string, streams []*batchStream, now time.Time) {
+	for _, stream := range streams {
+		for _, entry := range stream.entries {
+			c.metrics.sendLagSeconds.WithLabelValues(c.cfg.URL.Host, tenantID).Observe(c.lagSeconds(now, entry.Timestamp))
+		}
 	}
 }
 
 func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
 	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, "POST", c.cfg.URL.String(), bytes.NewReader(buf))
+	req, err := c.newPushRequest(ctx, tenantID, bytes.NewReader(buf), "")
+	if err != nil {
+		return -1, err
+	}
+	return c.doPush(req)
+}
+
+// sendStream is like send, but for a request body that streams its
+// (already Snappy-framed) contents rather than a fully buffered slice; see
+// StreamingConfig.
+func (c *client) sendStream(ctx context.Context, tenantID string, body io.Reader) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	req, err := c.newPushRequest(ctx, tenantID, body, contentEncodingSnappyFramed)
 	if err != nil {
 		return -1, err
 	}
+	return c.doPush(req)
+}
+
+// newPushRequest builds a push request against cfg.URL with body as its
+// content, tagging it with contentEncoding if non-empty.
+func (c *client) newPushRequest(ctx context.Context, tenantID string, body io.Reader, contentEncoding string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.cfg.URL.String(), body)
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Set("Content-Type", contentType)
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
 	req.Header.Set("User-Agent", UserAgent)
 
 	// If the tenant ID is not empty promtail is running in multi-tenant mode, so
@@ -466,6 +867,12 @@ func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, er
 		}
 	}
 
+	return req, nil
+}
+
+// doPush executes req and translates a non-2xx response into an error, the
+// way both send and sendStream need.
+func (c *client) doPush(req *http.Request) (int, error) {
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return -1, err
@@ -513,13 +920,68 @@ func (c *client) StopNow() {
 }
 
 func (c *client) processEntry(e api.Entry) (api.Entry, string) {
+	rewritten := false
 	if len(c.externalLabels) > 0 {
-		e.Labels = c.externalLabels.Merge(e.Labels)
+		e.Labels = mergeExternalLabels(c.externalLabels, e.Labels, c.cfg.ExternalLabelsOverride, c.labelConflicts.warn)
+		rewritten = true
+	}
+	if len(c.dropLabels) > 0 {
+		var cloned bool
+		for _, l := range c.dropLabels {
+			if _, ok := e.Labels[l]; !ok {
+				continue
+			}
+			// e.Labels may still be the same map instance handed to every
+			// other fanned-out client (see Manager.startWithForward); it
+			// must be cloned before mutating in place, or this races with
+			// those clients reading it concurrently. externalLabels.Merge
+			// above already returns a fresh map, so this only clones once.
+			if !cloned {
+				clone := make(model.LabelSet, len(e.Labels))
+				for k, v := range e.Labels {
+					clone[k] = v
+				}
+				e.Labels = clone
+				cloned = true
+			}
+			delete(e.Labels, l)
+			rewritten = true
+		}
+	}
+	if rewritten {
+		c.metrics.rewrittenStreams.WithLabelValues(c.cfg.URL.Host).Inc()
+	}
+	if c.sharder != nil {
+		e.Labels = c.sharder.shard(e.Labels, len(e.Line), c.batchGeneration())
 	}
 	tenantID := c.getTenantID(e.Labels)
 	return e, tenantID
 }
 
+// asLabelNames converts a list of label name strings, as configured via
+// Config.DropLabels, into model.LabelNames for direct use as LabelSet keys.
+func asLabelNames(names []string) []model.LabelName {
+	if len(names) == 0 {
+		return nil
+	}
+	out := make([]model.LabelName, len(names))
+	for i, n := range names {
+		out[i] = model.LabelName(n)
+	}
+	return out
+}
+
+// batchGeneration returns an identifier that stays constant for the
+// duration of a single BatchWait window, so that a sharded stream's shard
+// assignment is sticky across all entries destined for the same batch.
+func (c *client) batchGeneration() int64 {
+	wait := c.cfg.BatchWait
+	if wait <= 0 {
+		wait = BatchWait
+	}
+	return time.Now().UnixNano() / int64(wait)
+}
+
 func (c *client) Name() string {
 	return c.name
 }
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -715,6 +716,161 @@ func TestClient_StopNow(t *testing.T) {
 	}
 }
 
+// TestClient_ExternalLabelsAndDropLabels simulates fanning the same entry
+// out to two differently configured clients (as Manager.startWithForward
+// does), and verifies each client reshapes the stream independently via its
+// own external_labels/drop_labels, without the two mutating each other's
+// view of the entry's label set.
+func TestClient_ExternalLabelsAndDropLabels(t *testing.T) {
+	saasReqs := make(chan utils.RemoteWriteRequest, 1)
+	saasServer := utils.NewRemoteWriteServer(saasReqs, 200)
+	defer saasServer.Close()
+
+	onpremReqs := make(chan utils.RemoteWriteRequest, 1)
+	onpremServer := utils.NewRemoteWriteServer(onpremReqs, 200)
+	defer onpremServer.Close()
+
+	saasURL := flagext.URLValue{}
+	require.NoError(t, saasURL.Set(saasServer.URL))
+	onpremURL := flagext.URLValue{}
+	require.NoError(t, onpremURL.Set(onpremServer.URL))
+
+	reg := prometheus.NewRegistry()
+	m := NewMetrics(reg)
+
+	saasCfg := Config{
+		URL:            saasURL,
+		BatchWait:      10 * time.Millisecond,
+		BatchSize:      1024 * 1024,
+		Client:         config.HTTPClientConfig{},
+		ExternalLabels: lokiflag.LabelSet{LabelSet: model.LabelSet{"env": "prod"}},
+		DropLabels:     []string{"node_ip"},
+		Timeout:        time.Second,
+	}
+	saasClient, err := New(m, saasCfg, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+
+	onpremCfg := Config{
+		URL:       onpremURL,
+		BatchWait: 10 * time.Millisecond,
+		BatchSize: 1024 * 1024,
+		Client:    config.HTTPClientConfig{},
+		Timeout:   time.Second,
+	}
+	onpremClient, err := New(m, onpremCfg, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+
+	entry := api.Entry{
+		Labels: model.LabelSet{"job": "syslog", "node_ip": "10.0.0.1"},
+		Entry:  logproto.Entry{Timestamp: time.Unix(1, 0).UTC(), Line: "hello"},
+	}
+	// Fan the same entry out to both clients, as Manager.startWithForward does.
+	saasClient.Chan() <- entry
+	onpremClient.Chan() <- entry
+
+	saasClient.Stop()
+	onpremClient.Stop()
+	close(saasReqs)
+	close(onpremReqs)
+
+	saasReq := <-saasReqs
+	require.Len(t, saasReq.Request.Streams, 1)
+	require.Equal(t, `{env="prod", job="syslog"}`, saasReq.Request.Streams[0].Labels)
+
+	onpremReq := <-onpremReqs
+	require.Len(t, onpremReq.Request.Streams, 1)
+	require.Equal(t, `{job="syslog", node_ip="10.0.0.1"}`, onpremReq.Request.Streams[0].Labels)
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+                               # HELP promtail_rewritten_streams_total Number of streams whose label set was changed by this client's external_labels or drop_labels before sending.
+                               # TYPE promtail_rewritten_streams_total counter
+                               promtail_rewritten_streams_total{host=%q} 1
+                               promtail_rewritten_streams_total{host=%q} 0
+                       `, saasURL.Host, onpremURL.Host)), "promtail_rewritten_streams_total")
+	assert.NoError(t, err)
+}
+
+func TestClient_ExternalLabelsOverride(t *testing.T) {
+	tests := []struct {
+		name         string
+		override     bool
+		wantStreamLb string
+	}{
+		{
+			name:         "default precedence, entry label wins",
+			override:     false,
+			wantStreamLb: `{env="staging", job="syslog"}`,
+		},
+		{
+			name:         "override, external_labels value wins",
+			override:     true,
+			wantStreamLb: `{env="prod", job="syslog"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs := make(chan utils.RemoteWriteRequest, 1)
+			server := utils.NewRemoteWriteServer(reqs, 200)
+			defer server.Close()
+
+			u := flagext.URLValue{}
+			require.NoError(t, u.Set(server.URL))
+
+			cfg := Config{
+				URL:                    u,
+				BatchWait:              10 * time.Millisecond,
+				BatchSize:              1024 * 1024,
+				Client:                 config.HTTPClientConfig{},
+				ExternalLabels:         lokiflag.LabelSet{LabelSet: model.LabelSet{"env": "prod"}},
+				ExternalLabelsOverride: tt.override,
+				Timeout:                time.Second,
+			}
+			c, err := New(NewMetrics(prometheus.NewRegistry()), cfg, 0, 0, false, log.NewNopLogger())
+			require.NoError(t, err)
+
+			c.Chan() <- api.Entry{
+				Labels: model.LabelSet{"job": "syslog", "env": "staging"},
+				Entry:  logproto.Entry{Timestamp: time.Unix(1, 0).UTC(), Line: "hello"},
+			}
+			c.Stop()
+			close(reqs)
+
+			req := <-reqs
+			require.Len(t, req.Request.Streams, 1)
+			require.Equal(t, tt.wantStreamLb, req.Request.Streams[0].Labels)
+		})
+	}
+}
+
+func TestClient_DialTimeout(t *testing.T) {
+	// 192.0.2.0/24 is reserved for documentation (RFC 5737) and routed
+	// nowhere, so connecting to it blocks until something gives up on it.
+	// Without DialTimeout that wait is bounded only by Timeout below (a
+	// full minute here); DialTimeout should cut it off well before that.
+	u := flagext.URLValue{}
+	require.NoError(t, u.Set("http://192.0.2.1:81/loki/api/v1/push"))
+
+	cfg := Config{
+		URL:         u,
+		BatchWait:   10 * time.Millisecond,
+		BatchSize:   1024 * 1024,
+		Client:      config.HTTPClientConfig{},
+		Timeout:     time.Minute,
+		DialTimeout: 50 * time.Millisecond,
+	}
+	c, err := newClient(NewMetrics(prometheus.NewRegistry()), cfg, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+	defer c.StopNow()
+
+	start := time.Now()
+	_, err = c.client.Get(cfg.URL.String())
+	elapsed := time.Since(start)
+
+	require.Error(t, err)
+	require.Less(t, elapsed, 5*time.Second, "DialTimeout should fail the connection long before Timeout does")
+}
+
 type RoundTripperFunc func(*http.Request) (*http.Response, error)
 
 func (r RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -746,3 +902,199 @@ func Test_Tripperware(t *testing.T) {
 	c.Stop()
 	require.True(t, called)
 }
+
+// Test_ChannelBufferSize verifies that ChannelBufferSize entries can queue
+// up in Chan() while the client's run loop is busy sending a batch, and
+// that a further entry beyond that only proceeds once the loop frees up.
+func Test_ChannelBufferSize(t *testing.T) {
+	const bufferSize = 3
+
+	url, err := url.Parse("http://foo.com")
+	require.NoError(t, err)
+
+	wedged := make(chan struct{})
+	var wedgedOnce sync.Once
+	release := make(chan struct{})
+	var releaseOnce sync.Once
+	closeRelease := func() { releaseOnce.Do(func() { close(release) }) }
+	defer closeRelease()
+
+	c, err := NewWithTripperware(metrics, Config{
+		URL:               flagext.URLValue{URL: url},
+		BatchSize:         1,
+		BatchWait:         time.Minute,
+		ChannelBufferSize: bufferSize,
+		BackoffConfig:     backoff.Config{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, MaxRetries: 1},
+		Timeout:           time.Minute,
+	}, 0, 0, false, log.NewNopLogger(), func(_ http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(_ *http.Request) (*http.Response, error) {
+			wedgedOnce.Do(func() { close(wedged) })
+			<-release
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+		})
+	})
+	require.NoError(t, err)
+	defer c.StopNow()
+
+	entry := func(line string) api.Entry {
+		return api.Entry{Labels: model.LabelSet{"foo": "bar"}, Entry: logproto.Entry{Timestamp: time.Now(), Line: line}}
+	}
+
+	// The first entry starts a batch; with BatchSize set to 1 byte, the
+	// second immediately exceeds it and triggers a synchronous send that
+	// wedges in the tripperware above, stalling the run loop's reads from
+	// Chan().
+	c.Chan() <- entry("1")
+	c.Chan() <- entry("2")
+	select {
+	case <-wedged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the client's run loop to wedge on the blocked send")
+	}
+
+	// With the run loop wedged, up to bufferSize further entries should
+	// queue up in the channel without blocking the sender.
+	queued := make(chan struct{})
+	go func() {
+		for i := 0; i < bufferSize; i++ {
+			c.Chan() <- entry(fmt.Sprintf("queued-%d", i))
+		}
+		close(queued)
+	}()
+	select {
+	case <-queued:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected queueing up to ChannelBufferSize entries not to block")
+	}
+
+	// The channel is now full: one more entry should block until the
+	// wedged send is released.
+	overflowed := make(chan struct{})
+	go func() {
+		c.Chan() <- entry("overflow")
+		close(overflowed)
+	}()
+	select {
+	case <-overflowed:
+		t.Fatal("expected an entry beyond ChannelBufferSize to block")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	closeRelease()
+	select {
+	case <-overflowed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the overflow entry to proceed once the run loop was unwedged")
+	}
+}
+
+func TestClient_MaxEntriesPerStreamPerBatch(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	receivedReqsChan := make(chan utils.RemoteWriteRequest, 10)
+	server := utils.NewRemoteWriteServer(receivedReqsChan, http.StatusOK)
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+
+	cfg := Config{
+		URL:                         serverURL,
+		BatchWait:                   time.Minute,
+		BatchSize:                   1024 * 1024,
+		MaxEntriesPerStreamPerBatch: 2,
+		BackoffConfig:               backoff.Config{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, MaxRetries: 1},
+		Timeout:                     time.Second,
+	}
+
+	m := NewMetrics(reg)
+	c, err := New(m, cfg, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+
+	// A single stream's 5th entry should spill it into a third batch, since
+	// the cap of 2 is hit twice.
+	for i := 0; i < 5; i++ {
+		c.Chan() <- api.Entry{
+			Labels: model.LabelSet{"app": "app-1"},
+			Entry:  logproto.Entry{Timestamp: time.Unix(int64(i), 0).UTC(), Line: fmt.Sprintf("line%d", i)},
+		}
+	}
+
+	c.Stop()
+	close(receivedReqsChan)
+
+	var received []utils.RemoteWriteRequest
+	for req := range receivedReqsChan {
+		received = append(received, req)
+	}
+
+	require.Len(t, received, 3)
+	var allLines []string
+	for _, req := range received {
+		require.Len(t, req.Request.Streams, 1)
+		require.LessOrEqual(t, len(req.Request.Streams[0].Entries), 2)
+		for _, e := range req.Request.Streams[0].Entries {
+			allLines = append(allLines, e.Line)
+		}
+	}
+	// Order must be preserved across the spilled batches.
+	require.Equal(t, []string{"line0", "line1", "line2", "line3", "line4"}, allLines)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+		# HELP promtail_stream_entries_per_batch_spills_total Number of times a stream hit client.max-entries-per-stream-per-batch and its remaining entries spilled into a new batch.
+		# TYPE promtail_stream_entries_per_batch_spills_total counter
+		promtail_stream_entries_per_batch_spills_total{host=%q,tenant=""} 2
+	`, serverURL.Host)), "promtail_stream_entries_per_batch_spills_total"))
+}
+
+func TestClient_MaxEntriesPerStreamPerBatch_InteractionWithBatchSize(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	receivedReqsChan := make(chan utils.RemoteWriteRequest, 10)
+	server := utils.NewRemoteWriteServer(receivedReqsChan, http.StatusOK)
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+
+	// BatchSize is small enough that a second stream's single entry alone
+	// triggers a size-based spill, independent of the per-stream cap below.
+	cfg := Config{
+		URL:                         serverURL,
+		BatchWait:                   time.Minute,
+		BatchSize:                   len("line-a-0") + len("line-a-1"),
+		MaxEntriesPerStreamPerBatch: 5,
+		BackoffConfig:               backoff.Config{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, MaxRetries: 1},
+		Timeout:                     time.Second,
+	}
+
+	m := NewMetrics(reg)
+	c, err := New(m, cfg, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+
+	c.Chan() <- api.Entry{Labels: model.LabelSet{"app": "a"}, Entry: logproto.Entry{Timestamp: time.Unix(0, 0).UTC(), Line: "line-a-0"}}
+	c.Chan() <- api.Entry{Labels: model.LabelSet{"app": "a"}, Entry: logproto.Entry{Timestamp: time.Unix(1, 0).UTC(), Line: "line-a-1"}}
+	// Exceeds BatchSize on its own: forces a size-based spill, not a
+	// stream-entry-count one, since app-b hasn't hit the cap of 5.
+	c.Chan() <- api.Entry{Labels: model.LabelSet{"app": "b"}, Entry: logproto.Entry{Timestamp: time.Unix(2, 0).UTC(), Line: "line-b-0"}}
+
+	c.Stop()
+	close(receivedReqsChan)
+
+	var received []utils.RemoteWriteRequest
+	for req := range receivedReqsChan {
+		received = append(received, req)
+	}
+
+	require.Len(t, received, 2)
+	require.Len(t, received[0].Request.Streams, 1)
+	require.Equal(t, 2, len(received[0].Request.Streams[0].Entries))
+	require.Len(t, received[1].Request.Streams, 1)
+	require.Equal(t, "line-b-0", received[1].Request.Streams[0].Entries[0].Line)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+		# HELP promtail_stream_entries_per_batch_spills_total Number of times a stream hit client.max-entries-per-stream-per-batch and its remaining entries spilled into a new batch.
+		# TYPE promtail_stream_entries_per_batch_spills_total counter
+		promtail_stream_entries_per_batch_spills_total{host=%q,tenant=""} 0
+	`, serverURL.Host)), "promtail_stream_entries_per_batch_spills_total"))
+}
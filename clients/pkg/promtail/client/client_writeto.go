@@ -1,6 +1,8 @@
 package client
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"sync"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/prometheus/prometheus/tsdb/record"
 
 	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	promtail_wal "github.com/grafana/loki/v3/clients/pkg/promtail/wal"
 
 	"github.com/grafana/loki/v3/pkg/ingester/wal"
 	"github.com/grafana/loki/v3/pkg/util"
@@ -32,15 +35,21 @@ type clientWriteTo struct {
 
 	logger   log.Logger
 	toClient chan<- api.Entry
+
+	// ackJournal, if non-nil, dedupes AppendEntries calls against a prior
+	// run: see promtail_wal.AckJournal's own doc comment for exactly what
+	// this does and doesn't guarantee.
+	ackJournal *promtail_wal.AckJournal
 }
 
-// newClientWriteTo creates a new clientWriteTo
-func newClientWriteTo(toClient chan<- api.Entry, logger log.Logger) *clientWriteTo {
+// newClientWriteTo creates a new clientWriteTo. ackJournal may be nil, which disables hand-off deduplication.
+func newClientWriteTo(toClient chan<- api.Entry, logger log.Logger, ackJournal *promtail_wal.AckJournal) *clientWriteTo {
 	return &clientWriteTo{
 		series:        make(map[chunks.HeadSeriesRef]model.LabelSet),
 		seriesSegment: make(map[chunks.HeadSeriesRef]int),
 		toClient:      toClient,
 		logger:        logger,
+		ackJournal:    ackJournal,
 	}
 }
 
@@ -69,6 +78,12 @@ func (c *clientWriteTo) SeriesReset(segmentNum int) {
 			delete(c.series, k)
 		}
 	}
+
+	if c.ackJournal != nil {
+		if err := c.ackJournal.Compact(segmentNum + 1); err != nil {
+			level.Warn(c.logger).Log("msg", "failed to compact ack journal", "err", err)
+		}
+	}
 }
 
 func (c *clientWriteTo) AppendEntries(entries wal.RefEntries) error {
@@ -76,14 +91,47 @@ func (c *clientWriteTo) AppendEntries(entries wal.RefEntries) error {
 	c.seriesLock.RLock()
 	l, ok := c.series[entries.Ref]
 	c.seriesLock.RUnlock()
-	if ok {
-		entry.Labels = l
-		for _, e := range entries.Entries {
-			entry.Entry = e
-			c.toClient <- entry
-		}
-	} else {
+	if !ok {
 		level.Debug(c.logger).Log("msg", "series for entry not found")
+		return nil
+	}
+
+	var hash string
+	if c.ackJournal != nil {
+		hash = hashRefEntries(l, entries)
+		if c.ackJournal.IsAcked(hash) {
+			level.Debug(c.logger).Log("msg", "skipping already-acked batch", "hash", hash)
+			return nil
+		}
+	}
+
+	entry.Labels = l
+	for _, e := range entries.Entries {
+		entry.Entry = e
+		c.toClient <- entry
+	}
+
+	if c.ackJournal != nil {
+		c.seriesSegmentLock.RLock()
+		segment := c.seriesSegment[entries.Ref]
+		c.seriesSegmentLock.RUnlock()
+		if err := c.ackJournal.Ack(hash, segment); err != nil {
+			level.Warn(c.logger).Log("msg", "failed to ack batch", "err", err)
+		}
 	}
 	return nil
 }
+
+// hashRefEntries computes a stable, restart-safe checksum for a batch of WAL
+// entries: the series' labels plus each entry's timestamp and line. It does
+// not depend on the series ref or segment number, both of which are only
+// valid within a single WAL lifetime and would defeat deduplication across a
+// crash and replay of the same on-disk segment.
+func hashRefEntries(labels model.LabelSet, entries wal.RefEntries) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", labels.String())
+	for _, e := range entries.Entries {
+		fmt.Fprintf(h, "%d\t%s\n", e.Timestamp.UnixNano(), e.Line)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	promtail_wal "github.com/grafana/loki/v3/clients/pkg/promtail/wal"
 
 	"github.com/grafana/loki/v3/pkg/ingester/wal"
 	"github.com/grafana/loki/v3/pkg/logproto"
@@ -48,7 +49,7 @@ func TestClientWriter_LogEntriesAreReconstructedAndForwardedCorrectly(t *testing
 		"I'm in a starbucks",
 	}
 
-	writeTo := newClientWriteTo(ch, logger)
+	writeTo := newClientWriteTo(ch, logger, nil)
 	testAppLabelsRef := chunks.HeadSeriesRef(1)
 	writeTo.StoreSeries([]record.RefSeries{
 		{
@@ -108,7 +109,7 @@ func TestClientWriter_LogEntriesWithoutMatchingSeriesAreIgnored(t *testing.T) {
 		"I'm in a starbucks",
 	}
 
-	writeTo := newClientWriteTo(ch, logger)
+	writeTo := newClientWriteTo(ch, logger, nil)
 	testAppLabelsRef := chunks.HeadSeriesRef(1)
 	writeTo.StoreSeries([]record.RefSeries{
 		{
@@ -138,6 +139,56 @@ func TestClientWriter_LogEntriesWithoutMatchingSeriesAreIgnored(t *testing.T) {
 	require.Empty(t, receivedEntries, "no entry should have arrived")
 }
 
+// TestClientWriter_AckJournalPreventsDuplicateDeliveryAcrossRestart simulates a crash that happens after a batch has
+// been handed off to the client channel (i.e. the ack was journaled) but before its WAL segment was reclaimed, which
+// is exactly the window that causes the watcher to replay that segment - and the same AppendEntries call - on the
+// next startup. It asserts the ack journal recognizes the replay and does not forward the batch a second time.
+func TestClientWriter_AckJournalPreventsDuplicateDeliveryAcrossRestart(t *testing.T) {
+	logger := level.NewFilter(log.NewLogfmtLogger(os.Stdout), level.AllowDebug())
+	dir := t.TempDir()
+
+	testAppLabelsRef := chunks.HeadSeriesRef(1)
+	series := []record.RefSeries{
+		{
+			Ref: testAppLabelsRef,
+			Labels: []labels.Label{
+				{Name: "app", Value: "test"},
+			},
+		},
+	}
+	refEntries := wal.RefEntries{
+		Ref: testAppLabelsRef,
+		Entries: []logproto.Entry{
+			{Timestamp: time.Unix(0, 0), Line: "some entry"},
+		},
+	}
+
+	// First "run": deliver the batch once, then crash before the segment is reclaimed (no SeriesReset/Compact).
+	journal, err := promtail_wal.OpenAckJournal(dir + "/ack.journal")
+	require.NoError(t, err)
+
+	ch := make(chan api.Entry, 1)
+	writeTo := newClientWriteTo(ch, logger, journal)
+	writeTo.StoreSeries(series, 1)
+	require.NoError(t, writeTo.AppendEntries(refEntries))
+	require.Len(t, ch, 1, "first delivery should reach the client channel")
+	<-ch
+
+	require.NoError(t, journal.Close())
+
+	// "Restart": open a fresh ack journal from the same file, and a fresh clientWriteTo, then replay the exact same
+	// segment contents, as the watcher would after finding the segment still on disk.
+	reopened, err := promtail_wal.OpenAckJournal(dir + "/ack.journal")
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	ch2 := make(chan api.Entry, 1)
+	restarted := newClientWriteTo(ch2, logger, reopened)
+	restarted.StoreSeries(series, 1)
+	require.NoError(t, restarted.AppendEntries(refEntries))
+	require.Empty(t, ch2, "replayed batch must not be forwarded again after restart")
+}
+
 func BenchmarkClientWriteTo(b *testing.B) {
 	type testCase struct {
 		numWriters int
@@ -181,7 +232,7 @@ func bench(numWriters, totalLines int, b *testing.B) {
 		}
 	}()
 
-	writeTo := newClientWriteTo(ch, logger)
+	writeTo := newClientWriteTo(ch, logger, nil)
 
 	// spin up the numWriters routines
 	writersWG := sync.WaitGroup{}
@@ -40,6 +40,32 @@ type Config struct {
 	ExternalLabels lokiflag.LabelSet `yaml:"external_labels,omitempty"`
 	Timeout        time.Duration     `yaml:"timeout"`
 
+	// DialTimeout bounds how long establishing the underlying TCP connection
+	// may take, separately from Timeout, which bounds the whole request
+	// (connect, write, and read). Left at zero, the transport's default
+	// dialer (no timeout of its own) is used. Set this to fail fast against
+	// an unreachable Loki endpoint instead of waiting up to Timeout.
+	DialTimeout time.Duration `yaml:"dial_timeout,omitempty"`
+
+	// ExternalLabelsOverride flips the default precedence between
+	// ExternalLabels and an entry's own labels when both set the same label
+	// name: by default the entry's label wins (it's the more specific,
+	// dynamically discovered value), so set this to true to have the
+	// statically configured ExternalLabels value win instead.
+	ExternalLabelsOverride bool `yaml:"external_labels_override,omitempty"`
+
+	// Spool, when Enabled, turns this client into a spool client: it writes
+	// completed batches to a local directory instead of pushing them to
+	// URL. See SpoolConfig and the `promtail-unspool` command.
+	Spool SpoolConfig `yaml:"spool,omitempty"`
+
+	// DropLabels lists labels to remove from every stream sent to this
+	// client, applied after ExternalLabels. It lets a subset of clients in
+	// a multi-client (fanout) config reshape streams independently, e.g. a
+	// SaaS destination that shouldn't receive an internal-only label a
+	// self-hosted one relies on.
+	DropLabels []string `yaml:"drop_labels,omitempty"`
+
 	// The tenant ID to use when pushing logs to Loki (empty string means
 	// single tenant mode)
 	TenantID string `yaml:"tenant_id"`
@@ -48,6 +74,47 @@ type Config struct {
 	// 429 'Too Many Requests' response from the distributor. Helps
 	// prevent HOL blocking in multitenant deployments.
 	DropRateLimitedBatches bool `yaml:"drop_rate_limited_batches"`
+
+	// ShardStreams configures client-side sharding of streams that exceed a
+	// configurable byte rate, to avoid hitting Loki's per-stream rate limit.
+	ShardStreams ShardStreamsConfig `yaml:"shard_streams,omitempty"`
+
+	// Chargeback configures per-label shipped/dropped byte and line
+	// accounting, for attributing log volume back to its source.
+	Chargeback ChargebackConfig `yaml:"chargeback,omitempty"`
+
+	// ReorderBuffer configures a small per-stream buffer that releases
+	// entries to Loki in timestamp order, at the cost of bounded latency.
+	ReorderBuffer ReorderBufferConfig `yaml:"reorder_buffer,omitempty"`
+
+	// Streaming configures chunked-transfer, framed-snappy pushes for very
+	// large batches, so replaying hours of buffered backlog doesn't require
+	// holding a whole encoded push request in memory at once.
+	Streaming StreamingConfig `yaml:"streaming,omitempty"`
+
+	// ChannelBufferSize sets the buffer size of the channel returned by
+	// Client.Chan(). Left at zero, entries queue up one-for-one against a
+	// reader (the current default behavior). Raising it lets senders
+	// absorb bursts without blocking, at the cost of holding up to that
+	// many entries in memory at once - each holds its log line plus its
+	// labels' strings, so size this against expected line length and label
+	// cardinality, not just entry count.
+	ChannelBufferSize int `yaml:"channel_buffer_size"`
+
+	// Budget enforces a per-tenant ingestion byte budget over a rolling
+	// window, so a platform team can cap what a tenant ships before
+	// Loki-side limits (or cost) are affected.
+	Budget BudgetConfig `yaml:"budget,omitempty"`
+
+	// MaxEntriesPerStreamPerBatch caps how many entries of a single stream
+	// go into one push request; once a batch already holds that many
+	// entries for a stream, the next one spills into a subsequent batch
+	// instead, preserving order. Left at zero, no cap is applied. This
+	// complements ShardStreams: sharding spreads a hot stream's entries
+	// across multiple label-distinct streams, while this bounds how much
+	// of one stream's backlog a single request risks getting rejected for
+	// at once under Loki's per-stream rate limit.
+	MaxEntriesPerStreamPerBatch int `yaml:"max_entries_per_stream_per_batch,omitempty"`
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
@@ -65,6 +132,12 @@ func (c *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 
 	f.StringVar(&c.TenantID, prefix+"client.tenant-id", "", "Tenant ID to use when pushing logs to Loki (deprecated).")
 	f.BoolVar(&c.DropRateLimitedBatches, prefix+"client.drop-rate-limited-batches", false, "Do not retry batches that have been rate limited by Loki (deprecated).")
+	c.ShardStreams.RegisterFlagsWithPrefix(prefix, f)
+	c.Chargeback.RegisterFlagsWithPrefix(prefix, f)
+	c.ReorderBuffer.RegisterFlagsWithPrefix(prefix, f)
+	c.Streaming.RegisterFlagsWithPrefix(prefix, f)
+	c.Budget.RegisterFlagsWithPrefix(prefix, f)
+	f.IntVar(&c.MaxEntriesPerStreamPerBatch, prefix+"client.max-entries-per-stream-per-batch", 0, "Maximum number of entries of a single stream to include in one batch; the remainder spills into a subsequent batch, preserving order. 0 to disable.")
 }
 
 // RegisterFlags registers flags.
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+)
+
+// mergeExternalLabels merges a client's configured external labels into an
+// entry's labels, using the same precedence for every code path that can
+// hand entries to Loki: the real client's processEntry and the --dry-run
+// logger both call this instead of merging labels themselves.
+//
+// By default the entry's own label wins a name collision, since it's the
+// more specific, dynamically discovered value; override flips that so the
+// statically configured external label always wins instead. warn, if
+// non-nil, is called for every colliding label name so the caller can
+// surface it (see conflictWarner).
+func mergeExternalLabels(external, entryLabels model.LabelSet, override bool, warn func(name model.LabelName)) model.LabelSet {
+	if len(external) == 0 {
+		return entryLabels
+	}
+	if warn != nil {
+		for name := range external {
+			if _, ok := entryLabels[name]; ok {
+				warn(name)
+			}
+		}
+	}
+	if override {
+		return entryLabels.Merge(external)
+	}
+	return external.Merge(entryLabels)
+}
+
+// conflictWarner logs, at most once per label name, that an external label
+// collided with an entry's own label. A misconfigured external_labels entry
+// collides on every single line a target produces, so without the
+// once-per-name dedup this would log at line rate instead of once at
+// startup-ish frequency.
+type conflictWarner struct {
+	logger   log.Logger
+	client   string
+	override bool
+
+	mtx    sync.Mutex
+	warned map[model.LabelName]struct{}
+}
+
+func newConflictWarner(logger log.Logger, client string, override bool) *conflictWarner {
+	return &conflictWarner{
+		logger:   logger,
+		client:   client,
+		override: override,
+		warned:   map[model.LabelName]struct{}{},
+	}
+}
+
+// warn logs the collision on name the first time it's seen, and is a no-op
+// on every call after that.
+func (w *conflictWarner) warn(name model.LabelName) {
+	w.mtx.Lock()
+	_, seen := w.warned[name]
+	w.warned[name] = struct{}{}
+	w.mtx.Unlock()
+	if seen {
+		return
+	}
+
+	winner := "keeping entry label"
+	if w.override {
+		winner = "keeping external_labels value"
+	}
+	level.Warn(w.logger).Log(
+		"msg", "external_labels conflicts with an entry label of the same name",
+		"client", w.client,
+		"label", name,
+		"external_labels_override", w.override,
+		"resolution", winner,
+	)
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mergeExternalLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		external model.LabelSet
+		entry    model.LabelSet
+		override bool
+		want     model.LabelSet
+		conflict []model.LabelName
+	}{
+		{
+			name:     "no external labels",
+			external: model.LabelSet{},
+			entry:    model.LabelSet{"job": "syslog"},
+			want:     model.LabelSet{"job": "syslog"},
+		},
+		{
+			name:     "no conflict, both sets kept",
+			external: model.LabelSet{"env": "prod"},
+			entry:    model.LabelSet{"job": "syslog"},
+			want:     model.LabelSet{"env": "prod", "job": "syslog"},
+		},
+		{
+			name:     "conflict, entry wins by default",
+			external: model.LabelSet{"env": "prod", "job": "external"},
+			entry:    model.LabelSet{"job": "syslog"},
+			want:     model.LabelSet{"env": "prod", "job": "syslog"},
+			conflict: []model.LabelName{"job"},
+		},
+		{
+			name:     "conflict, override lets external_labels win",
+			external: model.LabelSet{"env": "prod", "job": "external"},
+			entry:    model.LabelSet{"job": "syslog"},
+			override: true,
+			want:     model.LabelSet{"env": "prod", "job": "external"},
+			conflict: []model.LabelName{"job"},
+		},
+		{
+			name:     "multiple conflicting names",
+			external: model.LabelSet{"job": "external", "env": "external"},
+			entry:    model.LabelSet{"job": "syslog", "env": "prod", "host": "a"},
+			want:     model.LabelSet{"job": "syslog", "env": "prod", "host": "a"},
+			conflict: []model.LabelName{"job", "env"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen []model.LabelName
+			got := mergeExternalLabels(tt.external, tt.entry, tt.override, func(name model.LabelName) {
+				seen = append(seen, name)
+			})
+			require.Equal(t, tt.want, got)
+			require.ElementsMatch(t, tt.conflict, seen)
+		})
+	}
+}
+
+func Test_conflictWarner_warnsOncePerName(t *testing.T) {
+	w := newConflictWarner(log.NewNopLogger(), "test-client", false)
+
+	// Warning on the same name repeatedly (the realistic case: every line
+	// from a misconfigured target collides) must only record it once.
+	for i := 0; i < 5; i++ {
+		w.warn("job")
+	}
+	w.warn("env")
+
+	require.Len(t, w.warned, 2)
+	_, ok := w.warned["job"]
+	require.True(t, ok)
+	_, ok = w.warned["env"]
+	require.True(t, ok)
+}
@@ -0,0 +1,20 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_PushSuccessRate(t *testing.T) {
+	m := NewMetrics(nil)
+
+	require.Equal(t, 1.0, m.PushSuccessRate(), "no entries processed yet should report a healthy rate")
+
+	m.sentTotal.Add(9)
+	m.droppedTotal.Add(1)
+	require.InDelta(t, 0.9, m.PushSuccessRate(), 0.0001)
+
+	m.droppedTotal.Add(9)
+	require.InDelta(t, 9.0/19.0, m.PushSuccessRate(), 0.0001)
+}
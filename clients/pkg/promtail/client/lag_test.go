@@ -0,0 +1,144 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/utils"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	lokiflag "github.com/grafana/loki/v3/pkg/util/flagext"
+)
+
+// histogramSampleCount reads back the observation count recorded against a
+// single label combination of a HistogramVec, the same way promtail_test.go
+// reads a plain counter's value back through dto.Metric.
+func histogramSampleCount(t *testing.T, h *prometheus.HistogramVec, labels prometheus.Labels) uint64 {
+	t.Helper()
+	observer, err := h.GetMetricWith(labels)
+	require.NoError(t, err)
+	histogram, ok := observer.(prometheus.Histogram)
+	require.True(t, ok, "HistogramVec.GetMetricWith should return a prometheus.Histogram")
+
+	pb := &dto.Metric{}
+	require.NoError(t, histogram.Write(pb))
+	return pb.GetHistogram().GetSampleCount()
+}
+
+// Test_Client_SendLag verifies that a batch accepted by the server produces
+// one sendLagSeconds observation per entry it carried, and that an entry
+// timestamped in the future (simulating the log source's clock running
+// ahead of promtail's) is clamped to zero and counted as clock skew instead
+// of being recorded as a negative lag.
+func Test_Client_SendLag(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	receivedReqsChan := make(chan utils.RemoteWriteRequest, 10)
+	server := utils.NewRemoteWriteServer(receivedReqsChan, 200)
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+
+	cfg := Config{
+		URL:            serverURL,
+		BatchWait:      100 * time.Millisecond,
+		BatchSize:      1 << 20,
+		Client:         config.HTTPClientConfig{},
+		BackoffConfig:  backoff.Config{MinBackoff: 1 * time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 3},
+		ExternalLabels: lokiflag.LabelSet{},
+		Timeout:        1 * time.Second,
+	}
+
+	m := NewMetrics(reg)
+	c, err := New(m, cfg, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+
+	skewed := time.Now().Add(time.Hour)
+	entries := []api.Entry{
+		{Labels: model.LabelSet{}, Entry: logproto.Entry{Timestamp: time.Now().Add(-time.Minute), Line: "old"}},
+		{Labels: model.LabelSet{}, Entry: logproto.Entry{Timestamp: time.Now().Add(-time.Second), Line: "recent"}},
+		{Labels: model.LabelSet{}, Entry: logproto.Entry{Timestamp: skewed, Line: "from-the-future"}},
+	}
+	for _, e := range entries {
+		c.Chan() <- e
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(receivedReqsChan) < 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	c.Stop()
+
+	require.EqualValues(t, len(entries), histogramSampleCount(t, m.sendLagSeconds, prometheus.Labels{HostLabel: serverURL.Host, TenantLabel: ""}))
+
+	pb := &dto.Metric{}
+	require.NoError(t, m.negativeLagTotal.WithLabelValues(serverURL.Host, "").Write(pb))
+	require.Equal(t, 1.0, pb.Counter.GetValue(), "the future-dated entry should be the only one counted as clock skew")
+}
+
+// Test_Client_ReadLag verifies that readLagSeconds tracks the most recently
+// ingested entry's own lag as soon as it's read, before it's even part of a
+// batch, and that it also clamps clock-skewed entries to zero.
+func Test_Client_ReadLag(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	receivedReqsChan := make(chan utils.RemoteWriteRequest, 10)
+	server := utils.NewRemoteWriteServer(receivedReqsChan, 200)
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+
+	cfg := Config{
+		URL:            serverURL,
+		BatchWait:      time.Hour,
+		BatchSize:      1 << 20,
+		Client:         config.HTTPClientConfig{},
+		BackoffConfig:  backoff.Config{MinBackoff: 1 * time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 3},
+		ExternalLabels: lokiflag.LabelSet{},
+		Timeout:        1 * time.Second,
+	}
+
+	m := NewMetrics(reg)
+	c, err := New(m, cfg, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+	defer c.StopNow()
+
+	c.Chan() <- api.Entry{Labels: model.LabelSet{}, Entry: logproto.Entry{Timestamp: time.Now().Add(-time.Hour), Line: "stale"}}
+
+	var lag float64
+	require.Eventually(t, func() bool {
+		pb := &dto.Metric{}
+		if err := m.readLagSeconds.WithLabelValues(serverURL.Host, "").Write(pb); err != nil {
+			return false
+		}
+		lag = pb.GetGauge().GetValue()
+		return lag > 0
+	}, time.Second, 10*time.Millisecond)
+	require.InDelta(t, time.Hour.Seconds(), lag, 5, "read lag should reflect the stale entry's own timestamp")
+
+	c.Chan() <- api.Entry{Labels: model.LabelSet{}, Entry: logproto.Entry{Timestamp: time.Now().Add(time.Hour), Line: "from-the-future"}}
+
+	require.Eventually(t, func() bool {
+		pb := &dto.Metric{}
+		require.NoError(t, m.readLagSeconds.WithLabelValues(serverURL.Host, "").Write(pb))
+		return pb.GetGauge().GetValue() == 0
+	}, time.Second, 10*time.Millisecond, "a future-dated entry should clamp the gauge back to zero")
+
+	pb := &dto.Metric{}
+	require.NoError(t, m.negativeLagTotal.WithLabelValues(serverURL.Host, "").Write(pb))
+	require.Equal(t, 1.0, pb.Counter.GetValue())
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"gopkg.in/yaml.v2"
 
 	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
@@ -34,9 +35,42 @@ type logger struct {
 	sync.Mutex
 	entries chan api.Entry
 
+	// mergers previews, for every entry, what each configured client would
+	// actually send it as: it applies that client's ExternalLabels with the
+	// same precedence processEntry does, so --dry-run output doesn't
+	// silently disagree with what the real client would ship.
+	mergers []externalLabelMerger
+
 	once sync.Once
 }
 
+// externalLabelMerger merges one configured client's external labels into
+// an entry's labels, sharing mergeExternalLabels with the real client's
+// processEntry so both paths resolve a name collision the same way.
+type externalLabelMerger struct {
+	clientName string
+	external   model.LabelSet
+	override   bool
+	conflicts  *conflictWarner
+}
+
+func newExternalLabelMerger(logger log.Logger, cfg Config) externalLabelMerger {
+	name := cfg.Name
+	if name == "" {
+		name = asSha256(cfg)
+	}
+	return externalLabelMerger{
+		clientName: name,
+		external:   cfg.ExternalLabels.LabelSet,
+		override:   cfg.ExternalLabelsOverride,
+		conflicts:  newConflictWarner(logger, name, cfg.ExternalLabelsOverride),
+	}
+}
+
+func (m externalLabelMerger) merge(entryLabels model.LabelSet) model.LabelSet {
+	return mergeExternalLabels(m.external, entryLabels, m.override, m.conflicts.warn)
+}
+
 // NewLogger creates a new client logger that logs entries instead of sending them.
 func NewLogger(metrics *Metrics, log log.Logger, cfgs ...Config) (Client, error) {
 	// make sure the clients config is valid
@@ -47,6 +81,7 @@ func NewLogger(metrics *Metrics, log log.Logger, cfgs ...Config) (Client, error)
 	c.Stop()
 
 	fmt.Println(yellow.Sprint("Clients configured:"))
+	mergers := make([]externalLabelMerger, 0, len(cfgs))
 	for _, cfg := range cfgs {
 		yaml, err := yaml.Marshal(cfg)
 		if err != nil {
@@ -54,11 +89,13 @@ func NewLogger(metrics *Metrics, log log.Logger, cfgs ...Config) (Client, error)
 		}
 		fmt.Println("----------------------")
 		fmt.Println(string(yaml))
+		mergers = append(mergers, newExternalLabelMerger(log, cfg))
 	}
 	entries := make(chan api.Entry)
 	l := &logger{
 		Writer:  tabwriter.NewWriter(os.Stdout, 0, 8, 0, '\t', 0),
 		entries: entries,
+		mergers: mergers,
 	}
 	go l.run()
 	return l, nil
@@ -74,12 +111,19 @@ func (l *logger) Chan() chan<- api.Entry {
 
 func (l *logger) run() {
 	for e := range l.entries {
-		fmt.Fprint(l.Writer, blue.Sprint(e.Timestamp.Format("2006-01-02T15:04:05.999999999-0700")))
-		fmt.Fprint(l.Writer, "\t")
-		fmt.Fprint(l.Writer, yellow.Sprint(e.Labels.String()))
-		fmt.Fprint(l.Writer, "\t")
-		fmt.Fprint(l.Writer, e.Line)
-		fmt.Fprint(l.Writer, "\n")
+		for _, m := range l.mergers {
+			labels := m.merge(e.Labels)
+			fmt.Fprint(l.Writer, blue.Sprint(e.Timestamp.Format("2006-01-02T15:04:05.999999999-0700")))
+			fmt.Fprint(l.Writer, "\t")
+			if len(l.mergers) > 1 {
+				fmt.Fprint(l.Writer, yellow.Sprint(m.clientName))
+				fmt.Fprint(l.Writer, "\t")
+			}
+			fmt.Fprint(l.Writer, yellow.Sprint(labels.String()))
+			fmt.Fprint(l.Writer, "\t")
+			fmt.Fprint(l.Writer, e.Line)
+			fmt.Fprint(l.Writer, "\n")
+		}
 		l.Flush()
 	}
 }
@@ -1,7 +1,10 @@
 package client
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 
@@ -14,6 +17,18 @@ import (
 	"github.com/grafana/loki/v3/clients/pkg/promtail/wal"
 )
 
+// ErrWALReloadUnsupported is returned by Manager.Reload for a WAL-enabled
+// Manager. Its WAL watchers are wired to a specific client's Chan() at
+// construction time (see NewManager), so swapping the client out from
+// under one would leave it publishing entries nobody reads. Callers with
+// WAL enabled should tear down and rebuild the whole Manager instead.
+var ErrWALReloadUnsupported = errors.New("client.Manager: Reload does not support a WAL-enabled Manager")
+
+// ackJournalFileName is the name of the ack journal file created inside the
+// WAL directory when it's enabled. It's shared by every client's writeTo,
+// since they all watch the same on-disk WAL segments.
+const ackJournalFileName = "ack.journal"
+
 // WriterEventsNotifier implements a notifier that's received by the Manager, to which wal.Watcher can subscribe for
 // writer events.
 type WriterEventsNotifier interface {
@@ -37,6 +52,13 @@ type Stoppable interface {
 	Stop()
 }
 
+// clientEntry pairs a running Client with the Config it was built from, so
+// Reload can tell whether a client with a given name needs replacing.
+type clientEntry struct {
+	cfg    Config
+	client Client
+}
+
 // Manager manages remote write client instantiation, and connects the related components to orchestrate the flow of api.Entry
 // from the scrape targets, to the remote write clients themselves.
 //
@@ -44,14 +66,27 @@ type Stoppable interface {
 // work, tracked in https://github.com/grafana/loki/issues/8197, this Manager will be responsible for instantiating all client
 // types: Logger, Multi and WAL.
 type Manager struct {
-	name        string
-	clients     []Client
+	name string
+	// clientsMtx guards clients against concurrent access between Reload
+	// and the startWithForward fan-out goroutine; it's an RWMutex rather
+	// than plain synchronization since the fan-out goroutine takes it once
+	// per entry and Reload is expected to run orders of magnitude less
+	// often.
+	clientsMtx  sync.RWMutex
+	clients     []clientEntry
 	walWatchers []Stoppable
+	walEnabled  bool
 
 	entries chan api.Entry
 	once    sync.Once
 
 	wg sync.WaitGroup
+
+	logger  log.Logger
+	metrics *Metrics
+	limits  limit.Config
+
+	ackJournal *wal.AckJournal
 }
 
 // NewManager creates a new Manager
@@ -64,8 +99,17 @@ func NewManager(metrics *Metrics, logger log.Logger, limits limit.Config, reg pr
 		return nil, fmt.Errorf("at least one client config must be provided")
 	}
 
+	var ackJournal *wal.AckJournal
+	if walCfg.Enabled {
+		var err error
+		ackJournal, err = wal.OpenAckJournal(filepath.Join(walCfg.Dir, ackJournalFileName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open WAL ack journal: %w", err)
+		}
+	}
+
 	clientsCheck := make(map[string]struct{})
-	clients := make([]Client, 0, len(clientCfgs))
+	clients := make([]clientEntry, 0, len(clientCfgs))
 	watchers := make([]Stoppable, 0, len(clientCfgs))
 	for _, cfg := range clientCfgs {
 		client, err := New(metrics, cfg, limits.MaxStreams, limits.MaxLineSize.Val(), limits.MaxLineSizeTruncate, logger)
@@ -79,7 +123,7 @@ func NewManager(metrics *Metrics, logger log.Logger, limits limit.Config, reg pr
 		}
 
 		clientsCheck[client.Name()] = fake
-		clients = append(clients, client)
+		clients = append(clients, clientEntry{cfg: cfg, client: client})
 
 		if walCfg.Enabled {
 			// Create and launch wal watcher for this client
@@ -87,7 +131,7 @@ func NewManager(metrics *Metrics, logger log.Logger, limits limit.Config, reg pr
 			// add some context information for the logger the watcher uses
 			wlog := log.With(logger, "client", client.Name())
 
-			writeTo := newClientWriteTo(client.Chan(), wlog)
+			writeTo := newClientWriteTo(client.Chan(), wlog, ackJournal)
 			// subscribe watcher's wal.WriteTo to writer events. This will make the writer trigger the cleanup of the wal.WriteTo
 			// series cache whenever a segment is deleted.
 			notifier.SubscribeCleanup(writeTo)
@@ -105,7 +149,12 @@ func NewManager(metrics *Metrics, logger log.Logger, limits limit.Config, reg pr
 	manager := &Manager{
 		clients:     clients,
 		walWatchers: watchers,
+		walEnabled:  walCfg.Enabled,
 		entries:     make(chan api.Entry),
+		logger:      logger,
+		metrics:     metrics,
+		limits:      limits,
+		ackJournal:  ackJournal,
 	}
 	if walCfg.Enabled {
 		manager.name = "wal"
@@ -140,25 +189,37 @@ func (m *Manager) startWithForward() {
 	go func() {
 		defer m.wg.Done()
 		for e := range m.entries {
-			for _, c := range m.clients {
-				c.Chan() <- e
+			// Hold the read lock across the send itself, not just the
+			// m.clients snapshot: Reload stops a stale client shortly
+			// after removing it from m.clients, and that Stop closes the
+			// client's channel. Without the lock held through the send,
+			// a stale client already dropped from m.clients here could
+			// still be closed concurrently, panicking this send.
+			m.clientsMtx.RLock()
+			for _, ce := range m.clients {
+				ce.client.Chan() <- e
 			}
+			m.clientsMtx.RUnlock()
 		}
 	}()
 }
 
 func (m *Manager) StopNow() {
-	for _, c := range m.clients {
-		c.StopNow()
+	m.clientsMtx.RLock()
+	defer m.clientsMtx.RUnlock()
+	for _, ce := range m.clients {
+		ce.client.StopNow()
 	}
 }
 
 func (m *Manager) Name() string {
+	m.clientsMtx.RLock()
+	defer m.clientsMtx.RUnlock()
 	var sb strings.Builder
 	sb.WriteString(m.name)
 	sb.WriteString(":")
-	for i, c := range m.clients {
-		sb.WriteString(c.Name())
+	for i, ce := range m.clients {
+		sb.WriteString(ce.client.Name())
 		if i != len(m.clients)-1 {
 			sb.WriteString(",")
 		}
@@ -170,6 +231,75 @@ func (m *Manager) Chan() chan<- api.Entry {
 	return m.entries
 }
 
+// Reload reconciles the Manager's clients against clientCfgs, matched by
+// their Name field, so unaffected clients keep running rather than every
+// client being torn down and rebuilt on every config change:
+//   - a name present in both configs, unchanged, keeps its existing client
+//     running untouched, with nothing to drain.
+//   - a name present in both, but changed (a new endpoint, credentials,
+//     headers, tenant, or batching parameters), gets a freshly built
+//     client under the new config; entries formed from the swap onward go
+//     to it. The old client is stopped in the background, which flushes
+//     its already-buffered batch to the old endpoint rather than dropping
+//     it.
+//   - a name no longer present is stopped and dropped the same way.
+//   - a name newly present gets a freshly built client.
+//
+// Reload returns ErrWALReloadUnsupported, and makes no changes, for a
+// WAL-enabled Manager; see ErrWALReloadUnsupported.
+func (m *Manager) Reload(clientCfgs ...Config) error {
+	if m.walEnabled {
+		return ErrWALReloadUnsupported
+	}
+	if len(clientCfgs) == 0 {
+		return fmt.Errorf("at least one client config must be provided")
+	}
+
+	m.clientsMtx.Lock()
+	defer m.clientsMtx.Unlock()
+
+	byName := make(map[string]clientEntry, len(m.clients))
+	for _, ce := range m.clients {
+		byName[ce.cfg.Name] = ce
+	}
+
+	seen := make(map[string]struct{}, len(clientCfgs))
+	next := make([]clientEntry, 0, len(clientCfgs))
+	var stale []Client
+	for _, cfg := range clientCfgs {
+		if _, dup := seen[cfg.Name]; dup {
+			return fmt.Errorf("duplicate client configs are not allowed, found duplicate for name: %s", cfg.Name)
+		}
+		seen[cfg.Name] = struct{}{}
+
+		existing, ok := byName[cfg.Name]
+		if ok && reflect.DeepEqual(existing.cfg, cfg) {
+			next = append(next, existing)
+			continue
+		}
+
+		c, err := New(m.metrics, cfg, m.limits.MaxStreams, m.limits.MaxLineSize.Val(), m.limits.MaxLineSizeTruncate, m.logger)
+		if err != nil {
+			return err
+		}
+		next = append(next, clientEntry{cfg: cfg, client: c})
+		if ok {
+			stale = append(stale, existing.client)
+		}
+	}
+	for name, ce := range byName {
+		if _, ok := seen[name]; !ok {
+			stale = append(stale, ce.client)
+		}
+	}
+
+	m.clients = next
+	for _, c := range stale {
+		go c.Stop()
+	}
+	return nil
+}
+
 func (m *Manager) Stop() {
 	// first stop the receiving channel
 	m.once.Do(func() { close(m.entries) })
@@ -179,7 +309,14 @@ func (m *Manager) Stop() {
 		walWatcher.Stop()
 	}
 	// close clients
-	for _, c := range m.clients {
-		c.Stop()
+	m.clientsMtx.RLock()
+	defer m.clientsMtx.RUnlock()
+	for _, ce := range m.clients {
+		ce.client.Stop()
+	}
+	if m.ackJournal != nil {
+		if err := m.ackJournal.Close(); err != nil {
+			level.Warn(m.logger).Log("msg", "failed to close WAL ack journal", "err", err)
+		}
 	}
 }
@@ -326,6 +326,133 @@ func TestManager_WALDisabled_MultipleConfigs(t *testing.T) {
 	require.Len(t, seenEntries, expectedTotalLines)
 }
 
+// TestManager_Reload confirms Reload keeps a client whose config is
+// unchanged running untouched, swaps in a new client under a matching name
+// whose config changed, and drains the old client's in-flight batch to its
+// old endpoint rather than dropping it.
+func TestManager_Reload(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	logger := log.NewLogfmtLogger(os.Stdout)
+	clientMetrics := NewMetrics(reg)
+
+	cfgA, reqsA, closeA := newServerAndClientConfig(t)
+	defer closeA.Close()
+
+	manager, err := NewManager(clientMetrics, logger, testLimitsConfig, prometheus.NewRegistry(), wal.Config{}, NilNotifier, cfgA)
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	manager.Chan() <- api.Entry{
+		Labels: model.LabelSet{"reload": "unchanged"},
+		Entry:  logproto.Entry{Timestamp: time.Now(), Line: "before-reload"},
+	}
+	var before utils.RemoteWriteRequest
+	select {
+	case before = <-reqsA:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the pre-reload request")
+	}
+	require.Equal(t, "before-reload", before.Request.Streams[0].Entries[0].Line)
+
+	unchangedClient := manager.clients[0].client
+
+	// Reloading with an identical config must keep the same client running,
+	// not stop and replace it.
+	require.NoError(t, manager.Reload(cfgA))
+	require.Same(t, unchangedClient, manager.clients[0].client)
+
+	cfgB, reqsB, closeB := newServerAndClientConfig(t)
+	defer closeB.Close()
+	cfgB.Name = cfgA.Name // Reload matches old and new configs by Name.
+
+	require.NoError(t, manager.Reload(cfgB))
+	require.NotSame(t, unchangedClient, manager.clients[0].client)
+
+	manager.Chan() <- api.Entry{
+		Labels: model.LabelSet{"reload": "changed"},
+		Entry:  logproto.Entry{Timestamp: time.Now(), Line: "after-reload"},
+	}
+	select {
+	case after := <-reqsB:
+		require.Equal(t, "after-reload", after.Request.Streams[0].Entries[0].Line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the post-reload request to reach the new endpoint")
+	}
+}
+
+// TestManager_Reload_DrainsInFlightEntry confirms that an entry pushed
+// just before Reload swaps out its client still reaches the old client's
+// endpoint, rather than being dropped when the old client is stopped.
+func TestManager_Reload_DrainsInFlightEntry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	logger := log.NewLogfmtLogger(os.Stdout)
+	clientMetrics := NewMetrics(reg)
+
+	cfgA, reqsA, closeA := newServerAndClientConfig(t)
+	defer closeA.Close()
+
+	manager, err := NewManager(clientMetrics, logger, testLimitsConfig, prometheus.NewRegistry(), wal.Config{}, NilNotifier, cfgA)
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	// Push an entry and reload immediately, without waiting for it to be
+	// delivered: it's still buffered on cfgA's client, or in flight to
+	// its server, at the moment Reload swaps the client out.
+	manager.Chan() <- api.Entry{
+		Labels: model.LabelSet{"reload": "in-flight"},
+		Entry:  logproto.Entry{Timestamp: time.Now(), Line: "in-flight-before-swap"},
+	}
+
+	cfgB, reqsB, closeB := newServerAndClientConfig(t)
+	defer closeB.Close()
+	cfgB.Name = cfgA.Name // Reload matches old and new configs by Name.
+
+	require.NoError(t, manager.Reload(cfgB))
+
+	select {
+	case req := <-reqsA:
+		require.Equal(t, "in-flight-before-swap", req.Request.Streams[0].Entries[0].Line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the in-flight entry to be drained to the old endpoint")
+	}
+
+	manager.Chan() <- api.Entry{
+		Labels: model.LabelSet{"reload": "after-swap"},
+		Entry:  logproto.Entry{Timestamp: time.Now(), Line: "after-swap"},
+	}
+	select {
+	case req := <-reqsB:
+		require.Equal(t, "after-swap", req.Request.Streams[0].Entries[0].Line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the post-swap entry to reach the new endpoint")
+	}
+}
+
+// TestManager_Reload_WALEnabled confirms Reload refuses to reconcile a
+// WAL-enabled Manager in place, since its WAL watchers are wired to a
+// specific client's Chan() at construction time.
+func TestManager_Reload_WALEnabled(t *testing.T) {
+	walDir := t.TempDir()
+	walConfig := wal.Config{
+		Dir:         walDir,
+		Enabled:     true,
+		WatchConfig: wal.DefaultWatchConfig,
+	}
+	logger := log.NewLogfmtLogger(os.Stdout)
+	testClientConfig, _, closeServer := newServerAndClientConfig(t)
+	defer closeServer.Close()
+
+	writer, err := wal.NewWriter(walConfig, logger, prometheus.NewRegistry())
+	require.NoError(t, err)
+	defer writer.Stop()
+
+	manager, err := NewManager(nilMetrics, logger, testLimitsConfig, prometheus.NewRegistry(), walConfig, writer, testClientConfig)
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	require.ErrorIs(t, manager.Reload(testClientConfig), ErrWALReloadUnsupported)
+}
+
 func TestManager_StopClients(t *testing.T) {
 	var stopped int
 
@@ -333,7 +460,7 @@ func TestManager_StopClients(t *testing.T) {
 		stopped++
 	}
 	fc := fake.New(stopping)
-	clients := []Client{fc, fc, fc, fc}
+	clients := []clientEntry{{client: fc}, {client: fc}, {client: fc}, {client: fc}}
 	m := &Manager{
 		clients: clients,
 		entries: make(chan api.Entry),
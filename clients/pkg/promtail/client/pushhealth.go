@@ -0,0 +1,73 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// PushHealth is the push status of a single client host, as tracked by
+// Metrics.recordPush and surfaced by the promtail readiness endpoint.
+type PushHealth struct {
+	// Attempted is true once at least one push to this host has been
+	// attempted, successful or not. A host with Attempted false hasn't had
+	// anything to send yet, which readiness treats as healthy rather than
+	// penalizing an idle client.
+	Attempted bool
+	// LastSuccess is the time of the most recent successful push to this
+	// host. Zero if no push to this host has ever succeeded.
+	LastSuccess time.Time
+}
+
+// Healthy reports whether this host's push status should be considered
+// ready: either it hasn't been attempted yet, or its last success was
+// within maxAge of now.
+func (h PushHealth) Healthy(maxAge time.Duration, now time.Time) bool {
+	if !h.Attempted {
+		return true
+	}
+	return !h.LastSuccess.IsZero() && now.Sub(h.LastSuccess) <= maxAge
+}
+
+// pushHealthTracker records, per host, whether a push has ever been
+// attempted and when one last succeeded, so readiness can flag a client
+// that's stopped landing pushes even though earlier ones went fine.
+type pushHealthTracker struct {
+	mtx    sync.Mutex
+	byHost map[string]PushHealth
+}
+
+func newPushHealthTracker() *pushHealthTracker {
+	return &pushHealthTracker{byHost: map[string]PushHealth{}}
+}
+
+func (t *pushHealthTracker) record(host string, success bool, now time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	h := t.byHost[host]
+	h.Attempted = true
+	if success {
+		h.LastSuccess = now
+	}
+	t.byHost[host] = h
+}
+
+func (t *pushHealthTracker) snapshot() map[string]PushHealth {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	out := make(map[string]PushHealth, len(t.byHost))
+	for k, v := range t.byHost {
+		out[k] = v
+	}
+	return out
+}
+
+// recordPush records a push attempt to host, successful or not, for
+// PushHealthSnapshot.
+func (m *Metrics) recordPush(host string, success bool, now time.Time) {
+	m.pushHealth.record(host, success, now)
+}
+
+// PushHealthSnapshot returns the current per-host push health.
+func (m *Metrics) PushHealthSnapshot() map[string]PushHealth {
+	return m.pushHealth.snapshot()
+}
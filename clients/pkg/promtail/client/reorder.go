@@ -0,0 +1,130 @@
+package client
+
+import (
+	"flag"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+)
+
+// reorderTickFrequency is how often the client checks for streams whose
+// reorder window has elapsed. It's independent of MaxDelay so that entries
+// aren't held back much longer than configured.
+const reorderTickFrequency = 50 * time.Millisecond
+
+// ReorderBufferConfig configures a small per-stream buffer that delays
+// entries just long enough to release them to Loki in timestamp order. This
+// is useful for sources, like a container's combined stdout/stderr, whose
+// entries can interleave with slightly jittered timestamps and otherwise
+// trigger Loki's out-of-order rejections. Disabled by default.
+type ReorderBufferConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	MaxDelay   time.Duration `yaml:"max_delay"`
+	MaxEntries int           `yaml:"max_entries"`
+}
+
+// RegisterFlagsWithPrefix registers flags where every name is prefixed by
+// prefix.
+func (cfg *ReorderBufferConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"client.reorder-buffer.enabled", false, "Buffer entries per stream briefly to release them to Loki in timestamp order.")
+	f.DurationVar(&cfg.MaxDelay, prefix+"client.reorder-buffer.max-delay", 500*time.Millisecond, "Maximum time an entry is held back waiting for out-of-order entries on the same stream.")
+	f.IntVar(&cfg.MaxEntries, prefix+"client.reorder-buffer.max-entries", 100, "Maximum entries buffered per stream before the buffer is flushed immediately.")
+}
+
+// entryReorderer buffers entries per stream fingerprint for up to
+// cfg.MaxDelay, releasing them in timestamp order once the window elapses.
+// A stream that buffers more than cfg.MaxEntries before its window elapses
+// is flushed immediately instead, to bound memory use.
+type entryReorderer struct {
+	cfg     ReorderBufferConfig
+	metrics *Metrics
+	host    string
+
+	mtx     sync.Mutex
+	streams map[model.Fingerprint]*reorderWindow
+}
+
+type reorderWindow struct {
+	start   time.Time
+	entries []api.Entry
+}
+
+func newEntryReorderer(cfg ReorderBufferConfig, host string, metrics *Metrics) *entryReorderer {
+	return &entryReorderer{
+		cfg:     cfg,
+		metrics: metrics,
+		host:    host,
+		streams: map[model.Fingerprint]*reorderWindow{},
+	}
+}
+
+// add buffers e and returns any entries that the resulting overflow forces
+// out immediately, in timestamp order. It returns nil in the common case.
+func (r *entryReorderer) add(e api.Entry) []api.Entry {
+	fp := e.Labels.Fingerprint()
+	now := time.Now()
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	w, ok := r.streams[fp]
+	if !ok {
+		w = &reorderWindow{start: now}
+		r.streams[fp] = w
+	}
+	w.entries = append(w.entries, e)
+
+	if len(w.entries) <= r.cfg.MaxEntries {
+		return nil
+	}
+
+	r.metrics.reorderBufferOverflows.WithLabelValues(r.host).Inc()
+	delete(r.streams, fp)
+	return sortedByTimestamp(w.entries)
+}
+
+// releaseExpired returns entries, across all streams, whose reorder window
+// has elapsed, each stream's entries in timestamp order.
+func (r *entryReorderer) releaseExpired() []api.Entry {
+	cutoff := time.Now().Add(-r.cfg.MaxDelay)
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	var out []api.Entry
+	for fp, w := range r.streams {
+		if w.start.After(cutoff) {
+			continue
+		}
+		out = append(out, sortedByTimestamp(w.entries)...)
+		delete(r.streams, fp)
+	}
+	return out
+}
+
+// releaseAll returns every buffered entry across all streams, regardless of
+// whether its reorder window has elapsed, each stream's entries in
+// timestamp order. It's meant for draining the buffer on shutdown, when
+// there's no more ticking left to wait for windows to expire naturally.
+func (r *entryReorderer) releaseAll() []api.Entry {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	var out []api.Entry
+	for fp, w := range r.streams {
+		out = append(out, sortedByTimestamp(w.entries)...)
+		delete(r.streams, fp)
+	}
+	return out
+}
+
+func sortedByTimestamp(entries []api.Entry) []api.Entry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+func entryAt(lbs model.LabelSet, ts time.Time, line string) api.Entry {
+	return api.Entry{
+		Labels: lbs,
+		Entry: logproto.Entry{
+			Timestamp: ts,
+			Line:      line,
+		},
+	}
+}
+
+func TestEntryReorderer_ReleasesInTimestampOrder(t *testing.T) {
+	metrics := NewMetrics(nil)
+	cfg := ReorderBufferConfig{Enabled: true, MaxDelay: 20 * time.Millisecond, MaxEntries: 100}
+	r := newEntryReorderer(cfg, "test-host", metrics)
+
+	lbs := model.LabelSet{"job": "test"}
+	base := time.Now()
+
+	// Fed out of order; nothing should be released yet.
+	require.Empty(t, r.add(entryAt(lbs, base.Add(2*time.Second), "second")))
+	require.Empty(t, r.add(entryAt(lbs, base.Add(0), "first")))
+	require.Empty(t, r.add(entryAt(lbs, base.Add(1*time.Second), "third-arrival-but-mid-timestamp")))
+
+	require.Eventually(t, func() bool {
+		out := r.releaseExpired()
+		if len(out) == 0 {
+			return false
+		}
+		require.Len(t, out, 3)
+		require.Equal(t, "first", out[0].Line)
+		require.Equal(t, "third-arrival-but-mid-timestamp", out[1].Line)
+		require.Equal(t, "second", out[2].Line)
+		return true
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestEntryReorderer_OverflowFlushesImmediately(t *testing.T) {
+	metrics := NewMetrics(nil)
+	cfg := ReorderBufferConfig{Enabled: true, MaxDelay: time.Hour, MaxEntries: 2}
+	r := newEntryReorderer(cfg, "test-host", metrics)
+
+	lbs := model.LabelSet{"job": "test"}
+	base := time.Now()
+
+	require.Empty(t, r.add(entryAt(lbs, base.Add(2*time.Second), "second")))
+	require.Empty(t, r.add(entryAt(lbs, base.Add(0), "first")))
+	out := r.add(entryAt(lbs, base.Add(1*time.Second), "third"))
+
+	require.Len(t, out, 3, "exceeding max_entries should flush the whole stream immediately")
+	require.Equal(t, "first", out[0].Line)
+	require.Equal(t, "third", out[1].Line)
+	require.Equal(t, "second", out[2].Line)
+
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.reorderBufferOverflows.WithLabelValues("test-host")))
+
+	// The stream was reset on overflow, so it starts a fresh window.
+	require.Empty(t, r.streams)
+}
+
+func TestEntryReorderer_ReleaseAllIgnoresWindow(t *testing.T) {
+	metrics := NewMetrics(nil)
+	cfg := ReorderBufferConfig{Enabled: true, MaxDelay: time.Hour, MaxEntries: 100}
+	r := newEntryReorderer(cfg, "test-host", metrics)
+
+	lbs := model.LabelSet{"job": "test"}
+	base := time.Now()
+
+	require.Empty(t, r.add(entryAt(lbs, base.Add(1*time.Second), "second")))
+	require.Empty(t, r.add(entryAt(lbs, base, "first")))
+
+	// releaseExpired wouldn't release anything yet: MaxDelay is an hour.
+	require.Empty(t, r.releaseExpired())
+
+	out := r.releaseAll()
+	require.Len(t, out, 2)
+	require.Equal(t, "first", out[0].Line)
+	require.Equal(t, "second", out[1].Line)
+	require.Empty(t, r.streams)
+}
+
+func TestEntryReorderer_SeparateStreamsDoNotInterfere(t *testing.T) {
+	metrics := NewMetrics(nil)
+	cfg := ReorderBufferConfig{Enabled: true, MaxDelay: time.Hour, MaxEntries: 1}
+	r := newEntryReorderer(cfg, "test-host", metrics)
+
+	stdout := model.LabelSet{"job": "test", "stream": "stdout"}
+	stderr := model.LabelSet{"job": "test", "stream": "stderr"}
+	now := time.Now()
+
+	require.Empty(t, r.add(entryAt(stdout, now, "out")))
+	require.Empty(t, r.add(entryAt(stderr, now, "err")))
+	require.Len(t, r.streams, 2)
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"flag"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/util/flagext"
+)
+
+// DefaultStreamShardLabel is the label injected into a stream's label set to
+// spread its entries across ShardStreamsConfig.NumShards derived streams.
+const DefaultStreamShardLabel = "__stream_shard__"
+
+// streamRateWindowSecs is the number of seconds over which a stream's
+// client-side byte rate is measured before deciding whether it should be
+// sharded.
+const streamRateWindowSecs = 10
+
+const streamRateWindow = streamRateWindowSecs * time.Second
+
+// sharderGCTickFrequency is how often the client checks for streams whose
+// sharding state has gone idle past ShardStreamsConfig.StreamIdleTTL.
+const sharderGCTickFrequency = time.Minute
+
+// defaultStreamIdleTTL is how long a stream can go without an entry before
+// streamSharder.gc drops its tracking state.
+const defaultStreamIdleTTL = time.Hour
+
+// ShardStreamsConfig configures client-side sharding of hot streams so that
+// they stay under Loki's per-stream ingestion rate limit. A stream whose
+// rate, measured over a sliding window, exceeds DesiredRate has NumShards
+// worth of LabelName values cycled into its label set.
+type ShardStreamsConfig struct {
+	Enabled     bool             `yaml:"enabled"`
+	DesiredRate flagext.ByteSize `yaml:"desired_rate"`
+	NumShards   int              `yaml:"num_shards"`
+	LabelName   string           `yaml:"label_name"`
+	// StreamIdleTTL bounds how long streamSharder keeps tracking a stream
+	// that has stopped sending entries. Without it, a host with high
+	// container churn would grow this map forever, one entry per
+	// short-lived stream that was ever seen. Left at zero, it defaults to
+	// defaultStreamIdleTTL.
+	StreamIdleTTL time.Duration `yaml:"stream_idle_ttl"`
+}
+
+// RegisterFlagsWithPrefix registers flags where every name is prefixed by
+// prefix.
+func (cfg *ShardStreamsConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"client.shard-streams.enabled", false, "Automatically shard streams whose client-observed rate exceeds the configured desired rate.")
+	cfg.DesiredRate.Set("1536KB") //nolint:errcheck
+	f.Var(&cfg.DesiredRate, prefix+"client.shard-streams.desired-rate", "Byte rate, measured over a 10s sliding window, above which a stream starts being sharded.")
+	f.IntVar(&cfg.NumShards, prefix+"client.shard-streams.num-shards", 2, "Number of shards a hot stream is split into once its rate crosses desired-rate.")
+	f.StringVar(&cfg.LabelName, prefix+"client.shard-streams.label-name", DefaultStreamShardLabel, "Name of the label used to carry the shard number.")
+	f.DurationVar(&cfg.StreamIdleTTL, prefix+"client.shard-streams.stream-idle-ttl", defaultStreamIdleTTL, "How long a stream can go without an entry before its rate-tracking state is garbage collected.")
+}
+
+// idleTTL returns cfg.StreamIdleTTL, or defaultStreamIdleTTL if unset.
+func (cfg *ShardStreamsConfig) idleTTL() time.Duration {
+	if cfg.StreamIdleTTL <= 0 {
+		return defaultStreamIdleTTL
+	}
+	return cfg.StreamIdleTTL
+}
+
+func (cfg *ShardStreamsConfig) labelName() string {
+	if cfg.LabelName == "" {
+		return DefaultStreamShardLabel
+	}
+	return cfg.LabelName
+}
+
+// streamSharder tracks per-stream byte rate and decides whether a stream
+// needs to be split into shards to avoid hitting per-stream rate limits.
+// Shard assignment is sticky within a single batch generation so that
+// entries belonging to the same outgoing batch aren't scattered randomly.
+type streamSharder struct {
+	cfg     ShardStreamsConfig
+	logger  log.Logger
+	metrics *Metrics
+	host    string
+
+	mtx     sync.Mutex
+	streams map[model.Fingerprint]*shardedStream
+}
+
+type shardedStream struct {
+	buckets    [streamRateWindowSecs]int64
+	bucketSecs [streamRateWindowSecs]int64
+
+	sharding   bool
+	shard      int
+	generation int64
+
+	// lastSeen is the UnixNano time of the last entry observed for this
+	// stream, used by gc to identify streams that have gone idle.
+	// Nanosecond resolution matters here, unlike in buckets/bucketSecs
+	// above: StreamIdleTTL can be configured well below a second, most
+	// visibly in tests.
+	lastSeen int64
+}
+
+func newStreamSharder(cfg ShardStreamsConfig, host string, metrics *Metrics, logger log.Logger) *streamSharder {
+	return &streamSharder{
+		cfg:     cfg,
+		logger:  logger,
+		metrics: metrics,
+		host:    host,
+		streams: map[model.Fingerprint]*shardedStream{},
+	}
+}
+
+// shard mutates lbs, if necessary, injecting the shard label. generation
+// identifies the current batch: all entries observed with the same
+// generation for a given stream receive the same shard.
+func (s *streamSharder) shard(lbs model.LabelSet, lineSize int, generation int64) model.LabelSet {
+	fp := lbs.Fingerprint()
+	nowTime := time.Now()
+	now := nowTime.Unix()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ss, ok := s.streams[fp]
+	if !ok {
+		ss = &shardedStream{}
+		s.streams[fp] = ss
+	}
+	ss.lastSeen = nowTime.UnixNano()
+	ss.add(now, lineSize)
+
+	rate := ss.rate(now)
+	wasSharding := ss.sharding
+	ss.sharding = rate > float64(s.cfg.DesiredRate)
+
+	if ss.sharding && !wasSharding {
+		s.metrics.shardedStreams.WithLabelValues(s.host).Inc()
+		level.Info(s.logger).Log("msg", "stream exceeded desired rate, sharding enabled", "stream", lbs.String(), "rate_bytes_sec", rate, "shards", s.cfg.NumShards)
+	} else if !ss.sharding && wasSharding {
+		s.metrics.shardedStreams.WithLabelValues(s.host).Dec()
+		level.Info(s.logger).Log("msg", "stream rate dropped below desired rate, sharding disabled", "stream", lbs.String(), "rate_bytes_sec", rate)
+	}
+
+	if !ss.sharding {
+		return lbs
+	}
+
+	if generation != ss.generation {
+		ss.generation = generation
+		ss.shard = (ss.shard + 1) % s.cfg.NumShards
+	}
+
+	sharded := lbs.Clone()
+	sharded[model.LabelName(s.cfg.labelName())] = model.LabelValue(strconv.Itoa(ss.shard))
+	return sharded
+}
+
+func (ss *shardedStream) add(nowUnix int64, n int) {
+	idx := nowUnix % int64(len(ss.buckets))
+	if ss.bucketSecs[idx] != nowUnix {
+		ss.bucketSecs[idx] = nowUnix
+		ss.buckets[idx] = 0
+	}
+	ss.buckets[idx] += int64(n)
+}
+
+// rate returns the average bytes/sec observed over the trailing window,
+// excluding stale buckets that fall outside of it.
+func (ss *shardedStream) rate(nowUnix int64) float64 {
+	var total int64
+	for i, sec := range ss.bucketSecs {
+		if nowUnix-sec < int64(len(ss.buckets)) {
+			total += ss.buckets[i]
+		}
+	}
+	return float64(total) / streamRateWindow.Seconds()
+}
+
+// gc drops tracking state for streams that haven't seen an entry in
+// cfg.idleTTL, so that hosts with high stream churn (e.g. short-lived
+// containers) don't grow s.streams without bound. A shardedStream carries
+// no buffered entries of its own, only rate-tracking counters, so there's
+// nothing to flush before dropping it. It reports the current tracked
+// stream count and the number collected.
+func (s *streamSharder) gc() (tracked, collected int) {
+	cutoff := time.Now().Add(-s.cfg.idleTTL()).UnixNano()
+
+	s.mtx.Lock()
+	for fp, ss := range s.streams {
+		if ss.lastSeen < cutoff {
+			if ss.sharding {
+				s.metrics.shardedStreams.WithLabelValues(s.host).Dec()
+			}
+			delete(s.streams, fp)
+			collected++
+		}
+	}
+	tracked = len(s.streams)
+	s.mtx.Unlock()
+
+	s.metrics.trackedShardedStreams.WithLabelValues(s.host).Set(float64(tracked))
+	if collected > 0 {
+		s.metrics.collectedShardedStreams.WithLabelValues(s.host).Add(float64(collected))
+	}
+	return tracked, collected
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamSharder_ActivatesAndDeactivates(t *testing.T) {
+	metrics := NewMetrics(nil)
+	cfg := ShardStreamsConfig{
+		Enabled:     true,
+		DesiredRate: 100,
+		NumShards:   4,
+		LabelName:   DefaultStreamShardLabel,
+	}
+	sharder := newStreamSharder(cfg, "test-host", metrics, log.NewNopLogger())
+
+	lbs := model.LabelSet{"job": "test"}
+
+	// Below the desired rate: no shard label is injected.
+	out := sharder.shard(lbs, 10, 1)
+	require.NotContains(t, out, model.LabelName(DefaultStreamShardLabel))
+
+	// Push the stream over the desired rate: it should start being sharded.
+	var sharded model.LabelSet
+	for i := 0; i < 20; i++ {
+		sharded = sharder.shard(lbs, 1000, int64(i))
+	}
+	require.Contains(t, sharded, model.LabelName(DefaultStreamShardLabel))
+
+	fp := lbs.Fingerprint()
+	require.True(t, sharder.streams[fp].sharding)
+
+	// Simulate the rate window sliding fully past the hot buckets above,
+	// as if no bytes had been observed for the stream in the last
+	// streamRateWindowSecs: rate() excludes any bucket whose recorded
+	// second falls outside the window, so zeroing bucketSecs makes every
+	// bucket stale against a real, much larger, current Unix time.
+	ss := sharder.streams[fp]
+	ss.buckets = [streamRateWindowSecs]int64{}
+	ss.bucketSecs = [streamRateWindowSecs]int64{}
+
+	out = sharder.shard(lbs, 10, 20)
+	require.NotContains(t, out, model.LabelName(DefaultStreamShardLabel))
+	require.False(t, sharder.streams[fp].sharding)
+}
+
+func TestStreamSharder_StickyPerGeneration(t *testing.T) {
+	metrics := NewMetrics(nil)
+	cfg := ShardStreamsConfig{
+		Enabled:     true,
+		DesiredRate: 1,
+		NumShards:   3,
+		LabelName:   DefaultStreamShardLabel,
+	}
+	sharder := newStreamSharder(cfg, "test-host", metrics, log.NewNopLogger())
+	lbs := model.LabelSet{"job": "test"}
+
+	// Prime the stream above the threshold.
+	sharder.shard(lbs, 1000, 1)
+
+	first := sharder.shard(lbs, 10, 1)
+	second := sharder.shard(lbs, 10, 1)
+	require.Equal(t, first[DefaultStreamShardLabel], second[DefaultStreamShardLabel], "shard must stay the same within one batch generation")
+
+	next := sharder.shard(lbs, 10, 2)
+	require.NotEqual(t, first[DefaultStreamShardLabel], next[DefaultStreamShardLabel], "shard must rotate on the next batch generation")
+}
+
+func TestStreamSharder_EvenDistribution(t *testing.T) {
+	metrics := NewMetrics(nil)
+	cfg := ShardStreamsConfig{
+		Enabled:     true,
+		DesiredRate: 1,
+		NumShards:   4,
+		LabelName:   DefaultStreamShardLabel,
+	}
+	sharder := newStreamSharder(cfg, "test-host", metrics, log.NewNopLogger())
+	lbs := model.LabelSet{"job": "test"}
+
+	sharder.shard(lbs, 1000, 0)
+
+	counts := map[model.LabelValue]int{}
+	for gen := int64(1); gen <= 40; gen++ {
+		out := sharder.shard(lbs, 10, gen)
+		counts[out[DefaultStreamShardLabel]]++
+	}
+
+	require.Len(t, counts, cfg.NumShards)
+	for _, c := range counts {
+		require.Equal(t, 10, c)
+	}
+}
+
+func TestStreamSharder_GCDropsIdleStreams(t *testing.T) {
+	metrics := NewMetrics(nil)
+	cfg := ShardStreamsConfig{
+		Enabled:       true,
+		DesiredRate:   100,
+		NumShards:     4,
+		LabelName:     DefaultStreamShardLabel,
+		StreamIdleTTL: time.Millisecond,
+	}
+	sharder := newStreamSharder(cfg, "test-host", metrics, log.NewNopLogger())
+
+	sharder.shard(model.LabelSet{"job": "idle"}, 10, 1)
+	tracked, collected := sharder.gc()
+	require.Equal(t, 1, tracked)
+	require.Equal(t, 0, collected)
+
+	time.Sleep(2 * time.Millisecond)
+
+	tracked, collected = sharder.gc()
+	require.Equal(t, 0, tracked)
+	require.Equal(t, 1, collected)
+}
+
+func TestStreamSharder_GCKeepsActiveStreams(t *testing.T) {
+	metrics := NewMetrics(nil)
+	cfg := ShardStreamsConfig{
+		Enabled:       true,
+		DesiredRate:   100,
+		NumShards:     4,
+		LabelName:     DefaultStreamShardLabel,
+		StreamIdleTTL: time.Hour,
+	}
+	sharder := newStreamSharder(cfg, "test-host", metrics, log.NewNopLogger())
+
+	sharder.shard(model.LabelSet{"job": "active"}, 10, 1)
+	tracked, collected := sharder.gc()
+	require.Equal(t, 1, tracked)
+	require.Equal(t, 0, collected)
+}
+
+// TestStreamSharder_BoundedMemoryUnderStreamChurn is a soak-style test
+// simulating a host with high container churn: tens of thousands of
+// one-shot streams, each seen exactly once and then abandoned. It
+// demonstrates that periodic gc keeps the tracked stream count bounded
+// instead of growing forever.
+func TestStreamSharder_BoundedMemoryUnderStreamChurn(t *testing.T) {
+	metrics := NewMetrics(nil)
+	cfg := ShardStreamsConfig{
+		Enabled:       true,
+		DesiredRate:   100,
+		NumShards:     4,
+		LabelName:     DefaultStreamShardLabel,
+		StreamIdleTTL: time.Millisecond,
+	}
+	sharder := newStreamSharder(cfg, "test-host", metrics, log.NewNopLogger())
+
+	const numStreams = 50000
+	for i := 0; i < numStreams; i++ {
+		lbs := model.LabelSet{"container_id": model.LabelValue(fmt.Sprintf("container-%d", i))}
+		sharder.shard(lbs, 10, int64(i))
+	}
+	require.Len(t, sharder.streams, numStreams)
+
+	time.Sleep(2 * time.Millisecond)
+
+	tracked, collected := sharder.gc()
+	require.Equal(t, 0, tracked)
+	require.Equal(t, numStreams, collected)
+	require.Empty(t, sharder.streams)
+}
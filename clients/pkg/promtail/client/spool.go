@@ -0,0 +1,293 @@
+package client
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+)
+
+// SpoolConfig configures a spool client: instead of pushing entries over
+// the network, it accumulates them into batches (the same BatchWait/
+// BatchSize thresholds a real client uses) and writes each completed batch
+// to its own gzip-compressed file under Directory, in the same
+// newline-delimited JSON format NewCaptureLogger writes. This is for
+// air-gapped sites that collect logs locally and physically transfer them;
+// a spool directory is read back and pushed to a real Loki with the
+// `promtail-unspool` command.
+type SpoolConfig struct {
+	// Enabled turns this client into a spool client. When set, URL, Client,
+	// and the other network-push settings on Config are ignored.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Directory is where spool files are written.
+	Directory string `yaml:"directory,omitempty"`
+
+	// MaxSizeBytes bounds the total size of files kept in Directory. Once a
+	// newly written file pushes the directory over this, the oldest spool
+	// files are deleted until it's back under the cap. 0 disables retention.
+	MaxSizeBytes int64 `yaml:"max_size_bytes,omitempty"`
+}
+
+// spoolFileSuffix names every file NewSpool writes, and is what
+// `promtail unspool` looks for in Directory.
+const spoolFileSuffix = ".jsonl.gz"
+
+type spoolClient struct {
+	cfg    SpoolConfig
+	name   string
+	logger log.Logger
+
+	batchWait time.Duration
+	batchSize int
+
+	entries chan api.Entry
+	once    sync.Once
+	wg      sync.WaitGroup
+
+	seq atomic.Uint64
+}
+
+// NewSpool creates a Client that writes completed batches to
+// cfg.Spool.Directory instead of pushing them to cfg.URL.
+func NewSpool(cfg Config, logger log.Logger) (Client, error) {
+	if cfg.Spool.Directory == "" {
+		return nil, errors.New("spool client needs a directory")
+	}
+	if err := os.MkdirAll(cfg.Spool.Directory, 0o750); err != nil {
+		return nil, fmt.Errorf("could not create spool directory: %w", err)
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = "spool"
+	}
+
+	batchWait := cfg.BatchWait
+	if batchWait <= 0 {
+		batchWait = BatchWait
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = BatchSize
+	}
+
+	c := &spoolClient{
+		cfg:       cfg.Spool,
+		name:      name,
+		logger:    logger,
+		batchWait: batchWait,
+		batchSize: batchSize,
+		entries:   make(chan api.Entry),
+	}
+	c.wg.Add(1)
+	go c.run()
+	return c, nil
+}
+
+// run mirrors the real client's per-tenant batching loop (see (*client).run
+// and .ingest): entries accrue into a *batch per tenant until either
+// batchSize or batchWait is reached, at which point the batch is flushed.
+// It drops the real client's sharding, reordering, and budget machinery,
+// none of which apply to a client that isn't shipping over the network.
+func (c *spoolClient) run() {
+	batches := map[string]*batch{}
+
+	minWaitCheckFrequency := 10 * time.Millisecond
+	maxWaitCheckFrequency := c.batchWait / 10
+	if maxWaitCheckFrequency < minWaitCheckFrequency {
+		maxWaitCheckFrequency = minWaitCheckFrequency
+	}
+	maxWaitCheck := time.NewTicker(maxWaitCheckFrequency)
+
+	defer func() {
+		maxWaitCheck.Stop()
+		for tenantID, b := range batches {
+			c.flush(tenantID, b)
+		}
+		c.wg.Done()
+	}()
+
+	for {
+		select {
+		case e, ok := <-c.entries:
+			if !ok {
+				return
+			}
+			tenantID := string(e.Labels[ReservedLabelTenantID])
+
+			b, ok := batches[tenantID]
+			if !ok {
+				batches[tenantID] = newBatch(0, "", e)
+				continue
+			}
+			if b.sizeBytesAfter(e) > c.batchSize {
+				c.flush(tenantID, b)
+				batches[tenantID] = newBatch(0, "", e)
+				continue
+			}
+			if err := b.add(e); err != nil {
+				level.Error(c.logger).Log("msg", "spool batch add err", "tenant", tenantID, "error", err)
+			}
+		case <-maxWaitCheck.C:
+			for tenantID, b := range batches {
+				if b.age() < c.batchWait {
+					continue
+				}
+				c.flush(tenantID, b)
+				delete(batches, tenantID)
+			}
+		}
+	}
+}
+
+// flush writes b's streams to a new spool file named for tenantID and b's
+// time range, then enforces MaxSizeBytes retention.
+func (c *spoolClient) flush(tenantID string, b *batch) {
+	streams := b.allStreams()
+	if len(streams) == 0 {
+		return
+	}
+
+	var minTs, maxTs time.Time
+	for _, s := range streams {
+		for _, e := range s.entries {
+			if minTs.IsZero() || e.Timestamp.Before(minTs) {
+				minTs = e.Timestamp
+			}
+			if e.Timestamp.After(maxTs) {
+				maxTs = e.Timestamp
+			}
+		}
+	}
+
+	tenantPart := tenantID
+	if tenantPart == "" {
+		tenantPart = "notenant"
+	}
+	seq := c.seq.Add(1)
+	name := fmt.Sprintf("%s_%d-%d_%06d%s", tenantPart, minTs.UnixNano(), maxTs.UnixNano(), seq, spoolFileSuffix)
+	path := filepath.Join(c.cfg.Directory, name)
+
+	if err := writeSpoolFile(path, tenantID, streams); err != nil {
+		level.Error(c.logger).Log("msg", "could not write spool file", "path", path, "err", err)
+		return
+	}
+
+	c.enforceRetention()
+}
+
+// writeSpoolFile writes streams to path as gzip-compressed
+// newline-delimited JSON, one CaptureEntry per log line - the same format
+// NewCaptureLogger produces, so `promtail unspool` can share its decoding.
+// It writes to a temporary file and renames it into place, so a reader
+// never observes a partially written spool file.
+func writeSpoolFile(path, tenantID string, streams []*batchStream) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(f)
+	enc := json.NewEncoder(gz)
+	var encodeErr error
+	for _, s := range streams {
+		for _, e := range s.entries {
+			if err := enc.Encode(CaptureEntry{
+				Labels:    s.labels,
+				Timestamp: e.Timestamp,
+				Line:      e.Line,
+				Tenant:    tenantID,
+			}); err != nil {
+				encodeErr = err
+				break
+			}
+		}
+	}
+
+	if err := gz.Close(); err != nil && encodeErr == nil {
+		encodeErr = err
+	}
+	if err := f.Close(); err != nil && encodeErr == nil {
+		encodeErr = err
+	}
+	if encodeErr != nil {
+		os.Remove(tmp)
+		return encodeErr
+	}
+	return os.Rename(tmp, path)
+}
+
+// enforceRetention deletes the oldest spool files in Directory until its
+// total size is back under MaxSizeBytes. It's a no-op if MaxSizeBytes is 0.
+func (c *spoolClient) enforceRetention() {
+	if c.cfg.MaxSizeBytes <= 0 {
+		return
+	}
+
+	des, err := os.ReadDir(c.cfg.Directory)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "could not list spool directory for retention", "err", err)
+		return
+	}
+
+	type spoolFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []spoolFile
+	var total int64
+	for _, de := range des {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), spoolFileSuffix) {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, spoolFile{filepath.Join(c.cfg.Directory, de.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	if total <= c.cfg.MaxSizeBytes {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if total <= c.cfg.MaxSizeBytes {
+			return
+		}
+		if err := os.Remove(f.path); err != nil {
+			level.Warn(c.logger).Log("msg", "could not remove spool file for retention", "file", f.path, "err", err)
+			continue
+		}
+		total -= f.size
+	}
+}
+
+func (c *spoolClient) Chan() chan<- api.Entry {
+	return c.entries
+}
+
+func (c *spoolClient) Stop() {
+	c.once.Do(func() { close(c.entries) })
+	c.wg.Wait()
+}
+
+func (c *spoolClient) StopNow() { c.Stop() }
+
+func (c *spoolClient) Name() string { return c.name }
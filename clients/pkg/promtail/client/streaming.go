@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"flag"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+)
+
+// defaultMaxEncodedRequestSize bounds a single streamed push request's
+// uncompressed size when StreamingConfig.MaxEncodedRequestSize is left at
+// zero.
+const defaultMaxEncodedRequestSize = 8 * 1024 * 1024
+
+// StreamingConfig configures chunked-transfer, framed-snappy pushes for
+// batches whose encoded size would otherwise require buffering a large push
+// request in memory all at once, such as when a WAL replay drains hours of
+// buffered backlog faster than it was written. Disabled by default, since
+// ordinary batches are already bounded by Config.BatchSize before they ever
+// reach encode.
+//
+// The receiving endpoint must understand the Snappy framing format (see
+// https://github.com/google/snappy/blob/master/framing_format.txt), not just
+// the block format Loki's push API otherwise expects; enable this only
+// against an endpoint that supports it.
+type StreamingConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// MaxEncodedRequestSize caps how many raw (pre-compression) bytes go
+	// into a single streamed push request. Batches larger than this are
+	// split into a paced series of smaller requests, sent one at a time, so
+	// peak memory during replay stays bounded regardless of how large the
+	// batch that accumulated is. Zero uses defaultMaxEncodedRequestSize.
+	MaxEncodedRequestSize int `yaml:"max_encoded_request_size"`
+}
+
+// RegisterFlagsWithPrefix registers flags where every name is prefixed by
+// prefix.
+func (cfg *StreamingConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"client.streaming.enabled", false, "Send batches whose encoded size would be very large as chunked-transfer, framed-snappy requests instead of buffering the whole request in memory.")
+	f.IntVar(&cfg.MaxEncodedRequestSize, prefix+"client.streaming.max-encoded-request-size", defaultMaxEncodedRequestSize, "Maximum raw bytes per streamed push request; larger batches are split into a paced series of requests of about this size.")
+}
+
+// maxRequestSize returns cfg.MaxEncodedRequestSize, or
+// defaultMaxEncodedRequestSize if it hasn't been set.
+func (cfg StreamingConfig) maxRequestSize() int {
+	if cfg.MaxEncodedRequestSize <= 0 {
+		return defaultMaxEncodedRequestSize
+	}
+	return cfg.MaxEncodedRequestSize
+}
+
+// sendBatchStreaming sends batch as one or more chunked-transfer,
+// Snappy-framed requests. Batches whose raw size exceeds
+// Streaming.MaxEncodedRequestSize are split into a paced series of smaller
+// requests first, so peak memory during a fast WAL replay of a large
+// backlog stays bounded to about one chunk's size rather than the whole
+// batch. Chargeback is recorded once for the whole batch, based on whether
+// every chunk shipped.
+func (c *client) sendBatchStreaming(tenantID string, batch *batch) {
+	allShipped := true
+	for _, streams := range batch.splitStreams(c.cfg.Streaming.maxRequestSize()) {
+		if !c.sendStreamGroup(tenantID, streams) {
+			allShipped = false
+		}
+	}
+	c.recordBatchChargeback(batch, allShipped)
+}
+
+// sendStreamGroup sends one chunk of a streamed batch, retrying with the
+// same backoff policy as sendBatch. Because the request body streams
+// straight out of the encoder rather than being buffered up front, a retry
+// re-runs the encoder instead of resending a buffered slice.
+func (c *client) sendStreamGroup(tenantID string, streams []*batchStream) bool {
+	entriesCount := 0
+	rawBytes := 0
+	for _, s := range streams {
+		entriesCount += len(s.entries)
+		rawBytes += s.sizeBytes()
+	}
+
+	bo := backoff.New(c.ctx, c.cfg.BackoffConfig)
+	var status int
+	var err error
+	var encodedBytes int64
+	for {
+		start := time.Now()
+		status, encodedBytes, err = c.encodeAndSendStream(tenantID, streams)
+		c.metrics.requestDuration.WithLabelValues(strconv.Itoa(status), c.cfg.URL.Host).Observe(time.Since(start).Seconds())
+
+		if c.cfg.DropRateLimitedBatches && batchIsRateLimited(status) {
+			level.Warn(c.logger).Log("msg", "dropping batch chunk due to rate limiting applied at ingester")
+			c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonRateLimited).Add(float64(rawBytes))
+			c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonRateLimited).Add(float64(entriesCount))
+			c.metrics.droppedTotal.Add(uint64(entriesCount))
+			c.metrics.recordPush(c.cfg.URL.Host, false, time.Now())
+			return false
+		}
+
+		if err == nil {
+			c.metrics.encodedBytes.WithLabelValues(c.cfg.URL.Host).Add(float64(encodedBytes))
+			c.metrics.sentBytes.WithLabelValues(c.cfg.URL.Host).Add(float64(encodedBytes))
+			c.metrics.sentEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
+			c.metrics.sentTotal.Add(uint64(entriesCount))
+			c.metrics.recordPush(c.cfg.URL.Host, true, time.Now())
+			c.recordSendLag(tenantID, streams, time.Now())
+			return true
+		}
+
+		// Only retry 429s, 500s and connection-level errors.
+		if status > 0 && !batchIsRateLimited(status) && status/100 != 5 {
+			break
+		}
+
+		level.Warn(c.logger).Log("msg", "error sending batch chunk, will retry", "status", status, "tenant", tenantID, "error", err)
+		c.metrics.batchRetries.WithLabelValues(c.cfg.URL.Host, tenantID).Inc()
+		bo.Wait()
+
+		if !bo.Ongoing() {
+			break
+		}
+	}
+
+	level.Error(c.logger).Log("msg", "final error sending batch chunk", "status", status, "tenant", tenantID, "error", err)
+	dropReason := ReasonGeneric
+	if batchIsRateLimited(status) {
+		dropReason = ReasonRateLimited
+	}
+	c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, dropReason).Add(float64(rawBytes))
+	c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, dropReason).Add(float64(entriesCount))
+	c.metrics.droppedTotal.Add(uint64(entriesCount))
+	c.metrics.recordPush(c.cfg.URL.Host, false, time.Now())
+	return false
+}
+
+// encodeAndSendStream marshals streams and streams the Snappy-framed result
+// directly into the request body via an io.Pipe, avoiding a second full
+// buffer for the compressed output. It returns the response status/error
+// along with the number of bytes actually written to the wire.
+func (c *client) encodeAndSendStream(tenantID string, streams []*batchStream) (status int, encodedBytes int64, err error) {
+	pr, pw := io.Pipe()
+	counter := &countingWriter{w: pw}
+	encodeErrC := make(chan error, 1)
+	go func() {
+		_, encErr := encodeStreamsTo(streams, counter)
+		encodeErrC <- encErr
+		_ = pw.CloseWithError(encErr)
+	}()
+
+	status, sendErr := c.sendStream(context.Background(), tenantID, pr)
+	encErr := <-encodeErrC
+	if sendErr == nil {
+		sendErr = encErr
+	}
+	return status, counter.n, sendErr
+}
+
+// countingWriter tallies bytes written to w, so the streaming send path can
+// report encoded/sent byte metrics without buffering the encoded output to
+// measure it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
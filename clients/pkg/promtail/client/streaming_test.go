@@ -0,0 +1,151 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+func TestBatch_SplitStreams(t *testing.T) {
+	b := newBatch(0, "",
+		api.Entry{Labels: model.LabelSet{"stream": "a"}, Entry: logproto.Entry{Timestamp: time.Unix(1, 0), Line: "0123456789"}},
+		api.Entry{Labels: model.LabelSet{"stream": "b"}, Entry: logproto.Entry{Timestamp: time.Unix(2, 0), Line: "0123456789"}},
+		api.Entry{Labels: model.LabelSet{"stream": "c"}, Entry: logproto.Entry{Timestamp: time.Unix(3, 0), Line: "0123456789"}},
+	)
+
+	groups := b.splitStreams(15)
+	require.Len(t, groups, 3, "each 10-byte stream should land in its own group under a 15-byte cap")
+
+	totalEntries := 0
+	for _, group := range groups {
+		size := 0
+		for _, s := range group {
+			size += s.sizeBytes()
+			totalEntries += len(s.entries)
+		}
+		assert.LessOrEqual(t, size, 15)
+	}
+	assert.Equal(t, 3, totalEntries)
+}
+
+func TestBatch_SplitStreams_PacksMultipleUnderCap(t *testing.T) {
+	b := newBatch(0, "",
+		api.Entry{Labels: model.LabelSet{"stream": "a"}, Entry: logproto.Entry{Timestamp: time.Unix(1, 0), Line: "01234"}},
+		api.Entry{Labels: model.LabelSet{"stream": "b"}, Entry: logproto.Entry{Timestamp: time.Unix(2, 0), Line: "01234"}},
+	)
+
+	groups := b.splitStreams(100)
+	require.Len(t, groups, 1, "streams that together fit under the cap should share one group")
+	assert.Len(t, groups[0], 2)
+}
+
+func TestEncodeStreamsTo_RoundTrips(t *testing.T) {
+	b := newBatch(0, "",
+		api.Entry{Labels: model.LabelSet{"stream": "a"}, Entry: logproto.Entry{Timestamp: time.Unix(1, 0), Line: "hello"}},
+	)
+
+	var buf bytes.Buffer
+	entriesCount, err := encodeStreamsTo(b.allStreams(), &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 1, entriesCount)
+
+	decoded, err := io.ReadAll(snappy.NewReader(&buf))
+	require.NoError(t, err)
+
+	var req logproto.PushRequest
+	require.NoError(t, proto.Unmarshal(decoded, &req))
+	require.Len(t, req.Streams, 1)
+	require.Len(t, req.Streams[0].Entries, 1)
+	assert.Equal(t, "hello", req.Streams[0].Entries[0].Line)
+}
+
+// TestClient_Streaming_BoundedMemory pushes a backlog through a client
+// configured with a small Streaming.MaxEncodedRequestSize against a fake
+// server that decodes and discards each chunk as it streams in. It asserts
+// that memory growth stays a small multiple of one chunk's size rather than
+// scaling with the whole backlog, the way encoding the whole batch into one
+// buffered request up front would. The backlog here is scaled down from a
+// production "hours of buffered logs" scenario to keep the test fast; the
+// encoding path doesn't change with scale.
+func TestClient_Streaming_BoundedMemory(t *testing.T) {
+	const (
+		numEntries    = 20000
+		lineSize      = 512
+		maxChunkBytes = 256 * 1024
+	)
+	line := make([]byte, lineSize)
+	for i := range line {
+		line[i] = 'a'
+	}
+
+	var receivedBytes int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := io.Copy(io.Discard, snappy.NewReader(r.Body))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		receivedBytes += n
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+
+	cfg := Config{
+		URL:       serverURL,
+		BatchWait: 10 * time.Millisecond,
+		BatchSize: 100 * 1024 * 1024, // large enough that the whole backlog forms one batch
+		Timeout:   10 * time.Second,
+		Streaming: StreamingConfig{
+			Enabled:               true,
+			MaxEncodedRequestSize: maxChunkBytes,
+		},
+	}
+	c, err := New(NewMetrics(prometheus.NewRegistry()), cfg, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	for i := 0; i < numEntries; i++ {
+		c.Chan() <- api.Entry{
+			Labels: model.LabelSet{"stream": "backlog"},
+			Entry:  logproto.Entry{Timestamp: time.Unix(int64(i), 0), Line: string(line)},
+		}
+	}
+	c.Stop()
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	totalRaw := int64(numEntries * lineSize)
+	require.Greater(t, receivedBytes, int64(0))
+
+	// A fully-buffered encode of the whole backlog would need on the order
+	// of totalRaw bytes alive at once (plus its protobuf and snappy copies).
+	// Streaming in maxChunkBytes-sized pieces should keep live heap growth
+	// well under that, regardless of how large the backlog is.
+	grown := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+	assert.Less(t, grown, totalRaw/4, fmt.Sprintf("heap grew by %d bytes for a %d byte backlog; streaming should avoid buffering it all at once", grown, totalRaw))
+}
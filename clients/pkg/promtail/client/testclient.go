@@ -0,0 +1,64 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+)
+
+// testClient is a Client that appends received entries to a caller-owned
+// slice instead of sending them anywhere, so a unit test can assert on
+// pipeline stage output without standing up an httptest.Server.
+type testClient struct {
+	entries chan api.Entry
+	mu      *sync.Mutex
+	sink    *[]api.Entry
+
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewTestClient returns a Client that appends every entry it receives to
+// *entries, guarded by mu, and never makes an HTTP request. mu is also used
+// to guard reads from EntriesReceived, so a test can share it with whatever
+// else touches entries (or pass its own *sync.Mutex to observe entries
+// while the client is still running).
+func NewTestClient(entries *[]api.Entry, mu *sync.Mutex) Client {
+	c := &testClient{
+		entries: make(chan api.Entry),
+		mu:      mu,
+		sink:    entries,
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for e := range c.entries {
+			c.mu.Lock()
+			*c.sink = append(*c.sink, e)
+			c.mu.Unlock()
+		}
+	}()
+	return c
+}
+
+func (c *testClient) Chan() chan<- api.Entry {
+	return c.entries
+}
+
+func (c *testClient) Stop() {
+	c.once.Do(func() { close(c.entries) })
+	c.wg.Wait()
+}
+
+func (c *testClient) StopNow() { c.Stop() }
+
+func (c *testClient) Name() string { return "test" }
+
+// EntriesReceived returns a copy of the entries received so far.
+func (c *testClient) EntriesReceived() []api.Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cpy := make([]api.Entry, len(*c.sink))
+	copy(cpy, *c.sink)
+	return cpy
+}
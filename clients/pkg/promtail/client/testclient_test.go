@@ -0,0 +1,41 @@
+package client
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+func Test_NewTestClient(t *testing.T) {
+	var (
+		entries []api.Entry
+		mu      sync.Mutex
+	)
+	c := NewTestClient(&entries, &mu)
+
+	c.Chan() <- api.Entry{
+		Labels: model.LabelSet{"foo": "bar"},
+		Entry:  logproto.Entry{Line: "line1"},
+	}
+	c.Chan() <- api.Entry{
+		Labels: model.LabelSet{"foo": "bar"},
+		Entry:  logproto.Entry{Line: "line2"},
+	}
+	c.StopNow()
+
+	got := c.(*testClient).EntriesReceived()
+	require.Len(t, got, 2)
+	require.Equal(t, "line1", got[0].Line)
+	require.Equal(t, "line2", got[1].Line)
+
+	mu.Lock()
+	require.Len(t, entries, 2)
+	mu.Unlock()
+
+	require.Equal(t, "test", c.Name())
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+// UnspoolOptions configures a single Unspool run.
+type UnspoolOptions struct {
+	// Directory is the spool directory to read, as written by a spool
+	// client (see SpoolConfig).
+	Directory string
+
+	// StateFile, if set, tracks which spool files a previous Unspool call
+	// already finished pushing, so a later call against the same
+	// StateFile resumes instead of re-sending them.
+	StateFile string
+
+	// TenantOverride, if set, replaces the tenant recorded in every spool
+	// entry.
+	TenantOverride string
+
+	// Speed is a playback speed multiplier applied to the gaps between
+	// entry timestamps, the same as promtail-replay's -speed flag. 0
+	// disables inter-entry waiting entirely.
+	Speed float64
+}
+
+// unspoolState is the on-disk format of an UnspoolOptions.StateFile.
+type unspoolState struct {
+	Done map[string]bool `json:"done"`
+}
+
+func loadUnspoolState(path string) (*unspoolState, error) {
+	if path == "" {
+		return &unspoolState{Done: map[string]bool{}}, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &unspoolState{Done: map[string]bool{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read unspool state file: %w", err)
+	}
+
+	var s unspoolState
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("could not parse unspool state file: %w", err)
+	}
+	if s.Done == nil {
+		s.Done = map[string]bool{}
+	}
+	return &s, nil
+}
+
+func (s *unspoolState) save(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Unspool reads every spool file in opts.Directory not already recorded as
+// done in opts.StateFile, oldest first, and pushes its entries through c.
+// Each file is marked done in opts.StateFile as soon as it finishes, so a
+// later Unspool call against the same StateFile picks up where this one
+// left off rather than re-sending already-pushed files. It returns the
+// number of entries pushed.
+func Unspool(logger log.Logger, c Client, opts UnspoolOptions) (int, error) {
+	state, err := loadUnspoolState(opts.StateFile)
+	if err != nil {
+		return 0, err
+	}
+
+	files, err := spoolFiles(opts.Directory)
+	if err != nil {
+		return 0, fmt.Errorf("could not list spool directory: %w", err)
+	}
+
+	var total int
+	for _, path := range files {
+		name := filepath.Base(path)
+		if state.Done[name] {
+			continue
+		}
+
+		n, err := unspoolFile(logger, c, path, opts)
+		if err != nil {
+			return total, fmt.Errorf("could not unspool %s: %w", name, err)
+		}
+		total += n
+
+		state.Done[name] = true
+		if err := state.save(opts.StateFile); err != nil {
+			level.Warn(logger).Log("msg", "could not persist unspool state file", "err", err)
+		}
+	}
+	return total, nil
+}
+
+// spoolFiles lists dir's spool files ordered by the minimum timestamp
+// encoded in each name, so Unspool replays entries in roughly the order
+// they were originally spooled even across tenants.
+func spoolFiles(dir string) ([]string, error) {
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type file struct {
+		path  string
+		minTs int64
+	}
+	var files []file
+	for _, de := range des {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), spoolFileSuffix) {
+			continue
+		}
+		minTs, err := parseSpoolMinTs(de.Name())
+		if err != nil {
+			continue
+		}
+		files = append(files, file{filepath.Join(dir, de.Name()), minTs})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].minTs != files[j].minTs {
+			return files[i].minTs < files[j].minTs
+		}
+		return files[i].path < files[j].path
+	})
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths, nil
+}
+
+// parseSpoolMinTs extracts the minimum-timestamp component out of a spool
+// filename written by writeSpoolFile:
+// <tenant>_<minTsUnixNano>-<maxTsUnixNano>_<seq>.jsonl.gz.
+func parseSpoolMinTs(name string) (int64, error) {
+	name = strings.TrimSuffix(name, spoolFileSuffix)
+	parts := strings.Split(name, "_")
+	if len(parts) < 3 {
+		return 0, fmt.Errorf("unrecognized spool filename: %s", name)
+	}
+	minStr, _, ok := strings.Cut(parts[len(parts)-2], "-")
+	if !ok {
+		return 0, fmt.Errorf("unrecognized spool filename: %s", name)
+	}
+	return strconv.ParseInt(minStr, 10, 64)
+}
+
+// unspoolFile decodes path's gzip-compressed CaptureEntry lines and pushes
+// each through c, pacing playback according to opts.Speed the same way
+// promtail-replay paces a capture file.
+func unspoolFile(logger log.Logger, c Client, path string, opts UnspoolOptions) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, fmt.Errorf("could not read gzip spool file: %w", err)
+	}
+	defer gz.Close()
+
+	dec := json.NewDecoder(gz)
+
+	var (
+		count       int
+		firstTs     time.Time
+		firstWallTs time.Time
+	)
+	for {
+		var ce CaptureEntry
+		if err := dec.Decode(&ce); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return count, fmt.Errorf("could not decode spool entry: %w", err)
+		}
+
+		if firstTs.IsZero() {
+			firstTs = ce.Timestamp
+			firstWallTs = time.Now()
+		} else if opts.Speed > 0 {
+			target := firstWallTs.Add(time.Duration(float64(ce.Timestamp.Sub(firstTs)) / opts.Speed))
+			if d := time.Until(target); d > 0 {
+				time.Sleep(d)
+			}
+		}
+
+		lbs, err := syntax.ParseLabels(ce.Labels)
+		if err != nil {
+			level.Warn(logger).Log("msg", "skipping spool entry with unparsable labels", "labels", ce.Labels, "error", err)
+			continue
+		}
+		labelSet := make(model.LabelSet, len(lbs)+1)
+		for _, l := range lbs {
+			labelSet[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+		}
+
+		tenant := ce.Tenant
+		if opts.TenantOverride != "" {
+			tenant = opts.TenantOverride
+		}
+		if tenant != "" {
+			labelSet[ReservedLabelTenantID] = model.LabelValue(tenant)
+		}
+
+		c.Chan() <- api.Entry{
+			Labels: labelSet,
+			Entry: logproto.Entry{
+				Timestamp: ce.Timestamp,
+				Line:      ce.Line,
+			},
+		}
+		count++
+	}
+
+	return count, nil
+}
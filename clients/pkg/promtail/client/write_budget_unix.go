@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package client
+
+import (
+	"os"
+
+	renameio "github.com/google/renameio/v2"
+)
+
+func writeBudgetFile(filename string, buf []byte) error {
+	return renameio.WriteFile(filename, buf, os.FileMode(budgetFileMode))
+}
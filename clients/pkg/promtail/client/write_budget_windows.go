@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package client
+
+import "os"
+
+// writeBudgetFile is a fall back for Windows because renameio does not
+// support Windows. See https://github.com/google/renameio#windows-support
+func writeBudgetFile(filename string, buf []byte) error {
+	temp := filename + "-new"
+
+	if err := os.WriteFile(temp, buf, os.FileMode(budgetFileMode)); err != nil {
+		return err
+	}
+
+	return os.Rename(temp, filename)
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/loki/v3/clients/pkg/logentry/stages"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+)
+
+// Problem describes a single issue found by Check.
+type Problem struct {
+	Component string `json:"component"`
+	Message   string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Component, p.Message)
+}
+
+// CheckOptions controls how thoroughly Check inspects a Config.
+type CheckOptions struct {
+	// CheckConnectivity, when set, makes Check additionally test-resolve
+	// docker_sd_configs hosts and dial client push URLs, bounded by
+	// Timeout. This is skipped by default since it requires network access
+	// and can be slow, which isn't always wanted in a config-only lint.
+	CheckConnectivity bool
+	// Timeout bounds each connectivity probe. Ignored unless
+	// CheckConnectivity is set.
+	Timeout time.Duration
+}
+
+// Check validates cfg beyond what YAML unmarshaling alone catches: unique
+// client names, positions file permissions, and pipeline stages that
+// actually compile, plus, with CheckOptions.CheckConnectivity, that docker
+// hosts and client push URLs are reachable. It collects every problem
+// found rather than stopping at the first one, so a single run surfaces
+// everything worth fixing before promtail crash-loops on it in production.
+//
+// Callers should invoke cfg.Setup on cfg before calling Check, the same as
+// real startup does, so that deprecated single-client config merging and
+// external label propagation have already happened.
+func Check(cfg *Config, opts CheckOptions) []Problem {
+	var problems []Problem
+
+	problems = append(problems, checkClients(cfg)...)
+	problems = append(problems, checkPositions(cfg.PositionsConfig)...)
+	for _, sc := range cfg.ScrapeConfig {
+		problems = append(problems, checkScrapeConfig(sc)...)
+		problems = append(problems, checkDockerSocketPermissions(sc)...)
+	}
+
+	if opts.CheckConnectivity {
+		problems = append(problems, checkClientConnectivity(cfg, opts.Timeout)...)
+		for _, sc := range cfg.ScrapeConfig {
+			problems = append(problems, checkDockerConnectivity(sc, opts.Timeout)...)
+		}
+	}
+
+	return problems
+}
+
+// checkClients validates the merged client list: every client needs a
+// push URL, and named clients (used to route entries to a subset of
+// clients) must be unique.
+func checkClients(cfg *Config) []Problem {
+	var problems []Problem
+	seen := map[string]struct{}{}
+	for i, c := range cfg.ClientConfigs {
+		component := fmt.Sprintf("clients[%d]", i)
+		if c.Name != "" {
+			component = fmt.Sprintf("clients[%d] (%s)", i, c.Name)
+			if _, ok := seen[c.Name]; ok {
+				problems = append(problems, Problem{Component: component, Message: fmt.Sprintf("duplicate client name %q", c.Name)})
+			}
+			seen[c.Name] = struct{}{}
+		}
+		if c.URL.URL == nil {
+			problems = append(problems, Problem{Component: component, Message: "missing client URL"})
+		}
+	}
+	return problems
+}
+
+// checkPositions confirms the positions file's directory exists and is
+// writable, the most common cause of a promtail pod crash-looping on
+// startup after a GitOps config change moves the positions path.
+func checkPositions(cfg positions.Config) []Problem {
+	component := "positions"
+	dir := filepath.Dir(cfg.PositionsFile)
+
+	probe := filepath.Join(dir, ".promtail-check-config")
+	f, err := os.OpenFile(probe, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return []Problem{{Component: component, Message: fmt.Sprintf("positions path %q is not writable: %v", cfg.PositionsFile, err)}}
+	}
+	f.Close()
+	os.Remove(probe)
+	return nil
+}
+
+// checkScrapeConfig compiles sc's pipeline stages the same way real
+// startup does, surfacing malformed stage configs (a bad JMESPath
+// expression, an unresolvable template) that YAML unmarshaling alone
+// doesn't catch.
+func checkScrapeConfig(sc scrapeconfig.Config) []Problem {
+	component := fmt.Sprintf("scrape_config %q", sc.JobName)
+
+	if _, err := stages.NewPipeline(log.NewNopLogger(), sc.PipelineStages, &sc.JobName, prometheus.NewRegistry()); err != nil {
+		return []Problem{{Component: component, Message: fmt.Sprintf("pipeline_stages: %v", err)}}
+	}
+	return nil
+}
+
+// checkDockerSocketPermissions stats every unix-socket docker_sd_configs
+// host in sc and reports if the socket doesn't exist or isn't accessible.
+// Unlike checkDockerConnectivity, this doesn't require CheckOptions.
+// CheckConnectivity: it's a local filesystem check, not a network dial, so
+// it's cheap enough to run unconditionally and it catches the single most
+// common Docker target failure (promtail's user isn't in the group that
+// owns the socket) before promtail starts up and retries forever.
+func checkDockerSocketPermissions(sc scrapeconfig.Config) []Problem {
+	var problems []Problem
+	for i, dc := range sc.DockerSDConfigs {
+		component := fmt.Sprintf("scrape_config %q docker_sd_configs[%d]", sc.JobName, i)
+
+		u, err := url.Parse(dc.Host)
+		if err != nil || u.Scheme != "unix" {
+			continue
+		}
+
+		f, err := os.OpenFile(u.Path, os.O_RDONLY, 0)
+		if err != nil {
+			if os.IsPermission(err) {
+				problems = append(problems, Problem{Component: component, Message: fmt.Sprintf("socket %q is not accessible: %v (is promtail in the group that owns the socket?)", u.Path, err)})
+			} else if os.IsNotExist(err) {
+				problems = append(problems, Problem{Component: component, Message: fmt.Sprintf("socket %q does not exist: %v", u.Path, err)})
+			}
+			continue
+		}
+		f.Close()
+	}
+	return problems
+}
+
+// checkClientConnectivity HEADs every client's push URL, bounded by
+// timeout, to catch a wrong hostname or an unreachable Loki before
+// promtail starts dropping entries against it.
+func checkClientConnectivity(cfg *Config, timeout time.Duration) []Problem {
+	var problems []Problem
+	httpClient := &http.Client{Timeout: timeout}
+
+	for i, c := range cfg.ClientConfigs {
+		if c.URL.URL == nil {
+			continue // already reported by checkClients
+		}
+		component := fmt.Sprintf("clients[%d]", i)
+
+		req, err := http.NewRequest(http.MethodHead, c.URL.String(), nil)
+		if err != nil {
+			problems = append(problems, Problem{Component: component, Message: fmt.Sprintf("building request: %v", err)})
+			continue
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			problems = append(problems, Problem{Component: component, Message: fmt.Sprintf("unreachable: %v", err)})
+			continue
+		}
+		resp.Body.Close()
+	}
+	return problems
+}
+
+// checkDockerConnectivity dials every docker_sd_configs host in sc,
+// bounded by timeout, to catch a Docker daemon that's unreachable from
+// where promtail runs.
+func checkDockerConnectivity(sc scrapeconfig.Config, timeout time.Duration) []Problem {
+	var problems []Problem
+	for i, dc := range sc.DockerSDConfigs {
+		component := fmt.Sprintf("scrape_config %q docker_sd_configs[%d]", sc.JobName, i)
+		if err := dialDockerHost(dc.Host, timeout); err != nil {
+			problems = append(problems, Problem{Component: component, Message: fmt.Sprintf("host %q unreachable: %v", dc.Host, err)})
+		}
+	}
+	return problems
+}
+
+func dialDockerHost(host string, timeout time.Duration) error {
+	u, err := url.Parse(host)
+	if err != nil {
+		return err
+	}
+
+	network, address := "tcp", u.Host
+	if u.Scheme == "unix" {
+		network, address = "unix", u.Path
+	}
+
+	conn, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+)
+
+func mustLoadConfig(t *testing.T, s string) Config {
+	t.Helper()
+	var c Config
+	require.NoError(t, yaml.Unmarshal([]byte(s), &c))
+	return c
+}
+
+func Test_Check_ValidConfig(t *testing.T) {
+	c := mustLoadConfig(t, testFile)
+	c.PositionsConfig.PositionsFile = filepath.Join(t.TempDir(), "positions.yaml")
+
+	require.Empty(t, Check(&c, CheckOptions{}))
+}
+
+func Test_Check_DuplicateClientName(t *testing.T) {
+	var c Config
+	c.PositionsConfig.PositionsFile = filepath.Join(t.TempDir(), "positions.yaml")
+	c.ClientConfigs = []client.Config{
+		{Name: "primary"},
+		{Name: "primary"},
+	}
+	require.NoError(t, c.ClientConfigs[0].URL.Set("https://example.com/loki/api/v1/push"))
+	require.NoError(t, c.ClientConfigs[1].URL.Set("https://example.com/loki/api/v1/push"))
+
+	problems := Check(&c, CheckOptions{})
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0].Message, "duplicate client name")
+}
+
+func Test_Check_MissingClientURL(t *testing.T) {
+	var c Config
+	c.PositionsConfig.PositionsFile = filepath.Join(t.TempDir(), "positions.yaml")
+	c.ClientConfigs = []client.Config{{Name: "no-url"}}
+
+	problems := Check(&c, CheckOptions{})
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0].Message, "missing client URL")
+}
+
+func Test_Check_UnwritablePositionsDir(t *testing.T) {
+	var c Config
+	c.PositionsConfig.PositionsFile = filepath.Join(t.TempDir(), "does-not-exist", "positions.yaml")
+
+	problems := Check(&c, CheckOptions{})
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0].Component, "positions")
+}
+
+func Test_Check_InvalidPipelineStage(t *testing.T) {
+	const badStageYAML = `
+job_name: bad-stage
+pipeline_stages:
+  - json:
+      expressions:
+        out: '[[['
+`
+	var sc scrapeconfig.Config
+	require.NoError(t, yaml.Unmarshal([]byte(badStageYAML), &sc))
+
+	var c Config
+	c.PositionsConfig.PositionsFile = filepath.Join(t.TempDir(), "positions.yaml")
+	c.ScrapeConfig = []scrapeconfig.Config{sc}
+
+	problems := Check(&c, CheckOptions{})
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0].Component, "scrape_config")
+}
+
+func Test_Check_DockerSocketDoesNotExist(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "does-not-exist.sock")
+	badSockYAML := "job_name: docker\ndocker_sd_configs:\n  - host: unix://" + sockPath + "\n"
+
+	var sc scrapeconfig.Config
+	require.NoError(t, yaml.Unmarshal([]byte(badSockYAML), &sc))
+
+	var c Config
+	c.PositionsConfig.PositionsFile = filepath.Join(t.TempDir(), "positions.yaml")
+	c.ScrapeConfig = []scrapeconfig.Config{sc}
+
+	problems := Check(&c, CheckOptions{})
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0].Message, "does not exist")
+}
+
+func Test_Check_DockerSocketNotAccessible(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("file permissions have no effect when running as root")
+	}
+
+	sockPath := filepath.Join(t.TempDir(), "docker.sock")
+	require.NoError(t, os.WriteFile(sockPath, nil, 0o000))
+	badSockYAML := "job_name: docker\ndocker_sd_configs:\n  - host: unix://" + sockPath + "\n"
+
+	var sc scrapeconfig.Config
+	require.NoError(t, yaml.Unmarshal([]byte(badSockYAML), &sc))
+
+	var c Config
+	c.PositionsConfig.PositionsFile = filepath.Join(t.TempDir(), "positions.yaml")
+	c.ScrapeConfig = []scrapeconfig.Config{sc}
+
+	problems := Check(&c, CheckOptions{})
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0].Message, "not accessible")
+}
+
+func Test_Check_ConnectivityUnreachableClient(t *testing.T) {
+	var c Config
+	c.PositionsConfig.PositionsFile = filepath.Join(t.TempDir(), "positions.yaml")
+	c.ClientConfigs = []client.Config{{Name: "unreachable"}}
+	require.NoError(t, c.ClientConfigs[0].URL.Set("http://127.0.0.1:1/loki/api/v1/push"))
+
+	problems := Check(&c, CheckOptions{CheckConnectivity: true, Timeout: time.Second})
+	require.NotEmpty(t, problems)
+	require.Contains(t, problems[len(problems)-1].Message, "unreachable")
+}
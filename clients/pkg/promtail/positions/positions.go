@@ -19,6 +19,16 @@ const (
 	positionFileMode = 0600
 	cursorKeyPrefix  = "cursor-"
 	journalKeyPrefix = "journal-"
+
+	// inodeKeySuffix is appended to a file's path by targets/file's tailer
+	// to form the key it stores that file's last-seen inode under,
+	// alongside (not instead of) the plain path key holding its byte
+	// offset. cleanup resolves it back to the real path below, rather than
+	// treating the whole "path:inode" string as a literal path to stat.
+	// Mirrored as a literal in the file target itself rather than exported
+	// from here, the same way that package already hardcodes "journal-"
+	// rather than importing journalKeyPrefix.
+	inodeKeySuffix = ":inode"
 )
 
 // Config describes where to get position information from.
@@ -50,6 +60,12 @@ type positions struct {
 	positions map[string]string
 	quit      chan struct{}
 	done      chan struct{}
+
+	// writeMtx and writeErr track the outcome of the most recent attempt to
+	// write the positions file, so LastWriteError can report whether the
+	// positions file is currently writable without waiting on save's mutex.
+	writeMtx sync.Mutex
+	writeErr error
 }
 
 // File format for the positions data.
@@ -76,6 +92,9 @@ type Positions interface {
 	Remove(path string)
 	// SyncPeriod returns how often the positions file gets resynced
 	SyncPeriod() time.Duration
+	// LastWriteError returns the error from the most recent attempt to
+	// write the positions file, or nil if it succeeded (or hasn't run yet).
+	LastWriteError() error
 	// Stop the Position tracker.
 	Stop()
 }
@@ -144,6 +163,12 @@ func (p *positions) SyncPeriod() time.Duration {
 	return p.cfg.SyncPeriod
 }
 
+func (p *positions) LastWriteError() error {
+	p.writeMtx.Lock()
+	defer p.writeMtx.Unlock()
+	return p.writeErr
+}
+
 func (p *positions) run() {
 	defer func() {
 		p.save()
@@ -175,9 +200,14 @@ func (p *positions) save() {
 	}
 	p.mtx.Unlock()
 
-	if err := writePositionFile(p.cfg.PositionsFile, positions); err != nil {
+	err := writePositionFile(p.cfg.PositionsFile, positions)
+	if err != nil {
 		level.Error(p.logger).Log("msg", "error writing positions file", "error", err)
 	}
+
+	p.writeMtx.Lock()
+	p.writeErr = err
+	p.writeMtx.Unlock()
 }
 
 // CursorKey returns a key that can be saved as a cursor that is never deleted.
@@ -197,7 +227,8 @@ func (p *positions) cleanup() {
 			continue
 		}
 
-		if _, err := os.Stat(k); err != nil {
+		statPath := strings.TrimSuffix(k, inodeKeySuffix)
+		if _, err := os.Stat(statPath); err != nil {
 			if os.IsNotExist(err) {
 				// File no longer exists.
 				toRemove = append(toRemove, k)
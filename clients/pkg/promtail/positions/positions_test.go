@@ -180,3 +180,30 @@ func Test_ReadOnly(t *testing.T) {
 	}, out)
 
 }
+
+func Test_LastWriteError(t *testing.T) {
+	temp := tempFilename(t)
+	defer func() {
+		_ = os.Remove(temp)
+	}()
+
+	p, err := New(util_log.Logger, Config{
+		SyncPeriod:    time.Hour,
+		PositionsFile: temp,
+	})
+	require.NoError(t, err)
+	defer p.Stop()
+
+	require.NoError(t, p.LastWriteError(), "a fresh positions file should be writable")
+
+	// Point PositionsFile at a directory that can't be written to as a
+	// regular file, so the next save fails.
+	p.(*positions).cfg.PositionsFile = t.TempDir()
+	p.(*positions).save()
+	require.Error(t, p.LastWriteError())
+
+	// A subsequent successful save clears the error.
+	p.(*positions).cfg.PositionsFile = temp
+	p.(*positions).save()
+	require.NoError(t, p.LastWriteError())
+}
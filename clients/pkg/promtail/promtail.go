@@ -2,10 +2,12 @@ package promtail
 
 import (
 	"crypto/md5"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
@@ -21,6 +23,7 @@ import (
 	"github.com/grafana/loki/v3/clients/pkg/promtail/server"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/util/goroutinelabels"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/utils"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/wal"
 
@@ -43,6 +46,20 @@ var reloadFailTotal = prometheus.NewCounter(prometheus.CounterOpts{
 	Help:      "Number of reload fail times.",
 })
 
+// configInfo is an info-style metric identifying the currently loaded
+// configuration, so a fleet-wide query can tell which promtails are still
+// running an old revision. sha256 is computed from the same
+// secret-redacted, canonically ordered YAML rendering used for change
+// detection in reloadConfig, so it's stable across restarts with identical
+// config and changes whenever the effective config does. It's reset and
+// re-set on every successful reload, since a gauge's label values can't be
+// changed in place.
+var configInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "promtail",
+	Name:      "config_info",
+	Help:      "A metric with a constant '1' value labeled by the sha256 of the loaded configuration and its client/scrape_config counts.",
+}, []string{"sha256", "clients", "scrape_configs"})
+
 var errConfigNotChange = errors.New("config has not changed")
 
 // Option is a function that can be passed to the New method of Promtail and
@@ -56,6 +73,16 @@ func WithLogger(log log.Logger) Option {
 	}
 }
 
+// WithDryRunCaptureFile makes a dry-run Promtail write entries as
+// newline-delimited JSON to path, instead of printing them to stdout. The
+// resulting file can later be replayed against a real Loki with
+// promtail-replay.
+func WithDryRunCaptureFile(path string) Option {
+	return func(p *Promtail) {
+		p.dryRunCaptureFile = path
+	}
+}
+
 // WithRegisterer overrides the default registerer for Promtail.
 func WithRegisterer(reg prometheus.Registerer) Option {
 	return func(p *Promtail) {
@@ -73,12 +100,13 @@ type Promtail struct {
 	logger         log.Logger
 	reg            prometheus.Registerer
 
-	stopped      bool
-	mtx          sync.Mutex
-	configLoaded string
-	newConfig    func() (*config.Config, error)
-	metrics      *client.Metrics
-	dryRun       bool
+	stopped           bool
+	mtx               sync.Mutex
+	configLoaded      string
+	newConfig         func() (*config.Config, error)
+	metrics           *client.Metrics
+	dryRun            bool
+	dryRunCaptureFile string
 }
 
 // New makes a new Promtail.
@@ -107,11 +135,16 @@ func New(cfg config.Config, newConfig func() (*config.Config, error), metrics *c
 	if err != nil {
 		return nil, fmt.Errorf("error register prometheus collector reloadFailTotal :%w", err)
 	}
+	err = promtail.reg.Register(configInfo)
+	if err != nil {
+		return nil, fmt.Errorf("error register prometheus collector configInfo :%w", err)
+	}
+	goroutinelabels.SetEnabled(cfg.ServerConfig.GoroutineLabelsEnabled)
 	err = promtail.reloadConfig(&cfg)
 	if err != nil {
 		return nil, err
 	}
-	server, err := server.New(cfg.ServerConfig, promtail.logger, promtail.targetManagers, cfg.String())
+	server, err := server.New(cfg.ServerConfig, promtail.logger, promtail.targetManagers, cfg.String(), promtail.metrics)
 	if err != nil {
 		return nil, fmt.Errorf("error creating loki server: %w", err)
 	}
@@ -134,9 +167,6 @@ func (p *Promtail) reloadConfig(cfg *config.Config) error {
 	if p.targetManagers != nil {
 		p.targetManagers.Stop()
 	}
-	if p.client != nil {
-		p.client.Stop()
-	}
 
 	cfg.Setup(p.logger)
 	if cfg.LimitsConfig.ReadlineRateEnabled {
@@ -149,7 +179,14 @@ func (p *Promtail) reloadConfig(cfg *config.Config) error {
 	// TODO: Refactor all client instantiation inside client.Manager
 	cfg.PositionsConfig.ReadOnly = cfg.PositionsConfig.ReadOnly || p.dryRun
 	if p.dryRun {
-		p.client, err = client.NewLogger(p.metrics, p.logger, cfg.ClientConfigs...)
+		if p.client != nil {
+			p.client.Stop()
+		}
+		if p.dryRunCaptureFile != "" {
+			p.client, err = client.NewCaptureLogger(p.dryRunCaptureFile)
+		} else {
+			p.client, err = client.NewLogger(p.metrics, p.logger, cfg.ClientConfigs...)
+		}
 		if err != nil {
 			return err
 		}
@@ -167,17 +204,45 @@ func (p *Promtail) reloadConfig(cfg *config.Config) error {
 			notifier = p.walWriter
 			entryHandlers = append(entryHandlers, p.walWriter)
 		}
-		p.client, err = client.NewManager(
-			p.metrics,
-			p.logger,
-			cfg.LimitsConfig,
-			p.reg,
-			cfg.WAL,
-			notifier,
-			cfg.ClientConfigs...,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to create client manager: %w", err)
+
+		// If the previous client is a Manager, reconcile it in place
+		// instead of tearing every client down: a client whose config
+		// hasn't changed keeps running mid-batch, and a client whose
+		// endpoint/auth/tenant did change gets a fresh client while its
+		// old one drains its current batch to its old endpoint in the
+		// background rather than losing it. This only applies while WAL
+		// stays disabled on both sides of the reload; mgr.Reload reports
+		// client.ErrWALReloadUnsupported itself if the existing Manager
+		// has WAL enabled, and a WAL toggled on in the new config needs a
+		// wal.Writer and per-client watchers that only NewManager sets up.
+		mgr, isManager := p.client.(*client.Manager)
+		reloaded := false
+		if isManager && !cfg.WAL.Enabled {
+			switch err := mgr.Reload(cfg.ClientConfigs...); {
+			case err == nil:
+				reloaded = true
+			case errors.Is(err, client.ErrWALReloadUnsupported):
+				// fall through to the full rebuild below
+			default:
+				return fmt.Errorf("failed to reload client manager: %w", err)
+			}
+		}
+		if !reloaded {
+			if p.client != nil {
+				p.client.Stop()
+			}
+			p.client, err = client.NewManager(
+				p.metrics,
+				p.logger,
+				cfg.LimitsConfig,
+				p.reg,
+				cfg.WAL,
+				notifier,
+				cfg.ClientConfigs...,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to create client manager: %w", err)
+			}
 		}
 	}
 
@@ -198,6 +263,12 @@ func (p *Promtail) reloadConfig(cfg *config.Config) error {
 		}
 		promtailServer.ReloadServer(p.targetManagers, cfg.String())
 	}
+	configInfo.Reset()
+	configInfo.WithLabelValues(
+		fmt.Sprintf("%x", sha256.Sum256([]byte(newConf))),
+		strconv.Itoa(len(cfg.ClientConfigs)),
+		strconv.Itoa(len(cfg.ScrapeConfig)),
+	).Set(1)
 	p.configLoaded = newConf
 	return nil
 }
@@ -2,6 +2,7 @@ package promtail
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"math"
@@ -20,6 +21,7 @@ import (
 	serverww "github.com/grafana/dskit/server"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/discovery"
@@ -765,6 +767,67 @@ func Test_Reload(t *testing.T) {
 	require.Equal(t, 1.0, pb.Counter.GetValue())
 }
 
+func Test_Reload_ConfigInfo(t *testing.T) {
+	f, err := os.CreateTemp("", "Test_Reload_ConfigInfo")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	cfg := config.Config{
+		ServerConfig: server.Config{
+			Reload: true,
+			Config: localhostConfig,
+		},
+		ClientConfig: client.Config{URL: flagext.URLValue{URL: &url.URL{Host: "string"}}},
+		PositionsConfig: positions.Config{
+			PositionsFile: f.Name(),
+			SyncPeriod:    time.Second,
+		},
+	}
+
+	expectedConfig := &config.Config{
+		ServerConfig: server.Config{
+			Reload: true,
+			Config: localhostConfig,
+		},
+		ClientConfig: client.Config{URL: flagext.URLValue{URL: &url.URL{Host: "reloadtesturl"}}},
+		PositionsConfig: positions.Config{
+			PositionsFile: f.Name(),
+			SyncPeriod:    time.Second,
+		},
+	}
+
+	prometheus.DefaultRegisterer = prometheus.NewRegistry() // reset registry, otherwise you can't create 2 weavework server.
+	promtailServer, err := New(cfg, func() (*config.Config, error) {
+		return expectedConfig, nil
+	}, clientMetrics, true, nil)
+	require.NoError(t, err)
+
+	shaBefore := fmt.Sprintf("%x", sha256.Sum256([]byte(promtailServer.configLoaded)))
+	require.Equal(t, 1.0, testutil.ToFloat64(configInfo.WithLabelValues(shaBefore, "1", "0")))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err = promtailServer.Run()
+		if err != nil {
+			err = errors.Wrap(err, "Failed to start promtail")
+		}
+	}()
+	defer promtailServer.Shutdown() // In case the test fails before the call to Shutdown below.
+
+	svr := promtailServer.server.(*pserver.PromtailServer)
+	_, err = reload(t, svr.Server.HTTPListenAddr())
+	require.NoError(t, err)
+
+	shaAfter := fmt.Sprintf("%x", sha256.Sum256([]byte(promtailServer.configLoaded)))
+	require.NotEqual(t, shaBefore, shaAfter)
+	require.Equal(t, 1.0, testutil.ToFloat64(configInfo.WithLabelValues(shaAfter, "1", "0")))
+	// the gauge is Reset on every reload, so the stale label combination no
+	// longer reports a series.
+	require.Equal(t, 0.0, testutil.ToFloat64(configInfo.WithLabelValues(shaBefore, "1", "0")))
+}
+
 func Test_ReloadFail_NotPanic(t *testing.T) {
 	f, err := os.CreateTemp("", "Test_Reload")
 	require.NoError(t, err)
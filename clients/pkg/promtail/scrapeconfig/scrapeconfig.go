@@ -47,12 +47,154 @@ type Config struct {
 	HerokuDrainConfig    *HerokuDrainTargetConfig    `mapstructure:"heroku_drain,omitempty" yaml:"heroku_drain,omitempty"`
 	RelabelConfigs       []*relabel.Config           `mapstructure:"relabel_configs,omitempty" yaml:"relabel_configs,omitempty"`
 	// List of Docker service discovery configurations.
-	DockerSDConfigs        []*moby.DockerSDConfig `mapstructure:"docker_sd_configs,omitempty" yaml:"docker_sd_configs,omitempty"`
+	DockerSDConfigs []*moby.DockerSDConfig `mapstructure:"docker_sd_configs,omitempty" yaml:"docker_sd_configs,omitempty"`
+	// DockerConfig configures how the docker target derives labels from
+	// container orchestrator metadata.
+	DockerConfig           DockerTargetConfig     `mapstructure:"docker,omitempty" yaml:"docker,omitempty"`
 	ServiceDiscoveryConfig ServiceDiscoveryConfig `mapstructure:",squash" yaml:",inline"`
 	Encoding               string                 `mapstructure:"encoding,omitempty" yaml:"encoding,omitempty"`
 	DecompressionCfg       *DecompressionConfig   `yaml:"decompression,omitempty"`
 }
 
+// DockerTargetConfig configures the mapping of well-known Docker Compose and
+// Docker Swarm container labels onto stream labels for the docker target, so
+// that teams don't need to write relabel_configs by hand to get them. Labels
+// produced this way are applied before relabeling runs, so they can still be
+// renamed or dropped through relabel_configs like any other label.
+type DockerTargetConfig struct {
+	// ComposeLabels enables mapping the `com.docker.compose.project` and
+	// `com.docker.compose.service` container labels onto ComposeProjectLabel
+	// and ComposeServiceLabel.
+	ComposeLabels bool `mapstructure:"compose_labels,omitempty" yaml:"compose_labels,omitempty"`
+
+	// ComposeProjectLabel is the target label name the Compose project is
+	// mapped to. Defaults to "compose_project".
+	ComposeProjectLabel string `mapstructure:"compose_project_label,omitempty" yaml:"compose_project_label,omitempty"`
+
+	// ComposeServiceLabel is the target label name the Compose service is
+	// mapped to. Defaults to "compose_service".
+	ComposeServiceLabel string `mapstructure:"compose_service_label,omitempty" yaml:"compose_service_label,omitempty"`
+
+	// SwarmLabels enables mapping the `com.docker.swarm.service.name`
+	// container label onto SwarmServiceLabel.
+	SwarmLabels bool `mapstructure:"swarm_labels,omitempty" yaml:"swarm_labels,omitempty"`
+
+	// SwarmServiceLabel is the target label name the Swarm service name is
+	// mapped to. Defaults to "service".
+	SwarmServiceLabel string `mapstructure:"swarm_service_label,omitempty" yaml:"swarm_service_label,omitempty"`
+
+	// MaxContainers caps the number of containers this target group runs
+	// at once. When the limit is reached, newly discovered containers are
+	// queued and started as running ones stop. Zero (the default) means
+	// unlimited.
+	MaxContainers int `mapstructure:"max_containers,omitempty" yaml:"max_containers,omitempty"`
+
+	// PauseLabel, when set, is a container label that pauses log tailing for
+	// that container while present (with any value). Tailing resumes once
+	// the label is removed. Empty (the default) disables pause watching.
+	PauseLabel string `mapstructure:"pause_label,omitempty" yaml:"pause_label,omitempty"`
+
+	// PauseStopsReading controls what happens to a container's log position
+	// while it's paused. When false (the default), promtail keeps reading
+	// and discarding entries, so the position keeps advancing and tailing
+	// resumes from the current tail. When true, promtail stops reading
+	// entirely while paused, freezing the position so no logs are skipped
+	// once tailing resumes.
+	PauseStopsReading bool `mapstructure:"pause_stops_reading,omitempty" yaml:"pause_stops_reading,omitempty"`
+
+	// ReadBufferEntries caps how many parsed log frames a target buffers
+	// per stream (stdout/stderr) while waiting for a slow pipeline or
+	// client to catch up, before it starts dropping frames instead of
+	// blocking the container's log reader. Zero (the default) uses a
+	// built-in default of 1000 frames.
+	ReadBufferEntries int `mapstructure:"read_buffer_entries,omitempty" yaml:"read_buffer_entries,omitempty"`
+
+	// ReadBufferBytes caps the same per-stream buffer by total bytes
+	// buffered rather than frame count, in case frames vary widely in
+	// size. Zero (the default) uses a built-in default of 4MB.
+	ReadBufferBytes int `mapstructure:"read_buffer_bytes,omitempty" yaml:"read_buffer_bytes,omitempty"`
+
+	// ErrorRecoveryPolicy controls what a target does when its log stream
+	// ends because of an error rather than an explicit Stop(). Valid
+	// values are "stop" (the default), which leaves the target not ready
+	// until it's restarted; "retry", which reconnects with exponential
+	// backoff; and "ignore", which logs the error but keeps reporting the
+	// target as ready. Empty (the default) behaves like "stop".
+	ErrorRecoveryPolicy string `mapstructure:"error_recovery_policy,omitempty" yaml:"error_recovery_policy,omitempty"`
+
+	// TrackContainerUptime enables the __meta_docker_container_uptime_seconds
+	// label, recalculated on every entry from the container's creation time
+	// fetched once at target startup. It's useful for spotting containers
+	// that are cycling rapidly, e.g. via a relabel rule that drops entries
+	// below some uptime threshold to flag crash-looping containers.
+	TrackContainerUptime bool `mapstructure:"track_container_uptime,omitempty" yaml:"track_container_uptime,omitempty"`
+
+	// TrackContainerHealth enables the __meta_docker_container_health
+	// label, populated from the container's health check status
+	// ("starting", "healthy", "unhealthy", or "none" if no health check is
+	// configured on the image). Unlike TrackContainerUptime, the status
+	// can only change when Docker re-evaluates the container's health
+	// check, so the target periodically re-inspects the container to keep
+	// it current rather than recomputing it on every entry. Useful for
+	// relabel rules that correlate log anomalies with health transitions.
+	TrackContainerHealth bool `mapstructure:"track_container_health,omitempty" yaml:"track_container_health,omitempty"`
+
+	// StdoutLevel and StderrLevel set LevelLabel to the given value on every
+	// entry read from the container's stdout/stderr respectively, before
+	// LevelDetectionRegex or relabeling run. Either can be left empty to
+	// leave that stream's entries alone. This is meant for runtimes that
+	// only signal errors by writing to stderr, so a query doesn't need its
+	// own heuristics to notice.
+	StdoutLevel string `mapstructure:"stdout_level,omitempty" yaml:"stdout_level,omitempty"`
+	StderrLevel string `mapstructure:"stderr_level,omitempty" yaml:"stderr_level,omitempty"`
+
+	// LevelLabel is the target label name StdoutLevel/StderrLevel are
+	// written to. Defaults to "level".
+	LevelLabel string `mapstructure:"level_label,omitempty" yaml:"level_label,omitempty"`
+
+	// LevelDetectionRegex, when set, is matched against the raw log line;
+	// a match means the line already carries its own level, so
+	// StdoutLevel/StderrLevel is skipped and whatever downstream pipeline
+	// stages or relabel_configs derive from the line wins instead.
+	LevelDetectionRegex string `mapstructure:"level_detection_regex,omitempty" yaml:"level_detection_regex,omitempty"`
+
+	// SwarmEnrichment enables the __meta_docker_container_swarm_node_hostname
+	// and __meta_docker_container_swarm_service_vip labels, resolved once
+	// per connection attempt (and cached briefly across reconnects) via a
+	// container inspect and, for the VIP, a DNS lookup of the container's
+	// Swarm service name. A failed inspect or lookup just omits the
+	// affected label rather than failing the target.
+	SwarmEnrichment bool `mapstructure:"swarm_enrichment,omitempty" yaml:"swarm_enrichment,omitempty"`
+
+	// StripLinePrefix, when set, is stripped from the start of each line
+	// read from the container, before the timestamp is parsed out of it.
+	// It's meant for runtimes that prepend their own framing to the log
+	// line ahead of Docker's timestamp, e.g. containerd's crictl shim
+	// writing a "F " or "P " (full/partial line) flag, which would
+	// otherwise make the timestamp unparseable.
+	StripLinePrefix string `mapstructure:"strip_line_prefix,omitempty" yaml:"strip_line_prefix,omitempty"`
+
+	// NormalizeWhitespace additionally trims trailing spaces and tabs from
+	// each fully assembled line, on top of the trailing \r or \n every line
+	// already has stripped from its own frame terminator. It's meant for
+	// Windows-built images and logging libraries that pad lines with
+	// trailing whitespace, which otherwise makes exact-match LogQL filters
+	// and deduplication unreliable. Off by default, since it does change
+	// line content rather than just framing.
+	NormalizeWhitespace bool `mapstructure:"normalize_whitespace,omitempty" yaml:"normalize_whitespace,omitempty"`
+
+	// AllowForeignPlatforms disables the platform check discovery normally
+	// runs before starting a target. By default, a container whose
+	// inspected platform isn't one promtail knows how to read logs from
+	// (e.g. a Windows container discovered on an LCOW-capable Windows
+	// daemon) is skipped rather than started, showing up as a dropped
+	// target with a reason in the targets API instead of failing
+	// repeatedly through the normal error recovery path. Set this if a
+	// daemon reports a foreign platform for containers promtail can
+	// actually read, to force it to attempt them anyway.
+	AllowForeignPlatforms bool `mapstructure:"allow_foreign_platforms,omitempty" yaml:"allow_foreign_platforms,omitempty"`
+}
+
 type DecompressionConfig struct {
 	Enabled      bool
 	InitialDelay time.Duration `yaml:"initial_delay"`
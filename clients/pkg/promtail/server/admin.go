@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+)
+
+// adminConfigResponse is the body of GET /-/config. It follows the
+// {"status":"success","data":{"yaml":"..."}} envelope Prometheus's own
+// /-/config endpoint uses, so tooling written against one can be pointed at
+// the other with minimal changes.
+type adminConfigResponse struct {
+	Status string          `json:"status"`
+	Data   adminConfigData `json:"data"`
+}
+
+type adminConfigData struct {
+	YAML string `json:"yaml"`
+}
+
+// adminConfig serves the currently active configuration as YAML text
+// wrapped in a JSON envelope. Secrets are already redacted by the time
+// s.promtailCfg was built: it comes from config.Config's String method,
+// whose yaml.Marshal renders HTTPClientConfig's Secret-typed fields (basic
+// auth passwords, bearer tokens, etc.) as "<secret>" rather than their real
+// values.
+func (s *PromtailServer) adminConfig(rw http.ResponseWriter, _ *http.Request) {
+	s.mtx.Lock()
+	cfg := s.promtailCfg
+	s.mtx.Unlock()
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(adminConfigResponse{
+		Status: "success",
+		Data:   adminConfigData{YAML: cfg},
+	}); err != nil {
+		level.Error(s.log).Log("msg", "error writing config response", "error", err)
+	}
+}
+
+// adminTargetView is one active target's status, as reported by
+// GET /-/config/targets. Details is type-specific (e.g. per-path read
+// offsets for a file target, cursor state for a journal target) since the
+// Target interface has no single notion of "last activity" that applies
+// uniformly across target types.
+type adminTargetView struct {
+	Type    string        `json:"type"`
+	Labels  model.LabelSet `json:"labels"`
+	Ready   bool          `json:"ready"`
+	Details interface{}   `json:"details,omitempty"`
+}
+
+// adminTargetViews flattens every target across every job's pool into a
+// single slice of adminTargetView, for the /-/config/targets JSON response.
+func adminTargetViews(pools map[string][]target.Target) []adminTargetView {
+	views := make([]adminTargetView, 0)
+	for _, ts := range pools {
+		for _, t := range ts {
+			views = append(views, adminTargetView{
+				Type:    string(t.Type()),
+				Labels:  t.Labels(),
+				Ready:   t.Ready(),
+				Details: t.Details(),
+			})
+		}
+	}
+	return views
+}
+
+// adminConfigTargets serves every currently active target's labels, ready
+// state, and type-specific details as a flat JSON array.
+func (s *PromtailServer) adminConfigTargets(rw http.ResponseWriter, _ *http.Request) {
+	s.mtx.Lock()
+	views := adminTargetViews(s.tms.ActiveTargets())
+	s.mtx.Unlock()
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(views); err != nil {
+		level.Error(s.log).Log("msg", "error writing config targets response", "error", err)
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+)
+
+func Test_adminTargetViews(t *testing.T) {
+	pools := map[string][]target.Target{
+		"docker": {
+			&fakeTarget{typ: target.DockerTargetType, labels: model.LabelSet{"project": "web"}, ready: true},
+		},
+		"varlog": {
+			&fakeTarget{typ: target.FileTargetType, labels: model.LabelSet{"job": "varlogs"}, ready: false},
+		},
+	}
+
+	views := adminTargetViews(pools)
+	require.Len(t, views, 2)
+
+	byType := map[string]adminTargetView{}
+	for _, v := range views {
+		byType[v.Type] = v
+	}
+
+	require.Equal(t, adminTargetView{
+		Type:   string(target.DockerTargetType),
+		Labels: model.LabelSet{"project": "web"},
+		Ready:  true,
+	}, byType[string(target.DockerTargetType)])
+	require.Equal(t, adminTargetView{
+		Type:   string(target.FileTargetType),
+		Labels: model.LabelSet{"job": "varlogs"},
+		Ready:  false,
+	}, byType[string(target.FileTargetType)])
+}
+
+func Test_adminTargetViews_Empty(t *testing.T) {
+	require.Empty(t, adminTargetViews(map[string][]target.Target{}))
+}
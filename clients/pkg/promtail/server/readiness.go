@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client"
+)
+
+// ReadinessConfig configures the thresholds /ready/detail applies when
+// judging each subsystem, so ordinary container churn or a slow-starting
+// target doesn't flap readiness.
+type ReadinessConfig struct {
+	// MinReadyTargetFraction is the minimum fraction, in [0, 1], of active
+	// targets that must report themselves ready for the targets subsystem
+	// to pass. Defaults to 1 (every active target must be ready).
+	MinReadyTargetFraction float64 `yaml:"min_ready_target_fraction"`
+	// ClientMaxPushAge is how stale a client's most recent successful push
+	// may be before its subsystem is considered unhealthy. A client that
+	// hasn't attempted a push yet always passes, since it hasn't had
+	// anything to send. Defaults to 5 minutes.
+	ClientMaxPushAge time.Duration `yaml:"client_max_push_age"`
+}
+
+// RegisterFlagsWithPrefix with prefix registers flags where every name is
+// prefixed by prefix. If prefix is a non-empty string, prefix should end
+// with a period.
+func (cfg *ReadinessConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.Float64Var(&cfg.MinReadyTargetFraction, prefix+"server.readiness.min-ready-target-fraction", 1, "Minimum fraction of active targets that must be ready for /ready/detail to report the targets subsystem healthy.")
+	f.DurationVar(&cfg.ClientMaxPushAge, prefix+"server.readiness.client-max-push-age", 5*time.Minute, "How stale a client's most recent successful push may be before /ready/detail reports that client unhealthy.")
+}
+
+// subsystemStatus is one subsystem's readiness, as reported in the
+// /ready/detail JSON body.
+type subsystemStatus struct {
+	Ready  bool   `json:"ready"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// readinessReport is the full /ready/detail JSON body: an overall verdict
+// plus a per-subsystem breakdown, so incident triage doesn't have to guess
+// whether it's targets, positions, or clients that are unhappy.
+type readinessReport struct {
+	Ready     bool                       `json:"ready"`
+	Positions subsystemStatus            `json:"positions"`
+	Targets   subsystemStatus            `json:"targets"`
+	Clients   map[string]subsystemStatus `json:"clients,omitempty"`
+}
+
+// buildReadinessReport combines the already-computed per-subsystem statuses
+// into a readinessReport, overall Ready iff every subsystem (and every
+// client) is ready. Kept separate from the subsystem-specific functions
+// below so tests can exercise every combination without a running server.
+func buildReadinessReport(positions, targets subsystemStatus, clients map[string]subsystemStatus) readinessReport {
+	report := readinessReport{
+		Ready:     positions.Ready && targets.Ready,
+		Positions: positions,
+		Targets:   targets,
+		Clients:   clients,
+	}
+	for _, c := range clients {
+		if !c.Ready {
+			report.Ready = false
+			break
+		}
+	}
+	return report
+}
+
+// positionsStatus reports the positions subsystem's readiness from the
+// error returned by its most recent write attempt. lastWriteErr is nil for
+// a promtail with no positions tracker (nothing configured to need one) or
+// whose most recent write succeeded.
+func positionsStatus(lastWriteErr error) subsystemStatus {
+	if lastWriteErr != nil {
+		return subsystemStatus{Ready: false, Reason: fmt.Sprintf("positions file not writable: %s", lastWriteErr)}
+	}
+	return subsystemStatus{Ready: true}
+}
+
+// targetsStatus reports the targets subsystem's readiness from the fraction
+// of active targets currently ready, against the configured minimum.
+func targetsStatus(readyFraction, minReadyFraction float64) subsystemStatus {
+	if readyFraction < minReadyFraction {
+		return subsystemStatus{
+			Ready:  false,
+			Reason: fmt.Sprintf("%.0f%% of active targets ready, below the %.0f%% minimum", readyFraction*100, minReadyFraction*100),
+		}
+	}
+	return subsystemStatus{Ready: true}
+}
+
+// clientsStatus reports each client host's readiness from its PushHealth
+// snapshot, against maxAge.
+func clientsStatus(snapshot map[string]client.PushHealth, maxAge time.Duration, now time.Time) map[string]subsystemStatus {
+	if len(snapshot) == 0 {
+		return nil
+	}
+	statuses := make(map[string]subsystemStatus, len(snapshot))
+	for host, health := range snapshot {
+		if health.Healthy(maxAge, now) {
+			statuses[host] = subsystemStatus{Ready: true}
+			continue
+		}
+		statuses[host] = subsystemStatus{
+			Ready:  false,
+			Reason: fmt.Sprintf("no successful push since %s, older than the %s maximum", health.LastSuccess.Format(time.RFC3339), maxAge),
+		}
+	}
+	return statuses
+}
+
+// readiness computes the current readinessReport from the server's
+// subsystems. Called with s.mtx held, since it reads s.tms.
+func (s *PromtailServer) readiness(now time.Time) readinessReport {
+	var lastWriteErr error
+	if positions := s.tms.Positions(); positions != nil {
+		lastWriteErr = positions.LastWriteError()
+	}
+
+	var clientHealth map[string]client.PushHealth
+	if s.clientMetrics != nil {
+		clientHealth = s.clientMetrics.PushHealthSnapshot()
+	}
+
+	return buildReadinessReport(
+		positionsStatus(lastWriteErr),
+		targetsStatus(s.tms.TargetReadyFraction(), s.readinessCfg.MinReadyTargetFraction),
+		clientsStatus(clientHealth, s.readinessCfg.ClientMaxPushAge, now),
+	)
+}
+
+// readyDetailed serves a per-subsystem readiness breakdown as JSON,
+// returning 503 if any subsystem is unready. See ready for the plain-text
+// counterpart used by health_check_target.
+func (s *PromtailServer) readyDetailed(rw http.ResponseWriter, _ *http.Request) {
+	s.mtx.Lock()
+	report := s.readiness(time.Now())
+	s.mtx.Unlock()
+
+	rw.Header().Set("Content-Type", "application/json")
+	if !report.Ready {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if err := json.NewEncoder(rw).Encode(report); err != nil {
+		level.Error(s.log).Log("msg", "error writing readiness response", "error", err)
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client"
+)
+
+func Test_positionsStatus(t *testing.T) {
+	t.Run("no write error is ready", func(t *testing.T) {
+		require.Equal(t, subsystemStatus{Ready: true}, positionsStatus(nil))
+	})
+
+	t.Run("a write error is not ready", func(t *testing.T) {
+		status := positionsStatus(errors.New("permission denied"))
+		require.False(t, status.Ready)
+		require.Contains(t, status.Reason, "permission denied")
+	})
+}
+
+func Test_targetsStatus(t *testing.T) {
+	t.Run("fraction at or above the minimum is ready", func(t *testing.T) {
+		require.Equal(t, subsystemStatus{Ready: true}, targetsStatus(1.0, 1.0))
+		require.Equal(t, subsystemStatus{Ready: true}, targetsStatus(0.9, 0.8))
+	})
+
+	t.Run("fraction below the minimum is not ready", func(t *testing.T) {
+		status := targetsStatus(0.5, 0.8)
+		require.False(t, status.Ready)
+		require.Contains(t, status.Reason, "50%")
+		require.Contains(t, status.Reason, "80%")
+	})
+}
+
+func Test_clientsStatus(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	maxAge := 5 * time.Minute
+
+	t.Run("no clients reports nil", func(t *testing.T) {
+		require.Nil(t, clientsStatus(nil, maxAge, now))
+	})
+
+	t.Run("never attempted is ready", func(t *testing.T) {
+		snapshot := map[string]client.PushHealth{"loki:3100": {}}
+		require.Equal(t, subsystemStatus{Ready: true}, clientsStatus(snapshot, maxAge, now)["loki:3100"])
+	})
+
+	t.Run("recent success is ready", func(t *testing.T) {
+		snapshot := map[string]client.PushHealth{
+			"loki:3100": {Attempted: true, LastSuccess: now.Add(-time.Minute)},
+		}
+		require.Equal(t, subsystemStatus{Ready: true}, clientsStatus(snapshot, maxAge, now)["loki:3100"])
+	})
+
+	t.Run("stale success is not ready", func(t *testing.T) {
+		snapshot := map[string]client.PushHealth{
+			"loki:3100": {Attempted: true, LastSuccess: now.Add(-time.Hour)},
+		}
+		status := clientsStatus(snapshot, maxAge, now)["loki:3100"]
+		require.False(t, status.Ready)
+		require.NotEmpty(t, status.Reason)
+	})
+
+	t.Run("attempted but never succeeded is not ready", func(t *testing.T) {
+		snapshot := map[string]client.PushHealth{"loki:3100": {Attempted: true}}
+		status := clientsStatus(snapshot, maxAge, now)["loki:3100"]
+		require.False(t, status.Ready)
+	})
+}
+
+func Test_buildReadinessReport(t *testing.T) {
+	ready := subsystemStatus{Ready: true}
+	notReady := subsystemStatus{Ready: false, Reason: "broken"}
+
+	t.Run("all ready", func(t *testing.T) {
+		report := buildReadinessReport(ready, ready, map[string]subsystemStatus{"loki:3100": ready})
+		require.True(t, report.Ready)
+	})
+
+	t.Run("positions not ready", func(t *testing.T) {
+		report := buildReadinessReport(notReady, ready, map[string]subsystemStatus{"loki:3100": ready})
+		require.False(t, report.Ready)
+	})
+
+	t.Run("targets not ready", func(t *testing.T) {
+		report := buildReadinessReport(ready, notReady, map[string]subsystemStatus{"loki:3100": ready})
+		require.False(t, report.Ready)
+	})
+
+	t.Run("a client not ready", func(t *testing.T) {
+		report := buildReadinessReport(ready, ready, map[string]subsystemStatus{"loki:3100": notReady})
+		require.False(t, report.Ready)
+	})
+
+	t.Run("no clients configured still reports ready", func(t *testing.T) {
+		report := buildReadinessReport(ready, ready, nil)
+		require.True(t, report.Ready)
+	})
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
@@ -23,6 +24,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/version"
 
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/server/ui"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
@@ -48,6 +50,8 @@ type PromtailServer struct {
 	reloadCh          chan chan error
 	healthCheckTarget bool
 	promtailCfg       string
+	clientMetrics     *client.Metrics
+	readinessCfg      ReadinessConfig
 }
 
 // Config extends weaveworks server config
@@ -58,6 +62,17 @@ type Config struct {
 	Disable           bool   `yaml:"disable"`
 	ProfilingEnabled  bool   `yaml:"profiling_enabled"`
 	Reload            bool   `yaml:"enable_runtime_reload"`
+
+	// GoroutineLabelsEnabled controls whether target and client goroutines
+	// are tagged with pprof labels (target type, key, client host) so
+	// go tool pprof can attribute CPU samples to them individually. It's on
+	// by default; disable it to shave off the small per-goroutine cost of
+	// labeling on installations that don't profile promtail.
+	GoroutineLabelsEnabled bool `yaml:"goroutine_labels_enabled"`
+
+	// Readiness configures the thresholds the /ready/detail endpoint
+	// applies when judging the targets and clients subsystems.
+	Readiness ReadinessConfig `yaml:"readiness"`
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
@@ -69,6 +84,8 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.BoolVar(&cfg.Disable, prefix+"server.disable", false, "Disable the http and grpc server.")
 	f.BoolVar(&cfg.ProfilingEnabled, prefix+"server.profiling_enabled", false, "Enable the /debug/fgprof and /debug/pprof endpoints for profiling.")
 	f.BoolVar(&cfg.Reload, prefix+"server.enable-runtime-reload", false, "Enable reload via HTTP request.")
+	f.BoolVar(&cfg.GoroutineLabelsEnabled, prefix+"server.goroutine-labels-enabled", true, "Tag target and client goroutines with pprof labels (target type, key, client host) so profiles can be attributed to them. Disable for minimal overhead.")
+	cfg.Readiness.RegisterFlagsWithPrefix(prefix, f)
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet
@@ -77,7 +94,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 }
 
 // New makes a new Server
-func New(cfg Config, log log.Logger, tms *targets.TargetManagers, promtailCfg string) (Server, error) {
+func New(cfg Config, log log.Logger, tms *targets.TargetManagers, promtailCfg string, clientMetrics *client.Metrics) (Server, error) {
 	if cfg.Disable {
 		return newNoopServer(log), nil
 	}
@@ -114,6 +131,8 @@ func New(cfg Config, log log.Logger, tms *targets.TargetManagers, promtailCfg st
 		externalURL:       externalURL,
 		healthCheckTarget: healthCheckTargetFlag,
 		promtailCfg:       promtailCfg,
+		clientMetrics:     clientMetrics,
+		readinessCfg:      cfg.Readiness,
 	}
 
 	// Register the /metrics route if cfg.RegisterInstrumentation was true
@@ -126,10 +145,16 @@ func New(cfg Config, log log.Logger, tms *targets.TargetManagers, promtailCfg st
 
 	serv.HTTP.Path("/").Handler(http.RedirectHandler(path.Join(serv.externalURL.Path, "/targets"), 303))
 	serv.HTTP.Path("/ready").Handler(http.HandlerFunc(serv.ready))
+	serv.HTTP.Path("/ready/detail").Handler(http.HandlerFunc(serv.readyDetailed))
+	serv.HTTP.Path("/client/health").Handler(http.HandlerFunc(serv.clientHealth))
+	serv.HTTP.Path("/client/chargeback").Handler(http.HandlerFunc(serv.clientChargeback))
 	serv.HTTP.PathPrefix("/static/").Handler(http.StripPrefix(externalURL.Path, http.FileServer(ui.Assets)))
 	serv.HTTP.Path("/service-discovery").Handler(http.HandlerFunc(serv.serviceDiscovery))
 	serv.HTTP.Path("/targets").Handler(http.HandlerFunc(serv.targets))
+	serv.HTTP.Path("/targets.json").Handler(http.HandlerFunc(serv.targetsJSON))
 	serv.HTTP.Path("/config").Handler(http.HandlerFunc(serv.config))
+	serv.HTTP.Path("/-/config").Handler(http.HandlerFunc(serv.adminConfig))
+	serv.HTTP.Path("/-/config/targets").Handler(http.HandlerFunc(serv.adminConfigTargets))
 	if cfg.ProfilingEnabled {
 		serv.HTTP.Path("/debug/fgprof").Handler(fgprof.Handler())
 	}
@@ -186,29 +211,29 @@ func (s *PromtailServer) serviceDiscovery(rw http.ResponseWriter, req *http.Requ
 		Name:         "service-discovery.html",
 		PageTitle:    "Service Discovery",
 		ExternalURL:  s.externalURL,
-		TemplateFuncs: template.FuncMap{
-			"fileTargetDetails": func(details interface{}) map[string]int64 {
-				// you can't cast with a text template in go so this is a helper
-				return details.(map[string]int64)
-			},
+		TemplateFuncs: mergeFuncMaps(targetTemplateFuncs(), template.FuncMap{
 			"dropReason": func(details interface{}) string {
 				if reason, ok := details.(string); ok {
 					return reason
 				}
 				return ""
 			},
-			"numReady": func(ts []target.Target) (readies int) {
-				for _, t := range ts {
-					if t.Ready() {
-						readies++
-					}
-				}
-				return
-			},
-		},
+		}),
 	})
 }
 
+// mergeFuncMaps returns a single template.FuncMap containing the entries of
+// all of maps, with later maps taking precedence on key collisions.
+func mergeFuncMaps(maps ...template.FuncMap) template.FuncMap {
+	merged := template.FuncMap{}
+	for _, m := range maps {
+		for name, fn := range m {
+			merged[name] = fn
+		}
+	}
+	return merged
+}
+
 func (s *PromtailServer) config(rw http.ResponseWriter, req *http.Request) {
 	executeTemplate(req.Context(), rw, templateOptions{
 		Data:         s.promtailCfg,
@@ -219,41 +244,136 @@ func (s *PromtailServer) config(rw http.ResponseWriter, req *http.Request) {
 	})
 }
 
-// targets serves the targets page.
+// targetGroupView is one namespace-style group of targets within a job, as
+// rendered by the targets page and served by targetsJSON.
+type targetGroupView struct {
+	Name    string
+	Ready   int
+	Targets []target.Target
+}
+
+// jobView is a job's targets, namespace-style grouped and with ready/total
+// counts precomputed for the template.
+type jobView struct {
+	Ready  int
+	Total  int
+	Groups []targetGroupView
+}
+
+// groupedTargets buckets pools' targets by job and then by
+// target.GroupForTarget, keeping only groups with at least one target whose
+// job, group, type or labels contain filter (case-insensitive). An empty
+// filter keeps everything. Groups within a job are sorted by name.
+func groupedTargets(pools map[string][]target.Target, filter string) map[string]jobView {
+	filter = strings.ToLower(filter)
+	byGroup := make(map[string]map[string][]target.Target, len(pools))
+	for job, ts := range pools {
+		for _, t := range ts {
+			group := target.GroupForTarget(t)
+			if filter != "" && !targetMatchesFilter(job, group, t, filter) {
+				continue
+			}
+			if byGroup[job] == nil {
+				byGroup[job] = make(map[string][]target.Target)
+			}
+			byGroup[job][group] = append(byGroup[job][group], t)
+		}
+	}
+
+	result := make(map[string]jobView, len(byGroup))
+	for job, groups := range byGroup {
+		names := make([]string, 0, len(groups))
+		for name := range groups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		view := jobView{Groups: make([]targetGroupView, 0, len(names))}
+		for _, name := range names {
+			ts := groups[name]
+			ready := 0
+			for _, t := range ts {
+				if t.Ready() {
+					ready++
+				}
+			}
+			view.Ready += ready
+			view.Total += len(ts)
+			view.Groups = append(view.Groups, targetGroupView{Name: name, Ready: ready, Targets: ts})
+		}
+		result[job] = view
+	}
+	return result
+}
+
+// targetMatchesFilter reports whether filter (already lower-cased) is a
+// substring of job, group, the target's type, or any of its label names or
+// values.
+func targetMatchesFilter(job, group string, t target.Target, filter string) bool {
+	if strings.Contains(strings.ToLower(job), filter) ||
+		strings.Contains(strings.ToLower(group), filter) ||
+		strings.Contains(strings.ToLower(string(t.Type())), filter) {
+		return true
+	}
+	for name, value := range t.Labels() {
+		if strings.Contains(strings.ToLower(string(name)), filter) || strings.Contains(strings.ToLower(string(value)), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// targetTemplateFuncs are the template helpers shared by the targets and
+// service-discovery pages.
+func targetTemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"fileTargetDetails": func(details interface{}) map[string]int64 {
+			// you can't cast with a text template in go so this is a helper
+			return details.(map[string]int64)
+		},
+		"journalTargetDetails": func(details interface{}) map[string]string {
+			// you can't cast with a text template in go so this is a helper
+			return details.(map[string]string)
+		},
+	}
+}
+
+// targets serves the targets page, grouped namespace-style within each job
+// and optionally narrowed by the "filter" query parameter.
 func (s *PromtailServer) targets(rw http.ResponseWriter, req *http.Request) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
+	filter := req.URL.Query().Get("filter")
 	executeTemplate(req.Context(), rw, templateOptions{
 		Data: struct {
-			TargetPools map[string][]target.Target
+			TargetJobs map[string]jobView
+			Filter     string
 		}{
-			TargetPools: s.tms.ActiveTargets(),
-		},
-		BuildVersion: version.Info(),
-		Name:         "targets.html",
-		PageTitle:    "Targets",
-		ExternalURL:  s.externalURL,
-		TemplateFuncs: template.FuncMap{
-			"fileTargetDetails": func(details interface{}) map[string]int64 {
-				// you can't cast with a text template in go so this is a helper
-				return details.(map[string]int64)
-			},
-			"journalTargetDetails": func(details interface{}) map[string]string {
-				// you can't cast with a text template in go so this is a helper
-				return details.(map[string]string)
-			},
-			"numReady": func(ts []target.Target) (readies int) {
-				for _, t := range ts {
-					if t.Ready() {
-						readies++
-					}
-				}
-				return
-			},
+			TargetJobs: groupedTargets(s.tms.ActiveTargets(), filter),
+			Filter:     filter,
 		},
+		BuildVersion:  version.Info(),
+		Name:          "targets.html",
+		PageTitle:     "Targets",
+		ExternalURL:   s.externalURL,
+		TemplateFuncs: targetTemplateFuncs(),
 	})
 }
 
+// targetsJSON serves the same namespace-style grouping as targets, as JSON,
+// for callers that want to consume it programmatically.
+func (s *PromtailServer) targetsJSON(rw http.ResponseWriter, req *http.Request) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	filter := req.URL.Query().Get("filter")
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(groupedTargets(s.tms.ActiveTargets(), filter)); err != nil {
+		level.Error(s.log).Log("msg", "error writing targets response", "error", err)
+	}
+}
+
 func (s *PromtailServer) reload(rw http.ResponseWriter, _ *http.Request) {
 	rc := make(chan error)
 	s.reloadCh <- rc
@@ -295,6 +415,53 @@ func (s *PromtailServer) ready(rw http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+// clientHealthStatus is unhealthy once the push success rate drops below
+// this threshold.
+const clientHealthMinSuccessRate = 0.9
+
+// clientHealth serves a health check endpoint that reflects the ratio of
+// log entries successfully pushed to Loki versus dropped since startup.
+func (s *PromtailServer) clientHealth(rw http.ResponseWriter, _ *http.Request) {
+	rate := 1.0
+	if s.clientMetrics != nil {
+		rate = s.clientMetrics.PushSuccessRate()
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if rate < clientHealthMinSuccessRate {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	resp := struct {
+		SuccessRate float64 `json:"success_rate"`
+		Healthy     bool    `json:"healthy"`
+	}{
+		SuccessRate: rate,
+		Healthy:     rate >= clientHealthMinSuccessRate,
+	}
+	if err := json.NewEncoder(rw).Encode(resp); err != nil {
+		level.Error(s.log).Log("msg", "error writing client health response", "error", err)
+	}
+}
+
+// clientChargeback serves the lifetime shipped/dropped byte and line totals
+// per chargeback key, so they can be exported without having to run PromQL
+// over the equivalent CounterVecs.
+func (s *PromtailServer) clientChargeback(rw http.ResponseWriter, _ *http.Request) {
+	var totals map[string]client.ChargebackTotals
+	if s.clientMetrics != nil {
+		totals = s.clientMetrics.ChargebackSnapshot()
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(totals); err != nil {
+		level.Error(s.log).Log("msg", "error writing client chargeback response", "error", err)
+	}
+}
+
 // computeExternalURL computes a sanitized external URL from a raw input. It infers unset
 // URL parts from the OS and the given listen address.
 func computeExternalURL(u string, port int) (*url.URL, error) {
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+)
+
+type fakeTarget struct {
+	target.Target
+	typ    target.TargetType
+	labels model.LabelSet
+	ready  bool
+}
+
+func (f *fakeTarget) Type() target.TargetType        { return f.typ }
+func (f *fakeTarget) Labels() model.LabelSet         { return f.labels }
+func (f *fakeTarget) DiscoveredLabels() model.LabelSet { return f.labels }
+func (f *fakeTarget) Ready() bool                    { return f.ready }
+func (f *fakeTarget) Details() interface{}           { return nil }
+
+func Test_groupedTargets(t *testing.T) {
+	pools := map[string][]target.Target{
+		"docker": {
+			&fakeTarget{typ: target.DockerTargetType, labels: model.LabelSet{"project": "web"}, ready: true},
+			&fakeTarget{typ: target.DockerTargetType, labels: model.LabelSet{"project": "web"}, ready: false},
+			&fakeTarget{typ: target.DockerTargetType, labels: model.LabelSet{"namespace": "billing"}, ready: true},
+		},
+		"varlogs": {
+			&fakeTarget{typ: target.FileTargetType, labels: model.LabelSet{"job": "varlogs"}, ready: true},
+		},
+	}
+
+	t.Run("groups within a job by GroupForTarget", func(t *testing.T) {
+		jobs := groupedTargets(pools, "")
+		docker := jobs["docker"]
+		require.Equal(t, 2, docker.Ready)
+		require.Equal(t, 3, docker.Total)
+		require.Len(t, docker.Groups, 2)
+		require.Equal(t, "billing", docker.Groups[0].Name)
+		require.Equal(t, "web", docker.Groups[1].Name)
+		require.Len(t, docker.Groups[1].Targets, 2)
+	})
+
+	t.Run("falls back to the job label when a target has no other group", func(t *testing.T) {
+		jobs := groupedTargets(pools, "")
+		varlogs := jobs["varlogs"]
+		require.Len(t, varlogs.Groups, 1)
+		require.Equal(t, "varlogs", varlogs.Groups[0].Name)
+	})
+
+	t.Run("filter narrows to matching targets only", func(t *testing.T) {
+		jobs := groupedTargets(pools, "billing")
+		require.Len(t, jobs, 1)
+		docker := jobs["docker"]
+		require.Len(t, docker.Groups, 1)
+		require.Equal(t, "billing", docker.Groups[0].Name)
+	})
+
+	t.Run("filter matches job names too", func(t *testing.T) {
+		jobs := groupedTargets(pools, "varlogs")
+		require.Len(t, jobs, 1)
+		_, ok := jobs["varlogs"]
+		require.True(t, ok)
+	})
+
+	t.Run("filter matching nothing returns no jobs", func(t *testing.T) {
+		jobs := groupedTargets(pools, "does-not-exist")
+		require.Empty(t, jobs)
+	})
+}
@@ -0,0 +1,107 @@
+package docker
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// defaultAdaptiveBufferFloor is the smallest size adaptiveBufferSizer ever
+// hands back, so a container with tiny lines doesn't leave payloadAcc
+// growing by a handful of bytes on every other line.
+const defaultAdaptiveBufferFloor = 256
+
+// adaptiveBufferReevalInterval is how many line-size observations
+// adaptiveBufferSizer collects before it recomputes its p99 and updates the
+// size it hands out. Recomputing on every observation would mean sorting a
+// few hundred samples per log line; batching amortizes that cost, at the
+// price of sizing responding to a shift in line size a batch late instead
+// of immediately.
+const adaptiveBufferReevalInterval = 64
+
+// adaptiveBufferSamples bounds how many of the most recent line sizes
+// adaptiveBufferSizer keeps for its p99 estimate. Older observations are
+// evicted round-robin, so the estimate tracks a container's recent line
+// sizes rather than its all-time distribution.
+const adaptiveBufferSamples = 512
+
+// adaptiveBufferSizer tracks a rolling p99 of recently observed line sizes
+// for one Docker target, across both its stdout and stderr streams, and
+// uses it to size how much process pre-grows payloadAcc before accumulating
+// a multi-frame line: large enough that most lines don't force a
+// reallocation, without permanently paying for a worst-case buffer on a
+// container that mostly emits small lines. See Target.process and
+// Target.Details.
+type adaptiveBufferSizer struct {
+	floor, ceiling int
+
+	mtx     sync.Mutex
+	samples [adaptiveBufferSamples]int
+	count   int // total observations recorded; wraps into samples round-robin
+	current int
+}
+
+// newAdaptiveBufferSizer creates an adaptiveBufferSizer whose size() is
+// always between floor and ceiling. current starts at floor, since there's
+// no observed line size distribution yet.
+func newAdaptiveBufferSizer(floor, ceiling int) *adaptiveBufferSizer {
+	if ceiling < floor {
+		ceiling = floor
+	}
+	return &adaptiveBufferSizer{
+		floor:   floor,
+		ceiling: ceiling,
+		current: floor,
+	}
+}
+
+// observe records a completed line's size. Every
+// adaptiveBufferReevalInterval observations it recomputes size() from the
+// rolling p99 of the last adaptiveBufferSamples sizes seen.
+func (a *adaptiveBufferSizer) observe(n int) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	a.samples[a.count%adaptiveBufferSamples] = n
+	a.count++
+
+	if a.count%adaptiveBufferReevalInterval == 0 {
+		a.recompute()
+	}
+}
+
+// recompute updates a.current from the p99 of the samples collected so far.
+// Callers must hold a.mtx.
+func (a *adaptiveBufferSizer) recompute() {
+	filled := a.count
+	if filled > adaptiveBufferSamples {
+		filled = adaptiveBufferSamples
+	}
+
+	sorted := make([]int, filled)
+	copy(sorted, a.samples[:filled])
+	sort.Ints(sorted)
+
+	idx := int(math.Ceil(0.99*float64(filled))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	p99 := sorted[idx]
+
+	switch {
+	case p99 < a.floor:
+		a.current = a.floor
+	case p99 > a.ceiling:
+		a.current = a.ceiling
+	default:
+		a.current = p99
+	}
+}
+
+// size returns the buffer size to pre-grow to right now, between floor and
+// ceiling.
+func (a *adaptiveBufferSizer) size() int {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.current
+}
@@ -0,0 +1,139 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AdaptiveBufferSizer_StartsAtFloor(t *testing.T) {
+	a := newAdaptiveBufferSizer(256, 64*1024)
+	require.Equal(t, 256, a.size(), "size() should be the floor before any observations")
+}
+
+func Test_AdaptiveBufferSizer_TracksP99WithinBounds(t *testing.T) {
+	a := newAdaptiveBufferSizer(256, 64*1024)
+
+	// 1000-byte lines, well within [floor, ceiling], should pull size() up
+	// off the floor once enough observations have accumulated to trigger a
+	// recompute.
+	for i := 0; i < adaptiveBufferReevalInterval; i++ {
+		a.observe(1000)
+	}
+	require.Equal(t, 1000, a.size())
+}
+
+func Test_AdaptiveBufferSizer_ClampsToCeiling(t *testing.T) {
+	a := newAdaptiveBufferSizer(256, 4096)
+
+	for i := 0; i < adaptiveBufferReevalInterval; i++ {
+		a.observe(1 << 20)
+	}
+	require.Equal(t, 4096, a.size(), "size() should never exceed ceiling even for oversized lines")
+}
+
+func Test_AdaptiveBufferSizer_ClampsToFloor(t *testing.T) {
+	a := newAdaptiveBufferSizer(256, 4096)
+
+	for i := 0; i < adaptiveBufferReevalInterval; i++ {
+		a.observe(10)
+	}
+	require.Equal(t, 256, a.size(), "size() should never go below floor even for tiny lines")
+}
+
+func Test_AdaptiveBufferSizer_IgnoresOutliersAtP99(t *testing.T) {
+	a := newAdaptiveBufferSizer(256, 64*1024)
+
+	// A handful of 50KB outliers (under 1% of a full sample window)
+	// shouldn't move the p99 past the 200-byte bulk of the distribution.
+	// This needs the full sample window rather than just one reeval
+	// interval: p99 of a small batch (e.g. 64 samples) degenerates to the
+	// max, since nearest-rank rounds 0.99*64 up to the last index. The
+	// 200-byte bulk itself is below the 256-byte floor, so size() clamps
+	// up to the floor rather than reporting the raw p99.
+	for i := 0; i < adaptiveBufferSamples-5; i++ {
+		a.observe(200)
+	}
+	for i := 0; i < 5; i++ {
+		a.observe(50 * 1024)
+	}
+	require.Equal(t, 256, a.size())
+}
+
+func Test_AdaptiveBufferSizer_RollsOffStaleSamples(t *testing.T) {
+	a := newAdaptiveBufferSizer(256, 64*1024)
+
+	for i := 0; i < adaptiveBufferSamples; i++ {
+		a.observe(8192)
+	}
+	require.Equal(t, 8192, a.size())
+
+	// Once enough small lines have cycled through to evict every 8192-byte
+	// sample, size() should track the new, smaller distribution instead of
+	// remembering the old one forever.
+	for i := 0; i < adaptiveBufferSamples; i++ {
+		a.observe(300)
+	}
+	require.Equal(t, 300, a.size())
+}
+
+func Test_AdaptiveBufferSizer_InvalidBoundsFallBackToFloor(t *testing.T) {
+	a := newAdaptiveBufferSizer(4096, 1024)
+	require.Equal(t, 4096, a.size(), "a ceiling below floor should widen to floor rather than produce an unusable range")
+}
+
+// mixedLineSizes builds a realistic distribution: mostly short lines with an
+// occasional large, JSON-heavy one, matching the profile described in the
+// request this sizing was added for.
+func mixedLineSizes(n int) []int {
+	sizes := make([]int, n)
+	for i := range sizes {
+		if i%50 == 0 {
+			sizes[i] = 48 * 1024
+		} else {
+			sizes[i] = 200
+		}
+	}
+	return sizes
+}
+
+// Benchmark_AdaptiveBufferGrow compares payloadAcc.Grow pre-sized from
+// adaptiveBufferSizer's rolling p99 against always pre-growing to a fixed
+// 256KB max_line_size buffer, across the mixed line size distribution
+// mixedLineSizes produces. The adaptive variant should report far fewer
+// allocated bytes, since it grows most lines' buffers to roughly their
+// actual size instead of the worst case every time.
+func Benchmark_AdaptiveBufferGrow(b *testing.B) {
+	sizes := mixedLineSizes(1000)
+	payloads := make([]string, len(sizes))
+	for i, n := range sizes {
+		payloads[i] = strings.Repeat("a", n)
+	}
+
+	b.Run("fixed_max", func(b *testing.B) {
+		const fixedSize = 256 * 1024
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var acc strings.Builder
+			acc.Grow(fixedSize)
+			acc.WriteString(payloads[i%len(payloads)])
+		}
+	})
+
+	b.Run("adaptive", func(b *testing.B) {
+		a := newAdaptiveBufferSizer(defaultAdaptiveBufferFloor, 256*1024)
+		for _, n := range sizes {
+			a.observe(n)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var acc strings.Builder
+			acc.Grow(a.size())
+			acc.WriteString(payloads[i%len(payloads)])
+		}
+	})
+}
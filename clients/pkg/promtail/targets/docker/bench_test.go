@@ -0,0 +1,238 @@
+package docker
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+)
+
+// perLineAllocBudget bounds the number of heap allocations
+// Benchmark_ProcessLine's per-line path (extractTs plus handleOutput) may
+// make for a single log line, now that handleOutput pools its
+// labels.Builder (see labelBuilderPool) and extractTs uses strings.Cut
+// instead of strings.SplitN to avoid a throwaway slice. It isn't zero:
+// filtered still allocates a fresh model.LabelSet per line, and
+// labels.Builder.Labels() has to allocate its result slice since it
+// escapes the builder. Test_ProcessLine_AllocBudget enforces it, so a
+// change that reintroduces a per-line Builder, an unbounded label copy, or
+// a re-slicing string copy fails CI instead of quietly regressing
+// throughput at the ~50k lines/sec/host this target is run at.
+const perLineAllocBudget = 8
+
+// discardHandler is an api.EntryHandler that drops every entry it receives
+// instead of accumulating them, so a benchmark or allocation test measuring
+// the docker target's own per-line work isn't also charged for a test
+// handler's bookkeeping (e.g. client/fake.Client appends every entry it
+// receives to a slice).
+func newDiscardHandler() api.EntryHandler {
+	entries := make(chan api.Entry)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range entries {
+		}
+	}()
+	return api.NewEntryHandler(entries, func() {
+		close(entries)
+		<-done
+	})
+}
+
+func newBenchTarget(tb testing.TB, labels model.LabelSet, relabelConfig []*relabel.Config) *Target {
+	tb.Helper()
+
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: tb.TempDir() + "/positions.yml",
+	})
+	require.NoError(tb, err)
+	tb.Cleanup(ps.Stop)
+
+	handler := newDiscardHandler()
+	tb.Cleanup(handler.Stop)
+
+	return &Target{
+		logger:        log.NewNopLogger(),
+		handler:       handler,
+		positions:     ps,
+		containerName: "bench",
+		labels:        labels,
+		relabelConfig: relabelConfig,
+		metrics:       NewMetrics(nil),
+		levelLabel:    defaultLevelLabel,
+		paused:        atomic.NewBool(false),
+	}
+}
+
+// dockerFrame builds a single already-timestamped Docker log frame carrying
+// payload, the shape FramedStdCopy hands process() for one complete line.
+func dockerFrame(payload string) []byte {
+	return []byte(time.Now().UTC().Format(time.RFC3339Nano) + " " + payload + "\n")
+}
+
+// processLine runs a single frame through the same per-line path
+// process()'s fast path does: extract its timestamp, then hand the payload
+// to handleOutput. It's factored out of process() itself here so a
+// benchmark or AllocsPerRun run measures only that per-line work, not the
+// one-time cost of standing up a frameBuffer and its channel.
+func processLine(t *Target, stream string, frame []byte) {
+	ts, payload, _ := extractTs(string(frame))
+	t.handleOutput(stream, ts, payload)
+}
+
+var lineSizes = []struct {
+	name    string
+	payload string
+}{
+	{"short", "level=info msg=\"request served\" status=200 duration=12ms"},
+	{"long", fmt.Sprintf("level=info msg=%q status=200 duration=12ms trace_id=abcdef0123456789", stringOfLen(2048))},
+}
+
+var labelCounts = []struct {
+	name   string
+	labels model.LabelSet
+}{
+	{"few_labels", model.LabelSet{"job": "docker", "container": "bench"}},
+	{"many_labels", manyLabels(20)},
+}
+
+func stringOfLen(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = 'a'
+	}
+	return string(b)
+}
+
+func manyLabels(n int) model.LabelSet {
+	ls := make(model.LabelSet, n)
+	for i := 0; i < n; i++ {
+		ls[model.LabelName(fmt.Sprintf("label_%d", i))] = model.LabelValue(fmt.Sprintf("value_%d", i))
+	}
+	return ls
+}
+
+// Benchmark_ProcessLine covers the full per-line path (extractTs, then
+// handleOutput's label building, relabeling, and filtering) across the
+// realistic combinations of line size and discovered label count this
+// target sees in practice.
+func Benchmark_ProcessLine(b *testing.B) {
+	for _, size := range lineSizes {
+		for _, lc := range labelCounts {
+			b.Run(size.name+"/"+lc.name, func(b *testing.B) {
+				target := newBenchTarget(b, lc.labels, []*relabel.Config{})
+				frame := dockerFrame(size.payload)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					processLine(target, "stdout", frame)
+				}
+			})
+		}
+	}
+}
+
+// Test_ProcessLine_AllocBudget enforces perLineAllocBudget against the
+// few_labels/short combination Benchmark_ProcessLine also covers, using
+// testing.AllocsPerRun rather than -benchmem so it runs (and fails CI) as
+// part of `go test`, without needing -bench.
+func Test_ProcessLine_AllocBudget(t *testing.T) {
+	target := newBenchTarget(t, labelCounts[0].labels, []*relabel.Config{})
+	frame := dockerFrame(lineSizes[0].payload)
+
+	avg := testing.AllocsPerRun(200, func() {
+		processLine(target, "stdout", frame)
+	})
+	t.Logf("avg allocs per line: %.1f (budget %d)", avg, perLineAllocBudget)
+	require.LessOrEqual(t, avg, float64(perLineAllocBudget))
+}
+
+// countingHandler is an api.EntryHandler that counts the entries it
+// receives instead of accumulating them, so BenchmarkDockerTargetProcess
+// can tell when the whole stream it wrote has come out the other end
+// without paying for a slice growing to hundreds of thousands of entries.
+func countingHandler() (api.EntryHandler, *atomic.Int64) {
+	var count atomic.Int64
+	entries := make(chan api.Entry)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range entries {
+			count.Inc()
+		}
+	}()
+	return api.NewEntryHandler(entries, func() {
+		close(entries)
+		<-done
+	}), &count
+}
+
+// BenchmarkDockerTargetProcess drives a Target's whole log pipeline end to
+// end, rather than just the per-line path Benchmark_ProcessLine covers: a
+// stopRaceAPIClient (see target_test.go) hands processLoop an io.Pipe as
+// its container's log stream, and lines are written to it framed the same
+// way the real Docker daemon frames them (via stdcopy.NewStdWriter), so
+// FramedStdCopy, the frameBuffer, and process's scanner loop are all
+// exercised, not just extractTs and handleOutput. b.N lines are written
+// with realistic RFC3339Nano timestamps and logfmt-shaped payloads; ns/op
+// is therefore entries processed per second, and -benchmem/b.ReportAllocs
+// surfaces allocation hotspots across the whole pipeline, including
+// FramedStdCopy's own framing and the frameBuffer's frame copies, that
+// Benchmark_ProcessLine's narrower scope can't see.
+func BenchmarkDockerTargetProcess(b *testing.B) {
+	pr, pw := io.Pipe()
+	handler, processed := countingHandler()
+	b.Cleanup(handler.Stop)
+
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: b.TempDir() + "/positions.yml",
+	})
+	require.NoError(b, err)
+	b.Cleanup(ps.Stop)
+
+	target, err := NewTarget(
+		NewMetrics(nil),
+		log.NewNopLogger(),
+		handler,
+		ps,
+		"bench",
+		model.LabelSet{"job": "docker", "container": "bench"},
+		[]*relabel.Config{},
+		&stopRaceAPIClient{logs: pr},
+		0, "", false, 0, 0, "", "", false, false, "", "", "", nil, nil, "", false,
+	)
+	require.NoError(b, err)
+	b.Cleanup(target.Stop)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	go func() {
+		out := stdcopy.NewStdWriter(pw, stdcopy.Stdout)
+		for i := 0; i < b.N; i++ {
+			line := fmt.Sprintf("%s level=info msg=\"request served\" status=200 duration=12ms line=%d\n",
+				time.Now().UTC().Format(time.RFC3339Nano), i)
+			if _, err := out.Write([]byte(line)); err != nil {
+				return
+			}
+		}
+	}()
+
+	for processed.Load() < int64(b.N) {
+		time.Sleep(time.Microsecond)
+	}
+	b.StopTimer()
+}
@@ -0,0 +1,89 @@
+package docker
+
+import "go.uber.org/atomic"
+
+// Defaults for frameBuffer capacity, used when a DockerTargetConfig leaves
+// ReadBufferEntries/ReadBufferBytes at zero.
+const (
+	defaultBufferEntries = 1000
+	defaultBufferBytes   = 4 * 1024 * 1024
+)
+
+// frameBuffer is a bounded, non-blocking buffer of raw Docker log frames
+// sitting between the container's log reader and process(). Docker's log
+// stream has no backpressure signal of its own, so without a bound here a
+// stalled handler downstream would make the reader block indefinitely, and
+// a sufficiently chatty container could grow an unbounded buffer without
+// limit. When full, frameBuffer drops the incoming frame and counts it as
+// an overflow instead of blocking the caller.
+type frameBuffer struct {
+	frames   chan []byte
+	maxBytes int
+	bytes    *atomic.Int64
+
+	metrics   *Metrics
+	container string
+	stream    string
+}
+
+// newFrameBuffer creates a frameBuffer for one container/stream pair.
+// maxEntries and maxBytes fall back to defaultBufferEntries/defaultBufferBytes
+// when zero or negative.
+func newFrameBuffer(metrics *Metrics, container, stream string, maxEntries, maxBytes int) *frameBuffer {
+	if maxEntries <= 0 {
+		maxEntries = defaultBufferEntries
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultBufferBytes
+	}
+	return &frameBuffer{
+		frames:    make(chan []byte, maxEntries),
+		maxBytes:  maxBytes,
+		bytes:     atomic.NewInt64(0),
+		metrics:   metrics,
+		container: container,
+		stream:    stream,
+	}
+}
+
+// push queues frame for a later pop. If the buffer is already at its entry
+// or byte capacity, frame is dropped and counted in bufferDropped rather
+// than blocking the caller.
+func (b *frameBuffer) push(frame []byte) {
+	if b.bytes.Load()+int64(len(frame)) > int64(b.maxBytes) {
+		b.metrics.bufferDropped.WithLabelValues(b.container, b.stream).Inc()
+		return
+	}
+	select {
+	case b.frames <- frame:
+		b.bytes.Add(int64(len(frame)))
+		b.metrics.bufferOccupancy.WithLabelValues(b.container, b.stream).Set(float64(len(b.frames)))
+	default:
+		b.metrics.bufferDropped.WithLabelValues(b.container, b.stream).Inc()
+	}
+}
+
+// pop blocks until a frame is available or close has drained the buffer, in
+// which case ok is false, mirroring range over a channel.
+func (b *frameBuffer) pop() (frame []byte, ok bool) {
+	frame, ok = <-b.frames
+	if ok {
+		b.bytes.Sub(int64(len(frame)))
+		b.metrics.bufferOccupancy.WithLabelValues(b.container, b.stream).Set(float64(len(b.frames)))
+	}
+	return frame, ok
+}
+
+// close signals that no more frames will be pushed. Buffered frames already
+// queued remain available to pop until drained.
+func (b *frameBuffer) close() {
+	close(b.frames)
+}
+
+// unregisterMetrics removes this buffer's series from bufferOccupancy and
+// bufferDropped, so a container's metrics don't linger once its target
+// stops.
+func (b *frameBuffer) unregisterMetrics() {
+	b.metrics.bufferOccupancy.DeleteLabelValues(b.container, b.stream)
+	b.metrics.bufferDropped.DeleteLabelValues(b.container, b.stream)
+}
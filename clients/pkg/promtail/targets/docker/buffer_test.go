@@ -0,0 +1,70 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FrameBuffer_BlocksUntilCapThenResumes(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	buf := newFrameBuffer(metrics, "container", "stdout", 2, 1024)
+
+	// Fill the buffer to its entry cap; each push should succeed since
+	// nothing has consumed yet.
+	buf.push([]byte("one"))
+	buf.push([]byte("two"))
+	require.Len(t, buf.frames, 2)
+
+	// A push past the cap is dropped instead of blocking the caller.
+	buf.push([]byte("three"))
+	require.Len(t, buf.frames, 2)
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.bufferDropped.WithLabelValues("container", "stdout")))
+
+	// Draining makes room again, and subsequent pushes/pops resume cleanly.
+	frame, ok := buf.pop()
+	require.True(t, ok)
+	require.Equal(t, "one", string(frame))
+
+	buf.push([]byte("four"))
+	require.Len(t, buf.frames, 2)
+
+	frame, ok = buf.pop()
+	require.True(t, ok)
+	require.Equal(t, "two", string(frame))
+
+	frame, ok = buf.pop()
+	require.True(t, ok)
+	require.Equal(t, "four", string(frame))
+}
+
+func Test_FrameBuffer_DropsOnByteLimit(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	buf := newFrameBuffer(metrics, "container", "stdout", 100, 10)
+
+	buf.push([]byte("12345"))
+	buf.push([]byte("12345"))
+	require.Len(t, buf.frames, 2)
+
+	// The buffer has room for two more entries but not two more bytes.
+	buf.push([]byte("x"))
+	require.Len(t, buf.frames, 2, "push exceeding the byte limit should be dropped even though the entry cap isn't reached")
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.bufferDropped.WithLabelValues("container", "stdout")))
+}
+
+func Test_FrameBuffer_CloseDrainsThenEnds(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	buf := newFrameBuffer(metrics, "container", "stdout", 10, 1024)
+
+	buf.push([]byte("one"))
+	buf.close()
+
+	frame, ok := buf.pop()
+	require.True(t, ok)
+	require.Equal(t, "one", string(frame))
+
+	_, ok = buf.pop()
+	require.False(t, ok, "pop should report false once a closed buffer is drained")
+}
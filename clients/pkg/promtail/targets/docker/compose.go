@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+)
+
+const (
+	// dockerLabelContainerLabelPrefix mirrors the meta-label prefix that
+	// github.com/prometheus/prometheus/discovery/moby applies to every
+	// container label it discovers, after sanitizing the label name (dots
+	// and other unsupported characters become underscores).
+	dockerLabelContainerLabelPrefix = dockerLabelContainerPrefix + "label_"
+
+	composeProjectContainerLabel   = dockerLabelContainerLabelPrefix + "com_docker_compose_project"
+	composeServiceContainerLabel   = dockerLabelContainerLabelPrefix + "com_docker_compose_service"
+	swarmServiceNameContainerLabel = dockerLabelContainerLabelPrefix + "com_docker_swarm_service_name"
+
+	defaultComposeProjectLabel = "compose_project"
+	defaultComposeServiceLabel = "compose_service"
+	defaultSwarmServiceLabel   = "service"
+)
+
+// orchestratorLabels derives Compose and/or Swarm stream labels from the
+// container labels discovered for a single target, according to cfg. It
+// returns an empty set if none of the relevant options are enabled or none
+// of the underlying container labels are present.
+func orchestratorLabels(cfg scrapeconfig.DockerTargetConfig, discoveredLabels model.LabelSet) model.LabelSet {
+	labels := model.LabelSet{}
+
+	if cfg.ComposeLabels {
+		projectLabel := model.LabelName(cfg.ComposeProjectLabel)
+		if projectLabel == "" {
+			projectLabel = defaultComposeProjectLabel
+		}
+		serviceLabel := model.LabelName(cfg.ComposeServiceLabel)
+		if serviceLabel == "" {
+			serviceLabel = defaultComposeServiceLabel
+		}
+		if v, ok := discoveredLabels[composeProjectContainerLabel]; ok {
+			labels[projectLabel] = v
+		}
+		if v, ok := discoveredLabels[composeServiceContainerLabel]; ok {
+			labels[serviceLabel] = v
+		}
+	}
+
+	if cfg.SwarmLabels {
+		serviceLabel := model.LabelName(cfg.SwarmServiceLabel)
+		if serviceLabel == "" {
+			serviceLabel = defaultSwarmServiceLabel
+		}
+		if v, ok := discoveredLabels[swarmServiceNameContainerLabel]; ok {
+			labels[serviceLabel] = v
+		}
+	}
+
+	return labels
+}
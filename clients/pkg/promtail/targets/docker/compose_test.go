@@ -0,0 +1,77 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+)
+
+func Test_orchestratorLabels(t *testing.T) {
+	composeContainer := model.LabelSet{
+		composeProjectContainerLabel: "myproject",
+		composeServiceContainerLabel: "web",
+	}
+	swarmContainer := model.LabelSet{
+		swarmServiceNameContainerLabel: "myservice",
+	}
+	plainContainer := model.LabelSet{
+		dockerLabelContainerID: "abc123",
+	}
+
+	tests := []struct {
+		name       string
+		cfg        scrapeconfig.DockerTargetConfig
+		discovered model.LabelSet
+		want       model.LabelSet
+	}{
+		{
+			name:       "compose labels disabled",
+			cfg:        scrapeconfig.DockerTargetConfig{},
+			discovered: composeContainer,
+			want:       model.LabelSet{},
+		},
+		{
+			name:       "compose labels enabled with defaults",
+			cfg:        scrapeconfig.DockerTargetConfig{ComposeLabels: true},
+			discovered: composeContainer,
+			want:       model.LabelSet{"compose_project": "myproject", "compose_service": "web"},
+		},
+		{
+			name: "compose labels enabled with custom names",
+			cfg: scrapeconfig.DockerTargetConfig{
+				ComposeLabels:       true,
+				ComposeProjectLabel: "compose_project",
+				ComposeServiceLabel: "compose_service",
+			},
+			discovered: composeContainer,
+			want:       model.LabelSet{"compose_project": "myproject", "compose_service": "web"},
+		},
+		{
+			name:       "swarm labels enabled with defaults",
+			cfg:        scrapeconfig.DockerTargetConfig{SwarmLabels: true},
+			discovered: swarmContainer,
+			want:       model.LabelSet{"service": "myservice"},
+		},
+		{
+			name:       "swarm labels enabled but container is not part of a swarm service",
+			cfg:        scrapeconfig.DockerTargetConfig{SwarmLabels: true},
+			discovered: plainContainer,
+			want:       model.LabelSet{},
+		},
+		{
+			name:       "compose labels enabled but container is plain docker",
+			cfg:        scrapeconfig.DockerTargetConfig{ComposeLabels: true},
+			discovered: plainContainer,
+			want:       model.LabelSet{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, orchestratorLabels(tc.cfg, tc.discovered))
+		})
+	}
+}
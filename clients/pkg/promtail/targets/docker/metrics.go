@@ -1,13 +1,45 @@
 package docker
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+)
 
 // Metrics holds a set of Docker target metrics.
 type Metrics struct {
 	reg prometheus.Registerer
 
-	dockerEntries prometheus.Counter
-	dockerErrors  prometheus.Counter
+	dockerErrors                 prometheus.Counter
+	dockerTargetsLimited         prometheus.Counter
+	dockerTargetsPaused          prometheus.Gauge
+	dockerTargetsSkippedPlatform prometheus.Counter
+
+	// swarmEnrichmentErrors counts failed swarmEnricher lookups (inspect or
+	// DNS), whether or not they left some labels populated; see
+	// swarmEnricher.resolveLabels.
+	swarmEnrichmentErrors prometheus.Counter
+
+	// normalizedLines counts lines Target.finalizeLine actually changed,
+	// i.e. ones that had trailing spaces or tabs to trim. Only incremented
+	// when scrapeconfig.DockerTargetConfig.NormalizeWhitespace is set.
+	normalizedLines prometheus.Counter
+
+	// bufferOccupancy tracks how many parsed log frames are currently
+	// buffered per container and stream (stdout/stderr) between the
+	// container's log reader and the point where entries are handed off
+	// to the handler, so backpressure is visible before it turns into
+	// bufferDropped.
+	bufferOccupancy *prometheus.GaugeVec
+	// bufferDropped counts frames dropped because a container's read
+	// buffer was full when the frame arrived, per container and stream.
+	bufferDropped *prometheus.CounterVec
+
+	// entryHandler tracks entries and bytes forwarded per container, in
+	// place of a Docker-specific entries counter, so this accounting is
+	// shared with every other target type that wraps its handler with
+	// api.NewInstrumentedEntryHandler.
+	entryHandler *api.EntryHandlerMetrics
 }
 
 // NewMetrics creates a new set of Docker target metrics. If reg is non-nil, the
@@ -16,23 +48,60 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 	var m Metrics
 	m.reg = reg
 
-	m.dockerEntries = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: "promtail",
-		Name:      "docker_target_entries_total",
-		Help:      "Total number of successful entries sent to the Docker target",
-	})
 	m.dockerErrors = prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: "promtail",
 		Name:      "docker_target_parsing_errors_total",
 		Help:      "Total number of parsing errors while receiving Docker messages",
 	})
+	m.dockerTargetsLimited = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "docker_targets_limited_total",
+		Help:      "Total number of times a Docker container was queued instead of started because max_containers was reached",
+	})
+	m.dockerTargetsPaused = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "promtail",
+		Name:      "docker_targets_paused",
+		Help:      "Number of Docker targets currently paused because pause_label is set on their container",
+	})
+	m.dockerTargetsSkippedPlatform = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "docker_targets_skipped_platform_total",
+		Help:      "Total number of times a Docker container was skipped because its platform isn't one promtail can read logs from",
+	})
+	m.bufferOccupancy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "promtail",
+		Name:      "docker_target_read_buffer_entries",
+		Help:      "Number of parsed log frames currently buffered for a container, waiting to be handed off to the handler",
+	}, []string{"container", "stream"})
+	m.bufferDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "docker_target_read_buffer_dropped_total",
+		Help:      "Total number of log frames dropped because a container's read buffer was full",
+	}, []string{"container", "stream"})
+	m.swarmEnrichmentErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "docker_target_swarm_enrichment_errors_total",
+		Help:      "Total number of failed Swarm node hostname or service VIP lookups",
+	})
+	m.normalizedLines = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "docker_target_normalized_lines_total",
+		Help:      "Total number of lines with trailing whitespace trimmed by normalize_whitespace",
+	})
 
 	if reg != nil {
 		reg.MustRegister(
-			m.dockerEntries,
 			m.dockerErrors,
+			m.dockerTargetsLimited,
+			m.dockerTargetsPaused,
+			m.dockerTargetsSkippedPlatform,
+			m.bufferOccupancy,
+			m.bufferDropped,
+			m.swarmEnrichmentErrors,
+			m.normalizedLines,
 		)
 	}
+	m.entryHandler = api.NewEntryHandlerMetrics(reg, nil)
 
 	return &m
 }
@@ -0,0 +1,136 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// swarmServiceNameLabel is the raw container label the Swarm scheduler sets
+// to the container's service name, as returned by ContainerInspect. It's
+// the label Swarm's embedded DNS server resolves to the service's virtual
+// IP inside the overlay network, which is why resolveLabels looks it up
+// with swarmResolver rather than reading an IP off the container itself.
+const swarmServiceNameLabel = "com.docker.swarm.service.name"
+
+// swarmEnrichmentTTL bounds how long a swarmEnricher trusts a cached
+// result before resolving again. It's a var rather than a const so tests
+// can shrink it.
+var swarmEnrichmentTTL = 5 * time.Minute
+
+// swarmResolver looks up the addresses behind a hostname. It's satisfied by
+// *net.Resolver in production and faked in tests, since a real DNS lookup
+// against the Swarm-internal resolver isn't available outside a running
+// Swarm.
+type swarmResolver interface {
+	LookupHost(ctx context.Context, host string) (addrs []string, err error)
+}
+
+// inspector is the subset of client.APIClient swarmEnricher needs, so tests
+// can fake ContainerInspect without standing up a full Docker client.
+type inspector interface {
+	ContainerInspect(ctx context.Context, container string) (types.ContainerJSON, error)
+}
+
+// swarmEnrichResult is a cached lookup outcome, valid until expiresAt.
+type swarmEnrichResult struct {
+	labels    model.LabelSet
+	expiresAt time.Time
+}
+
+// swarmEnricher resolves the __meta_docker_container_swarm_node_hostname
+// and __meta_docker_container_swarm_service_vip labels for a container,
+// caching the result per container ID for swarmEnrichmentTTL so a target
+// reconnecting doesn't re-inspect and re-resolve on every attempt. It's
+// shared by every target in a targetGroup, the same way tg.metrics is.
+//
+// Lookup failures never propagate to the caller: Enrich logs and counts
+// them, then returns whatever labels it managed to gather (possibly none),
+// so a slow or unreachable resolver can only ever narrow the label set, not
+// block a target's hot path.
+type swarmEnricher struct {
+	logger           log.Logger
+	client           inspector
+	resolve          swarmResolver
+	enrichmentErrors prometheus.Counter
+
+	mtx   sync.Mutex
+	cache map[string]swarmEnrichResult
+}
+
+// newSwarmEnricher creates a swarmEnricher that inspects containers via
+// client and resolves service hostnames via the system resolver.
+func newSwarmEnricher(logger log.Logger, client inspector, enrichmentErrors prometheus.Counter) *swarmEnricher {
+	return &swarmEnricher{
+		logger:           logger,
+		client:           client,
+		resolve:          net.DefaultResolver,
+		enrichmentErrors: enrichmentErrors,
+		cache:            make(map[string]swarmEnrichResult),
+	}
+}
+
+// Enrich returns the Swarm node hostname and service VIP labels for
+// containerID, resolving them if there's no unexpired cache entry. Callers
+// are expected to call it once per connection attempt (e.g. from
+// processLoop, on every reconnect), not per log entry.
+func (e *swarmEnricher) Enrich(ctx context.Context, containerID string) model.LabelSet {
+	e.mtx.Lock()
+	if cached, ok := e.cache[containerID]; ok && time.Now().Before(cached.expiresAt) {
+		e.mtx.Unlock()
+		return cached.labels
+	}
+	e.mtx.Unlock()
+
+	labels := e.resolveLabels(ctx, containerID)
+
+	e.mtx.Lock()
+	e.cache[containerID] = swarmEnrichResult{labels: labels, expiresAt: time.Now().Add(swarmEnrichmentTTL)}
+	e.mtx.Unlock()
+
+	return labels
+}
+
+// resolveLabels inspects containerID and, best-effort, resolves its Swarm
+// node hostname and service virtual IP. Any failure along the way is
+// counted against enrichmentErrors and simply omits the affected label
+// rather than being returned to the caller.
+func (e *swarmEnricher) resolveLabels(ctx context.Context, containerID string) model.LabelSet {
+	labels := model.LabelSet{}
+
+	info, err := e.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		e.enrichmentErrors.Inc()
+		level.Debug(e.logger).Log("msg", "could not inspect container for Swarm enrichment", "container", containerID, "err", err)
+		return labels
+	}
+
+	if info.Node != nil && info.Node.Name != "" {
+		labels[dockerLabelSwarmNodeHostname] = model.LabelValue(info.Node.Name)
+	}
+
+	serviceName := ""
+	if info.Config != nil {
+		serviceName = info.Config.Labels[swarmServiceNameLabel]
+	}
+	if serviceName == "" {
+		return labels
+	}
+
+	addrs, err := e.resolve.LookupHost(ctx, serviceName)
+	if err != nil || len(addrs) == 0 {
+		e.enrichmentErrors.Inc()
+		level.Debug(e.logger).Log("msg", "could not resolve Swarm service VIP", "container", containerID, "service", serviceName, "err", err)
+		return labels
+	}
+	labels[dockerLabelSwarmServiceVIP] = model.LabelValue(addrs[0])
+
+	return labels
+}
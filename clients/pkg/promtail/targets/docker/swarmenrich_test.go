@@ -0,0 +1,124 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInspector returns a fixed result (or error) for every ContainerInspect
+// call, regardless of the container ID passed in.
+type fakeInspector struct {
+	info types.ContainerJSON
+	err  error
+}
+
+func (f fakeInspector) ContainerInspect(_ context.Context, _ string) (types.ContainerJSON, error) {
+	return f.info, f.err
+}
+
+// fakeResolver returns fixed addresses (or an error) for every LookupHost
+// call, regardless of the host passed in.
+type fakeResolver struct {
+	addrs []string
+	err   error
+}
+
+func (f fakeResolver) LookupHost(_ context.Context, _ string) ([]string, error) {
+	return f.addrs, f.err
+}
+
+func swarmContainerInfo(nodeHostname, serviceName string) types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			Node: &types.ContainerNode{Name: nodeHostname},
+		},
+		Config: &container.Config{
+			Labels: map[string]string{swarmServiceNameLabel: serviceName},
+		},
+	}
+}
+
+func Test_SwarmEnricher_Enrich(t *testing.T) {
+	t.Run("resolves both labels", func(t *testing.T) {
+		e := newSwarmEnricher(log.NewNopLogger(), fakeInspector{info: swarmContainerInfo("node-1", "myservice")}, prometheus.NewCounter(prometheus.CounterOpts{}))
+		e.resolve = fakeResolver{addrs: []string{"10.0.0.5"}}
+
+		got := e.Enrich(context.Background(), "abc123")
+		require.Equal(t, model.LabelSet{
+			dockerLabelSwarmNodeHostname: "node-1",
+			dockerLabelSwarmServiceVIP:   "10.0.0.5",
+		}, got)
+	})
+
+	t.Run("caches until the TTL expires", func(t *testing.T) {
+		// expiresAt is computed once, when a result is cached, from
+		// swarmEnrichmentTTL at that moment, so the TTL needs shrinking
+		// before priming the cache, not after, for a later Enrich call to
+		// actually observe it as expired.
+		previousTTL := swarmEnrichmentTTL
+		swarmEnrichmentTTL = 10 * time.Millisecond
+		defer func() { swarmEnrichmentTTL = previousTTL }()
+
+		inspector := &countingInspector{fakeInspector: fakeInspector{info: swarmContainerInfo("node-1", "myservice")}}
+		e := newSwarmEnricher(log.NewNopLogger(), inspector, prometheus.NewCounter(prometheus.CounterOpts{}))
+		e.resolve = fakeResolver{addrs: []string{"10.0.0.5"}}
+
+		e.Enrich(context.Background(), "abc123")
+		e.Enrich(context.Background(), "abc123")
+		require.Equal(t, 1, inspector.calls)
+
+		time.Sleep(20 * time.Millisecond)
+
+		e.Enrich(context.Background(), "abc123")
+		require.Equal(t, 2, inspector.calls)
+	})
+
+	t.Run("inspect failure omits both labels without erroring", func(t *testing.T) {
+		errs := prometheus.NewCounter(prometheus.CounterOpts{})
+		e := newSwarmEnricher(log.NewNopLogger(), fakeInspector{err: errors.New("no such container")}, errs)
+
+		got := e.Enrich(context.Background(), "abc123")
+		require.Empty(t, got)
+		require.Equal(t, float64(1), testutil.ToFloat64(errs))
+	})
+
+	t.Run("missing service label omits only the VIP", func(t *testing.T) {
+		e := newSwarmEnricher(log.NewNopLogger(), fakeInspector{info: swarmContainerInfo("node-1", "")}, prometheus.NewCounter(prometheus.CounterOpts{}))
+
+		got := e.Enrich(context.Background(), "abc123")
+		require.Equal(t, model.LabelSet{dockerLabelSwarmNodeHostname: "node-1"}, got)
+	})
+
+	t.Run("DNS lookup failure omits only the VIP", func(t *testing.T) {
+		errs := prometheus.NewCounter(prometheus.CounterOpts{})
+		e := newSwarmEnricher(log.NewNopLogger(), fakeInspector{info: swarmContainerInfo("node-1", "myservice")}, errs)
+		e.resolve = fakeResolver{err: errors.New("no such host")}
+
+		got := e.Enrich(context.Background(), "abc123")
+		require.Equal(t, model.LabelSet{dockerLabelSwarmNodeHostname: "node-1"}, got)
+		require.Equal(t, float64(1), testutil.ToFloat64(errs))
+	})
+}
+
+// countingInspector wraps fakeInspector to count ContainerInspect calls, so
+// the cache test can assert a second Enrich within the TTL window skips the
+// inspect entirely.
+type countingInspector struct {
+	fakeInspector
+	calls int
+}
+
+func (c *countingInspector) ContainerInspect(ctx context.Context, id string) (types.ContainerJSON, error) {
+	c.calls++
+	return c.fakeInspector.ContainerInspect(ctx, id)
+}
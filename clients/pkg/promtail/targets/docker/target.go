@@ -2,12 +2,17 @@ package docker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 	"github.com/go-kit/log"
@@ -20,27 +25,178 @@ import (
 	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/util/backoff"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/util/goroutinelabels"
 
 	"github.com/grafana/loki/v3/pkg/framedstdcopy"
 	"github.com/grafana/loki/v3/pkg/logproto"
 )
 
+// pauseCheckInterval is how often a Target with a configured pause label
+// re-inspects its container to check whether the label has been set or
+// removed. It's a var rather than a const so tests can shrink it.
+var pauseCheckInterval = 5 * time.Second
+
+// ErrorRecoveryPolicy controls how a Target responds when it fails to fetch
+// or read a container's logs; see scrapeconfig.DockerTargetConfig's
+// ErrorRecoveryPolicy field.
+const (
+	// ErrorRecoveryStop is the default: the target stops for good, same as
+	// calling Target.Stop(). It's only picked up again if the container is
+	// rediscovered (e.g. it's recreated).
+	ErrorRecoveryStop = "stop"
+
+	// ErrorRecoveryRetry reconnects with exponential backoff whenever the
+	// log stream ends, whether that's due to an error or the container
+	// itself stopping.
+	ErrorRecoveryRetry = "retry"
+
+	// ErrorRecoveryIgnore behaves like ErrorRecoveryStop, except Ready()
+	// keeps reporting true after a non-fatal error, so a target that failed
+	// to start doesn't count against readiness for containers considered
+	// best-effort.
+	ErrorRecoveryIgnore = "ignore"
+)
+
+// defaultLevelLabel is the label name stdout_level/stderr_level are written
+// to when scrapeconfig.DockerTargetConfig.LevelLabel is left empty.
+const defaultLevelLabel = "level"
+
+// labelBuilderPool recycles labels.Builders across handleOutput calls. A
+// Builder's backing add/del slices grow to whatever a container's label set
+// needs and then stay reused at that size, rather than every line
+// allocating a fresh Builder to throw away a few lines later.
+var labelBuilderPool = sync.Pool{
+	New: func() interface{} { return labels.NewBuilder(nil) },
+}
+
+// reconnectBackoffConfig bounds the reconnection delay used by
+// ErrorRecoveryRetry: it starts at MinBackoff and doubles after every
+// unsuccessful attempt, up to MaxBackoff, with full jitter so a fleet of
+// promtails reconnecting to the same daemon or watching the same overloaded
+// host don't all retry in lockstep. MaxRetries is left at zero (retry
+// forever), since ErrorRecoveryRetry's whole point is to keep trying until
+// the container's logs become readable again. Declared as a var, rather
+// than a const, so tests can shrink it.
+var reconnectBackoffConfig = backoff.Config{
+	MinBackoff:     time.Second,
+	MaxBackoff:     2 * time.Minute,
+	JitterFraction: 1,
+}
+
+// isPermissionError reports whether err (possibly wrapped) is a filesystem
+// permission error, as opposed to the container having stopped, the daemon
+// being unreachable, or some other failure. It's used to force a retry even
+// under ErrorRecoveryStop, since a permission error against a socket owned
+// by another group is often fixed at runtime (e.g. an operator adds the
+// promtail user to the group) rather than requiring promtail to restart.
+func isPermissionError(err error) bool {
+	return errors.Is(err, os.ErrPermission)
+}
+
+// wrapPermissionError adds a hint about group membership and the configured
+// socket path to a permission error, so it's actionable wherever it
+// surfaces (logs, Details()) without the caller needing to know t.host.
+func (t *Target) wrapPermissionError(err error) error {
+	if !isPermissionError(err) {
+		return err
+	}
+	return fmt.Errorf("%w: promtail may not be in the group that owns the docker socket %q", err, t.host)
+}
+
 type Target struct {
-	logger        log.Logger
-	handler       api.EntryHandler
-	since         int64
-	positions     positions.Positions
-	containerName string
-	labels        model.LabelSet
-	relabelConfig []*relabel.Config
-	metrics       *Metrics
-	maxLineSize   int
-
-	cancel  context.CancelFunc
-	client  client.APIClient
-	wg      sync.WaitGroup
-	running *atomic.Bool
-	err     error
+	logger              log.Logger
+	handler             api.EntryHandler
+	since               int64
+	positions           positions.Positions
+	containerName       string
+	// positionKey is the key t's position is stored under, initially
+	// derived from containerName but repointed at the container's actual
+	// Docker ID once known; see adoptContainerIdentity.
+	positionKey string
+	// containerID and containerDisplayName are the Docker ID and name of
+	// the container currently backing this target, as of the most recent
+	// successful inspect. Both are empty until the first inspect
+	// succeeds. See adoptContainerIdentity.
+	containerID          string
+	containerDisplayName string
+	labels               model.LabelSet
+	relabelConfig       []*relabel.Config
+	metrics             *Metrics
+	maxLineSize         int
+	pauseLabel          string
+	pauseStopsReading   bool
+	bufferEntries       int
+	bufferBytes         int
+	errorRecoveryPolicy string
+	// bufferSizer tracks this target's rolling p99 line size and picks how
+	// much process should pre-grow its per-line accumulation buffer to; see
+	// adaptiveBufferSizer. nil in Targets built directly rather than via
+	// NewTarget (e.g. in tests and benchmarks), in which case process and
+	// Details fall back to behaving as if it were disabled.
+	bufferSizer *adaptiveBufferSizer
+	// host is the Docker daemon address this target's client talks to
+	// (e.g. "unix:///var/run/docker.sock"), used only to make a permission
+	// error's log message and Details() actionable.
+	host string
+
+	// trackUptime enables the dockerLabelContainerUptime label; see
+	// containerCreatedAt.
+	trackUptime bool
+	// containerCreatedAt is fetched once via ContainerInspect during
+	// NewTarget when trackUptime is set, and used on every entry to
+	// compute how long the container has been running. It's the zero
+	// value if trackUptime is false or the inspect call failed.
+	containerCreatedAt time.Time
+
+	// trackHealth enables the dockerLabelContainerHealth label; see
+	// containerHealth and watchContainerState.
+	trackHealth bool
+	// containerHealth holds the container's most recently observed health
+	// check status ("starting", "healthy", "unhealthy", or "" if
+	// trackHealth is false, the image has no health check configured, or
+	// no inspect has succeeded yet). Refreshed periodically by
+	// watchContainerState rather than per entry, since it only changes
+	// when Docker re-evaluates the container's health check.
+	containerHealth *atomic.String
+
+	// stdoutLevel/stderrLevel are the level label values applied to entries
+	// from that stream, unless levelDetectionRegex matches the line first;
+	// either can be empty to leave that stream's entries alone. levelLabel
+	// is the label name they're written to.
+	stdoutLevel         string
+	stderrLevel         string
+	levelLabel          string
+	levelDetectionRegex *regexp.Regexp
+
+	// stripLinePrefix, when non-empty, is trimmed from the start of each
+	// raw log line before extractTs runs; see
+	// scrapeconfig.DockerTargetConfig.StripLinePrefix.
+	stripLinePrefix string
+
+	// normalizeWhitespace enables finalizeLine's trailing space/tab trim;
+	// see scrapeconfig.DockerTargetConfig.NormalizeWhitespace.
+	normalizeWhitespace bool
+
+	// swarmEnricher resolves dockerLabelSwarmNodeHostname and
+	// dockerLabelSwarmServiceVIP once per connection attempt; see
+	// processLoop and swarmLabels. nil disables Swarm enrichment.
+	swarmEnricher *swarmEnricher
+	// swarmLabels holds the result of the most recent swarmEnricher.Enrich
+	// call, applied to every entry until the next reconnect refreshes it.
+	swarmLabels model.LabelSet
+
+	// cancelMtx guards cancel: startIfNotRunning (called both at
+	// construction and, concurrently, by the pause-label watcher goroutine
+	// on resume) writes it, and Stop reads it.
+	cancelMtx   sync.Mutex
+	cancel      context.CancelFunc
+	client      client.APIClient
+	wg          sync.WaitGroup
+	running     *atomic.Bool
+	paused      *atomic.Bool
+	watchCancel context.CancelFunc
+	err         error
 }
 
 func NewTarget(
@@ -53,9 +209,25 @@ func NewTarget(
 	relabelConfig []*relabel.Config,
 	client client.APIClient,
 	maxLineSize int,
+	pauseLabel string,
+	pauseStopsReading bool,
+	bufferEntries int,
+	bufferBytes int,
+	errorRecoveryPolicy string,
+	host string,
+	trackContainerUptime bool,
+	trackContainerHealth bool,
+	stdoutLevel string,
+	stderrLevel string,
+	levelLabel string,
+	levelDetectionRegex *regexp.Regexp,
+	swarmEnricher *swarmEnricher,
+	stripLinePrefix string,
+	normalizeWhitespace bool,
 ) (*Target, error) {
 
-	pos, err := position.Get(positions.CursorKey(containerName))
+	positionKey := positions.CursorKey(containerName)
+	pos, err := position.Get(positionKey)
 	if err != nil {
 		return nil, err
 	}
@@ -64,31 +236,183 @@ func NewTarget(
 		since = pos
 	}
 
+	if errorRecoveryPolicy == "" {
+		errorRecoveryPolicy = ErrorRecoveryStop
+	}
+
+	if levelLabel == "" {
+		levelLabel = defaultLevelLabel
+	}
+
+	var containerCreatedAt time.Time
+	if trackContainerUptime {
+		if info, err := client.ContainerInspect(context.Background(), containerName); err != nil {
+			level.Warn(logger).Log("msg", "could not determine container creation time, uptime label will be omitted", "container", containerName, "err", err)
+		} else if createdAt, err := time.Parse(time.RFC3339Nano, info.Created); err != nil {
+			level.Warn(logger).Log("msg", "could not parse container creation time, uptime label will be omitted", "container", containerName, "err", err)
+		} else {
+			containerCreatedAt = createdAt
+		}
+	}
+
+	containerHealth := atomic.NewString("")
+	if trackContainerHealth {
+		if info, err := client.ContainerInspect(context.Background(), containerName); err != nil {
+			level.Warn(logger).Log("msg", "could not determine initial container health, health label will be omitted", "container", containerName, "err", err)
+		} else {
+			containerHealth.Store(containerHealthStatus(info))
+		}
+	}
+
 	t := &Target{
-		logger:        logger,
-		handler:       handler,
-		since:         since,
-		positions:     position,
-		containerName: containerName,
-		labels:        labels,
-		relabelConfig: relabelConfig,
-		metrics:       metrics,
-		maxLineSize:   maxLineSize,
+		logger:              logger,
+		handler:             handler,
+		since:               since,
+		positions:           position,
+		containerName:       containerName,
+		positionKey:         positionKey,
+		labels:              labels,
+		relabelConfig:       relabelConfig,
+		metrics:             metrics,
+		maxLineSize:         maxLineSize,
+		pauseLabel:          pauseLabel,
+		pauseStopsReading:   pauseStopsReading,
+		bufferEntries:       bufferEntries,
+		bufferBytes:         bufferBytes,
+		bufferSizer:         newAdaptiveBufferSizer(defaultAdaptiveBufferFloor, resolvedLineSizeLimit(maxLineSize)),
+		errorRecoveryPolicy: errorRecoveryPolicy,
+		host:                host,
+		trackUptime:         trackContainerUptime,
+		containerCreatedAt:  containerCreatedAt,
+		trackHealth:         trackContainerHealth,
+		containerHealth:     containerHealth,
+		stdoutLevel:         stdoutLevel,
+		stderrLevel:         stderrLevel,
+		levelLabel:          levelLabel,
+		levelDetectionRegex: levelDetectionRegex,
+		swarmEnricher:       swarmEnricher,
+		stripLinePrefix:     stripLinePrefix,
+		normalizeWhitespace: normalizeWhitespace,
 
 		client:  client,
 		running: atomic.NewBool(false),
+		paused:  atomic.NewBool(false),
 	}
 	t.startIfNotRunning()
+	if pauseLabel != "" || trackContainerHealth {
+		var watchCtx context.Context
+		watchCtx, t.watchCancel = context.WithCancel(context.Background())
+		go t.watchContainerState(watchCtx)
+	}
 	return t, nil
 }
 
-func (t *Target) processLoop(ctx context.Context) {
-	t.running.Store(true)
-	defer t.running.Store(false)
+// containerHealthStatus returns info's health check status, or "" if the
+// container has no health check configured on its image.
+func containerHealthStatus(info types.ContainerJSON) string {
+	if info.State == nil || info.State.Health == nil {
+		return ""
+	}
+	return info.State.Health.Status
+}
+
+// NewTargetFromEnv is like NewTarget, but constructs its own Docker client
+// from the standard Docker SDK environment variables (DOCKER_HOST,
+// DOCKER_TLS_VERIFY, DOCKER_CERT_PATH) instead of taking one as a
+// parameter. It lets Promtail attach to whichever daemon the environment
+// already points at, without a docker_sd_configs host to configure.
+func NewTargetFromEnv(
+	metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	position positions.Positions,
+	containerName string,
+	labels model.LabelSet,
+	relabelConfig []*relabel.Config,
+	maxLineSize int,
+	pauseLabel string,
+	pauseStopsReading bool,
+	bufferEntries int,
+	bufferBytes int,
+	errorRecoveryPolicy string,
+	host string,
+	trackContainerUptime bool,
+	trackContainerHealth bool,
+	stdoutLevel string,
+	stderrLevel string,
+	levelLabel string,
+	levelDetectionRegex *regexp.Regexp,
+	swarmEnricher *swarmEnricher,
+	stripLinePrefix string,
+	normalizeWhitespace bool,
+) (*Target, error) {
+	c, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("could not create docker client from environment: %w", err)
+	}
 
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+
+	return NewTarget(metrics, logger, handler, position, containerName, labels, relabelConfig, c, maxLineSize, pauseLabel, pauseStopsReading, bufferEntries, bufferBytes, errorRecoveryPolicy, host, trackContainerUptime, trackContainerHealth, stdoutLevel, stderrLevel, levelLabel, levelDetectionRegex, swarmEnricher, stripLinePrefix, normalizeWhitespace)
+}
+
+// run drives processLoop according to t.errorRecoveryPolicy. ErrorRecoveryStop
+// and ErrorRecoveryIgnore both run it once. ErrorRecoveryRetry re-invokes it
+// with exponential backoff whenever it returns because the log stream ended
+// on its own, as opposed to ctx being cancelled by an explicit Stop(). A
+// permission error (e.g. the docker socket is owned by a group promtail
+// isn't in) always retries with backoff regardless of errorRecoveryPolicy,
+// since it's commonly fixed at runtime rather than requiring a restart.
+func (t *Target) run(ctx context.Context) {
+	ctx, resetLabels := goroutinelabels.Apply(ctx, "component", "promtail_target", "target_type", "docker", "target", t.containerName)
+	defer resetLabels()
+
+	t.wg.Add(1)
+	defer func() {
+		t.wg.Done()
+		t.running.Store(false)
+	}()
+
+	b := backoff.New(ctx, reconnectBackoffConfig)
+	for {
+		t.err = nil
+		t.processLoop(ctx)
+
+		permissionErr := isPermissionError(t.err)
+		if ctx.Err() != nil || (t.errorRecoveryPolicy != ErrorRecoveryRetry && !permissionErr) {
+			return
+		}
+
+		if permissionErr {
+			level.Warn(t.logger).Log("msg", "docker target hit a permission error, retrying in case it's fixed at runtime", "container", t.containerName, "err", t.err)
+		} else {
+			level.Warn(t.logger).Log("msg", "docker target log stream ended, reconnecting", "container", t.containerName, "err", t.err)
+		}
+		b.Wait()
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// processLoop fetches and reads a container's logs for one connection
+// attempt. It returns once the log stream ends, whether that's because ctx
+// was cancelled, the container stopped, or an unrecoverable error occurred
+// setting things up; see run for what happens next.
+func (t *Target) processLoop(ctx context.Context) {
 	t.wg.Add(1)
 	defer t.wg.Done()
 
+	inspectInfo, err := t.client.ContainerInspect(ctx, t.containerName)
+	if err != nil {
+		t.err = t.wrapPermissionError(err)
+		level.Error(t.logger).Log("msg", "could not inspect container info", "container", t.containerName, "err", t.err)
+		return
+	}
+	t.adoptContainerIdentity(inspectInfo)
+
 	opts := container.LogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
@@ -96,29 +420,46 @@ func (t *Target) processLoop(ctx context.Context) {
 		Timestamps: true,
 		Since:      strconv.FormatInt(t.since, 10),
 	}
-	inspectInfo, err := t.client.ContainerInspect(ctx, t.containerName)
-	if err != nil {
-		level.Error(t.logger).Log("msg", "could not inspect container info", "container", t.containerName, "err", err)
-		t.err = err
-		return
+	if t.swarmEnricher != nil {
+		t.swarmLabels = t.swarmEnricher.Enrich(ctx, t.containerName)
 	}
 	logs, err := t.client.ContainerLogs(ctx, t.containerName, opts)
 	if err != nil {
-		level.Error(t.logger).Log("msg", "could not fetch logs for container", "container", t.containerName, "err", err)
-		t.err = err
+		t.err = t.wrapPermissionError(err)
+		level.Error(t.logger).Log("msg", "could not fetch logs for container", "container", t.containerName, "err", t.err)
 		return
 	}
 
-	// Start transferring
+	// attemptCtx scopes just this connection attempt: the copy goroutine
+	// below cancels it once the log stream ends, without touching ctx
+	// itself, so run can tell an attempt ending from an explicit Stop()
+	// (which cancels ctx) and decide whether to reconnect.
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Start transferring. cstdout/cstderr carry raw frames straight out of
+	// FramedStdCopy, which can only write to a channel, not our bounded
+	// frameBuffer; the pump goroutines below drain them immediately into a
+	// frameBuffer each, so FramedStdCopy is never blocked by a slow
+	// handler downstream, only by frameBuffer's own (non-blocking) push.
 	cstdout := make(chan []byte)
 	cstderr := make(chan []byte)
-	t.wg.Add(1)
+	stdoutBuf := newFrameBuffer(t.metrics, t.containerName, "stdout", t.bufferEntries, t.bufferBytes)
+	stderrBuf := newFrameBuffer(t.metrics, t.containerName, "stderr", t.bufferEntries, t.bufferBytes)
+
+	// All Add calls happen here, before any of the goroutines below are
+	// started. Adding the deltas for the process goroutines after starting
+	// the copy goroutine would race with that goroutine cancelling
+	// attemptCtx (and this function therefore returning and calling
+	// wg.Done) as soon as it finishes, since the counter could briefly
+	// reach zero in between.
+	t.wg.Add(5)
 	go func() {
 		defer func() {
 			t.wg.Done()
 			close(cstdout)
 			close(cstderr)
-			t.Stop()
+			cancel()
 		}()
 		var written int64
 		var err error
@@ -129,69 +470,191 @@ func (t *Target) processLoop(ctx context.Context) {
 		}
 		if err != nil {
 			level.Warn(t.logger).Log("msg", "could not transfer logs", "written", written, "container", t.containerName, "err", err)
+			t.err = err
 		} else {
 			level.Info(t.logger).Log("msg", "finished transferring logs", "written", written, "container", t.containerName)
 		}
 	}()
 
+	// Pump raw frames into their bounded buffers.
+	go t.pumpToBuffer(cstdout, stdoutBuf)
+	go t.pumpToBuffer(cstderr, stderrBuf)
+
 	// Start processing
-	t.wg.Add(2)
-	go t.process(cstdout, "stdout")
-	go t.process(cstderr, "stderr")
+	go t.process(stdoutBuf, "stdout")
+	go t.process(stderrBuf, "stderr")
 
 	// Wait until done
-	<-ctx.Done()
+	<-attemptCtx.Done()
 	logs.Close()
 	level.Debug(t.logger).Log("msg", "done processing Docker logs", "container", t.containerName)
 }
 
-// extractTs tries for read the timestamp from the beginning of the log line.
-// It's expected to follow the format 2006-01-02T15:04:05.999999999Z07:00.
+// adoptContainerIdentity records info's container ID and name, and repoints
+// t's position tracking at that ID rather than whatever identifier t was
+// constructed with (usually already the same ID, since docker_sd_configs
+// resolves containers by ID; this matters when t was instead constructed
+// with a container name, e.g. via NewTargetFromEnv outside of discovery).
+// It's called once per connection attempt, right after inspecting the
+// container, so it also catches a container recreated with the same name
+// but a new ID behind an already-running target: rather than resuming from
+// the old container's Since and skipping the new container's early lines,
+// it resets Since to zero so the new container is read from its start.
+func (t *Target) adoptContainerIdentity(info types.ContainerJSON) {
+	name := strings.TrimPrefix(info.Name, "/")
+	if info.ID == t.containerID {
+		t.containerDisplayName = name
+		return
+	}
+
+	oldID := t.containerID
+	oldKey := t.positionKey
+	t.containerID = info.ID
+	t.containerDisplayName = name
+	t.positionKey = positions.CursorKey(info.ID)
+
+	if oldID != "" {
+		level.Info(t.logger).Log("msg", "docker container was recreated with a new ID, resetting log position", "container", t.containerName, "old_id", oldID, "new_id", info.ID)
+		t.since = 0
+		t.positions.Remove(oldKey)
+		return
+	}
+
+	// First inspect for this target. Adopt whatever position is already
+	// recorded under the ID-keyed entry, if it differs from oldKey; t.since
+	// already reflects oldKey (adopt-and-migrate: NewTarget seeded it from
+	// the identifier t was constructed with, which covers a legacy
+	// name-keyed entry from an older promtail version), so there's nothing
+	// more to migrate when the two keys are the same.
+	if oldKey == t.positionKey {
+		return
+	}
+	if pos, err := t.positions.Get(t.positionKey); err == nil && pos != 0 {
+		t.since = pos
+	}
+}
+
+// fallbackTimestampLayouts are tried, in order, against the token before the
+// first space in a Docker log line once the RFC3339Nano fast path in
+// extractTs fails. They cover timestamp variants observed in the wild:
+// RFC3339 without a nanosecond component, and a space rather than 'T'
+// separating the date and time.
+var fallbackTimestampLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05.999999999Z07:00",
+}
+
+// extractTs tries to read the timestamp from the beginning of the log line.
+// The fast path assumes a single space separates a
+// 2006-01-02T15:04:05.999999999Z07:00 timestamp (Docker's normal format)
+// from the payload; most lines take it. If that fails, it strips a leading
+// run of non-printable bytes (seen after a daemon hiccup truncates a line
+// mid-header) and retries the split against fallbackTimestampLayouts. A
+// line whose timestamp still can't be parsed ships with time.Now() rather
+// than being dropped, since Loki still needs some timestamp to store it
+// against.
 func extractTs(line string) (time.Time, string, error) {
-	pair := strings.SplitN(line, " ", 2)
-	if len(pair) != 2 {
-		return time.Now(), line, fmt.Errorf("could not find timestamp in '%s'", line)
+	// strings.Cut re-slices line rather than copying or allocating a slice
+	// header, unlike strings.SplitN(line, " ", 2); this is on the hot path
+	// for every log line, so avoiding an allocation here matters.
+	if head, rest, ok := strings.Cut(line, " "); ok {
+		if ts, err := time.Parse(time.RFC3339Nano, head); err == nil {
+			return ts, rest, nil
+		}
 	}
-	ts, err := time.Parse("2006-01-02T15:04:05.999999999Z07:00", pair[0])
-	if err != nil {
-		return time.Now(), line, fmt.Errorf("could not parse timestamp from '%s': %w", pair[0], err)
+
+	trimmed := strings.TrimLeftFunc(line, func(r rune) bool { return !unicode.IsPrint(r) })
+
+	// One of fallbackTimestampLayouts has a space between the date and time
+	// themselves, so the timestamp can span more than one space-separated
+	// token. Widen the candidate at each successive space until a layout
+	// parses it, rather than cutting only at the first one.
+	searchFrom := 0
+	for {
+		spacePos := strings.IndexByte(trimmed[searchFrom:], ' ')
+		if spacePos == -1 {
+			return time.Now(), line, fmt.Errorf("could not find timestamp in '%s'", line)
+		}
+		spacePos += searchFrom
+
+		head := trimmed[:spacePos]
+		for _, layout := range fallbackTimestampLayouts {
+			if ts, err := time.Parse(layout, head); err == nil {
+				return ts, trimmed[spacePos+1:], nil
+			}
+		}
+		searchFrom = spacePos + 1
+	}
+}
+
+// pumpToBuffer drains raw frames off in as fast as they arrive and pushes
+// them into buf, so the copy goroutine writing to in is never blocked by a
+// slow handler downstream; see the comment in processLoop. It closes buf
+// once in is closed, so process's range over buf.pop terminates in turn.
+func (t *Target) pumpToBuffer(in chan []byte, buf *frameBuffer) {
+	defer func() {
+		t.wg.Done()
+		buf.close()
+		buf.unregisterMetrics()
+	}()
+	for frame := range in {
+		buf.push(frame)
+	}
+}
+
+// resolvedLineSizeLimit returns the size process forcibly flushes a
+// multi-frame line at: maxLineSize, or a fixed 256KB safety limit if
+// maxLineSize is disabled (set to 0). Without this fallback we could in
+// theory have infinite buffer growth: we can't guarantee there's any bound
+// on Docker logs, they could be an infinite stream without newlines for all
+// we know. To protect promtail from OOM in that case, this introduces the
+// same safety limit into the Docker target that Loki's own max_line_size
+// defaults to: https://grafana.com/docs/loki/latest/configure/#limits_config.
+// It also doubles as adaptiveBufferSizer's ceiling, since sizing the
+// pre-growth buffer past the point process will flush it at is pointless.
+func resolvedLineSizeLimit(maxLineSize int) int {
+	if maxLineSize == 0 {
+		return 256 * 1024
 	}
-	return ts, pair[1], nil
+	return maxLineSize
 }
 
-func (t *Target) process(frames chan []byte, logStream string) {
+func (t *Target) process(frames *frameBuffer, logStream string) {
 	defer func() {
 		t.wg.Done()
 	}()
 
 	var (
-		sizeLimit            = t.maxLineSize
+		sizeLimit            = resolvedLineSizeLimit(t.maxLineSize)
 		discardRemainingLine = false
 		payloadAcc           strings.Builder
 		curTs                = time.Now()
 	)
 
-	// If max_line_size is disabled (set to 0), we can in theory have infinite buffer growth.
-	// We can't guarantee that there's any bound on Docker logs, they could be an infinite stream
-	// without newlines for all we know. To protect promtail from OOM in that case, we introduce
-	// this safety limit into the Docker target, inspired by the default Loki max_line_size value:
-	// https://grafana.com/docs/loki/latest/configure/#limits_config
-	if sizeLimit == 0 {
-		sizeLimit = 256 * 1024
-	}
+	for {
+		frame, ok := frames.pop()
+		if !ok {
+			break
+		}
+
+		line := string(frame)
+		if t.stripLinePrefix != "" {
+			line = strings.TrimPrefix(line, t.stripLinePrefix)
+		}
 
-	for frame := range frames {
 		// Split frame into timestamp and payload
-		ts, payload, err := extractTs(string(frame))
+		ts, payload, err := extractTs(line)
 		if err != nil {
-			if payloadAcc.Len() == 0 {
+			t.metrics.dockerErrors.Inc()
+			if payloadAcc.Len() > 0 {
 				// If we are currently accumulating a line split over multiple frames, we would still expect
 				// timestamps in every frame, but since we don't use those secondary ones, we don't log an error in that case.
-				level.Error(t.logger).Log("msg", "error reading docker log line, skipping line", "err", err)
-				t.metrics.dockerErrors.Inc()
-				continue
+				ts = curTs
+			} else {
+				// extractTs already fell back to time.Now(); ship the line rather than dropping it.
+				level.Error(t.logger).Log("msg", "error reading docker log timestamp, using current time", "err", err)
 			}
-			ts = curTs
 		}
 
 		// If time has changed, we are looking at a new event (although we should have seen a new line..),
@@ -199,7 +662,7 @@ func (t *Target) process(frames chan []byte, logStream string) {
 		if ts != curTs {
 			discardRemainingLine = false
 			if payloadAcc.Len() > 0 {
-				t.handleOutput(logStream, curTs, payloadAcc.String())
+				t.handleOutput(logStream, curTs, t.finalizeLine(payloadAcc.String()))
 				payloadAcc.Reset()
 			}
 		}
@@ -220,33 +683,191 @@ func (t *Target) process(frames chan []byte, logStream string) {
 		// Fast path: Most log lines are a single frame. If we have a full line in frame and buffer is empty,
 		// then don't use the buffer at all.
 		if payloadAcc.Len() == 0 && isEol {
-			t.handleOutput(logStream, ts, payload)
+			line := t.finalizeLine(payload)
+			t.handleOutput(logStream, ts, line)
+			t.observeLineSize(len(line))
 			continue
 		}
 
-		// Add to buffer
+		// Add to buffer, pre-growing it to this target's adaptively sized
+		// estimate so a multi-frame line doesn't force payloadAcc through
+		// several reallocations on its way there.
+		if payloadAcc.Len() == 0 {
+			payloadAcc.Grow(t.adaptiveBufferSize())
+		}
 		payloadAcc.WriteString(payload)
 		curTs = ts
 
 		// Send immediately if line ended or we built a very large event
 		if isEol || payloadAcc.Len() > sizeLimit {
 			discardRemainingLine = !isEol
-			t.handleOutput(logStream, curTs, payloadAcc.String())
+			line := t.finalizeLine(payloadAcc.String())
+			t.handleOutput(logStream, curTs, line)
+			t.observeLineSize(len(line))
 			payloadAcc.Reset()
 		}
 	}
 }
 
+// finalizeLine applies normalizeWhitespace's trailing space/tab trim to a
+// fully assembled line, just before it's handed to handleOutput. It runs
+// once per assembled line, not per incoming frame, so a multi-frame line's
+// interior frames aren't stripped of whitespace that was actually part of
+// the payload rather than trailing padding. It's a no-op when
+// normalizeWhitespace is unset; trailing \r and \n from the frame's own
+// terminator are already stripped above regardless, by the unconditional
+// strings.TrimRight(payload, "\r\n").
+func (t *Target) finalizeLine(line string) string {
+	if !t.normalizeWhitespace {
+		return line
+	}
+	trimmed := strings.TrimRight(line, " \t")
+	if trimmed != line {
+		t.metrics.normalizedLines.Inc()
+	}
+	return trimmed
+}
+
+// observeLineSize records a completed line's size for this target's
+// bufferSizer. It's a no-op if bufferSizer is nil, which is the case for a
+// Target built directly rather than via NewTarget (e.g. in tests and
+// benchmarks).
+func (t *Target) observeLineSize(n int) {
+	if t.bufferSizer != nil {
+		t.bufferSizer.observe(n)
+	}
+}
+
+// adaptiveBufferSize returns how much process should Grow payloadAcc to
+// before accumulating a new multi-frame line, per bufferSizer's current
+// estimate. It returns 0 (no pre-growth) if bufferSizer is nil.
+func (t *Target) adaptiveBufferSize() int {
+	if t.bufferSizer == nil {
+		return 0
+	}
+	return t.bufferSizer.size()
+}
+
+// watchContainerState periodically re-inspects the container to refresh
+// state that can only be observed that way, until ctx is cancelled. It runs
+// independently of the per-run processLoop context so it keeps working
+// across Stop/startIfNotRunning cycles, including the one it triggers
+// itself when pauseStopsReading is set. Started whenever t.pauseLabel or
+// t.trackHealth is configured; each tick only does the checks the enabled
+// features need.
+func (t *Target) watchContainerState(ctx context.Context) {
+	ticker := time.NewTicker(pauseCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if t.pauseLabel != "" {
+				t.checkPauseLabel(ctx)
+			}
+			if t.trackHealth {
+				t.checkContainerHealth(ctx)
+			}
+		}
+	}
+}
+
+// checkContainerHealth re-inspects the container and refreshes
+// t.containerHealth from its current health check status.
+func (t *Target) checkContainerHealth(ctx context.Context) {
+	info, err := t.client.ContainerInspect(ctx, t.containerName)
+	if err != nil {
+		level.Debug(t.logger).Log("msg", "could not inspect container for health status", "container", t.containerName, "err", err)
+		return
+	}
+	t.containerHealth.Store(containerHealthStatus(info))
+}
+
+func (t *Target) checkPauseLabel(ctx context.Context) {
+	info, err := t.client.ContainerInspect(ctx, t.containerName)
+	if err != nil {
+		level.Debug(t.logger).Log("msg", "could not inspect container for pause label", "container", t.containerName, "err", err)
+		return
+	}
+
+	_, labelSet := info.Config.Labels[t.pauseLabel]
+	wasPaused := t.paused.Swap(labelSet)
+	if labelSet == wasPaused {
+		return
+	}
+
+	if labelSet {
+		level.Info(t.logger).Log("msg", "pausing Docker target", "container", t.containerName, "label", t.pauseLabel)
+		t.metrics.dockerTargetsPaused.Inc()
+		if t.pauseStopsReading {
+			t.Stop()
+		}
+	} else {
+		level.Info(t.logger).Log("msg", "resuming Docker target", "container", t.containerName, "label", t.pauseLabel)
+		t.metrics.dockerTargetsPaused.Dec()
+		if t.pauseStopsReading {
+			t.startIfNotRunning()
+		}
+	}
+}
+
+// detectedLevel returns the level label value to apply for an entry read
+// from logStream, or "" if none should be applied: either that stream has
+// no configured level, or payload already carries an obvious level token
+// matching t.levelDetectionRegex, in which case a downstream pipeline stage
+// or relabel_configs is expected to derive the level instead.
+func (t *Target) detectedLevel(logStream, payload string) string {
+	var lvl string
+	switch logStream {
+	case "stdout":
+		lvl = t.stdoutLevel
+	case "stderr":
+		lvl = t.stderrLevel
+	}
+	if lvl == "" {
+		return ""
+	}
+	if t.levelDetectionRegex != nil && t.levelDetectionRegex.MatchString(payload) {
+		return ""
+	}
+	return lvl
+}
+
 func (t *Target) handleOutput(logStream string, ts time.Time, payload string) {
+	if t.paused.Load() {
+		t.positions.Put(t.positionKey, ts.Unix())
+		t.since = ts.Unix()
+		return
+	}
+
 	// Add all labels from the config, relabel and filter them.
-	lb := labels.NewBuilder(nil)
+	lb := labelBuilderPool.Get().(*labels.Builder)
+	lb.Reset(nil)
+	defer labelBuilderPool.Put(lb)
+
 	for k, v := range t.labels {
 		lb.Set(string(k), string(v))
 	}
 	lb.Set(dockerLabelLogStream, logStream)
+	if lvl := t.detectedLevel(logStream, payload); lvl != "" {
+		lb.Set(t.levelLabel, lvl)
+	}
+	if t.trackUptime && !t.containerCreatedAt.IsZero() {
+		lb.Set(dockerLabelContainerUptime, strconv.FormatFloat(time.Since(t.containerCreatedAt).Seconds(), 'f', -1, 64))
+	}
+	if t.trackHealth {
+		if health := t.containerHealth.Load(); health != "" {
+			lb.Set(dockerLabelContainerHealth, health)
+		}
+	}
+	for k, v := range t.swarmLabels {
+		lb.Set(string(k), string(v))
+	}
 	processed, _ := relabel.Process(lb.Labels(), t.relabelConfig...)
 
-	filtered := make(model.LabelSet)
+	filtered := make(model.LabelSet, len(processed))
 	for _, lbl := range processed {
 		if strings.HasPrefix(lbl.Name, "__") {
 			continue
@@ -261,8 +882,7 @@ func (t *Target) handleOutput(logStream string, ts time.Time, payload string) {
 			Line:      payload,
 		},
 	}
-	t.metrics.dockerEntries.Inc()
-	t.positions.Put(positions.CursorKey(t.containerName), ts.Unix())
+	t.positions.Put(t.positionKey, ts.Unix())
 	t.since = ts.Unix()
 }
 
@@ -271,25 +891,51 @@ func (t *Target) startIfNotRunning() {
 	if t.running.CompareAndSwap(false, true) {
 		level.Debug(t.logger).Log("msg", "starting process loop", "container", t.containerName)
 		ctx, cancel := context.WithCancel(context.Background())
+		t.cancelMtx.Lock()
 		t.cancel = cancel
-		go t.processLoop(ctx)
+		t.cancelMtx.Unlock()
+		go t.run(ctx)
 	} else {
 		level.Debug(t.logger).Log("msg", "attempted to start process loop but it's already running", "container", t.containerName)
 	}
 }
 
 func (t *Target) Stop() {
-	t.cancel()
+	t.cancelMtx.Lock()
+	cancel := t.cancel
+	t.cancelMtx.Unlock()
+	cancel()
 	t.wg.Wait()
 	level.Debug(t.logger).Log("msg", "stopped Docker target", "container", t.containerName)
 }
 
+// Close stops the target for good, including its pause-label watcher and
+// handler. Unlike Stop, which is also called internally to end a single
+// processLoop run (e.g. so pause can later resume it), Close should only
+// be used when the target is being torn down permanently. The watcher is
+// cancelled first so it can't call startIfNotRunning and resurrect the
+// target after Stop has already torn it down.
+func (t *Target) Close() {
+	if t.watchCancel != nil {
+		t.watchCancel()
+	}
+	t.Stop()
+	t.handler.Stop()
+}
+
 func (t *Target) Type() target.TargetType {
 	return target.DockerTargetType
 }
 
+// Ready reports whether the target is actively reading logs. Under
+// ErrorRecoveryIgnore it also reports ready once a run has ended with an
+// error, since that policy treats the target as best-effort rather than
+// failed.
 func (t *Target) Ready() bool {
-	return t.running.Load()
+	if t.running.Load() {
+		return true
+	}
+	return t.errorRecoveryPolicy == ErrorRecoveryIgnore && t.err != nil
 }
 
 func (t *Target) DiscoveredLabels() model.LabelSet {
@@ -307,9 +953,14 @@ func (t *Target) Details() interface{} {
 		errMsg = t.err.Error()
 	}
 	return map[string]string{
-		"id":       t.containerName,
-		"error":    errMsg,
-		"position": t.positions.GetString(positions.CursorKey(t.containerName)),
-		"running":  strconv.FormatBool(t.running.Load()),
+		"id":           t.containerName,
+		"container_id": t.containerID,
+		"name":         t.containerDisplayName,
+		"error":        errMsg,
+		"position":     t.positions.GetString(t.positionKey),
+		"running":      strconv.FormatBool(t.running.Load()),
+		"paused":       strconv.FormatBool(t.paused.Load()),
+		"health":       t.containerHealth.Load(),
+		"buffer_bytes": strconv.Itoa(t.adaptiveBufferSize()),
 	}
 }
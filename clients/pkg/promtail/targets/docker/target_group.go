@@ -1,15 +1,18 @@
 package docker
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
 	"sync"
 	"time"
 
 	"github.com/docker/docker/client"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/discovery/targetgroup"
@@ -19,6 +22,7 @@ import (
 
 	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
 )
 
@@ -28,24 +32,54 @@ const DockerSource = "Docker"
 type targetGroup struct {
 	metrics          *Metrics
 	logger           log.Logger
+	jobName          string
 	positions        positions.Positions
 	entryHandler     api.EntryHandler
 	defaultLabels    model.LabelSet
 	relabelConfig    []*relabel.Config
+	dockerConfig     scrapeconfig.DockerTargetConfig
 	host             string
 	httpClientConfig config.HTTPClientConfig
 	client           client.APIClient
 	refreshInterval  model.Duration
 	maxLineSize      int
+	// levelDetectionRegex is compiled once for the whole target group, from
+	// dockerConfig.LevelDetectionRegex, so it isn't recompiled per
+	// container or per line; nil if LevelDetectionRegex is unset.
+	levelDetectionRegex *regexp.Regexp
+	// swarmEnricher is shared by every target in the group, so its cache
+	// and DNS resolver aren't duplicated per container. nil unless
+	// dockerConfig.SwarmEnrichment is set.
+	swarmEnricher *swarmEnricher
 
 	mtx     sync.Mutex
 	targets map[string]*Target
+	// queue holds containers discovered while at dockerConfig.MaxContainers
+	// capacity, in the order they were seen. They're started as running
+	// targets stop; see promoteQueued.
+	queue []queuedTarget
+	// droppedTargets holds a target.DroppedTargetType entry for every
+	// container id skipped by the platform check in addTarget, so it shows
+	// up in the targets API with a reason instead of silently
+	// disappearing. Checked before re-inspecting a container on every
+	// sync, so a skipped container isn't re-inspected on every discovery
+	// cycle.
+	droppedTargets map[string]target.Target
+}
+
+// queuedTarget is a container discovered while at capacity, waiting for
+// room to start.
+type queuedTarget struct {
+	id     string
+	labels model.LabelSet
 }
 
 func (tg *targetGroup) sync(groups []*targetgroup.Group) {
 	tg.mtx.Lock()
 	defer tg.mtx.Unlock()
 
+	tg.promoteQueued()
+
 	for _, group := range groups {
 		if group.Source != DockerSource {
 			continue
@@ -106,22 +140,85 @@ func (tg *targetGroup) addTarget(id string, discoveredLabels model.LabelSet) err
 		}
 	}
 
+	if tg.dockerConfig.SwarmEnrichment && tg.swarmEnricher == nil {
+		tg.swarmEnricher = newSwarmEnricher(tg.logger, tg.client, tg.metrics.swarmEnrichmentErrors)
+	}
+
 	if t, ok := tg.targets[id]; ok {
 		level.Debug(tg.logger).Log("msg", "container target already exists", "container", id)
 		t.startIfNotRunning()
 		return nil
 	}
 
+	if _, ok := tg.droppedTargets[id]; ok {
+		return nil
+	}
+
+	if tg.isQueued(id) {
+		return nil
+	}
+
+	if !tg.dockerConfig.AllowForeignPlatforms {
+		if reason, unsupported := tg.unsupportedPlatformReason(id); unsupported {
+			level.Info(tg.logger).Log("msg", "skipping Docker target with unsupported platform", "containerID", id, "reason", reason)
+			tg.metrics.dockerTargetsSkippedPlatform.Inc()
+			tg.droppedTargets[id] = target.NewDroppedTarget(reason, discoveredLabels)
+			return nil
+		}
+	}
+
+	if tg.dockerConfig.MaxContainers > 0 && tg.runningCount() >= tg.dockerConfig.MaxContainers {
+		tg.metrics.dockerTargetsLimited.Inc()
+		level.Warn(tg.logger).Log(
+			"msg", "max_containers reached, queueing container",
+			"containerID", id, "max_containers", tg.dockerConfig.MaxContainers,
+		)
+		tg.queue = append(tg.queue, queuedTarget{id: id, labels: discoveredLabels})
+		return nil
+	}
+
+	return tg.startTarget(id, discoveredLabels)
+}
+
+// startTarget creates and starts a Target for the container with the given
+// id and discovered labels, and records it in tg.targets. Callers must hold
+// tg.mtx.
+func (tg *targetGroup) startTarget(id string, discoveredLabels model.LabelSet) error {
+	labels := orchestratorLabels(tg.dockerConfig, discoveredLabels).Merge(discoveredLabels).Merge(tg.defaultLabels)
+
+	if created, err := tg.containerCreatedLabel(id); err != nil {
+		level.Warn(tg.logger).Log("msg", "could not determine container creation time", "containerID", id, "err", err)
+	} else {
+		labels = labels.Merge(created)
+	}
+
+	handler := api.NewInstrumentedEntryHandler(tg.metrics.entryHandler, prometheus.Labels{"job": tg.jobName, "target": id}, tg.entryHandler)
+
 	t, err := NewTarget(
 		tg.metrics,
 		log.With(tg.logger, "target", fmt.Sprintf("docker/%s", id)),
-		tg.entryHandler,
+		handler,
 		tg.positions,
 		id,
-		discoveredLabels.Merge(tg.defaultLabels),
+		labels,
 		tg.relabelConfig,
 		tg.client,
 		tg.maxLineSize,
+		tg.dockerConfig.PauseLabel,
+		tg.dockerConfig.PauseStopsReading,
+		tg.dockerConfig.ReadBufferEntries,
+		tg.dockerConfig.ReadBufferBytes,
+		tg.dockerConfig.ErrorRecoveryPolicy,
+		tg.host,
+		tg.dockerConfig.TrackContainerUptime,
+		tg.dockerConfig.TrackContainerHealth,
+		tg.dockerConfig.StdoutLevel,
+		tg.dockerConfig.StderrLevel,
+		tg.dockerConfig.LevelLabel,
+		tg.levelDetectionRegex,
+		tg.swarmEnricher,
+		tg.dockerConfig.StripLinePrefix,
+		tg.dockerConfig.NormalizeWhitespace,
 	)
 	if err != nil {
 		return err
@@ -131,6 +228,77 @@ func (tg *targetGroup) addTarget(id string, discoveredLabels model.LabelSet) err
 	return nil
 }
 
+// containerCreatedLabel inspects the container with the given id and
+// returns its creation time (RFC 3339, as reported by the Docker daemon)
+// as the dockerLabelContainerCreated label, so relabel rules can filter
+// containers by age, e.g. to skip tailing containers that started more
+// than some threshold ago and are likely stuck in a failed state.
+func (tg *targetGroup) containerCreatedLabel(id string) (model.LabelSet, error) {
+	info, err := tg.client.ContainerInspect(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	return model.LabelSet{dockerLabelContainerCreated: model.LabelValue(info.Created)}, nil
+}
+
+// unsupportedPlatformReason inspects the container with the given id and
+// reports whether its platform isn't one this target can read logs from
+// (currently, anything other than "linux"), along with a human-readable
+// reason for the caller to record against the dropped target. A failed
+// inspect, or a daemon that doesn't report a platform at all (older API
+// versions), is treated as supported: ContainerLogs is the authoritative
+// check either way, and this is only meant to catch the common LCOW case
+// of Windows containers turning up on a mixed-platform host up front,
+// rather than after they've already failed once.
+func (tg *targetGroup) unsupportedPlatformReason(id string) (string, bool) {
+	info, err := tg.client.ContainerInspect(context.Background(), id)
+	if err != nil {
+		return "", false
+	}
+	if info.Platform == "" || info.Platform == "linux" {
+		return "", false
+	}
+	return fmt.Sprintf("unsupported container platform %q", info.Platform), true
+}
+
+// runningCount returns the number of targets currently running. Callers
+// must hold tg.mtx.
+func (tg *targetGroup) runningCount() int {
+	var running int
+	for _, t := range tg.targets {
+		if t.Ready() {
+			running++
+		}
+	}
+	return running
+}
+
+// isQueued reports whether id is already waiting in tg.queue. Callers must
+// hold tg.mtx.
+func (tg *targetGroup) isQueued(id string) bool {
+	for _, q := range tg.queue {
+		if q.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// promoteQueued starts as many queued containers as dockerConfig.MaxContainers
+// allows given the currently running targets. Callers must hold tg.mtx.
+func (tg *targetGroup) promoteQueued() {
+	for len(tg.queue) > 0 {
+		if tg.dockerConfig.MaxContainers > 0 && tg.runningCount() >= tg.dockerConfig.MaxContainers {
+			return
+		}
+		next := tg.queue[0]
+		tg.queue = tg.queue[1:]
+		if err := tg.startTarget(next.id, next.labels); err != nil {
+			level.Error(tg.logger).Log("msg", "could not start queued target", "containerID", next.id, "err", err)
+		}
+	}
+}
+
 // Ready returns true if at least one target is running.
 func (tg *targetGroup) Ready() bool {
 	tg.mtx.Lock()
@@ -151,7 +319,7 @@ func (tg *targetGroup) Stop() {
 	defer tg.mtx.Unlock()
 
 	for _, t := range tg.targets {
-		t.Stop()
+		t.Close()
 	}
 	tg.entryHandler.Stop()
 }
@@ -170,11 +338,18 @@ func (tg *targetGroup) ActiveTargets() []target.Target {
 	return result
 }
 
-// AllTargets returns all targets of this group.
+// AllTargets returns all targets of this group, including containers
+// dropped by the platform check in addTarget.
 func (tg *targetGroup) AllTargets() []target.Target {
-	result := make([]target.Target, 0, len(tg.targets))
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+
+	result := make([]target.Target, 0, len(tg.targets)+len(tg.droppedTargets))
 	for _, t := range tg.targets {
 		result = append(result, t)
 	}
+	for _, t := range tg.droppedTargets {
+		result = append(result, t)
+	}
 	return result
 }
@@ -0,0 +1,285 @@
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+)
+
+// newTestTargetGroup returns a targetGroup wired to a fake Docker daemon
+// that serves an empty, never-ending log stream for any container, so
+// targets it starts stay running without producing entries.
+func newTestTargetGroup(t *testing.T, maxContainers int) *targetGroup {
+	t.Helper()
+
+	daemon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/logs"):
+			// Block without writing anything so the target's process loop
+			// stays up until the test stops it.
+			<-r.Context().Done()
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			info := types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{},
+				Mounts:            []types.MountPoint{},
+				Config:            &container.Config{Tty: false},
+				NetworkSettings:   &types.NetworkSettings{},
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(info))
+		}
+	}))
+	t.Cleanup(daemon.Close)
+
+	c, err := client.NewClientWithOpts(client.WithHost(daemon.URL), client.WithHTTPClient(daemon.Client()))
+	require.NoError(t, err)
+
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	return &targetGroup{
+		metrics:       NewMetrics(prometheus.NewRegistry()),
+		logger:        log.NewNopLogger(),
+		positions:     ps,
+		targets:       make(map[string]*Target),
+		entryHandler:  fake.New(func() {}),
+		defaultLabels: model.LabelSet{},
+		dockerConfig:  scrapeconfig.DockerTargetConfig{MaxContainers: maxContainers},
+		client:        c,
+	}
+}
+
+func Test_TargetGroup_MaxContainers_Queues(t *testing.T) {
+	tg := newTestTargetGroup(t, 1)
+	defer tg.Stop()
+
+	addTestTarget(t, tg, "container-1")
+	addTestTarget(t, tg, "container-2")
+
+	require.Eventually(t, func() bool {
+		tg.mtx.Lock()
+		defer tg.mtx.Unlock()
+		return tg.runningCount() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	tg.mtx.Lock()
+	require.Len(t, tg.targets, 1)
+	require.Len(t, tg.queue, 1)
+	require.Equal(t, "container-2", tg.queue[0].id)
+	tg.mtx.Unlock()
+}
+
+func Test_TargetGroup_MaxContainers_PromotesOnStop(t *testing.T) {
+	tg := newTestTargetGroup(t, 1)
+	defer tg.Stop()
+
+	addTestTarget(t, tg, "container-1")
+	addTestTarget(t, tg, "container-2")
+
+	require.Eventually(t, func() bool {
+		tg.mtx.Lock()
+		defer tg.mtx.Unlock()
+		return len(tg.targets) == 1 && len(tg.queue) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	tg.mtx.Lock()
+	tg.targets["container-1"].Stop()
+	tg.mtx.Unlock()
+
+	// A fresh sync gives promoteQueued a chance to run.
+	tg.sync(nil)
+
+	require.Eventually(t, func() bool {
+		tg.mtx.Lock()
+		defer tg.mtx.Unlock()
+		_, ok := tg.targets["container-2"]
+		return ok && len(tg.queue) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_TargetGroup_AddsContainerCreatedLabel(t *testing.T) {
+	const created = "2024-01-15T10:00:00Z"
+
+	daemon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/logs"):
+			<-r.Context().Done()
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			info := types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{Created: created},
+				Mounts:            []types.MountPoint{},
+				Config:            &container.Config{Tty: false},
+				NetworkSettings:   &types.NetworkSettings{},
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(info))
+		}
+	}))
+	t.Cleanup(daemon.Close)
+
+	c, err := client.NewClientWithOpts(client.WithHost(daemon.URL), client.WithHTTPClient(daemon.Client()))
+	require.NoError(t, err)
+
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	tg := &targetGroup{
+		metrics:       NewMetrics(prometheus.NewRegistry()),
+		logger:        log.NewNopLogger(),
+		positions:     ps,
+		targets:       make(map[string]*Target),
+		entryHandler:  fake.New(func() {}),
+		defaultLabels: model.LabelSet{},
+		dockerConfig:  scrapeconfig.DockerTargetConfig{},
+		client:        c,
+	}
+	defer tg.Stop()
+
+	addTestTarget(t, tg, "container-1")
+
+	require.Eventually(t, func() bool {
+		tg.mtx.Lock()
+		defer tg.mtx.Unlock()
+		target, ok := tg.targets["container-1"]
+		return ok && target.Labels()[dockerLabelContainerCreated] == model.LabelValue(created)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_TargetGroup_SkipsUnsupportedPlatform(t *testing.T) {
+	daemon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		info := types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{Platform: "windows"},
+			Mounts:            []types.MountPoint{},
+			Config:            &container.Config{Tty: false},
+			NetworkSettings:   &types.NetworkSettings{},
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(info))
+	}))
+	t.Cleanup(daemon.Close)
+
+	c, err := client.NewClientWithOpts(client.WithHost(daemon.URL), client.WithHTTPClient(daemon.Client()))
+	require.NoError(t, err)
+
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	tg := &targetGroup{
+		metrics:        NewMetrics(prometheus.NewRegistry()),
+		logger:         log.NewNopLogger(),
+		positions:      ps,
+		targets:        make(map[string]*Target),
+		droppedTargets: make(map[string]target.Target),
+		entryHandler:   fake.New(func() {}),
+		defaultLabels:  model.LabelSet{},
+		dockerConfig:   scrapeconfig.DockerTargetConfig{},
+		client:         c,
+	}
+	defer tg.Stop()
+
+	addTestTarget(t, tg, "container-1")
+
+	tg.mtx.Lock()
+	require.Empty(t, tg.targets)
+	tg.mtx.Unlock()
+
+	all := tg.AllTargets()
+	require.Len(t, all, 1)
+	require.Equal(t, target.DroppedTargetType, all[0].Type())
+	require.Contains(t, all[0].Details(), `"windows"`)
+}
+
+func Test_TargetGroup_AllowForeignPlatforms_StartsAnyway(t *testing.T) {
+	daemon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/logs"):
+			<-r.Context().Done()
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			info := types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{Platform: "windows"},
+				Mounts:            []types.MountPoint{},
+				Config:            &container.Config{Tty: false},
+				NetworkSettings:   &types.NetworkSettings{},
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(info))
+		}
+	}))
+	t.Cleanup(daemon.Close)
+
+	c, err := client.NewClientWithOpts(client.WithHost(daemon.URL), client.WithHTTPClient(daemon.Client()))
+	require.NoError(t, err)
+
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	tg := &targetGroup{
+		metrics:        NewMetrics(prometheus.NewRegistry()),
+		logger:         log.NewNopLogger(),
+		positions:      ps,
+		targets:        make(map[string]*Target),
+		droppedTargets: make(map[string]target.Target),
+		entryHandler:   fake.New(func() {}),
+		defaultLabels:  model.LabelSet{},
+		dockerConfig:   scrapeconfig.DockerTargetConfig{AllowForeignPlatforms: true},
+		client:         c,
+	}
+	defer tg.Stop()
+
+	addTestTarget(t, tg, "container-1")
+
+	require.Eventually(t, func() bool {
+		tg.mtx.Lock()
+		defer tg.mtx.Unlock()
+		return len(tg.targets) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_TargetGroup_NoLimit_StartsAllImmediately(t *testing.T) {
+	tg := newTestTargetGroup(t, 0)
+	defer tg.Stop()
+
+	addTestTarget(t, tg, "container-1")
+	addTestTarget(t, tg, "container-2")
+
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+	require.Len(t, tg.targets, 2)
+	require.Empty(t, tg.queue)
+}
+
+// addTestTarget calls tg.addTarget while holding tg.mtx, as sync() would.
+func addTestTarget(t *testing.T, tg *targetGroup, id string) {
+	t.Helper()
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+	require.NoError(t, tg.addTarget(id, model.LabelSet{dockerLabelContainerID: model.LabelValue(id)}))
+}
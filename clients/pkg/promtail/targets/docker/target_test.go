@@ -1,23 +1,32 @@
 package docker
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	uatomic "go.uber.org/atomic"
 
 	"github.com/grafana/loki/v3/clients/pkg/promtail/client/fake"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
@@ -86,6 +95,21 @@ func Test_DockerTarget(t *testing.T) {
 		[]*relabel.Config{},
 		client,
 		0,
+		"",
+		false,
+		0,
+		0,
+		"",
+		"",
+		false,
+		false,
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		"",
+		false,
 	)
 	require.NoError(t, err)
 
@@ -117,6 +141,156 @@ func Test_DockerTarget(t *testing.T) {
 	}, 5*time.Second, 100*time.Millisecond, "Expected log lines after restart were not found within the time limit.")
 }
 
+// recreatedContainerDaemon is a fake Docker daemon whose ContainerInspect
+// response swaps to a new container ID, behind the same stable Name, once
+// swap is called. It also records the "since" query parameter each /logs
+// request was made with, so a test can confirm a target reset its position
+// to zero rather than resuming the old container's Since.
+type recreatedContainerDaemon struct {
+	mtx       sync.Mutex
+	swapped   bool
+	lastSince string
+}
+
+func (d *recreatedContainerDaemon) swap() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.swapped = true
+}
+
+func (d *recreatedContainerDaemon) server(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.mtx.Lock()
+		swapped := d.swapped
+		d.mtx.Unlock()
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/logs"):
+			d.mtx.Lock()
+			d.lastSince = r.URL.Query().Get("since")
+			d.mtx.Unlock()
+
+			filePath := "testdata/flog.log"
+			if swapped {
+				filePath = "testdata/flog_after_restart.log"
+			}
+			dat, err := os.ReadFile(filePath)
+			require.NoError(t, err)
+			_, err = w.Write(dat)
+			require.NoError(t, err)
+		default:
+			id := "container-before-recreate"
+			if swapped {
+				id = "container-after-recreate"
+			}
+			w.Header().Set("Content-Type", "application/json")
+			info := types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{ID: id, Name: "/stable-name"},
+				Mounts:            []types.MountPoint{},
+				Config:            &container.Config{},
+				NetworkSettings:   &types.NetworkSettings{},
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(info))
+		}
+	}))
+}
+
+// Test_DockerTarget_ContainerRecreatedSameName covers a container removed
+// and recreated with the same name while a target is reconnecting: the
+// Docker daemon keeps handing back the same name but a different ID. The
+// target must notice the ID changed and reset Since to zero, so the new
+// container's early lines are read rather than skipped as if they were a
+// continuation of the old container's stream.
+func Test_DockerTarget_ContainerRecreatedSameName(t *testing.T) {
+	d := &recreatedContainerDaemon{}
+	ts := d.server(t)
+	defer ts.Close()
+
+	logger := log.NewNopLogger()
+	entryHandler := fake.New(func() {})
+	c, err := client.NewClientWithOpts(client.WithHost(ts.URL))
+	require.NoError(t, err)
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	target, err := NewTarget(
+		NewMetrics(prometheus.NewRegistry()),
+		logger,
+		entryHandler,
+		ps,
+		"stable-name",
+		model.LabelSet{"job": "docker"},
+		[]*relabel.Config{},
+		c,
+		0,
+		"",
+		false,
+		0,
+		0,
+		"",
+		"",
+		false,
+		false,
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		"",
+		false,
+	)
+	require.NoError(t, err)
+
+	expectedLines := []string{
+		"5.3.69.55 - - [09/Dec/2021:09:15:02 +0000] \"HEAD /brand/users/clicks-and-mortar/front-end HTTP/2.0\" 503 27087",
+		"101.54.183.185 - - [09/Dec/2021:09:15:03 +0000] \"POST /next-generation HTTP/1.0\" 416 11468",
+		"69.27.137.160 - runolfsdottir2670 [09/Dec/2021:09:15:03 +0000] \"HEAD /content/visionary/engineer/cultivate HTTP/1.1\" 302 2975",
+		"28.104.242.74 - - [09/Dec/2021:09:15:03 +0000] \"PATCH /value-added/cultivate/systems HTTP/2.0\" 405 11843",
+		"150.187.51.54 - satterfield1852 [09/Dec/2021:09:15:03 +0000] \"GET /incentivize/deliver/innovative/cross-platform HTTP/1.1\" 301 13032",
+	}
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assertExpectedLog(c, entryHandler, expectedLines)
+	}, 5*time.Second, 100*time.Millisecond, "Expected log lines were not found within the time limit.")
+
+	details, ok := target.Details().(map[string]string)
+	require.True(t, ok)
+	require.Equal(t, "container-before-recreate", details["container_id"])
+	require.Equal(t, "stable-name", details["name"])
+
+	target.Stop()
+	entryHandler.Clear()
+	d.swap()
+
+	// Reconnect. The daemon now reports a new ID behind the same name;
+	// the target should read the new container's logs from the start
+	// rather than resuming the old container's Since.
+	target.startIfNotRunning()
+
+	expectedLinesAfterRecreate := []string{
+		"243.115.12.215 - - [09/Dec/2023:09:16:57 +0000] \"DELETE /morph/exploit/granular HTTP/1.0\" 500 26468",
+		"221.41.123.237 - - [09/Dec/2023:09:16:57 +0000] \"DELETE /user-centric/whiteboard HTTP/2.0\" 205 22487",
+		"89.111.144.144 - - [09/Dec/2023:09:16:57 +0000] \"DELETE /open-source/e-commerce HTTP/1.0\" 401 11092",
+		"62.180.191.187 - - [09/Dec/2023:09:16:57 +0000] \"DELETE /cultivate/integrate/technologies HTTP/2.0\" 302 12979",
+		"156.249.2.192 - - [09/Dec/2023:09:16:57 +0000] \"POST /revolutionize/mesh/metrics HTTP/2.0\" 401 5297",
+	}
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assertExpectedLog(c, entryHandler, expectedLinesAfterRecreate)
+	}, 5*time.Second, 100*time.Millisecond, "Expected log lines from the recreated container were not found within the time limit.")
+
+	d.mtx.Lock()
+	lastSince := d.lastSince
+	d.mtx.Unlock()
+	require.Equal(t, "0", lastSince, "target should have reset Since to 0 after detecting the container was recreated")
+
+	details, ok = target.Details().(map[string]string)
+	require.True(t, ok)
+	require.Equal(t, "container-after-recreate", details["container_id"])
+}
+
 func doTestPartial(t *testing.T, tty bool) {
 	var filePath string
 	if tty {
@@ -151,6 +325,21 @@ func doTestPartial(t *testing.T, tty bool) {
 		[]*relabel.Config{},
 		client,
 		0,
+		"",
+		false,
+		0,
+		0,
+		"",
+		"",
+		false,
+		false,
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		"",
+		false,
 	)
 	require.NoError(t, err)
 
@@ -195,3 +384,923 @@ func containsString(slice []string, str string) bool {
 	}
 	return false
 }
+
+// pausableDaemon serves an inspect endpoint whose labels can be toggled at
+// runtime, and a /logs endpoint that streams one line per push on lines.
+type pausableDaemon struct {
+	mtx    sync.Mutex
+	labels map[string]string
+	lines  chan string
+}
+
+func newPausableDaemon(t *testing.T) (*httptest.Server, *pausableDaemon) {
+	t.Helper()
+	d := &pausableDaemon{labels: map[string]string{}, lines: make(chan string, 16)}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/logs"):
+			flusher, ok := w.(http.Flusher)
+			require.True(t, ok)
+			out := stdcopy.NewStdWriter(w, stdcopy.Stdout)
+			for {
+				select {
+				case <-r.Context().Done():
+					return
+				case line := <-d.lines:
+					_, err := out.Write([]byte(time.Now().UTC().Format("2006-01-02T15:04:05.999999999Z07:00") + " " + line + "\n"))
+					if err != nil {
+						return
+					}
+					flusher.Flush()
+				}
+			}
+		default:
+			d.mtx.Lock()
+			labels := make(map[string]string, len(d.labels))
+			for k, v := range d.labels {
+				labels[k] = v
+			}
+			d.mtx.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+			info := types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{},
+				Mounts:            []types.MountPoint{},
+				Config:            &container.Config{Labels: labels},
+				NetworkSettings:   &types.NetworkSettings{},
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(info))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, d
+}
+
+func (d *pausableDaemon) setPaused(paused bool) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if paused {
+		d.labels["promtail.pause"] = "true"
+	} else {
+		delete(d.labels, "promtail.pause")
+	}
+}
+
+func Test_DockerTarget_Pause_KeepReading(t *testing.T) {
+	origInterval := pauseCheckInterval
+	pauseCheckInterval = 20 * time.Millisecond
+	defer func() { pauseCheckInterval = origInterval }()
+
+	srv, daemon := newPausableDaemon(t)
+
+	logger := log.NewNopLogger()
+	entryHandler := fake.New(func() {})
+	c, err := client.NewClientWithOpts(client.WithHost(srv.URL))
+	require.NoError(t, err)
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	target, err := NewTarget(
+		NewMetrics(prometheus.NewRegistry()),
+		logger,
+		entryHandler,
+		ps,
+		"flog",
+		model.LabelSet{"job": "docker"},
+		[]*relabel.Config{},
+		c,
+		0,
+		"promtail.pause",
+		false,
+		0,
+		0,
+		"",
+		"",
+		false,
+		false,
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		"",
+		false,
+	)
+	require.NoError(t, err)
+	defer target.Close()
+
+	daemon.lines <- "before-pause"
+	assert.EventuallyWithT(t, func(ct *assert.CollectT) {
+		assertExpectedLog(ct, entryHandler, []string{"before-pause"})
+	}, 5*time.Second, 50*time.Millisecond)
+
+	daemon.setPaused(true)
+	require.Eventually(t, func() bool {
+		return target.paused.Load()
+	}, time.Second, 10*time.Millisecond)
+
+	entryHandler.Clear()
+	daemon.lines <- "during-pause"
+	time.Sleep(200 * time.Millisecond)
+	require.Empty(t, entryHandler.Received(), "no entries should be sent while paused")
+	require.True(t, target.Ready(), "target should keep reading, not stop, while paused")
+
+	daemon.setPaused(false)
+	require.Eventually(t, func() bool {
+		return !target.paused.Load()
+	}, time.Second, 10*time.Millisecond)
+
+	daemon.lines <- "after-resume"
+	assert.EventuallyWithT(t, func(ct *assert.CollectT) {
+		assertExpectedLog(ct, entryHandler, []string{"after-resume"})
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func Test_DockerTarget_Pause_StopsReading(t *testing.T) {
+	origInterval := pauseCheckInterval
+	pauseCheckInterval = 20 * time.Millisecond
+	defer func() { pauseCheckInterval = origInterval }()
+
+	srv, daemon := newPausableDaemon(t)
+
+	logger := log.NewNopLogger()
+	entryHandler := fake.New(func() {})
+	c, err := client.NewClientWithOpts(client.WithHost(srv.URL))
+	require.NoError(t, err)
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	target, err := NewTarget(
+		NewMetrics(prometheus.NewRegistry()),
+		logger,
+		entryHandler,
+		ps,
+		"flog",
+		model.LabelSet{"job": "docker"},
+		[]*relabel.Config{},
+		c,
+		0,
+		"promtail.pause",
+		true,
+		0,
+		0,
+		"",
+		"",
+		false,
+		false,
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		"",
+		false,
+	)
+	require.NoError(t, err)
+	defer target.Close()
+
+	require.Eventually(t, func() bool {
+		return target.Ready()
+	}, time.Second, 10*time.Millisecond)
+
+	daemon.setPaused(true)
+	require.Eventually(t, func() bool {
+		return !target.Ready()
+	}, time.Second, 10*time.Millisecond, "target should stop reading while paused")
+
+	daemon.setPaused(false)
+	require.Eventually(t, func() bool {
+		return target.Ready()
+	}, time.Second, 10*time.Millisecond, "target should resume reading once unpaused")
+}
+
+func Test_DockerTarget_ErrorRecoveryIgnore_StaysReadyAfterError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	logger := log.NewNopLogger()
+	entryHandler := fake.New(func() {})
+	c, err := client.NewClientWithOpts(client.WithHost(ts.URL))
+	require.NoError(t, err)
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	target, err := NewTarget(
+		NewMetrics(prometheus.NewRegistry()),
+		logger,
+		entryHandler,
+		ps,
+		"flog",
+		model.LabelSet{"job": "docker"},
+		[]*relabel.Config{},
+		c,
+		0,
+		"",
+		false,
+		0,
+		0,
+		ErrorRecoveryIgnore,
+		"",
+		false,
+		false,
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		"",
+		false,
+	)
+	require.NoError(t, err)
+	defer target.Close()
+
+	require.Eventually(t, func() bool {
+		return target.Ready()
+	}, time.Second, 10*time.Millisecond, "target should stay ready despite the inspect error, since it's ignored")
+}
+
+func Test_DockerTarget_ErrorRecoveryRetry_ReconnectsAfterError(t *testing.T) {
+	origCfg := reconnectBackoffConfig
+	reconnectBackoffConfig.MinBackoff = 10 * time.Millisecond
+	reconnectBackoffConfig.MaxBackoff = 20 * time.Millisecond
+	defer func() { reconnectBackoffConfig = origCfg }()
+
+	var failuresLeft atomic.Int32
+	failuresLeft.Store(2)
+	var logsServed atomic.Bool
+	h := handlerForPath(t, []urlContainToPath{{"", "testdata/flog.log"}}, false)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/logs") {
+			if failuresLeft.Add(-1) >= 0 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+		// Serve the log content only once, so a later reconnect attempt
+		// (ErrorRecoveryRetry always reconnects, whether the previous
+		// attempt ended in success or failure) doesn't re-emit the same
+		// lines and trip up assertExpectedLog's duplicate check.
+		if !logsServed.CompareAndSwap(false, true) {
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	logger := log.NewNopLogger()
+	entryHandler := fake.New(func() {})
+	c, err := client.NewClientWithOpts(client.WithHost(ts.URL))
+	require.NoError(t, err)
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	target, err := NewTarget(
+		NewMetrics(prometheus.NewRegistry()),
+		logger,
+		entryHandler,
+		ps,
+		"flog",
+		model.LabelSet{"job": "docker"},
+		[]*relabel.Config{},
+		c,
+		0,
+		"",
+		false,
+		0,
+		0,
+		ErrorRecoveryRetry,
+		"",
+		false,
+		false,
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		"",
+		false,
+	)
+	require.NoError(t, err)
+	defer target.Close()
+
+	expectedLines := []string{
+		"5.3.69.55 - - [09/Dec/2021:09:15:02 +0000] \"HEAD /brand/users/clicks-and-mortar/front-end HTTP/2.0\" 503 27087",
+	}
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assertExpectedLog(c, entryHandler, expectedLines)
+	}, 5*time.Second, 50*time.Millisecond, "target should reconnect after the inspect errors and eventually read logs")
+}
+
+func Test_NewTargetFromEnv(t *testing.T) {
+	h := handlerForPath(t, []urlContainToPath{{"", "testdata/flog.log"}}, false)
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	t.Setenv("DOCKER_HOST", ts.URL)
+	t.Setenv("DOCKER_TLS_VERIFY", "")
+	t.Setenv("DOCKER_CERT_PATH", "")
+
+	w := log.NewSyncWriter(os.Stderr)
+	logger := log.NewLogfmtLogger(w)
+	entryHandler := fake.New(func() {})
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	target, err := NewTargetFromEnv(
+		NewMetrics(prometheus.NewRegistry()),
+		logger,
+		entryHandler,
+		ps,
+		"flog",
+		model.LabelSet{"job": "docker"},
+		[]*relabel.Config{},
+		0,
+		"",
+		false,
+		0,
+		0,
+		"",
+		"",
+		false,
+		false,
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		"",
+		false,
+	)
+	require.NoError(t, err)
+	defer target.Stop()
+
+	require.Eventually(t, func() bool {
+		return target.Ready()
+	}, time.Second, 10*time.Millisecond, "target built from the environment should start reading")
+}
+
+func Test_extractTs(t *testing.T) {
+	for name, line := range map[string]string{
+		"RFC3339Nano":            "2019-05-01T15:04:05.999999999Z hello world",
+		"RFC3339 no nanoseconds": "2019-05-01T15:04:05Z hello world",
+		"space-separated":        "2019-05-01 15:04:05.999999999Z hello world",
+		"with offset":            "2019-05-01T15:04:05.999999999+02:00 hello world",
+	} {
+		t.Run(name, func(t *testing.T) {
+			ts, payload, err := extractTs(line)
+			require.NoError(t, err)
+			assert.Equal(t, "hello world", payload)
+			assert.Equal(t, 2019, ts.Year())
+		})
+	}
+
+	t.Run("leading non-printable bytes are skipped", func(t *testing.T) {
+		ts, payload, err := extractTs("\x00\x01\x02" + "2019-05-01T15:04:05.999999999Z hello world")
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", payload)
+		assert.Equal(t, 2019, ts.Year())
+	})
+
+	t.Run("unparseable line ships with time.Now() instead of an error payload", func(t *testing.T) {
+		before := time.Now()
+		ts, payload, err := extractTs("garbage with no timestamp")
+		require.Error(t, err)
+		assert.Equal(t, "garbage with no timestamp", payload)
+		assert.WithinDuration(t, before, ts, time.Second)
+	})
+}
+
+// Test_process_StripLinePrefix confirms a configured stripLinePrefix is
+// removed before extractTs runs, so a runtime-added flag like containerd's
+// "F "/"P " ahead of Docker's own timestamp doesn't make the line look
+// unparseable.
+func Test_process_StripLinePrefix(t *testing.T) {
+	entryHandler := fake.New(func() {})
+	defer entryHandler.Stop()
+
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+	defer ps.Stop()
+
+	target := &Target{
+		logger:          log.NewNopLogger(),
+		handler:         entryHandler,
+		positions:       ps,
+		containerName:   "strip-prefix",
+		labels:          model.LabelSet{"job": "docker"},
+		metrics:         NewMetrics(prometheus.NewRegistry()),
+		levelLabel:      defaultLevelLabel,
+		paused:          uatomic.NewBool(false),
+		stripLinePrefix: "F ",
+	}
+
+	buf := newFrameBuffer(target.metrics, "strip-prefix", "stdout", 0, 0)
+	buf.push([]byte("F 2019-05-01T15:04:05.999999999Z hello world\n"))
+	buf.close()
+
+	target.wg.Add(1)
+	target.process(buf, "stdout")
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assertExpectedLog(c, entryHandler, []string{"hello world"})
+	}, 5*time.Second, 100*time.Millisecond, "stripped line was not received")
+}
+
+// Test_process_NormalizeWhitespace confirms normalizeWhitespace trims
+// trailing spaces and tabs from a fully assembled line, and that a plain
+// "\r\n"/"\n" terminator is stripped either way, since that already happens
+// unconditionally before normalizeWhitespace ever runs.
+func Test_process_NormalizeWhitespace(t *testing.T) {
+	for name, tc := range map[string]struct {
+		normalize bool
+		frame     string
+		expected  string
+	}{
+		"trims trailing spaces and tabs when enabled": {
+			normalize: true,
+			frame:     "2019-05-01T15:04:05.999999999Z hello world  \t\n",
+			expected:  "hello world",
+		},
+		"leaves trailing whitespace alone when disabled": {
+			normalize: false,
+			frame:     "2019-05-01T15:04:05.999999999Z hello world  \t\n",
+			expected:  "hello world  \t",
+		},
+		"CRLF terminator is stripped regardless of the flag": {
+			normalize: false,
+			frame:     "2019-05-01T15:04:05.999999999Z hello world\r\n",
+			expected:  "hello world",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			entryHandler := fake.New(func() {})
+			defer entryHandler.Stop()
+
+			ps, err := positions.New(log.NewNopLogger(), positions.Config{
+				SyncPeriod:    10 * time.Second,
+				PositionsFile: t.TempDir() + "/positions.yml",
+			})
+			require.NoError(t, err)
+			defer ps.Stop()
+
+			target := &Target{
+				logger:              log.NewNopLogger(),
+				handler:             entryHandler,
+				positions:           ps,
+				containerName:       "normalize-whitespace",
+				labels:              model.LabelSet{"job": "docker"},
+				metrics:             NewMetrics(prometheus.NewRegistry()),
+				levelLabel:          defaultLevelLabel,
+				paused:              uatomic.NewBool(false),
+				normalizeWhitespace: tc.normalize,
+			}
+
+			buf := newFrameBuffer(target.metrics, "normalize-whitespace", "stdout", 0, 0)
+			buf.push([]byte(tc.frame))
+			buf.close()
+
+			target.wg.Add(1)
+			target.process(buf, "stdout")
+
+			assert.EventuallyWithT(t, func(c *assert.CollectT) {
+				assertExpectedLog(c, entryHandler, []string{tc.expected})
+			}, 5*time.Second, 100*time.Millisecond, "expected line was not received")
+		})
+	}
+}
+
+// Test_process_NormalizeWhitespace_MultiFrameLine confirms normalizeWhitespace
+// trims only the trailing whitespace on the fully assembled line, not
+// whitespace that happens to fall at the end of an interior frame of a line
+// split across multiple frames (docker's own event framing, independent of
+// promtail's line-length-triggered splitting).
+func Test_process_NormalizeWhitespace_MultiFrameLine(t *testing.T) {
+	entryHandler := fake.New(func() {})
+	defer entryHandler.Stop()
+
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+	defer ps.Stop()
+
+	target := &Target{
+		logger:              log.NewNopLogger(),
+		handler:             entryHandler,
+		positions:           ps,
+		containerName:       "normalize-whitespace-multi-frame",
+		labels:              model.LabelSet{"job": "docker"},
+		metrics:             NewMetrics(prometheus.NewRegistry()),
+		levelLabel:          defaultLevelLabel,
+		paused:              uatomic.NewBool(false),
+		normalizeWhitespace: true,
+	}
+
+	buf := newFrameBuffer(target.metrics, "normalize-whitespace-multi-frame", "stdout", 0, 0)
+	// Same timestamp on every frame, and no trailing "\n" on the first two,
+	// mirrors how Docker splits a single event across several frames.
+	buf.push([]byte("2019-05-01T15:04:05.999999999Z hello "))
+	buf.push([]byte("2019-05-01T15:04:05.999999999Z world  \t\n"))
+	buf.close()
+
+	target.wg.Add(1)
+	target.process(buf, "stdout")
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assertExpectedLog(c, entryHandler, []string{"hello world"})
+	}, 5*time.Second, 100*time.Millisecond, "joined line was not received")
+}
+
+// Test_extractTs_MalformedPrefixes feeds extractTs a corpus of malformed
+// and partial-header lines to confirm it never panics and never drops a
+// line: every input, however garbled, comes back with a non-empty payload.
+func Test_extractTs_MalformedPrefixes(t *testing.T) {
+	corpus := []string{
+		"",
+		" ",
+		"2019-05-01T15:04:05.999999999Z",
+		"\x00\x00\x00\x00",
+		"\x00\x00\x00\x00 ",
+		"2019-05-01",
+		"2019-05-01T15:04:05.999999999",
+		"not-a-timestamp hello world",
+		"\xffbroken utf8 hello world",
+		strings.Repeat("\x01", 100) + "2019-05-01T15:04:05Z tail",
+		"2019-13-40T99:99:99.999999999Z hello world",
+	}
+
+	for _, line := range corpus {
+		line := line
+		t.Run(line, func(t *testing.T) {
+			require.NotPanics(t, func() {
+				_, payload, err := extractTs(line)
+				if err != nil {
+					// Unparseable: the whole line ships as payload rather than being dropped.
+					assert.Equal(t, line, payload)
+				}
+			})
+		})
+	}
+}
+
+// Test_DockerTarget_PermissionError_RetriesUnderErrorRecoveryStop simulates
+// a docker socket owned by another group by chmod'ing a unix socket file
+// to remove read/write permission, and confirms the target keeps retrying
+// with backoff even though it's configured with ErrorRecoveryStop, since a
+// permission error is often fixed at runtime rather than requiring a
+// restart.
+func Test_DockerTarget_PermissionError_RetriesUnderErrorRecoveryStop(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("file permissions have no effect when running as root")
+	}
+
+	origCfg := reconnectBackoffConfig
+	reconnectBackoffConfig.MinBackoff = 10 * time.Millisecond
+	reconnectBackoffConfig.MaxBackoff = 20 * time.Millisecond
+	defer func() { reconnectBackoffConfig = origCfg }()
+
+	sockPath := t.TempDir() + "/docker.sock"
+	require.NoError(t, os.WriteFile(sockPath, nil, 0o000))
+
+	logger := log.NewNopLogger()
+	entryHandler := fake.New(func() {})
+	c, err := client.NewClientWithOpts(client.WithHost("unix://" + sockPath))
+	require.NoError(t, err)
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	target, err := NewTarget(
+		NewMetrics(prometheus.NewRegistry()),
+		logger,
+		entryHandler,
+		ps,
+		"flog",
+		model.LabelSet{"job": "docker"},
+		[]*relabel.Config{},
+		c,
+		0,
+		"",
+		false,
+		0,
+		0,
+		ErrorRecoveryStop,
+		sockPath,
+		false,
+		false,
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		"",
+		false,
+	)
+	require.NoError(t, err)
+	defer target.Close()
+
+	require.Eventually(t, func() bool {
+		return isPermissionError(target.err)
+	}, time.Second, 10*time.Millisecond, "target should keep retrying and surface a permission error, not give up under ErrorRecoveryStop")
+}
+
+func Test_DockerTarget_TrackContainerUptime(t *testing.T) {
+	created := time.Now().Add(-time.Hour).UTC()
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/logs"):
+			dat, err := os.ReadFile("testdata/flog.log")
+			require.NoError(t, err)
+			_, err = w.Write(dat)
+			require.NoError(t, err)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			info := types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{Created: created.Format(time.RFC3339Nano)},
+				Mounts:            []types.MountPoint{},
+				Config:            &container.Config{},
+				NetworkSettings:   &types.NetworkSettings{},
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(info))
+		}
+	})
+
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	logger := log.NewNopLogger()
+	entryHandler := fake.New(func() {})
+	c, err := client.NewClientWithOpts(client.WithHost(ts.URL))
+	require.NoError(t, err)
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	relabelConfig := []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{dockerLabelContainerUptime},
+			TargetLabel:  "uptime_seconds",
+			Replacement:  "$1",
+			Action:       relabel.Replace,
+			Regex:        relabel.MustNewRegexp("(.*)"),
+		},
+	}
+
+	target, err := NewTarget(
+		NewMetrics(prometheus.NewRegistry()),
+		logger,
+		entryHandler,
+		ps,
+		"flog",
+		model.LabelSet{"job": "docker"},
+		relabelConfig,
+		c,
+		0,
+		"",
+		false,
+		0,
+		0,
+		"",
+		"",
+		true,
+		false,
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		"",
+		false,
+	)
+	require.NoError(t, err)
+	defer target.Close()
+
+	require.False(t, target.containerCreatedAt.IsZero())
+
+	assert.EventuallyWithT(t, func(ct *assert.CollectT) {
+		entries := entryHandler.Received()
+		if !assert.NotEmpty(ct, entries) {
+			return
+		}
+		uptime, ok := entries[0].Labels["uptime_seconds"]
+		if !assert.True(ct, ok, "expected uptime_seconds label to be set") {
+			return
+		}
+		seconds, err := strconv.ParseFloat(string(uptime), 64)
+		assert.NoError(ct, err)
+		assert.Greater(ct, seconds, float64(0))
+	}, 5*time.Second, 100*time.Millisecond, "expected an entry with a uptime_seconds label")
+}
+
+func Test_isPermissionError(t *testing.T) {
+	_, err := os.Open("/nonexistent-path-for-test")
+	require.False(t, isPermissionError(err), "a not-exist error is not a permission error")
+
+	sockPath := t.TempDir() + "/docker.sock"
+	require.NoError(t, os.WriteFile(sockPath, nil, 0o000))
+	if os.Getuid() == 0 {
+		t.Skip("file permissions have no effect when running as root")
+	}
+	_, err = os.OpenFile(sockPath, os.O_RDWR, 0)
+	require.True(t, isPermissionError(err))
+}
+
+func newLevelTestTarget(t *testing.T, entryHandler *fake.Client, stdoutLevel, stderrLevel string, levelDetectionRegex *regexp.Regexp) *Target {
+	t.Helper()
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+	t.Cleanup(ps.Stop)
+
+	return &Target{
+		logger:              log.NewNopLogger(),
+		handler:             entryHandler,
+		positions:           ps,
+		containerName:       "flog",
+		labels:              model.LabelSet{"job": "docker"},
+		metrics:             NewMetrics(prometheus.NewRegistry()),
+		stdoutLevel:         stdoutLevel,
+		stderrLevel:         stderrLevel,
+		levelLabel:          defaultLevelLabel,
+		levelDetectionRegex: levelDetectionRegex,
+		running:             uatomic.NewBool(true),
+		paused:              uatomic.NewBool(false),
+	}
+}
+
+func Test_DockerTarget_LevelLabel_StderrDefault(t *testing.T) {
+	entryHandler := fake.New(func() {})
+	target := newLevelTestTarget(t, entryHandler, "", "error", nil)
+
+	target.handleOutput("stderr", time.Unix(1, 0), "boom")
+	target.handleOutput("stdout", time.Unix(2, 0), "all good")
+	entryHandler.Stop()
+
+	received := entryHandler.Received()
+	require.Len(t, received, 2)
+	require.Equal(t, model.LabelValue("error"), received[0].Labels["level"])
+	require.NotContains(t, received[1].Labels, model.LabelName("level"))
+}
+
+func Test_DockerTarget_LevelLabel_SkippedWhenLineAlreadyHasLevel(t *testing.T) {
+	entryHandler := fake.New(func() {})
+	target := newLevelTestTarget(t, entryHandler, "", "error", regexp.MustCompile(`(?i)\b(info|warn|error|debug)\b`))
+
+	target.handleOutput("stderr", time.Unix(1, 0), "level=warn something happened")
+	entryHandler.Stop()
+
+	received := entryHandler.Received()
+	require.Len(t, received, 1)
+	require.NotContains(t, received[0].Labels, model.LabelName("level"))
+}
+
+func Test_DockerTarget_LevelLabel_RelabelOverride(t *testing.T) {
+	entryHandler := fake.New(func() {})
+	target := newLevelTestTarget(t, entryHandler, "", "error", nil)
+	target.relabelConfig = []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{"level"},
+			Regex:        relabel.MustNewRegexp("error"),
+			Replacement:  "critical",
+			TargetLabel:  "level",
+			Action:       relabel.Replace,
+		},
+	}
+
+	target.handleOutput("stderr", time.Unix(1, 0), "boom")
+	entryHandler.Stop()
+
+	received := entryHandler.Received()
+	require.Len(t, received, 1)
+	require.Equal(t, model.LabelValue("critical"), received[0].Labels["level"])
+}
+
+// stopRaceAPIClient is a client.APIClient that serves ContainerInspect from a
+// canned response and ContainerLogs from a caller-supplied io.ReadCloser,
+// giving TestStopDuringProcessLoop precise control over exactly when and how
+// many log lines processLoop sees. Embedding client.APIClient satisfies the
+// rest of the (large) interface with nil method values Target never calls.
+type stopRaceAPIClient struct {
+	client.APIClient
+	logs io.ReadCloser
+}
+
+func (m *stopRaceAPIClient) ContainerInspect(_ context.Context, _ string) (types.ContainerJSON, error) {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{},
+		Config:            &container.Config{},
+	}, nil
+}
+
+func (m *stopRaceAPIClient) ContainerLogs(_ context.Context, _ string, _ container.LogsOptions) (io.ReadCloser, error) {
+	return m.logs, nil
+}
+
+// TestStopDuringProcessLoop guards against the class of concurrency bugs
+// where Stop() racing with an in-flight processLoop panics or deadlocks
+// instead of cleanly ending the log stream. Run with -race.
+func TestStopDuringProcessLoop(t *testing.T) {
+	pr, pw := io.Pipe()
+
+	tenWritten := make(chan struct{})
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		out := stdcopy.NewStdWriter(pw, stdcopy.Stdout)
+		for i := 0; ; i++ {
+			line := fmt.Sprintf("%s line %d\n", time.Now().UTC().Format(time.RFC3339Nano), i)
+			if _, err := out.Write([]byte(line)); err != nil {
+				return
+			}
+			if i == 9 {
+				close(tenWritten)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	logger := log.NewNopLogger()
+	entryHandler := fake.New(func() {})
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	target, err := NewTarget(
+		NewMetrics(prometheus.NewRegistry()),
+		logger,
+		entryHandler,
+		ps,
+		"race",
+		model.LabelSet{"job": "docker"},
+		[]*relabel.Config{},
+		&stopRaceAPIClient{logs: pr},
+		0,
+		"",
+		false,
+		0,
+		0,
+		"",
+		"",
+		false,
+		false,
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		"",
+		false,
+	)
+	require.NoError(t, err)
+
+	<-tenWritten
+	target.Stop()
+
+	// pw.Write above now fails with io.ErrClosedPipe once Stop() closes pr,
+	// so the writer goroutine is guaranteed to exit; wait for it so the test
+	// doesn't leak it.
+	select {
+	case <-writerDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writer goroutine did not exit after Stop()")
+	}
+}
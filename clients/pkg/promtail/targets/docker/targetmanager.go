@@ -3,6 +3,7 @@ package docker
 import (
 	"context"
 	"fmt"
+	"regexp"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -24,6 +25,28 @@ const (
 	dockerLabelContainerPrefix = dockerLabel + "container_"
 	dockerLabelContainerID     = dockerLabelContainerPrefix + "id"
 	dockerLabelLogStream       = dockerLabelContainerPrefix + "log_stream"
+
+	// dockerLabelContainerCreated isn't set by discovery/moby; the target
+	// group adds it itself from a ContainerInspect call, since ContainerList
+	// (what discovery/moby scans) doesn't return a container's creation
+	// time.
+	dockerLabelContainerCreated = dockerLabelContainerPrefix + "created"
+
+	// dockerLabelContainerUptime is set on every entry, not just once at
+	// discovery, since it changes continuously; see Target.trackUptime.
+	dockerLabelContainerUptime = dockerLabelContainerPrefix + "uptime_seconds"
+
+	// dockerLabelSwarmNodeHostname and dockerLabelSwarmServiceVIP aren't
+	// set by discovery/moby either; the target resolves them itself, once
+	// per connection attempt, via swarmEnricher.
+	dockerLabelSwarmNodeHostname = dockerLabelContainerPrefix + "swarm_node_hostname"
+	dockerLabelSwarmServiceVIP   = dockerLabelContainerPrefix + "swarm_service_vip"
+
+	// dockerLabelContainerHealth isn't set by discovery/moby either; the
+	// target re-inspects the container periodically to keep it current, so
+	// it can reflect transitions between the health states Docker reports
+	// (starting, healthy, unhealthy); see Target.trackHealth.
+	dockerLabelContainerHealth = dockerLabelContainerPrefix + "health"
 )
 
 type TargetManager struct {
@@ -80,23 +103,39 @@ func NewTargetManager(
 				return nil, err
 			}
 
+			var levelDetectionRegex *regexp.Regexp
+			if cfg.DockerConfig.LevelDetectionRegex != "" {
+				levelDetectionRegex, err = regexp.Compile(cfg.DockerConfig.LevelDetectionRegex)
+				if err != nil {
+					return nil, fmt.Errorf("could not compile level_detection_regex for job %q: %w", cfg.JobName, err)
+				}
+			}
+
 			for _, sdConfig := range cfg.DockerSDConfigs {
 				syncerKey := fmt.Sprintf("%s/%s:%d", cfg.JobName, sdConfig.Host, sdConfig.Port)
 				_, ok := tm.groups[syncerKey]
 				if !ok {
-					tm.groups[syncerKey] = &targetGroup{
-						metrics:          metrics,
-						logger:           logger,
-						positions:        positions,
-						targets:          make(map[string]*Target),
-						entryHandler:     pipeline.Wrap(pushClient),
-						defaultLabels:    model.LabelSet{},
-						relabelConfig:    cfg.RelabelConfigs,
-						host:             sdConfig.Host,
-						httpClientConfig: sdConfig.HTTPClientConfig,
-						refreshInterval:  sdConfig.RefreshInterval,
-						maxLineSize:      maxLineSize,
+					tg := &targetGroup{
+						metrics:             metrics,
+						logger:              logger,
+						jobName:             cfg.JobName,
+						positions:           positions,
+						targets:             make(map[string]*Target),
+						droppedTargets:      make(map[string]target.Target),
+						entryHandler:        pipeline.Wrap(pushClient),
+						defaultLabels:       model.LabelSet{},
+						relabelConfig:       cfg.RelabelConfigs,
+						dockerConfig:        cfg.DockerConfig,
+						host:                sdConfig.Host,
+						httpClientConfig:    sdConfig.HTTPClientConfig,
+						refreshInterval:     sdConfig.RefreshInterval,
+						maxLineSize:         maxLineSize,
+						levelDetectionRegex: levelDetectionRegex,
 					}
+					// swarmEnricher inspects containers through tg.client,
+					// which addTarget creates lazily on first use, so it's
+					// constructed there instead of here; see addTarget.
+					tm.groups[syncerKey] = tg
 				}
 				configs[syncerKey] = append(configs[syncerKey], sdConfig)
 			}
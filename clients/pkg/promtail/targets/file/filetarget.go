@@ -23,6 +23,15 @@ import (
 
 const (
 	FilenameLabel = "filename"
+
+	// RealPathLabel is set alongside FilenameLabel whenever
+	// Config.ResolveSymlinks resolves a tailed file to a different real
+	// path, carrying that real path. It's added directly as a stream
+	// label rather than as a dunder-prefixed meta label, since it's only
+	// known once a file is matched and opened, well after the target's
+	// own relabel_configs have already run against the discovered
+	// __path__.
+	RealPathLabel = "path_resolved"
 )
 
 var errFileTargetStopped = errors.New("File target is stopped")
@@ -31,6 +40,18 @@ var errFileTargetStopped = errors.New("File target is stopped")
 type Config struct {
 	SyncPeriod time.Duration `mapstructure:"sync_period" yaml:"sync_period"`
 	Stdin      bool          `mapstructure:"stdin" yaml:"stdin"`
+
+	// ResolveSymlinks enables resolving each tailed file's path with
+	// filepath.EvalSymlinks before it's used to track that file's read
+	// position, so a file reached through a symlink (common with
+	// Kubernetes pod log paths, which point at a container's real log
+	// file under a per-pod symlink) keeps its position tied to the real
+	// file rather than to whatever symlink name happened to reach it.
+	// This also means two glob matches that resolve to the same real
+	// file are only tailed once. A file that fails to resolve (e.g. it's
+	// not actually a symlink, or was removed) falls back to being
+	// tracked under its original path.
+	ResolveSymlinks bool `mapstructure:"resolve_symlinks,omitempty" yaml:"resolve_symlinks,omitempty"`
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
@@ -38,6 +59,7 @@ type Config struct {
 func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.DurationVar(&cfg.SyncPeriod, prefix+"target.sync-period", 10*time.Second, "Period to resync directories being watched and files being tailed.")
 	f.BoolVar(&cfg.Stdin, prefix+"stdin", false, "Set to true to pipe logs to promtail.")
+	f.BoolVar(&cfg.ResolveSymlinks, prefix+"target.resolve-symlinks", false, "Track each tailed file's position under its resolved real path rather than the path it was discovered at, so a symlinked file isn't tailed twice under different names.")
 }
 
 // RegisterFlags register flags.
@@ -103,6 +125,13 @@ type FileTarget struct {
 
 	readers      map[string]Reader
 	readersMutex sync.Mutex
+	// realPaths maps a resolved real path to the originally matched path
+	// currently being tailed for it, so a second matched path resolving
+	// to the same real file is recognized and skipped rather than tailed
+	// a second time. Only populated when targetConfig.ResolveSymlinks is
+	// set; guarded by readersMutex, since it's always updated alongside
+	// readers.
+	realPaths map[string]string
 
 	targetConfig *Config
 	watchConfig  WatchConfig
@@ -141,6 +170,7 @@ func NewFileTarget(
 		quit:               make(chan struct{}),
 		done:               make(chan struct{}),
 		readers:            map[string]Reader{},
+		realPaths:          map[string]string{},
 		targetConfig:       targetConfig,
 		watchConfig:        watchConfig,
 		fileEventWatcher:   fileEventWatcher,
@@ -184,8 +214,8 @@ func (t *FileTarget) Labels() model.LabelSet {
 func (t *FileTarget) Details() interface{} {
 	files := map[string]int64{}
 	t.readersMutex.Lock()
-	for fileName := range t.readers {
-		files[fileName], _ = t.positions.Get(fileName)
+	for fileName, reader := range t.readers {
+		files[fileName], _ = t.positions.Get(reader.Path())
 	}
 	t.readersMutex.Unlock()
 	return files
@@ -378,6 +408,19 @@ func (t *FileTarget) startTailing(ps []string) {
 			continue
 		}
 
+		positionPath, realPath := p, ""
+		if t.targetConfig.ResolveSymlinks {
+			if real, err := filepath.EvalSymlinks(p); err != nil {
+				level.Warn(t.logger).Log("msg", "failed to resolve symlinks, tracking position under the original path", "error", err, "filename", p)
+			} else if real != p {
+				if owner, ok := t.getRealPath(real); ok && owner != p {
+					level.Info(t.logger).Log("msg", "skipping file, already tailing an equivalent path via a different symlink", "filename", p, "real_path", real, "tailed_as", owner)
+					continue
+				}
+				positionPath, realPath = real, real
+			}
+		}
+
 		fi, err := os.Stat(p)
 		if err != nil {
 			level.Error(t.logger).Log("msg", "failed to tail file, stat failed", "error", err, "filename", p)
@@ -414,6 +457,14 @@ func (t *FileTarget) startTailing(ps []string) {
 				continue
 			}
 			reader = decompressor
+		} else if fi.Mode()&os.ModeNamedPipe != 0 {
+			level.Debug(t.logger).Log("msg", "reading from named pipe", "filename", p)
+			pipeTailer, err := newPipeTailer(t.metrics, t.logger, t.handler, p)
+			if err != nil {
+				level.Error(t.logger).Log("msg", "failed to start pipe tailer", "error", err, "filename", p)
+				continue
+			}
+			reader = pipeTailer
 		} else {
 			watchOptions := watch.PollingFileWatcherOptions{
 				MinPollFrequency: t.watchConfig.MinPollFrequency,
@@ -421,13 +472,16 @@ func (t *FileTarget) startTailing(ps []string) {
 			}
 
 			level.Debug(t.logger).Log("msg", "tailing new file", "filename", p)
-			tailer, err := newTailer(t.metrics, t.logger, t.handler, t.positions, watchOptions, p, t.encoding)
+			tailer, err := newTailer(t.metrics, t.logger, t.handler, t.positions, watchOptions, p, positionPath, t.encoding)
 			if err != nil {
 				level.Error(t.logger).Log("msg", "failed to start tailer", "error", err, "filename", p)
 				continue
 			}
 			reader = tailer
 		}
+		if realPath != "" {
+			t.setRealPath(realPath, p)
+		}
 		t.setReader(p, reader)
 	}
 }
@@ -440,6 +494,7 @@ func (t *FileTarget) stopTailingAndRemovePosition(ps []string) {
 			reader.Stop()
 			t.positions.Remove(reader.Path())
 			t.removeReader(p)
+			t.clearRealPath(p)
 		}
 	}
 }
@@ -456,6 +511,11 @@ func (t *FileTarget) pruneStoppedTailers() {
 	}
 	for _, tr := range toRemove {
 		delete(t.readers, tr)
+		for real, owner := range t.realPaths {
+			if owner == tr {
+				delete(t.realPaths, real)
+			}
+		}
 	}
 	t.readersMutex.Unlock()
 }
@@ -479,6 +539,34 @@ func (t *FileTarget) setReader(val string, reader Reader) {
 	t.readers[val] = reader
 }
 
+// getRealPath returns the matched path currently tailing real, and whether
+// one is registered; see FileTarget.realPaths.
+func (t *FileTarget) getRealPath(real string) (string, bool) {
+	t.readersMutex.Lock()
+	defer t.readersMutex.Unlock()
+	p, ok := t.realPaths[real]
+	return p, ok
+}
+
+func (t *FileTarget) setRealPath(real, p string) {
+	t.readersMutex.Lock()
+	defer t.readersMutex.Unlock()
+	t.realPaths[real] = p
+}
+
+// clearRealPath removes any realPaths entry owned by p, once p stops being
+// tailed, so a later match resolving to the same real path isn't skipped
+// as a duplicate of a reader that no longer exists.
+func (t *FileTarget) clearRealPath(p string) {
+	t.readersMutex.Lock()
+	defer t.readersMutex.Unlock()
+	for real, owner := range t.realPaths {
+		if owner == p {
+			delete(t.realPaths, real)
+		}
+	}
+}
+
 func (t *FileTarget) getWatch(val string) (struct{}, bool) {
 	t.watchesMutex.Lock()
 	defer t.watchesMutex.Unlock()
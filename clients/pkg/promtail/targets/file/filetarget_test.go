@@ -561,6 +561,57 @@ func TestFileTargetPathExclusion(t *testing.T) {
 	ps.Stop()
 }
 
+func TestFileTargetResolveSymlinks(t *testing.T) {
+	w := log.NewSyncWriter(os.Stderr)
+	logger := log.NewLogfmtLogger(w)
+
+	dirName := newTestLogDirectories(t)
+	positionsFileName := filepath.Join(dirName, "positions.yml")
+	realFile := filepath.Join(dirName, "real.log")
+	link1 := filepath.Join(dirName, "link1.log")
+	link2 := filepath.Join(dirName, "link2.log")
+
+	require.NoError(t, os.WriteFile(realFile, []byte("line1\n"), 0o644))
+	require.NoError(t, os.Symlink(realFile, link1))
+	require.NoError(t, os.Symlink(realFile, link2))
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Minute,
+		PositionsFile: positionsFileName,
+	})
+	require.NoError(t, err)
+
+	client := fake.New(func() {})
+	defer client.Stop()
+
+	fakeHandler := make(chan fileTargetEvent, 10)
+	path := filepath.Join(dirName, "link*.log")
+	target, err := NewFileTarget(NewMetrics(nil), logger, client, ps, path, "", nil, nil, &Config{
+		SyncPeriod:      1 * time.Minute,
+		ResolveSymlinks: true,
+	}, DefaultWatchConig, nil, fakeHandler, "", nil)
+	require.NoError(t, err)
+
+	err = target.sync()
+	require.NoError(t, err)
+
+	// Both symlinks matched the glob, but they resolve to the same real
+	// file, so only one of them should actually be tailed.
+	assert.Equal(t, 1, target.getReadersLen(),
+		"Expected only one tailer, since both symlinks resolve to the same file",
+	)
+
+	target.Stop()
+	ps.Stop()
+
+	// The position should have been saved under the real path, not either
+	// symlink, so a later run resolving a different symlink to the same
+	// file picks up where this one left off.
+	pos, err := ps.Get(realFile)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("line1\n")), pos)
+}
+
 func TestHandleFileCreationEvent(t *testing.T) {
 	w := log.NewSyncWriter(os.Stderr)
 	logger := log.NewLogfmtLogger(w)
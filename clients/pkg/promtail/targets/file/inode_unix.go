@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package file
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileInode returns fi's inode number, used by newTailer to detect when the
+// file at a path has been replaced (e.g. truncate-based log rotation)
+// rather than appended to. ok is false if fi's underlying Sys() isn't a
+// *syscall.Stat_t, which shouldn't happen on a supported unix platform.
+func fileInode(fi os.FileInfo) (inode uint64, ok bool) {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return stat.Ino, true
+}
@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package file
+
+import "os"
+
+// fileInode is unsupported on Windows: os.FileInfo doesn't expose an
+// equivalent identifier without extra syscalls, so ok is always false and
+// newTailer's inode-change detection is a no-op there, falling back to the
+// existing size-based truncation check.
+func fileInode(_ os.FileInfo) (inode uint64, ok bool) {
+	return 0, false
+}
@@ -0,0 +1,165 @@
+//go:build !windows
+// +build !windows
+
+package file
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+// pipeReadRetryInterval is how long pipeTailer waits before retrying a read
+// that found no data yet, since a FIFO opened non-blocking returns
+// immediately instead of blocking until a writer produces more.
+const pipeReadRetryInterval = 250 * time.Millisecond
+
+// pipeTailer reads lines from a named pipe (FIFO). Unlike tailer, it uses
+// neither inotify nor a resumable position: a FIFO has no backing content to
+// seek into, so it just reads whatever a writer produces from the point it's
+// opened.
+type pipeTailer struct {
+	metrics *Metrics
+	logger  log.Logger
+	handler api.EntryHandler
+	path    string
+	file    *os.File
+
+	running  *atomic.Bool
+	stopOnce sync.Once
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+// newPipeTailer opens path, which must be a FIFO, in non-blocking mode and
+// starts reading lines from it in the background.
+func newPipeTailer(metrics *Metrics, logger log.Logger, handler api.EntryHandler, path string) (*pipeTailer, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	logger = log.With(logger, "component", "pipe_tailer")
+	pt := &pipeTailer{
+		metrics: metrics,
+		logger:  logger,
+		handler: api.AddLabelsMiddleware(model.LabelSet{FilenameLabel: model.LabelValue(path)}).Wrap(handler),
+		path:    path,
+		file:    f,
+		running: atomic.NewBool(false),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go pt.readLines()
+	metrics.filesActive.Add(1.)
+	return pt, nil
+}
+
+// readLines runs in a goroutine, reading path in a loop until quit is
+// closed. There's no channel to select on for readiness, since a
+// non-blocking read on a FIFO with nothing to read returns immediately
+// rather than parking a goroutine we could select against.
+func (pt *pipeTailer) readLines() {
+	level.Info(pt.logger).Log("msg", "pipe routine: started", "path", pt.path)
+	pt.running.Store(true)
+	defer func() {
+		pt.running.Store(false)
+		level.Info(pt.logger).Log("msg", "pipe routine: exited", "path", pt.path)
+		close(pt.done)
+	}()
+
+	entries := pt.handler.Chan()
+	buf := make([]byte, 4096)
+	var line strings.Builder
+
+	for {
+		select {
+		case <-pt.quit:
+			return
+		default:
+		}
+
+		n, err := pt.file.Read(buf)
+		for _, b := range buf[:n] {
+			if b != '\n' {
+				line.WriteByte(b)
+				continue
+			}
+			pt.metrics.readLines.WithLabelValues(pt.path).Inc()
+			entries <- api.Entry{
+				Labels: model.LabelSet{},
+				Entry: logproto.Entry{
+					Timestamp: time.Now(),
+					Line:      line.String(),
+				},
+			}
+			line.Reset()
+		}
+
+		if err != nil && !isPipeNotReady(err) {
+			level.Error(pt.logger).Log("msg", "pipe routine: error reading from pipe, stopping", "path", pt.path, "error", err)
+			return
+		}
+
+		if n == 0 {
+			select {
+			case <-time.After(pipeReadRetryInterval):
+			case <-pt.quit:
+				return
+			}
+		}
+	}
+}
+
+// isPipeNotReady reports whether err from a non-blocking read on a FIFO just
+// means "no data (or writer) right now", as opposed to a real failure.
+func isPipeNotReady(err error) bool {
+	return errors.Is(err, syscall.EAGAIN) || errors.Is(err, io.EOF)
+}
+
+func (pt *pipeTailer) Stop() {
+	pt.stopOnce.Do(func() {
+		close(pt.quit)
+		<-pt.done
+		if err := pt.file.Close(); err != nil {
+			level.Error(pt.logger).Log("msg", "error closing pipe", "path", pt.path, "error", err)
+		}
+		pt.cleanupMetrics()
+		level.Info(pt.logger).Log("msg", "stopped tailing pipe", "path", pt.path)
+		pt.handler.Stop()
+	})
+}
+
+func (pt *pipeTailer) IsRunning() bool {
+	return pt.running.Load()
+}
+
+func (pt *pipeTailer) Path() string {
+	return pt.path
+}
+
+// MarkPositionAndSize is a no-op: a FIFO has no persistent content to record
+// an offset into.
+func (pt *pipeTailer) MarkPositionAndSize() error {
+	return nil
+}
+
+// cleanupMetrics removes all metrics exported by this pipeTailer.
+func (pt *pipeTailer) cleanupMetrics() {
+	pt.metrics.filesActive.Add(-1.)
+	pt.metrics.readLines.DeleteLabelValues(pt.path)
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+// +build !windows
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client/fake"
+)
+
+func Test_PipeTailer_ReadsLinesWrittenToFifo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.fifo")
+	require.NoError(t, syscall.Mkfifo(path, 0644))
+
+	client := fake.New(func() {})
+	defer client.Stop()
+
+	metrics := NewMetrics(nil)
+	pt, err := newPipeTailer(metrics, log.NewNopLogger(), client, path)
+	require.NoError(t, err)
+	defer pt.Stop()
+
+	require.True(t, pt.IsRunning())
+	require.Equal(t, path, pt.Path())
+	require.NoError(t, pt.MarkPositionAndSize())
+
+	w, err := os.OpenFile(path, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.WriteString("line one\nline two\n")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	received := client.Received()
+	require.Equal(t, "line one", received[0].Line)
+	require.Equal(t, "line two", received[1].Line)
+}
+
+func Test_PipeTailer_StopIsIdempotentAndDrainsCleanly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.fifo")
+	require.NoError(t, syscall.Mkfifo(path, 0644))
+
+	client := fake.New(func() {})
+	defer client.Stop()
+
+	metrics := NewMetrics(nil)
+	pt, err := newPipeTailer(metrics, log.NewNopLogger(), client, path)
+	require.NoError(t, err)
+
+	pt.Stop()
+	pt.Stop()
+
+	require.False(t, pt.IsRunning())
+}
@@ -0,0 +1,26 @@
+//go:build windows
+// +build windows
+
+package file
+
+import (
+	"errors"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+)
+
+// pipeTailer isn't implemented on Windows: our named pipe support assumes
+// POSIX FIFO semantics (open non-blocking, read in a loop), which don't
+// carry over to Windows named pipes.
+type pipeTailer struct{}
+
+func newPipeTailer(_ *Metrics, _ log.Logger, _ api.EntryHandler, _ string) (*pipeTailer, error) {
+	return nil, errors.New("reading from named pipes is not supported on windows")
+}
+
+func (pt *pipeTailer) Stop()                      {}
+func (pt *pipeTailer) IsRunning() bool            { return false }
+func (pt *pipeTailer) Path() string               { return "" }
+func (pt *pipeTailer) MarkPositionAndSize() error { return nil }
@@ -3,6 +3,7 @@ package file
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
@@ -24,6 +25,13 @@ import (
 	"github.com/grafana/loki/v3/pkg/util"
 )
 
+// inodeKeySuffix mirrors the unexported constant of the same name in the
+// positions package, which strips it back off to resolve a real path for
+// its own file-existence cleanup. It's duplicated here rather than
+// exported, since positions' constructors all take a same-named "positions"
+// parameter that would shadow a package-qualified reference to it.
+const inodeKeySuffix = ":inode"
+
 type tailer struct {
 	metrics   *Metrics
 	logger    log.Logger
@@ -31,7 +39,11 @@ type tailer struct {
 	positions positions.Positions
 
 	path string
-	tail *tail.Tail
+	// positionPath is the key positions are tracked under; equal to path
+	// unless Config.ResolveSymlinks resolved path to a different real
+	// path in FileTarget.startTailing. See Path.
+	positionPath string
+	tail         *tail.Tail
 
 	posAndSizeMtx sync.Mutex
 	stopOnce      sync.Once
@@ -44,20 +56,38 @@ type tailer struct {
 	decoder *encoding.Decoder
 }
 
-func newTailer(metrics *Metrics, logger log.Logger, handler api.EntryHandler, positions positions.Positions, pollOptions watch.PollingFileWatcherOptions, path string, encoding string) (*tailer, error) {
+func newTailer(metrics *Metrics, logger log.Logger, handler api.EntryHandler, positions positions.Positions, pollOptions watch.PollingFileWatcherOptions, path string, positionPath string, encoding string) (*tailer, error) {
 	// Simple check to make sure the file we are tailing doesn't
 	// have a position already saved which is past the end of the file.
 	fi, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
-	pos, err := positions.Get(path)
+	pos, err := positions.Get(positionPath)
 	if err != nil {
 		return nil, err
 	}
 
 	if fi.Size() < pos {
-		positions.Remove(path)
+		positions.Remove(positionPath)
+		pos = 0
+	}
+
+	// If the file at this path has been replaced since we last read it (e.g.
+	// truncate-based log rotation, which overwrites a file in place instead
+	// of renaming it), its inode will have changed even though its name
+	// hasn't. The saved offset belongs to whatever used to be at this path,
+	// so it doesn't mean anything for the new file; reset it to 0 rather
+	// than seeking into unrelated content.
+	if inode, ok := fileInode(fi); ok {
+		key := positionPath + inodeKeySuffix
+		current := strconv.FormatUint(inode, 10)
+		if stored := positions.GetString(key); stored != "" && stored != current {
+			level.Info(logger).Log("msg", "detected inode change, restarting tailer from the beginning of the file", "path", path, "old_inode", stored, "new_inode", current)
+			positions.Remove(positionPath)
+			pos = 0
+		}
+		positions.PutString(key, current)
 	}
 
 	tail, err := tail.TailFile(path, tail.Config{
@@ -76,16 +106,22 @@ func newTailer(metrics *Metrics, logger log.Logger, handler api.EntryHandler, po
 		return nil, err
 	}
 
+	streamLabels := model.LabelSet{FilenameLabel: model.LabelValue(path)}
+	if positionPath != path {
+		streamLabels[RealPathLabel] = model.LabelValue(positionPath)
+	}
+
 	logger = log.With(logger, "component", "tailer")
 	tailer := &tailer{
-		metrics:   metrics,
-		logger:    logger,
-		handler:   api.AddLabelsMiddleware(model.LabelSet{FilenameLabel: model.LabelValue(path)}).Wrap(handler),
-		positions: positions,
-		path:      path,
-		tail:      tail,
-		running:   atomic.NewBool(false),
-		posquit:   make(chan struct{}),
+		metrics:      metrics,
+		logger:       logger,
+		handler:      api.AddLabelsMiddleware(streamLabels).Wrap(handler),
+		positions:    positions,
+		path:         path,
+		positionPath: positionPath,
+		tail:         tail,
+		running:      atomic.NewBool(false),
+		posquit:      make(chan struct{}),
 		posdone:   make(chan struct{}),
 		done:      make(chan struct{}),
 	}
@@ -218,7 +254,7 @@ func (t *tailer) MarkPositionAndSize() error {
 	// Update metrics and positions file all together to avoid race conditions when `t.tail` is stopped.
 	t.metrics.totalBytes.WithLabelValues(t.path).Set(float64(size))
 	t.metrics.readBytes.WithLabelValues(t.path).Set(float64(pos))
-	t.positions.Put(t.path, pos)
+	t.positions.Put(t.positionPath, pos)
 
 	return nil
 }
@@ -269,6 +305,10 @@ func (t *tailer) cleanupMetrics() {
 	t.metrics.totalBytes.DeleteLabelValues(t.path)
 }
 
+// Path returns the key t's position is tracked under, which callers use to
+// remove that position once t stops; see FileTarget.stopTailingAndRemovePosition.
+// Equal to the tailed file's own path unless Config.ResolveSymlinks
+// resolved it to a different real path.
 func (t *tailer) Path() string {
-	return t.path
+	return t.positionPath
 }
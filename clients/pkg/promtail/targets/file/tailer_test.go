@@ -0,0 +1,79 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/tail/watch"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+)
+
+// startTestTailer starts a tailer against path with its own fake client,
+// returning the tailer and a function that waits for it to have delivered
+// exactly wantLines entries.
+func startTestTailer(t *testing.T, ps positions.Positions, path string) (tl *tailer, waitForLines func(wantLines int)) {
+	t.Helper()
+
+	client := fake.New(func() {})
+	tl, err := newTailer(NewMetrics(nil), log.NewNopLogger(), client, ps, watch.PollingFileWatcherOptions{}, path, path, "")
+	require.NoError(t, err)
+
+	return tl, func(wantLines int) {
+		require.Eventually(t, func() bool {
+			return len(client.Received()) >= wantLines
+		}, 5*time.Second, 10*time.Millisecond, "tailer should have delivered %d lines", wantLines)
+	}
+}
+
+func Test_Tailer_InodeChangeResetsPosition(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fileInode is unsupported on windows, so inode-change detection is a no-op there")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	// Both files are the same length, so the pre-existing "saved position
+	// past end of file" check (which resets a position beyond the new
+	// file's size) can't be what catches this: it's specifically the inode
+	// check that has to notice the file was replaced.
+	original := "AAAAAAAAAA\n"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0o644))
+
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    time.Hour,
+		PositionsFile: filepath.Join(dir, "positions.yml"),
+	})
+	require.NoError(t, err)
+	defer ps.Stop()
+
+	tl, waitForLines := startTestTailer(t, ps, path)
+	waitForLines(1)
+	// Stop marks the final position before returning, so it's safe to read
+	// it back right after.
+	tl.Stop()
+	pos, err := ps.Get(path)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(original)), pos, "tailer should have read to the end of the original file")
+
+	// Simulate truncate-based rotation: the file at this path is replaced
+	// (removed and recreated) rather than appended to, so it gets a new
+	// inode despite keeping the same name and the same length.
+	require.NoError(t, os.Remove(path))
+	fresh := "BBBBBBBBBB\n"
+	require.NoError(t, os.WriteFile(path, []byte(fresh), 0o644))
+
+	tl2, waitForLines2 := startTestTailer(t, ps, path)
+	waitForLines2(1)
+	tl2.Stop()
+
+	pos, err = ps.Get(path)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(fresh)), pos, "tailer should start over from 0 instead of treating the old offset as already at the end of the new file")
+}
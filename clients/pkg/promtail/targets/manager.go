@@ -323,6 +323,33 @@ func (tm *TargetManagers) Ready() bool {
 	return false
 }
 
+// Positions returns the shared Positions tracker used by the file and
+// journal target managers, or nil if none of the configured scrape configs
+// need one.
+func (tm *TargetManagers) Positions() positions.Positions {
+	return tm.positions
+}
+
+// TargetReadyFraction returns the fraction, in [0, 1], of active targets
+// across all target managers that report themselves ready. Returns 1 when
+// there are no active targets, since an idle promtail with nothing to
+// scrape yet isn't unhealthy.
+func (tm *TargetManagers) TargetReadyFraction() float64 {
+	var ready, total int
+	for _, ts := range tm.ActiveTargets() {
+		for _, t := range ts {
+			total++
+			if t.Ready() {
+				ready++
+			}
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(ready) / float64(total)
+}
+
 // Stop the TargetManagers.
 func (tm *TargetManagers) Stop() {
 	for _, t := range tm.targetManagers {
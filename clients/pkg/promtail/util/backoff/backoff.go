@@ -0,0 +1,126 @@
+// Package backoff provides a shared, jittered exponential backoff for
+// promtail's various retry loops (the log-push client, the Docker target's
+// reconnect logic, and any future ones), so they don't each reinvent
+// slightly different backoff math with their own hard-coded parameters, and
+// so a fleet of promtails recovering from the same Loki outage doesn't
+// retry in lockstep against it.
+package backoff
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Config configures a Backoff.
+type Config struct {
+	MinBackoff time.Duration `yaml:"min_period"`
+	MaxBackoff time.Duration `yaml:"max_period"`
+	// MaxRetries is the number of times to back off and retry before giving
+	// up. Zero means retry forever.
+	MaxRetries int `yaml:"max_retries"`
+	// JitterFraction is the fraction of each delay that's randomized,
+	// between 0 (no jitter: the delay is always exactly the deterministic
+	// exponential value) and 1 (full jitter: the delay is picked uniformly
+	// between 0 and the deterministic value). Full jitter is what prevents
+	// a fleet of promtails from retrying in lockstep after a shared outage.
+	JitterFraction float64 `yaml:"jitter_fraction"`
+}
+
+// RegisterFlagsWithPrefix registers flags for Config, with every flag name
+// prefixed by prefix.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.DurationVar(&cfg.MinBackoff, prefix+"backoff-min-period", 500*time.Millisecond, "Minimum delay when backing off.")
+	f.DurationVar(&cfg.MaxBackoff, prefix+"backoff-max-period", 5*time.Minute, "Maximum delay when backing off.")
+	f.IntVar(&cfg.MaxRetries, prefix+"backoff-retries", 10, "Number of times to backoff and retry before failing. 0 retries forever.")
+	f.Float64Var(&cfg.JitterFraction, prefix+"backoff-jitter-fraction", 1, "Fraction of each delay to randomize, between 0 (no jitter) and 1 (full jitter).")
+}
+
+// Backoff implements jittered exponential backoff: the deterministic delay
+// starts at Config.MinBackoff and doubles after every attempt up to
+// Config.MaxBackoff, and Config.JitterFraction of it is then randomized.
+type Backoff struct {
+	cfg        Config
+	ctx        context.Context
+	numRetries int
+	nextDelay  time.Duration
+}
+
+// New creates a Backoff. Pass a Context that can also terminate Wait.
+func New(ctx context.Context, cfg Config) *Backoff {
+	return &Backoff{cfg: cfg, ctx: ctx, nextDelay: cfg.MinBackoff}
+}
+
+// Reset returns the Backoff to its initial state.
+func (b *Backoff) Reset() {
+	b.numRetries = 0
+	b.nextDelay = b.cfg.MinBackoff
+}
+
+// Ongoing reports whether the caller should attempt again: the context
+// hasn't errored and MaxRetries (0 means unlimited) hasn't been reached.
+func (b *Backoff) Ongoing() bool {
+	return b.ctx.Err() == nil && (b.cfg.MaxRetries == 0 || b.numRetries < b.cfg.MaxRetries)
+}
+
+// Err returns the reason Ongoing stopped returning true, or nil if it
+// hasn't stopped.
+func (b *Backoff) Err() error {
+	if b.ctx.Err() != nil {
+		return b.ctx.Err()
+	}
+	if b.cfg.MaxRetries != 0 && b.numRetries >= b.cfg.MaxRetries {
+		return fmt.Errorf("terminated after %d retries", b.numRetries)
+	}
+	return nil
+}
+
+// NumRetries returns the number of retries taken so far.
+func (b *Backoff) NumRetries() int {
+	return b.numRetries
+}
+
+// NextDelay returns the jittered delay before the next attempt, advancing
+// the retry count and the deterministic exponential schedule for the
+// following call.
+func (b *Backoff) NextDelay() time.Duration {
+	b.numRetries++
+
+	delay := b.nextDelay
+	if next := b.nextDelay * 2; next > b.nextDelay && next <= b.cfg.MaxBackoff {
+		b.nextDelay = next
+	} else {
+		b.nextDelay = b.cfg.MaxBackoff
+	}
+
+	return jitter(delay, b.cfg.JitterFraction)
+}
+
+// jitter randomizes delay by fraction, picking uniformly between
+// delay*(1-fraction) and delay. fraction is clamped to [0, 1].
+func jitter(delay time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || delay <= 0 {
+		return delay
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	lo := time.Duration(float64(delay) * (1 - fraction))
+	span := delay - lo
+	if span <= 0 {
+		return lo
+	}
+	return lo + time.Duration(rand.Int63n(int64(span)+1))
+}
+
+// Wait sleeps for NextDelay, returning early if the context is done.
+func (b *Backoff) Wait() {
+	timer := time.NewTimer(b.NextDelay())
+	defer timer.Stop()
+	select {
+	case <-b.ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,116 @@
+package backoff
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Backoff_MaxRetries(t *testing.T) {
+	b := New(context.Background(), Config{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, MaxRetries: 3})
+
+	require.True(t, b.Ongoing())
+	b.NextDelay()
+	require.True(t, b.Ongoing())
+	b.NextDelay()
+	require.True(t, b.Ongoing())
+	b.NextDelay()
+	require.False(t, b.Ongoing())
+	require.Error(t, b.Err())
+	require.Equal(t, 3, b.NumRetries())
+}
+
+func Test_Backoff_MaxRetriesZeroMeansUnlimited(t *testing.T) {
+	b := New(context.Background(), Config{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	for i := 0; i < 1000; i++ {
+		require.True(t, b.Ongoing())
+		b.NextDelay()
+	}
+	require.True(t, b.Ongoing())
+}
+
+func Test_Backoff_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := New(ctx, Config{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	require.True(t, b.Ongoing())
+	cancel()
+	require.False(t, b.Ongoing())
+	require.ErrorIs(t, b.Err(), context.Canceled)
+}
+
+func Test_Backoff_DoublesUpToMax(t *testing.T) {
+	b := New(context.Background(), Config{MinBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	require.Equal(t, time.Second, b.nextDelay)
+	b.NextDelay()
+	require.Equal(t, 2*time.Second, b.nextDelay)
+	b.NextDelay()
+	require.Equal(t, 4*time.Second, b.nextDelay)
+	b.NextDelay()
+	require.Equal(t, 8*time.Second, b.nextDelay)
+	b.NextDelay()
+	require.Equal(t, 10*time.Second, b.nextDelay)
+	b.NextDelay()
+	require.Equal(t, 10*time.Second, b.nextDelay)
+}
+
+func Test_Backoff_Reset(t *testing.T) {
+	b := New(context.Background(), Config{MinBackoff: time.Second, MaxBackoff: 10 * time.Second})
+	b.NextDelay()
+	b.NextDelay()
+	require.Equal(t, 2, b.NumRetries())
+
+	b.Reset()
+	require.Equal(t, 0, b.NumRetries())
+	require.Equal(t, time.Second, b.nextDelay)
+}
+
+func Test_jitter_FullJitterStaysWithinBounds(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	var sawBelowHalf, sawAboveHalf bool
+	for i := 0; i < 1000; i++ {
+		d := jitter(delay, 1)
+		require.GreaterOrEqual(t, d, time.Duration(0))
+		require.LessOrEqual(t, d, delay)
+		if d < delay/2 {
+			sawBelowHalf = true
+		} else {
+			sawAboveHalf = true
+		}
+	}
+	// A statistical sanity check that full jitter actually spreads across
+	// the whole range, rather than clustering near one end.
+	require.True(t, sawBelowHalf, "expected some delays below half of the deterministic delay")
+	require.True(t, sawAboveHalf, "expected some delays above half of the deterministic delay")
+}
+
+func Test_jitter_ZeroFractionIsDeterministic(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		require.Equal(t, delay, jitter(delay, 0))
+	}
+}
+
+func Test_jitter_PartialFractionBoundedBelow(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	const fraction = 0.25
+	minExpected := time.Duration(float64(delay) * (1 - fraction))
+
+	for i := 0; i < 1000; i++ {
+		d := jitter(delay, fraction)
+		require.GreaterOrEqual(t, d, minExpected)
+		require.LessOrEqual(t, d, delay)
+	}
+}
+
+func Test_jitter_FractionAboveOneClampedToFull(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		d := jitter(delay, 2)
+		require.GreaterOrEqual(t, d, time.Duration(0))
+		require.LessOrEqual(t, d, delay)
+	}
+}
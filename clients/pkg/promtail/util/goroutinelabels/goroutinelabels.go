@@ -0,0 +1,44 @@
+// Package goroutinelabels attaches pprof labels to promtail's long-lived
+// per-target and per-client goroutines, so `go tool pprof` can attribute CPU
+// samples to the target or client responsible for them instead of lumping
+// everything under a single goroutine function name.
+package goroutinelabels
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync/atomic"
+)
+
+// enabled controls whether Apply attaches labels at all. It defaults to true
+// and is expected to be set once at startup, from Config.Enabled.
+var enabled atomic.Bool
+
+func init() {
+	enabled.Store(true)
+}
+
+// SetEnabled turns labeling on or off. Labeling has a small but nonzero cost
+// per goroutine start (a context allocation and a runtime call), so it can
+// be disabled for minimal overhead.
+func SetEnabled(e bool) {
+	enabled.Store(e)
+}
+
+// Apply attaches the given key/value pairs (as accepted by pprof.Labels) to
+// ctx and to the calling goroutine, and returns the labeled context along
+// with a reset func that restores the calling goroutine's original labels.
+// The caller must defer the reset func so that, if the goroutine is later
+// reused (e.g. returned to a worker pool), it doesn't keep reporting under a
+// target or client that has since gone away.
+//
+// If labeling is disabled, Apply returns ctx unchanged and a no-op reset.
+func Apply(ctx context.Context, kv ...string) (context.Context, func()) {
+	if !enabled.Load() {
+		return ctx, func() {}
+	}
+
+	labeled := pprof.WithLabels(ctx, pprof.Labels(kv...))
+	pprof.SetGoroutineLabels(labeled)
+	return labeled, func() { pprof.SetGoroutineLabels(ctx) }
+}
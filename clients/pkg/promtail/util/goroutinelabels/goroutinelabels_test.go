@@ -0,0 +1,89 @@
+package goroutinelabels
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func labelValue(key string) (string, bool) {
+	var (
+		val   string
+		found bool
+	)
+	pprof.ForLabels(context.Background(), func(k, v string) bool {
+		if k == key {
+			val, found = v, true
+			return false
+		}
+		return true
+	})
+	return val, found
+}
+
+func Test_Apply_SetsAndRestoresLabels(t *testing.T) {
+	SetEnabled(true)
+
+	_, found := labelValue("target")
+	require.False(t, found, "test goroutine should start with no labels")
+
+	_, reset := Apply(context.Background(), "target", "docker/abc123")
+	v, found := labelValue("target")
+	require.True(t, found)
+	require.Equal(t, "docker/abc123", v)
+
+	reset()
+	_, found = labelValue("target")
+	require.False(t, found, "reset should remove the label from the goroutine")
+}
+
+// Test_Apply_NoLeakAcrossGoroutineReuse simulates a goroutine that's reused
+// (as happens in a worker pool): it labels itself as one target, resets, and
+// is then reused for a second target that never calls Apply. The second run
+// must not observe the first target's labels.
+func Test_Apply_NoLeakAcrossGoroutineReuse(t *testing.T) {
+	SetEnabled(true)
+
+	work := make(chan func(), 1)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for f := range work {
+			f()
+			done <- struct{}{}
+		}
+	}()
+	defer close(work)
+
+	work <- func() {
+		_, reset := Apply(context.Background(), "target", "docker/first")
+		defer reset()
+	}
+	<-done
+
+	var sawLabel bool
+	work <- func() {
+		_, sawLabel = labelValue("target")
+	}
+	<-done
+
+	require.False(t, sawLabel, "second unit of work should not see the first unit's goroutine label")
+	wg.Wait()
+}
+
+func Test_Apply_Disabled(t *testing.T) {
+	SetEnabled(false)
+	defer SetEnabled(true)
+
+	ctx := context.Background()
+	labeled, reset := Apply(ctx, "target", "docker/abc123")
+	require.Equal(t, ctx, labeled)
+	_, found := labelValue("target")
+	require.False(t, found)
+	reset()
+}
@@ -0,0 +1,145 @@
+package wal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// AckJournal is a small append-only file that records the checksum of every
+// batch of WAL entries a WriteTo has already handed off to its client, so
+// that a crash-and-restart doesn't hand the same batch off a second time.
+//
+// This closes the most common duplicate-on-crash window for the WAL disk
+// buffer (a crash after entries have been read out of a still-on-disk
+// segment but before that segment is reclaimed), but it does NOT guarantee
+// exactly-once delivery to Loki: the network client batches, retries, and
+// ships entries asynchronously on its own schedule, decoupled from this
+// hand-off by an unbuffered channel, so there's no signal here for "Loki
+// actually accepted this push." A crash between that channel hand-off and
+// the client's HTTP push succeeding can still duplicate. This is
+// at-least-once delivery with best-effort deduplication, not exactly-once.
+type AckJournal struct {
+	mu    sync.Mutex
+	f     *os.File
+	acked map[string]int
+}
+
+// OpenAckJournal opens (creating if necessary) the ack journal at path and
+// replays it into memory, so IsAcked reflects everything journaled by a
+// previous run.
+func OpenAckJournal(path string) (*AckJournal, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("opening ack journal: %w", err)
+	}
+
+	acked := map[string]int{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		hash, segment, ok := parseAckJournalLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		acked[hash] = segment
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading ack journal: %w", err)
+	}
+
+	return &AckJournal{f: f, acked: acked}, nil
+}
+
+func parseAckJournalLine(line string) (hash string, segment int, ok bool) {
+	hash, segmentStr, found := strings.Cut(line, "\t")
+	if !found {
+		return "", 0, false
+	}
+	segment, err := strconv.Atoi(segmentStr)
+	if err != nil {
+		return "", 0, false
+	}
+	return hash, segment, true
+}
+
+// IsAcked reports whether hash was already journaled by a prior call to Ack,
+// in this run or a previous one.
+func (j *AckJournal) IsAcked(hash string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, ok := j.acked[hash]
+	return ok
+}
+
+// Ack journals hash as delivered, alongside the WAL segment its entries came
+// from (used by Compact to age it back out once that segment is reclaimed).
+func (j *AckJournal) Ack(hash string, segment int) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := fmt.Fprintf(j.f, "%s\t%d\n", hash, segment); err != nil {
+		return fmt.Errorf("writing ack journal: %w", err)
+	}
+	j.acked[hash] = segment
+	return nil
+}
+
+// Compact drops every journaled hash whose segment is lower than
+// minSegment and rewrites the journal file to match, bounding the
+// journal's size to the same retention window as the WAL segments
+// themselves: once a segment is reclaimed, its acks can never be looked up
+// again anyway, since the watcher will never see that segment replayed.
+func (j *AckJournal) Compact(minSegment int) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	kept := make(map[string]int, len(j.acked))
+	for hash, segment := range j.acked {
+		if segment >= minSegment {
+			kept[hash] = segment
+		}
+	}
+	if len(kept) == len(j.acked) {
+		return nil
+	}
+
+	path := j.f.Name()
+	tmp := path + ".tmp"
+	tmpFile, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("creating compacted ack journal: %w", err)
+	}
+	for hash, segment := range kept {
+		if _, err := fmt.Fprintf(tmpFile, "%s\t%d\n", hash, segment); err != nil {
+			tmpFile.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("writing compacted ack journal: %w", err)
+		}
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("closing compacted ack journal: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replacing ack journal: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("reopening compacted ack journal: %w", err)
+	}
+	j.f.Close()
+	j.f = f
+	j.acked = kept
+	return nil
+}
+
+// Close closes the underlying journal file.
+func (j *AckJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.f.Close()
+}
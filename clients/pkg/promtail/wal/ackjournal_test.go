@@ -0,0 +1,64 @@
+package wal
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAckJournal_AckAndIsAcked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ack.journal")
+
+	j, err := OpenAckJournal(path)
+	require.NoError(t, err)
+	defer j.Close()
+
+	require.False(t, j.IsAcked("abc"))
+	require.NoError(t, j.Ack("abc", 3))
+	require.True(t, j.IsAcked("abc"))
+	require.False(t, j.IsAcked("def"))
+}
+
+func TestAckJournal_SurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ack.journal")
+
+	j, err := OpenAckJournal(path)
+	require.NoError(t, err)
+	require.NoError(t, j.Ack("abc", 1))
+	require.NoError(t, j.Ack("def", 2))
+	require.NoError(t, j.Close())
+
+	// Simulate a restart: a fresh AckJournal opened against the same file
+	// should know about every hash journaled before the crash.
+	reopened, err := OpenAckJournal(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	require.True(t, reopened.IsAcked("abc"))
+	require.True(t, reopened.IsAcked("def"))
+	require.False(t, reopened.IsAcked("ghi"))
+}
+
+func TestAckJournal_CompactDropsReclaimedSegments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ack.journal")
+
+	j, err := OpenAckJournal(path)
+	require.NoError(t, err)
+	defer j.Close()
+
+	require.NoError(t, j.Ack("old", 1))
+	require.NoError(t, j.Ack("new", 5))
+
+	require.NoError(t, j.Compact(3))
+	require.False(t, j.IsAcked("old"), "ack from a reclaimed segment should be dropped")
+	require.True(t, j.IsAcked("new"))
+
+	// Compaction must persist: reopening should not resurrect the dropped ack.
+	require.NoError(t, j.Close())
+	reopened, err := OpenAckJournal(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+	require.False(t, reopened.IsAcked("old"))
+	require.True(t, reopened.IsAcked("new"))
+}
@@ -22,7 +22,8 @@ type Config struct {
 	// is finished.
 	Enabled bool `yaml:"enabled"`
 
-	// Path where the WAL is written to.
+	// Path where the WAL is written to. When enabled, this directory also holds the ack journal (see AckJournal) used
+	// to deduplicate batches that are handed off to the client more than once, e.g. after a crash and restart.
 	Dir string `yaml:"dir"`
 
 	// MaxSegmentAge is threshold at which a WAL segment is considered old enough to be cleaned up. Default: 1h.
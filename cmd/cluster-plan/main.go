@@ -0,0 +1,516 @@
+// Command cluster-plan sizes a Loki cluster from a sustained ingest rate
+// and retention window, printing the resulting component breakdown and
+// estimated cost.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/v3/pkg/sizing"
+)
+
+func main() {
+	ingestMBPerSec := flag.Float64("ingest-mb-per-sec", 10, "Sustained log ingest rate to size for, in MB/s.")
+	retentionDays := flag.Int("retention-days", 30, "How long ingested logs must be retained in object storage, in days.")
+	output := flag.String("output", "text", "Output format: text, verbose, csv, json, kubernetes, summary, tfvars, or ansible.")
+	tfvarsPrefix := flag.String("tfvars-var-prefix", "", "Prefix prepended to every variable name in --output tfvars, e.g. \"loki_\" for loki_node_count (--output tfvars only).")
+	noRolloutHeadroom := flag.Bool("no-rollout-headroom", false, "Don't pad the ingester component for WAL replay during a rolling restart.")
+	cacheBackend := flag.String("cache-backend", "embedded", "How to account for the results cache: memcached, embedded, or none.")
+	cacheWorkingSetGB := flag.Float64("cache-working-set-gb", 4, "Size of the results cache working set to provision for, in GB.")
+	compareCacheBackends := flag.Bool("compare-cache-backends", false, "Print a monthly cost comparison between the memcached and embedded cache backends and exit.")
+	save := flag.String("save", "", "Save the resulting plan as JSON to this path, in addition to printing --output.")
+	diffAgainst := flag.String("diff-against", "", "Path to a previously saved plan (see --save) to diff the resulting plan against, printed to stderr.")
+	indexOverheadFraction := flag.Float64("index-overhead-fraction", 0, "TSDB index storage to size on top of chunk storage, as a fraction of chunk storage (e.g. 0.05 for 5%). Zero sizes no index storage.")
+	minQueriesPerSecond := flag.Float64("min-queries-per-second", 0, "Baseline query rate to size querier autoscaling for. Requires --peak-queries-per-second.")
+	peakQueriesPerSecond := flag.Float64("peak-queries-per-second", 0, "Peak query rate to size querier autoscaling for. Set alongside --min-queries-per-second to enable --output kubernetes.")
+	hpaCPUTargetPercent := flag.Int("hpa-cpu-target-percent", 75, "Target average CPU utilization percentage for the querier HorizontalPodAutoscaler (--output kubernetes only).")
+	hpaScaleDownStabilizationSeconds := flag.Int("hpa-scale-down-stabilization-seconds", 300, "Scale-down stabilization window, in seconds, for the querier HorizontalPodAutoscaler (--output kubernetes only).")
+	existing := flag.Bool("existing", false, "Assess capacity headroom against an already-running cluster instead of sizing a new one, and exit. Describe its footprint with --existing-file or the --existing-* flags below.")
+	existingFile := flag.String("existing-file", "", "Path to a YAML file describing the existing cluster's footprint (nodes, cpus, memory_gb, disk_tb, obj_storage_tb). Used with --existing; overrides the --existing-* flags.")
+	existingNodes := flag.Int("existing-nodes", 0, "Existing cluster's node count. Used with --existing.")
+	existingCPUs := flag.Int("existing-cpus", 0, "Existing cluster's CPU count. Used with --existing.")
+	existingMemoryGB := flag.Int("existing-memory-gb", 0, "Existing cluster's memory, in GB. Used with --existing.")
+	existingDiskTB := flag.Float64("existing-disk-tb", 0, "Existing cluster's disk capacity, in TB. Used with --existing.")
+	existingObjStorageTB := flag.Float64("existing-obj-storage-tb", 0, "Existing cluster's object storage budget, in TB. Used with --existing.")
+	maxCPUCoresPerReplica := flag.Float64("max-cpu-cores-per-replica", 32, "Per-replica CPU sanity bound, in cores; exceeding it increases the component's replica count to compensate (or just warns with --no-auto-split).")
+	maxMemoryGBPerReplica := flag.Float64("max-memory-gb-per-replica", 256, "Per-replica memory sanity bound, in GB; exceeding it increases the component's replica count to compensate (or just warns with --no-auto-split).")
+	noAutoSplit := flag.Bool("no-auto-split", false, "Don't increase a component's replica count when its per-replica size exceeds --max-cpu-cores-per-replica/--max-memory-gb-per-replica; print a warning instead.")
+	experimentalComponents := flag.Bool("experimental-components", false, "Include the experimental Bloom builder and gateway components in the plan, marked as experimental in every output format.")
+	cloudProvider := flag.String("cloud-provider", "", "Cloud provider (aws, gcp, or azure) to validate the model's per-unit cost assumptions against. Leave empty to skip validation.")
+	tenantCount := flag.Int("tenant-count", 0, "Number of tenants the cluster serves, used to scale ingester and compactor memory for per-tenant overhead. Zero or one applies no overhead.")
+	compressionRatio := flag.Float64("compression-ratio", 0, "Flat raw-to-compressed ratio to assume when sizing chunk storage, e.g. 10 for 10x. Zero assumes no compression. Ignored if any -ingest-mix-*-percent flag is set.")
+	ingestMixStructuredPercent := flag.Float64("ingest-mix-structured-percent", 0, "Percent of ingest volume that's structured logs (JSON, logfmt), for blending a compression ratio instead of using -compression-ratio. Must be set alongside -ingest-mix-text-percent and -ingest-mix-binary-percent, summing to 100.")
+	ingestMixTextPercent := flag.Float64("ingest-mix-text-percent", 0, "Percent of ingest volume that's unstructured plain text. See -ingest-mix-structured-percent.")
+	ingestMixBinaryPercent := flag.Float64("ingest-mix-binary-percent", 0, "Percent of ingest volume that's already-compressed or high-entropy binary-ish payloads. See -ingest-mix-structured-percent.")
+	ingestMixStructuredRatio := flag.Float64("ingest-mix-structured-ratio", 0, "Override the default compression ratio assumed for the structured share of -ingest-mix-structured-percent. Zero uses the built-in default.")
+	ingestMixTextRatio := flag.Float64("ingest-mix-text-ratio", 0, "Override the default compression ratio assumed for the text share of -ingest-mix-text-percent. Zero uses the built-in default.")
+	ingestMixBinaryRatio := flag.Float64("ingest-mix-binary-ratio", 0, "Override the default compression ratio assumed for the binary share of -ingest-mix-binary-percent. Zero uses the built-in default.")
+	replicationFactor := flag.Int("replication-factor", 0, "Number of copies of each stream the ingester ring keeps, used to size replication network traffic. Zero uses Loki's own default.")
+	availabilityZones := flag.Int("availability-zones", 0, "Number of availability zones components are spread across, used to estimate inter-AZ replication transfer. Zero or one estimates no inter-AZ traffic.")
+	costPerGBTransfer := flag.Float64("cost-per-gb-transfer", 0, "Cost per GB of inter-AZ data transfer, used to price the estimated monthly inter-AZ transfer when -availability-zones is greater than one. Zero estimates no cost.")
+	haSpares := flag.Int("ha-spares", 0, "Extra active ingester replicas to add on top of the steady-state count, for tolerating that many ingester losses at once (e.g. 1 for N+1, 2 for N+2). Applied after auto-split. Zero adds no spares.")
+	monthlyBudget := flag.Float64("monthly-budget", 0, "Invert sizing: instead of sizing for -ingest-mb-per-sec, binary-search the highest sustained ingest rate whose plan costs no more than this many dollars/month, holding every other flag fixed. Zero disables budget mode.")
+	flag.Parse()
+
+	backend := sizing.CacheBackend(*cacheBackend)
+	switch backend {
+	case sizing.CacheBackendMemcached, sizing.CacheBackendEmbedded, sizing.CacheBackendNone:
+	default:
+		fmt.Fprintf(os.Stderr, "cluster-plan: unknown --cache-backend %q, must be memcached, embedded, or none\n", *cacheBackend)
+		os.Exit(1)
+	}
+
+	var ingestMix *sizing.IngestMix
+	if *ingestMixStructuredPercent != 0 || *ingestMixTextPercent != 0 || *ingestMixBinaryPercent != 0 {
+		mix := sizing.IngestMix{
+			StructuredPercent: *ingestMixStructuredPercent,
+			TextPercent:       *ingestMixTextPercent,
+			BinaryPercent:     *ingestMixBinaryPercent,
+			StructuredRatio:   *ingestMixStructuredRatio,
+			TextRatio:         *ingestMixTextRatio,
+			BinaryRatio:       *ingestMixBinaryRatio,
+		}
+		if err := mix.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "cluster-plan: %v\n", err)
+			os.Exit(1)
+		}
+		ingestMix = &mix
+	}
+
+	inputs := sizing.SizeInputs{
+		IngestMBPerSec:         *ingestMBPerSec,
+		RetentionDays:          *retentionDays,
+		CacheBackend:           backend,
+		CacheWorkingSetGB:      *cacheWorkingSetGB,
+		DisableRolloutHeadroom: *noRolloutHeadroom,
+		IndexOverheadFraction:  *indexOverheadFraction,
+		MinQueriesPerSecond:    *minQueriesPerSecond,
+		PeakQueriesPerSecond:   *peakQueriesPerSecond,
+
+		MaxCPUMillicoresPerReplica:   int(*maxCPUCoresPerReplica * 1000),
+		MaxMemoryGBPerReplica:        *maxMemoryGBPerReplica,
+		DisableAutoSplit:             *noAutoSplit,
+		EnableExperimentalComponents: *experimentalComponents,
+		TenantCount:                  *tenantCount,
+		CompressionRatio:             *compressionRatio,
+		IngestMix:                    ingestMix,
+		ReplicationFactor:            *replicationFactor,
+		AvailabilityZones:            *availabilityZones,
+		CostPerGBTransfer:            *costPerGBTransfer,
+		HASpares:                     *haSpares,
+	}
+
+	if *compareCacheBackends {
+		if err := writeCacheBackendComparison(os.Stdout, inputs); err != nil {
+			fmt.Fprintf(os.Stderr, "cluster-plan: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *existing {
+		existingCluster, err := loadExistingCluster(*existingFile, *existingNodes, *existingCPUs, *existingMemoryGB, *existingDiskTB, *existingObjStorageTB)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cluster-plan: %v\n", err)
+			os.Exit(1)
+		}
+		if err := writeHeadroomAssessment(os.Stdout, sizing.EstimateHeadroom(inputs, existingCluster)); err != nil {
+			fmt.Fprintf(os.Stderr, "cluster-plan: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *monthlyBudget > 0 {
+		result := sizing.BudgetSearch(inputs, *monthlyBudget)
+		if err := writeBudgetSearch(os.Stdout, *output, result); err != nil {
+			fmt.Fprintf(os.Stderr, "cluster-plan: %v\n", err)
+			os.Exit(1)
+		}
+		if !result.Feasible {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *cloudProvider != "" {
+		for _, w := range sizing.ValidateCosts(*cloudProvider, sizing.DefaultUnitCosts()) {
+			fmt.Fprintf(os.Stderr, "cluster-plan: cost warning: %s\n", w)
+		}
+	}
+
+	plan := sizing.NewPlan(inputs)
+	resources := plan.Resources
+	for _, w := range resources.Warnings {
+		fmt.Fprintf(os.Stderr, "cluster-plan: warning: %s\n", w)
+	}
+
+	if *diffAgainst != "" {
+		if err := diffAgainstSavedPlan(os.Stderr, *diffAgainst, plan); err != nil {
+			fmt.Fprintf(os.Stderr, "cluster-plan: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *save != "" {
+		if err := savePlan(*save, plan); err != nil {
+			fmt.Fprintf(os.Stderr, "cluster-plan: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var err error
+	switch *output {
+	case "text":
+		err = writeText(os.Stdout, resources)
+	case "verbose":
+		if _, werr := fmt.Fprint(os.Stdout, resources.DescribeArchitecture()); werr != nil {
+			err = werr
+			break
+		}
+		_, err = fmt.Fprintln(os.Stdout, resources.SummarizeCost(resources.MonthlyCosts()))
+	case "csv":
+		err = writeCSV(os.Stdout, resources)
+	case "json":
+		err = plan.Save(os.Stdout)
+	case "kubernetes":
+		err = writeKubernetesHPA(os.Stdout, resources, *hpaCPUTargetPercent, *hpaScaleDownStabilizationSeconds)
+	case "summary":
+		err = writeSummary(os.Stdout, plan)
+	case "tfvars":
+		err = writeTFVars(os.Stdout, plan, *tfvarsPrefix)
+	case "ansible":
+		err = writeAnsible(os.Stdout, plan)
+	default:
+		fmt.Fprintf(os.Stderr, "cluster-plan: unknown --output %q, must be text, verbose, csv, json, kubernetes, summary, tfvars, or ansible\n", *output)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cluster-plan: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// savePlan writes plan as JSON to path, creating or truncating it.
+func savePlan(path string, plan sizing.Plan) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return plan.Save(f)
+}
+
+// diffAgainstSavedPlan loads the plan previously saved at path and prints
+// its differences against current to w, one per line. It warns first if
+// the two plans were sized by different versions of the sizing model.
+func diffAgainstSavedPlan(w io.Writer, path string, current sizing.Plan) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening %s for --diff-against: %w", path, err)
+	}
+	defer f.Close()
+
+	saved, err := sizing.LoadPlan(f)
+	if err != nil {
+		return fmt.Errorf("loading %s for --diff-against: %w", path, err)
+	}
+
+	diffs := sizing.DiffPlans(saved, current)
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "cluster-plan: no difference from", path)
+		return nil
+	}
+	for _, d := range diffs {
+		fmt.Fprintf(w, "cluster-plan: %s\n", d)
+	}
+	return nil
+}
+
+// writeCacheBackendComparison prints the estimated monthly cost of sizing
+// the same cluster with a memcached-backed cache versus an embedded one.
+func writeCacheBackendComparison(w io.Writer, in sizing.SizeInputs) error {
+	memcached, embedded := sizing.CompareCacheBackends(in)
+	_, err := fmt.Fprintf(w, "cache backend comparison (working set: %.0fGB):\n  memcached: $%.2f/mo\n  embedded:  $%.2f/mo\n",
+		in.CacheWorkingSetGB, memcached.TotalMonthlyCostUSD(), embedded.TotalMonthlyCostUSD())
+	return err
+}
+
+// loadExistingCluster builds the ExistingCluster describing an
+// already-running cluster's footprint. If path is set, it's read as YAML
+// and the individual flag values are ignored; otherwise the flag values
+// are used directly.
+func loadExistingCluster(path string, nodes, cpus, memoryGB int, diskTB, objStorageTB float64) (sizing.ExistingCluster, error) {
+	if path == "" {
+		return sizing.ExistingCluster{
+			Nodes:        nodes,
+			CPUs:         cpus,
+			MemoryGB:     memoryGB,
+			DiskTB:       diskTB,
+			ObjStorageTB: objStorageTB,
+		}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return sizing.ExistingCluster{}, fmt.Errorf("opening %s for --existing-file: %w", path, err)
+	}
+	defer f.Close()
+
+	var existing sizing.ExistingCluster
+	if err := yaml.NewDecoder(f).Decode(&existing); err != nil {
+		return sizing.ExistingCluster{}, fmt.Errorf("parsing %s for --existing-file: %w", path, err)
+	}
+	return existing, nil
+}
+
+// writeHeadroomAssessment prints the result of --existing in the same
+// terse, script-friendly register as writeText.
+func writeHeadroomAssessment(w io.Writer, a sizing.HeadroomAssessment) error {
+	_, err := fmt.Fprintf(w, "Capacity headroom: %.2f MB/s current, %.2f MB/s max sustainable, %.2f MB/s headroom (binding constraint: %s)\n",
+		a.CurrentMBPerSec, a.MaxSustainableMBPerSec, a.HeadroomMBPerSec, a.BindingConstraint)
+	return err
+}
+
+// budgetSearchOutput is the JSON body written by writeBudgetSearch for
+// --output json. Plan is omitted (via its pointer's omitempty) when the
+// budget was infeasible, since there's no ingest rate to report a plan for.
+type budgetSearchOutput struct {
+	Feasible                         bool         `json:"feasible"`
+	InfeasibleReason                 string       `json:"infeasible_reason,omitempty"`
+	MaxSupportedIngestBytesPerSecond float64      `json:"max_supported_ingest_bytes_per_second,omitempty"`
+	Plan                             *sizing.Plan `json:"plan,omitempty"`
+}
+
+// mbPerSecToBytesPerSec converts a MB/s ingest rate to decimal bytes/sec,
+// matching the decimal-megabyte convention SizeCluster already uses when
+// converting IngestMBPerSec to storage (see rawTB in component.go).
+const mbPerSecToBytesPerSec = 1e6
+
+// writeBudgetSearch prints the result of -monthly-budget's inverse sizing
+// search: for --output json, a budgetSearchOutput; for everything else, a
+// one-line summary highlighting the supported ingest rate, followed by the
+// resulting plan in the requested format. An infeasible result prints its
+// explanation instead of a plan.
+func writeBudgetSearch(w io.Writer, output string, r sizing.BudgetResult) error {
+	if output == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		out := budgetSearchOutput{Feasible: r.Feasible, InfeasibleReason: r.InfeasibleReason}
+		if r.Feasible {
+			out.MaxSupportedIngestBytesPerSecond = r.MaxSupportedMBPerSec * mbPerSecToBytesPerSec
+			out.Plan = &r.Plan
+		}
+		return enc.Encode(out)
+	}
+
+	if !r.Feasible {
+		_, err := fmt.Fprintf(w, "cluster-plan: %s\n", r.InfeasibleReason)
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "cluster-plan: budget supports up to %.2f MB/s (%.0f B/s) sustained ingest\n",
+		r.MaxSupportedMBPerSec, r.MaxSupportedMBPerSec*mbPerSecToBytesPerSec); err != nil {
+		return err
+	}
+
+	switch output {
+	case "text", "":
+		return writeText(w, r.Plan.Resources)
+	case "csv":
+		return writeCSV(w, r.Plan.Resources)
+	case "summary":
+		return writeSummary(w, r.Plan)
+	default:
+		return fmt.Errorf("--output %q is not supported with -monthly-budget, use text, csv, summary, or json", output)
+	}
+}
+
+// writeSummary prints plan's -summary output: a single human-readable
+// paragraph, e.g. for pasting into a Slack message, followed by a one-line
+// JSON object with the same figures for chatops tooling to parse. Both are
+// derived from the same Plan, so the numbers can't diverge between them.
+func writeSummary(w io.Writer, plan sizing.Plan) error {
+	if _, err := fmt.Fprintln(w, plan.HumanizedSummary()); err != nil {
+		return err
+	}
+	machine, err := plan.MachineSummaryJSON()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, machine)
+	return err
+}
+
+// writeTFVars writes plan's -output tfvars document: a flat JSON object of
+// Terraform variable name to value, for a provisioning module invoked with
+// -var-file against it, or copied in as a tfvars.json file. See
+// sizing.Plan.TFVarsJSON for exactly what's included.
+func writeTFVars(w io.Writer, plan sizing.Plan, varPrefix string) error {
+	doc, err := plan.TFVarsJSON(varPrefix)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, doc)
+	return err
+}
+
+// writeAnsible writes plan's -output ansible document: a YAML map of
+// Ansible variable name to value, for a vars_files: entry consumed by an
+// Ansible-based Loki deployment. See sizing.Plan.AnsibleVarsYAML for
+// exactly what's included.
+func writeAnsible(w io.Writer, plan sizing.Plan) error {
+	doc, err := plan.AnsibleVarsYAML()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(w, doc)
+	return err
+}
+
+func writeText(w io.Writer, r sizing.ClusterResources) error {
+	_, err := fmt.Fprintln(w, r.Summarize())
+	return err
+}
+
+// writeCSV writes one row per component plus a totals row, so the plan can
+// be imported directly into a spreadsheet without manual copy-paste.
+func writeCSV(w io.Writer, r sizing.ClusterResources) error {
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	header := []string{
+		"component", "replicas", "memory_request_gb", "memory_limit_gb",
+		"cpu_request_millicores", "cpu_limit_millicores", "disk_gb", "storage_class", "monthly_cost_usd", "experimental",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	var totalReplicas, totalCPURequest, totalCPULimit int
+	var totalMemRequest, totalMemLimit, totalDisk, totalCost float64
+	for _, c := range r.Components {
+		if err := cw.Write(componentRow(c.Name, c)); err != nil {
+			return err
+		}
+
+		totalReplicas += c.Replicas
+		totalMemRequest += c.MemoryRequestGB * float64(c.Replicas)
+		totalMemLimit += c.MemoryLimitGB * float64(c.Replicas)
+		totalCPURequest += c.CPURequestMillicores * c.Replicas
+		totalCPULimit += c.CPULimitMillicores * c.Replicas
+		totalDisk += c.DiskGB * float64(c.Replicas)
+		totalCost += c.MonthlyCostUSD
+	}
+
+	totals := sizing.Component{
+		Replicas:             totalReplicas,
+		MemoryRequestGB:      totalMemRequest,
+		MemoryLimitGB:        totalMemLimit,
+		CPURequestMillicores: totalCPURequest,
+		CPULimitMillicores:   totalCPULimit,
+		DiskGB:               totalDisk,
+		MonthlyCostUSD:       totalCost,
+	}
+	if err := cw.Write(componentRow("total", totals)); err != nil {
+		return err
+	}
+
+	if exp := r.TotalExperimentalMonthlyCostUSD(); exp > 0 {
+		return cw.Write(componentRow("experimental-subtotal", sizing.Component{MonthlyCostUSD: exp, Experimental: true}))
+	}
+	return nil
+}
+
+// writeKubernetesHPA writes a HorizontalPodAutoscaler manifest targeting
+// the querier deployment, sized from r.QuerierMinReplicas/QuerierMaxReplicas.
+// It errors if those weren't computed, which happens when --min-queries-
+// per-second and --peak-queries-per-second weren't supplied.
+func writeKubernetesHPA(w io.Writer, r sizing.ClusterResources, cpuTargetPercent, scaleDownStabilizationSeconds int) error {
+	if !r.QuerierAutoscalingConfigured() {
+		return fmt.Errorf("--output kubernetes requires --min-queries-per-second and --peak-queries-per-second to be set")
+	}
+
+	querier, _ := componentByName(r.Components, "querier")
+
+	_, err := fmt.Fprintf(w, `apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: querier
+  labels:
+%s
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: querier
+  minReplicas: %d
+  maxReplicas: %d
+  metrics:
+  - type: Resource
+    resource:
+      name: cpu
+      target:
+        type: Utilization
+        averageUtilization: %d
+  behavior:
+    scaleDown:
+      stabilizationWindowSeconds: %d
+`, formatYAMLLabels(querier.Labels, 4), r.QuerierMinReplicas, r.QuerierMaxReplicas, cpuTargetPercent, scaleDownStabilizationSeconds)
+	return err
+}
+
+// componentByName returns the Component named name from components, so
+// callers that need a single component's detail (e.g. its Labels) don't
+// have to loop inline.
+func componentByName(components []sizing.Component, name string) (sizing.Component, bool) {
+	for _, c := range components {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return sizing.Component{}, false
+}
+
+// formatYAMLLabels renders labels as sorted "key: value" YAML map entries,
+// each indented by indent spaces, for embedding under a manifest's
+// metadata.labels.
+func formatYAMLLabels(labels map[string]string, indent int) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pad := strings.Repeat(" ", indent)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s%s: %s\n", pad, k, labels[k])
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func componentRow(name string, c sizing.Component) []string {
+	return []string{
+		name,
+		strconv.Itoa(c.Replicas),
+		strconv.FormatFloat(c.MemoryRequestGB, 'f', 2, 64),
+		strconv.FormatFloat(c.MemoryLimitGB, 'f', 2, 64),
+		strconv.Itoa(c.CPURequestMillicores),
+		strconv.Itoa(c.CPULimitMillicores),
+		strconv.FormatFloat(c.DiskGB, 'f', 2, 64),
+		c.StorageClassName(),
+		strconv.FormatFloat(c.MonthlyCostUSD, 'f', 2, 64),
+		strconv.FormatBool(c.Experimental),
+	}
+}
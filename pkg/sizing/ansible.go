@@ -0,0 +1,53 @@
+package sizing
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ansibleVarPrefix is the fixed variable name prefix AnsibleVarsYAML uses,
+// matching the "loki_" example already given for --tfvars-var-prefix.
+// Unlike TFVarsJSON's varPrefix, this isn't a flag: Ansible role variables
+// are conventionally namespaced by the role name up front, so a caller
+// dropping this into vars_files: alongside a "loki" role doesn't need to
+// pick a prefix.
+const ansibleVarPrefix = "loki_"
+
+// AnsibleVarsYAML returns p as a YAML document of Ansible variables,
+// suitable for a vars_files: entry: a node_count and object_storage_gb
+// variable, plus per-component <name>_replicas,
+// <name>_memory_request_mb/_memory_limit_mb,
+// <name>_cpu_request_millicores/_cpu_limit_millicores, <name>_disk_gb, and
+// <name>_storage_class variables. Every name is prefixed with
+// ansibleVarPrefix.
+//
+// Like TFVarsJSON, values here carry the plan's raw numbers rather than
+// HumanizedSummary/MachineSummaryJSON's rounded figures, since a playbook
+// applies exactly what it's told. Memory is emitted in MB rather than
+// TFVarsJSON's GB, since Ansible's own community modules for provisioning
+// memory (e.g. ec2 launch templates, Kubernetes resource requests via
+// kubernetes.core) conventionally take MB.
+func (p Plan) AnsibleVarsYAML() (string, error) {
+	vars := map[string]interface{}{
+		ansibleVarPrefix + "node_count":        p.Resources.Nodes,
+		ansibleVarPrefix + "object_storage_gb": p.Resources.ObjStorageTB * 1024,
+	}
+	for _, c := range p.Resources.Components {
+		name := ansibleVarPrefix + strings.ReplaceAll(c.Name, "-", "_")
+		vars[name+"_replicas"] = c.Replicas
+		vars[name+"_memory_request_mb"] = c.MemoryRequestGB * 1024
+		vars[name+"_memory_limit_mb"] = c.MemoryLimitGB * 1024
+		vars[name+"_cpu_request_millicores"] = c.CPURequestMillicores
+		vars[name+"_cpu_limit_millicores"] = c.CPULimitMillicores
+		vars[name+"_disk_gb"] = c.DiskGB
+		vars[name+"_storage_class"] = c.StorageClassName()
+	}
+
+	b, err := yaml.Marshal(vars)
+	if err != nil {
+		return "", fmt.Errorf("marshaling ansible vars: %w", err)
+	}
+	return string(b), nil
+}
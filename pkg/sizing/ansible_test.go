@@ -0,0 +1,46 @@
+package sizing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func Test_Plan_AnsibleVarsYAML(t *testing.T) {
+	plan := NewPlan(SizeInputs{IngestMBPerSec: 45, RetentionDays: 30, CacheBackend: CacheBackendEmbedded, CacheWorkingSetGB: 8})
+
+	doc, err := plan.AnsibleVarsYAML()
+	require.NoError(t, err)
+
+	var vars map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(doc), &vars))
+
+	require.InDelta(t, float64(plan.Resources.Nodes), vars["loki_node_count"], 1e-9)
+	require.InDelta(t, plan.Resources.ObjStorageTB*1024, vars["loki_object_storage_gb"], 1e-9)
+
+	require.NotEmpty(t, plan.Resources.Components)
+	for _, c := range plan.Resources.Components {
+		name := "loki_" + strings.ReplaceAll(c.Name, "-", "_")
+		require.InDelta(t, float64(c.Replicas), vars[name+"_replicas"], 1e-9)
+		require.InDelta(t, c.MemoryRequestGB*1024, vars[name+"_memory_request_mb"], 1e-9)
+		require.InDelta(t, c.MemoryLimitGB*1024, vars[name+"_memory_limit_mb"], 1e-9)
+		require.InDelta(t, float64(c.CPURequestMillicores), vars[name+"_cpu_request_millicores"], 1e-9)
+		require.InDelta(t, float64(c.CPULimitMillicores), vars[name+"_cpu_limit_millicores"], 1e-9)
+		require.InDelta(t, c.DiskGB, vars[name+"_disk_gb"], 1e-9)
+		require.Equal(t, c.StorageClassName(), vars[name+"_storage_class"])
+	}
+}
+
+func Test_Plan_AnsibleVarsYAML_FixedPrefix(t *testing.T) {
+	plan := NewPlan(SizeInputs{IngestMBPerSec: 45, RetentionDays: 30})
+
+	doc, err := plan.AnsibleVarsYAML()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(doc, "loki_"))
+
+	var vars map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(doc), &vars))
+	require.NotContains(t, vars, "node_count")
+}
@@ -0,0 +1,82 @@
+package sizing
+
+import "fmt"
+
+// BudgetResult is the result of BudgetSearch: the highest sustained ingest
+// rate whose plan cost fits within a monthly budget, or an explanation of
+// why no ingest rate does.
+type BudgetResult struct {
+	// Feasible is false when even a cluster sized for essentially zero
+	// ingest costs more than the budget allows.
+	Feasible bool
+	// InfeasibleReason explains why no ingest rate fits the budget. Empty
+	// when Feasible is true.
+	InfeasibleReason string
+	// MaxSupportedMBPerSec is the highest ingest rate BudgetSearch found
+	// whose plan cost fits within budget. Zero when infeasible.
+	MaxSupportedMBPerSec float64
+	// Plan is the plan sized at MaxSupportedMBPerSec. Its zero value when
+	// infeasible.
+	Plan Plan
+}
+
+// budgetSearchIterations bounds BudgetSearch's binary search to a fixed
+// number of steps, for the same reason as headroomSearchIterations: plan
+// cost is a monotonically non-decreasing but irregular step function of
+// IngestMBPerSec (it jumps whenever a component's replica count changes),
+// so a fixed step count is simpler than a target precision and still
+// resolves well past any real replica-count threshold.
+const budgetSearchIterations = 40
+
+// BudgetSearch inverts SizeCluster's cost against a monthly budget: it
+// finds the highest sustained ingest rate whose plan cost fits within
+// monthlyBudgetUSD, holding every other SizeInputs field (retention,
+// cache, rollout headroom, query autoscaling) fixed to in.
+//
+// It's a binary search, like EstimateHeadroom, rather than a closed-form
+// inversion, since plan cost is a monotonically non-decreasing but
+// otherwise irregular step function of IngestMBPerSec. The search is
+// deterministic: it always runs budgetSearchIterations steps, so the same
+// inputs always produce the same result.
+func BudgetSearch(in SizeInputs, monthlyBudgetUSD float64) BudgetResult {
+	costAt := func(mbPerSec float64) float64 {
+		trial := in
+		trial.IngestMBPerSec = mbPerSec
+		res := SizeCluster(trial)
+		return res.TotalMonthlyCostUSD()
+	}
+
+	minCost := costAt(0)
+	if minCost > monthlyBudgetUSD {
+		return BudgetResult{
+			InfeasibleReason: fmt.Sprintf(
+				"monthly budget $%.2f is below the minimum viable cluster cost of $%.2f (at essentially zero ingest, %d-day retention)",
+				monthlyBudgetUSD, minCost, in.RetentionDays),
+		}
+	}
+
+	fits := func(mbPerSec float64) bool { return costAt(mbPerSec) <= monthlyBudgetUSD }
+
+	lo := 0.0
+	hi := 1.0
+	for fits(hi) {
+		hi *= 2
+	}
+	for i := 0; i < budgetSearchIterations; i++ {
+		mid := (lo + hi) / 2
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	maxSized := in
+	maxSized.IngestMBPerSec = lo
+
+	return BudgetResult{
+		Feasible:             true,
+		MaxSupportedMBPerSec: lo,
+		Plan:                 NewPlan(maxSized),
+	}
+}
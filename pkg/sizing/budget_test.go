@@ -0,0 +1,46 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BudgetSearch_FitsWithinBudget(t *testing.T) {
+	in := SizeInputs{RetentionDays: 30}
+	budget := 3000.0
+
+	r := BudgetSearch(in, budget)
+
+	require.True(t, r.Feasible)
+	require.Greater(t, r.MaxSupportedMBPerSec, 0.0)
+	require.LessOrEqual(t, r.Plan.Resources.TotalMonthlyCostUSD(), budget)
+
+	// One step past the search's own answer should no longer fit, or the
+	// search stopped short of the true maximum.
+	over := in
+	over.IngestMBPerSec = r.MaxSupportedMBPerSec * 1.05
+	overRes := SizeCluster(over)
+	require.Greater(t, overRes.TotalMonthlyCostUSD(), budget)
+}
+
+func Test_BudgetSearch_Monotonic(t *testing.T) {
+	in := SizeInputs{RetentionDays: 30}
+
+	small := BudgetSearch(in, 500)
+	large := BudgetSearch(in, 5000)
+
+	require.True(t, small.Feasible)
+	require.True(t, large.Feasible)
+	require.Greater(t, large.MaxSupportedMBPerSec, small.MaxSupportedMBPerSec)
+}
+
+func Test_BudgetSearch_BelowMinimum(t *testing.T) {
+	in := SizeInputs{RetentionDays: 30}
+
+	r := BudgetSearch(in, 0.01)
+
+	require.False(t, r.Feasible)
+	require.NotEmpty(t, r.InfeasibleReason)
+	require.Equal(t, 0.0, r.MaxSupportedMBPerSec)
+}
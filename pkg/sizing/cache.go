@@ -0,0 +1,46 @@
+package sizing
+
+// CacheBackend selects how SizeCluster accounts for Loki's results cache:
+// as a separate memcached deployment, folded into querier memory as an
+// embedded cache, or left out entirely.
+type CacheBackend string
+
+const (
+	CacheBackendMemcached CacheBackend = "memcached"
+	CacheBackendEmbedded  CacheBackend = "embedded"
+	CacheBackendNone      CacheBackend = "none"
+)
+
+// cacheReplicaMemoryGB is the memory limit given to each memcached replica
+// when CacheBackend is memcached.
+const cacheReplicaMemoryGB = 8
+
+// cacheComponent returns the memcached component for a memcached-backed
+// cache sized to hold workingSetGB, or the extra memory to add to each
+// querier replica so an embedded cache holds the same working set overall.
+// Exactly one of the two is non-zero for a given backend, so total cluster
+// memory is conserved between the memcached and embedded modes.
+func cacheComponent(backend CacheBackend, workingSetGB float64, querierReplicas int) (memcached *Component, querierExtraMemoryGB float64) {
+	switch backend {
+	case CacheBackendMemcached:
+		c := newComponent("memcached", replicasFor(workingSetGB, cacheReplicaMemoryGB), cacheReplicaMemoryGB, cacheReplicaMemoryGB, 500, 1000, 0, DiskClassStandard)
+		return &c, 0
+	case CacheBackendEmbedded:
+		if querierReplicas < 1 {
+			querierReplicas = 1
+		}
+		return nil, workingSetGB / float64(querierReplicas)
+	default:
+		return nil, 0
+	}
+}
+
+// CompareCacheBackends sizes the cluster once with a memcached-backed cache
+// and once with an embedded cache, holding every other input equal, so
+// their costs can be compared directly.
+func CompareCacheBackends(in SizeInputs) (memcached, embedded ClusterResources) {
+	memcachedIn, embeddedIn := in, in
+	memcachedIn.CacheBackend = CacheBackendMemcached
+	embeddedIn.CacheBackend = CacheBackendEmbedded
+	return SizeCluster(memcachedIn), SizeCluster(embeddedIn)
+}
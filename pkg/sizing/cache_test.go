@@ -0,0 +1,46 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SizeCluster_CacheBackends_ConserveMemory(t *testing.T) {
+	in := SizeInputs{IngestMBPerSec: 100, RetentionDays: 30, CacheWorkingSetGB: 16}
+
+	memcachedIn := in
+	memcachedIn.CacheBackend = CacheBackendMemcached
+	memcached := SizeCluster(memcachedIn)
+
+	embeddedIn := in
+	embeddedIn.CacheBackend = CacheBackendEmbedded
+	embedded := SizeCluster(embeddedIn)
+
+	require.InDelta(t, memcached.MemoryGB, embedded.MemoryGB, 1)
+}
+
+func Test_SizeCluster_CacheBackendNone(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 10, RetentionDays: 30, CacheBackend: CacheBackendNone, CacheWorkingSetGB: 4})
+
+	require.NotEmpty(t, r.Warnings)
+	for _, c := range r.Components {
+		require.NotEqual(t, "memcached", c.Name)
+	}
+}
+
+func Test_CompareCacheBackends(t *testing.T) {
+	memcached, embedded := CompareCacheBackends(SizeInputs{IngestMBPerSec: 50, RetentionDays: 14, CacheWorkingSetGB: 8})
+
+	var hasMemcached bool
+	for _, c := range memcached.Components {
+		if c.Name == "memcached" {
+			hasMemcached = true
+		}
+	}
+	require.True(t, hasMemcached)
+
+	for _, c := range embedded.Components {
+		require.NotEqual(t, "memcached", c.Name)
+	}
+}
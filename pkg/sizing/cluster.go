@@ -0,0 +1,227 @@
+package sizing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClusterResources is the aggregate hardware footprint of a sized Loki
+// cluster, as produced by the cluster-plan tool.
+type ClusterResources struct {
+	Nodes        int
+	MemoryGB     int
+	CPUs         int
+	DiskTB       float64
+	ObjStorageTB float64
+
+	// SSDDiskTB and StandardDiskTB break DiskTB (their sum) down by
+	// DiskClass, since SSD-class disk (ingester WAL, compactor scratch)
+	// costs meaningfully more per GB than the standard disk a component
+	// like index-gateway's cache can live on.
+	SSDDiskTB      float64
+	StandardDiskTB float64
+
+	// ChunksStorageTB and IndexStorageTB break ObjStorageTB (their sum)
+	// down into chunk data and the TSDB index files stored alongside it.
+	// Both are zero for a ClusterResources built by hand rather than sized
+	// from SizeInputs; IndexStorageTB is also zero whenever
+	// SizeInputs.IndexOverheadFraction was left unset.
+	ChunksStorageTB float64
+	IndexStorageTB  float64
+
+	// EffectiveCompressionRatio is the raw-to-compressed ratio
+	// ComputeObjectStorage applied when sizing ChunksStorageTB, blended
+	// from SizeInputs.IngestMix when set, else SizeInputs.CompressionRatio,
+	// else 1 (no compression assumed). It's always populated for a
+	// ClusterResources produced by SizeCluster, even when neither input
+	// was set, so callers can't mistake an unconfigured ratio for a zero
+	// value.
+	EffectiveCompressionRatio float64
+
+	// Components is the per-component breakdown behind the totals above,
+	// as produced by SizeCluster. It's nil for a ClusterResources built by
+	// hand rather than sized from SizeInputs.
+	Components []Component
+
+	// Warnings holds non-fatal advisories about the sizing choices made by
+	// SizeCluster, such as a cache backend that leaves queries unbounded.
+	Warnings []string
+
+	// Version is the AlgorithmVersion of the sizing model that produced
+	// this plan. It's empty for a ClusterResources built by hand rather
+	// than sized from SizeInputs. Compare it before diffing two plans;
+	// see DiffPlans.
+	Version string
+
+	// IngesterRolloutHeadroom is the extra ingester memory, disk, and cost
+	// that SizeCluster padded onto the ingester component (already
+	// reflected in Components) to cover WAL replay during a rolling
+	// restart. It's nil when SizeInputs.DisableRolloutHeadroom was set.
+	IngesterRolloutHeadroom *RolloutHeadroom
+
+	// QuerierMinReplicas and QuerierMaxReplicas are the recommended bounds
+	// for autoscaling the querier component, derived from
+	// SizeInputs.MinQueriesPerSecond and PeakQueriesPerSecond. Both are
+	// zero when SizeInputs.PeakQueriesPerSecond wasn't set, meaning
+	// autoscaling wasn't sized.
+	QuerierMinReplicas int
+	QuerierMaxReplicas int
+
+	// EffectiveThroughputs reports the per-replica workload actually implied
+	// by Components' final replica counts (after any auto-split from
+	// checkReplicaBounds), for the components whose input workload is known.
+	// It's derived from the same per-replica tables SizeCluster used to size
+	// those replica counts in the first place, so the two can't drift apart.
+	// Nil for a ClusterResources built by hand rather than sized from
+	// SizeInputs.
+	EffectiveThroughputs []EffectiveThroughput
+
+	// HASpares is the extra ingester replicas (and their cost) added by
+	// SizeInputs.HASpares on top of the steady-state count SizeCluster
+	// would otherwise size, already reflected in Components. Nil when
+	// SizeInputs.HASpares was left at zero.
+	HASpares *HASpares
+
+	// NetworkThroughput is the estimated bandwidth this plan implies:
+	// ingest, replication, flush, and (once query inputs exist) query read
+	// traffic, plus a monthly inter-AZ transfer estimate when
+	// SizeInputs.AvailabilityZones > 1. Zero-valued for a ClusterResources
+	// built by hand rather than sized from SizeInputs.
+	NetworkThroughput NetworkThroughput
+
+	// Queueing is the recommended querier concurrency and estimated
+	// scheduler queue wait for SizeInputs.PeakQueriesPerSecond, derived
+	// from QuerierMaxReplicas via an M/M/c queueing approximation; see
+	// QueueingEstimate. Nil when PeakQueriesPerSecond wasn't set.
+	Queueing *QueueingEstimate
+}
+
+// QuerierAutoscalingConfigured reports whether SizeCluster derived a
+// querier autoscaling range for this plan.
+func (c *ClusterResources) QuerierAutoscalingConfigured() bool {
+	return c.QuerierMaxReplicas > 0
+}
+
+// TotalMonthlyCostUSD sums the estimated monthly cost of every component,
+// including any experimental ones.
+func (c *ClusterResources) TotalMonthlyCostUSD() float64 {
+	var total float64
+	for _, comp := range c.Components {
+		total += comp.MonthlyCostUSD
+	}
+	return total
+}
+
+// TotalExperimentalMonthlyCostUSD sums the estimated monthly cost of just
+// the Component.Experimental components, already included in
+// TotalMonthlyCostUSD, so reviewers can see the incremental cost of
+// SizeInputs.EnableExperimentalComponents on its own. Zero when the flag
+// was off.
+func (c *ClusterResources) TotalExperimentalMonthlyCostUSD() float64 {
+	var total float64
+	for _, comp := range c.Components {
+		if comp.Experimental {
+			total += comp.MonthlyCostUSD
+		}
+	}
+	return total
+}
+
+// MonthlyCosts is the estimated monthly spend for a ClusterResources plan,
+// broken down by category.
+type MonthlyCosts struct {
+	ComputeUSD float64
+	StorageUSD float64
+	NetworkUSD float64
+}
+
+// Total returns the sum of all cost categories.
+func (c MonthlyCosts) Total() float64 {
+	return c.ComputeUSD + c.StorageUSD + c.NetworkUSD
+}
+
+// Summarize returns a single-line summary of the cluster's resources,
+// suitable for embedding in scripts, Slack notifications, or PR
+// descriptions. For a multi-line breakdown, see DescribeArchitecture.
+func (c *ClusterResources) Summarize() string {
+	s := fmt.Sprintf("Loki cluster: %d nodes, %dGB RAM, %d CPUs, %.0fTB disk, %.0fTB obj-storage (sizing model %s)",
+		c.Nodes, c.MemoryGB, c.CPUs, c.DiskTB, c.ObjStorageTB, c.Version)
+	if exp := c.TotalExperimentalMonthlyCostUSD(); exp > 0 {
+		s += fmt.Sprintf(", includes $%.2f/mo of experimental components", exp)
+	}
+	return s
+}
+
+// MonthlyCosts returns the categorized monthly cost breakdown for this
+// cluster, for use with SummarizeCost. ComputeUSD is TotalMonthlyCostUSD,
+// which already folds in every component's disk cost alongside CPU and
+// memory; StorageUSD is left at zero, since this model doesn't price object
+// storage (see ComputeObjectStorage). NetworkUSD is
+// NetworkThroughput.MonthlyInterAZTransferCostUSD, zero unless
+// SizeInputs.AvailabilityZones and CostPerGBTransfer were both set.
+func (c *ClusterResources) MonthlyCosts() MonthlyCosts {
+	return MonthlyCosts{
+		ComputeUSD: c.TotalMonthlyCostUSD(),
+		NetworkUSD: c.NetworkThroughput.MonthlyInterAZTransferCostUSD,
+	}
+}
+
+// SummarizeCost returns a single-line summary of the estimated monthly
+// cost for this cluster.
+func (c *ClusterResources) SummarizeCost(costs MonthlyCosts) string {
+	return fmt.Sprintf("Loki cluster cost: $%.2f/mo (compute $%.2f, storage $%.2f, network $%.2f)",
+		costs.Total(), costs.ComputeUSD, costs.StorageUSD, costs.NetworkUSD)
+}
+
+// DescribeArchitecture returns a verbose, multi-line breakdown of the
+// cluster's resources. Summarize/SummarizeCost above provide the terse,
+// one-line counterparts of this output.
+func (c *ClusterResources) DescribeArchitecture() string {
+	s := fmt.Sprintf(
+		"Cluster architecture:\n"+
+			"  Nodes:          %d\n"+
+			"  Memory:         %d GB\n"+
+			"  CPUs:           %d\n"+
+			"  Disk:           %.2f TB (ssd: %.2f TB, standard: %.2f TB)\n"+
+			"  Object storage: %.2f TB (chunks: %.2f TB, index: %.2f TB)\n",
+		c.Nodes, c.MemoryGB, c.CPUs, c.DiskTB, c.SSDDiskTB, c.StandardDiskTB, c.ObjStorageTB, c.ChunksStorageTB, c.IndexStorageTB,
+	)
+	if c.EffectiveCompressionRatio > 0 {
+		s += fmt.Sprintf("  Compression ratio:  %.1fx (effective, chunks above)\n", c.EffectiveCompressionRatio)
+	}
+	if h := c.IngesterRolloutHeadroom; h != nil {
+		s += fmt.Sprintf(
+			"  Restart headroom: +%.2f GB memory, +%.2f GB disk, +$%.2f/mo (included in ingester above)\n",
+			h.MemoryGB, h.DiskGB, h.MonthlyCostUSD,
+		)
+	}
+	if h := c.HASpares; h != nil {
+		s += fmt.Sprintf(
+			"  HA spares:      +%d ingester replicas (active-active), +$%.2f/mo (included in ingester above)\n",
+			h.IngesterReplicas, h.MonthlyCostUSD,
+		)
+	}
+	if c.QuerierAutoscalingConfigured() {
+		s += fmt.Sprintf("  Querier autoscaling: %d-%d replicas\n", c.QuerierMinReplicas, c.QuerierMaxReplicas)
+	}
+	if c.Queueing != nil {
+		s += "  Query concurrency and queueing:\n" + c.Queueing.String()
+	}
+	if exp := c.TotalExperimentalMonthlyCostUSD(); exp > 0 {
+		var names []string
+		for _, comp := range c.Components {
+			if comp.Experimental {
+				names = append(names, comp.Name)
+			}
+		}
+		s += fmt.Sprintf("  Experimental components (%s): +$%.2f/mo (included in totals above)\n", strings.Join(names, ", "), exp)
+	}
+	if len(c.EffectiveThroughputs) > 0 {
+		s += "  Effective throughput per replica:\n"
+		for _, t := range c.EffectiveThroughputs {
+			s += fmt.Sprintf("    %s\n", t)
+		}
+	}
+	s += "  Network throughput:\n" + c.NetworkThroughput.String()
+	return s
+}
@@ -0,0 +1,26 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ClusterResources_Summarize(t *testing.T) {
+	c := ClusterResources{
+		Nodes:        12,
+		MemoryGB:     192,
+		CPUs:         96,
+		DiskTB:       2,
+		ObjStorageTB: 50,
+	}
+
+	require.Equal(t, "Loki cluster: 12 nodes, 192GB RAM, 96 CPUs, 2TB disk, 50TB obj-storage", c.Summarize())
+}
+
+func Test_ClusterResources_SummarizeCost(t *testing.T) {
+	c := ClusterResources{}
+	costs := MonthlyCosts{ComputeUSD: 100, StorageUSD: 20, NetworkUSD: 5}
+
+	require.Equal(t, "Loki cluster cost: $125.00/mo (compute $100.00, storage $20.00, network $5.00)", c.SummarizeCost(costs))
+}
@@ -0,0 +1,393 @@
+package sizing
+
+// Component is a single deployable piece of a sized Loki cluster (for
+// example the distributor or the ingester), broken out with its own
+// replica count, resource requests/limits, and cost contribution.
+// ClusterResources.Components holds the per-component detail behind its
+// aggregate totals.
+type Component struct {
+	Name string
+	// MachineName is Name translated to the key the grafana/loki Helm chart
+	// and jsonnet library use for this component (e.g. "index-gateway"
+	// becomes "indexGateway"), so JSON/Helm/k8s output can be consumed
+	// without a caller having to know cluster-plan's own naming. See
+	// componentMachineNames.
+	MachineName          string
+	Replicas             int
+	MemoryRequestGB      float64
+	MemoryLimitGB        float64
+	CPURequestMillicores int
+	CPULimitMillicores   int
+	DiskGB               float64
+	// DiskClass is the disk performance tier DiskGB was costed and
+	// StorageClassName was hinted from; see DiskClass.
+	DiskClass DiskClass
+	// MonthlyCostUSD is the estimated cost of all replicas of this
+	// component combined, not a per-replica cost.
+	MonthlyCostUSD float64
+	// Experimental marks a component added by
+	// SizeInputs.EnableExperimentalComponents, so callers can call it out
+	// separately (see ClusterResources.TotalExperimentalMonthlyCostUSD).
+	Experimental bool
+	// Labels are the recommended Kubernetes selector labels
+	// (app.kubernetes.io/name, app.kubernetes.io/component,
+	// app.kubernetes.io/part-of) for this component's workload, so
+	// generated manifests are compatible with standard Kubernetes tooling
+	// that relies on those well-known labels. See componentLabels.
+	Labels map[string]string
+}
+
+// componentLabels returns the recommended Kubernetes selector labels for a
+// component named name.
+func componentLabels(name string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      "loki",
+		"app.kubernetes.io/component": name,
+		"app.kubernetes.io/part-of":   "loki",
+	}
+}
+
+// StorageClassName returns the Kubernetes StorageClass name hint for this
+// component's DiskClass, for the Kubernetes and Helm-facing outputs. It's
+// "standard" for a component with no disk requirement at all.
+func (c Component) StorageClassName() string {
+	return c.DiskClass.storageClassName()
+}
+
+// SizeInputs are the user-supplied parameters that drive SizeCluster.
+type SizeInputs struct {
+	// IngestMBPerSec is the sustained log ingest rate the cluster must
+	// support.
+	IngestMBPerSec float64
+	// RetentionDays is how long ingested logs must be retained in object
+	// storage.
+	RetentionDays int
+	// CacheBackend selects how the results cache is accounted for. The
+	// zero value behaves like CacheBackendNone.
+	CacheBackend CacheBackend
+	// CacheWorkingSetGB is the size of the results cache working set to
+	// provision for, regardless of backend.
+	CacheWorkingSetGB float64
+	// DisableRolloutHeadroom turns off the rollout headroom padding that
+	// SizeCluster otherwise applies to the ingester component; see
+	// applyRolloutHeadroom. Corresponds to cluster-plan's
+	// -no-rollout-headroom flag.
+	DisableRolloutHeadroom bool
+	// MinQueriesPerSecond and PeakQueriesPerSecond bound the query rate the
+	// querier component must autoscale across. Leaving PeakQueriesPerSecond
+	// at zero leaves ClusterResources.QuerierMinReplicas and
+	// QuerierMaxReplicas unset, since a cluster with no declared query
+	// range has nothing sensible to autoscale between.
+	MinQueriesPerSecond  float64
+	PeakQueriesPerSecond float64
+	// IndexOverheadFraction accounts for the TSDB index files Loki writes
+	// alongside chunks in object storage, as a fraction of chunk storage
+	// (roughly 0.05 for a typical label cardinality). Left at zero, index
+	// storage isn't sized at all. Object storage is assumed to provide its
+	// own replication/durability, so unlike disk-backed components there's
+	// no separate replication factor to apply here.
+	IndexOverheadFraction float64
+	// MaxCPUMillicoresPerReplica and MaxMemoryGBPerReplica cap the
+	// per-replica size SizeCluster will produce before compensating; see
+	// DisableAutoSplit. Left at zero, they default to
+	// defaultMaxCPUMillicoresPerReplica and defaultMaxMemoryGBPerReplica.
+	MaxCPUMillicoresPerReplica int
+	MaxMemoryGBPerReplica      float64
+	// DisableAutoSplit turns off automatically increasing a component's
+	// replica count when its per-replica size would exceed
+	// MaxCPUMillicoresPerReplica/MaxMemoryGBPerReplica, printing a warning
+	// instead. Corresponds to cluster-plan's -no-auto-split flag.
+	DisableAutoSplit bool
+	// EnableExperimentalComponents adds the Bloom builder and gateway
+	// components (see experimentalComponents) to the plan, marked
+	// Component.Experimental. They're entirely absent when false.
+	// Corresponds to cluster-plan's -experimental-components flag.
+	EnableExperimentalComponents bool
+	// TenantCount is the number of tenants the cluster serves, used to
+	// scale ingester and compactor memory for per-tenant overhead; see
+	// tenantOverheadFactor. Left at zero or one, no overhead is applied.
+	// Corresponds to cluster-plan's -tenant-count flag.
+	TenantCount int
+	// CompressionRatio is the flat raw-to-compressed ratio applied when
+	// sizing chunk storage in ComputeObjectStorage, e.g. 10 for 10x. Left
+	// at zero, no compression is assumed. Ignored when IngestMix is set;
+	// see IngestMix.EffectiveRatio for the blended alternative.
+	CompressionRatio float64
+	// IngestMix, when set, blends a compression ratio from the ingest
+	// volume's split across structured/text/binary classes instead of
+	// using the single flat CompressionRatio, since a mixed workload's
+	// object storage footprint can be off by multiples under a single
+	// assumed ratio. See IngestMix.EffectiveRatio.
+	IngestMix *IngestMix
+	// ReplicationFactor is the number of copies of each stream the
+	// ingester ring keeps, used to size NetworkThroughput.ReplicationMBPerSec.
+	// Left at zero, defaultReplicationFactor is assumed, matching Loki's
+	// own default ingester ring replication factor.
+	ReplicationFactor int
+	// AvailabilityZones is the number of availability zones components are
+	// spread across. Left at zero or one, NetworkThroughput.MonthlyInterAZTransferGB
+	// isn't estimated, since a single zone has no inter-AZ traffic.
+	AvailabilityZones int
+	// CostPerGBTransfer prices NetworkThroughput.MonthlyInterAZTransferGB
+	// into NetworkThroughput.MonthlyInterAZTransferCostUSD. Left at zero,
+	// no cost is estimated even when AvailabilityZones > 1. Corresponds to
+	// cluster-plan's -cost-per-gb-transfer flag.
+	CostPerGBTransfer float64
+	// HASpares is the number of spare replicas to add on top of the
+	// steady-state count SizeCluster would otherwise size, so the cluster
+	// can lose that many ingesters at once without shedding load. Applied
+	// to the ingester component only; compactor gets a warning noting it
+	// runs as a single active instance instead. Left at zero, no spares
+	// are added. Corresponds to cluster-plan's -ha-spares flag. See
+	// applyHASpares.
+	HASpares int
+}
+
+// Rough monthly on-demand cloud pricing used to turn a component's
+// resource requests into an estimated cost. These are deliberately
+// approximate; a plan is a starting point for capacity discussions, not a
+// quote. Disk is split by DiskClass since SSD-class disk costs
+// meaningfully more per GB than standard disk.
+const (
+	costPerCPUCoreMonth        = 20.0
+	costPerMemGBMonth          = 3.5
+	costPerSSDDiskGBMonth      = 0.10
+	costPerStandardDiskGBMonth = 0.04
+)
+
+// costPerDiskGBMonth returns the per-GB monthly disk cost for class.
+func costPerDiskGBMonth(class DiskClass) float64 {
+	if class == DiskClassStandard {
+		return costPerStandardDiskGBMonth
+	}
+	return costPerSSDDiskGBMonth
+}
+
+// querierQPSPerReplica is the sustained query rate a single querier
+// replica is assumed to absorb, used to derive QuerierMinReplicas and
+// QuerierMaxReplicas from SizeInputs.MinQueriesPerSecond and
+// PeakQueriesPerSecond.
+const querierQPSPerReplica = 5
+
+// distributorMBPerSecPerReplica and ingesterMBPerSecPerReplica are the
+// sustained ingest rate a single replica of each component is assumed to
+// absorb, used both to size their replica counts below and, alongside
+// querierQPSPerReplica, to report each component's effective per-replica
+// throughput (see effectiveThroughputs) from the same table rather than a
+// second copy of these numbers.
+const (
+	distributorMBPerSecPerReplica = 100
+	ingesterMBPerSecPerReplica    = 25
+)
+
+// SizeCluster derives a representative Loki component breakdown and
+// aggregate footprint from in. It's a rough sizing heuristic meant to give
+// operators a starting point for capacity planning, not a substitute for
+// load testing against their own traffic.
+func SizeCluster(in SizeInputs) ClusterResources {
+	querierReplicas := replicasFor(in.IngestMBPerSec, 50)
+	memcached, querierExtraMemoryGB := cacheComponent(in.CacheBackend, in.CacheWorkingSetGB, querierReplicas)
+
+	tenantFactor := tenantOverheadFactor(in.TenantCount)
+	ingester := applyTenantOverhead(newComponent("ingester", replicasFor(in.IngestMBPerSec, ingesterMBPerSecPerReplica), 4, 8, 1000, 2000, 50, DiskClassSSD), tenantFactor)
+	var headroom *RolloutHeadroom
+	if !in.DisableRolloutHeadroom {
+		var hr RolloutHeadroom
+		ingester, hr = applyRolloutHeadroom(ingester)
+		headroom = &hr
+	}
+	compactor := applyTenantOverhead(newComponent("compactor", 1, 4, 8, 1000, 2000, 100, DiskClassSSD), tenantFactor)
+
+	components := []Component{
+		newComponent("distributor", replicasFor(in.IngestMBPerSec, distributorMBPerSecPerReplica), 1, 2, 500, 1000, 0, DiskClassStandard),
+		ingester,
+		newComponent("querier", querierReplicas, 2, 4+querierExtraMemoryGB, 1000, 2000, 0, DiskClassStandard),
+		newComponent("index-gateway", 2, 2, 4, 500, 1000, 20, DiskClassStandard),
+		compactor,
+	}
+	if memcached != nil {
+		components = append(components, *memcached)
+	}
+	if in.EnableExperimentalComponents {
+		components = append(components, experimentalComponents(in)...)
+	}
+
+	var warnings []string
+	if in.CacheBackend == CacheBackendNone {
+		warnings = append(warnings, "cache backend is \"none\": queries will recompute results Loki would otherwise cache, increasing query latency and backend load")
+	}
+
+	maxCPUMillicoresPerReplica := in.MaxCPUMillicoresPerReplica
+	if maxCPUMillicoresPerReplica <= 0 {
+		maxCPUMillicoresPerReplica = defaultMaxCPUMillicoresPerReplica
+	}
+	maxMemoryGBPerReplica := in.MaxMemoryGBPerReplica
+	if maxMemoryGBPerReplica <= 0 {
+		maxMemoryGBPerReplica = defaultMaxMemoryGBPerReplica
+	}
+	for i, c := range components {
+		adjusted, warning := checkReplicaBounds(c, maxCPUMillicoresPerReplica, maxMemoryGBPerReplica, !in.DisableAutoSplit)
+		components[i] = adjusted
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	// HA spares are applied after checkReplicaBounds above, so they stack
+	// on top of the ingester's final, possibly auto-split, per-replica size
+	// rather than being folded into a split that redistributes totals
+	// across a different replica count.
+	var haSpares *HASpares
+	if in.HASpares > 0 {
+		for i, c := range components {
+			if c.Name != "ingester" {
+				continue
+			}
+			padded, spares := applyHASpares(c, in.HASpares)
+			components[i] = padded
+			haSpares = &spares
+			break
+		}
+		warnings = append(warnings, compactorHASparesNote(in.HASpares))
+	}
+
+	var nodes int
+	for _, c := range components {
+		nodes += c.Replicas
+	}
+	totals := totalResources(components)
+
+	minReplicas, maxReplicas := querierAutoscaleReplicas(in, querierReplicas)
+	storage := ComputeObjectStorage(in)
+
+	queueing, queueingWarning := estimateQueueing(in, maxReplicas)
+	if queueingWarning != "" {
+		warnings = append(warnings, queueingWarning)
+	}
+
+	return ClusterResources{
+		Nodes:                     nodes,
+		MemoryGB:                  int(totals.MemoryGB),
+		CPUs:                      totals.CPUMillicores / 1000,
+		DiskTB:                    (totals.SSDDiskGB + totals.StandardDiskGB) / 1024,
+		SSDDiskTB:                 totals.SSDDiskGB / 1024,
+		StandardDiskTB:            totals.StandardDiskGB / 1024,
+		ObjStorageTB:              storage.TotalTB(),
+		ChunksStorageTB:           storage.ChunksTB,
+		IndexStorageTB:            storage.IndexTB,
+		EffectiveCompressionRatio: effectiveCompressionRatio(in),
+		Components:                components,
+		Warnings:                  warnings,
+		Version:                   AlgorithmVersion,
+		IngesterRolloutHeadroom:   headroom,
+		QuerierMinReplicas:        minReplicas,
+		QuerierMaxReplicas:        maxReplicas,
+		EffectiveThroughputs:      effectiveThroughputs(in, components, maxReplicas),
+		NetworkThroughput:         estimateNetworkThroughput(in),
+		HASpares:                  haSpares,
+		Queueing:                  queueing,
+	}
+}
+
+// querierAutoscaleReplicas derives the querier autoscaling bounds from
+// in.MinQueriesPerSecond and in.PeakQueriesPerSecond. It returns 0, 0 when
+// PeakQueriesPerSecond isn't set, leaving autoscaling unconfigured rather
+// than guessing a range. The static querierReplicas floor ensures the
+// autoscaling range never dips below what SizeCluster already sized the
+// querier component to.
+func querierAutoscaleReplicas(in SizeInputs, querierReplicas int) (min, max int) {
+	if in.PeakQueriesPerSecond <= 0 {
+		return 0, 0
+	}
+
+	min = replicasFor(in.MinQueriesPerSecond, querierQPSPerReplica)
+	if min < querierReplicas {
+		min = querierReplicas
+	}
+
+	max = replicasFor(in.PeakQueriesPerSecond, querierQPSPerReplica)
+	if max < min {
+		max = min
+	}
+
+	return min, max
+}
+
+// componentMachineNames maps every Component.Name SizeCluster can emit to
+// the key the grafana/loki Helm chart and jsonnet library use for that
+// component. Test_ComponentMachineNames fails if SizeCluster ever emits a
+// name missing from this table, so JSON/Helm/k8s output can't silently
+// regress to an unmapped name.
+var componentMachineNames = map[string]string{
+	"distributor":   "distributor",
+	"ingester":      "ingester",
+	"querier":       "querier",
+	"index-gateway": "indexGateway",
+	"compactor":     "compactor",
+	"memcached":     "resultsCache",
+	"bloom-builder": "bloomBuilder",
+	"bloom-gateway": "bloomGateway",
+}
+
+// newComponent builds a Component and derives its MonthlyCostUSD from the
+// per-replica resource limits above. diskClass is ignored (no cost impact)
+// when diskGB is zero.
+func newComponent(name string, replicas int, memRequestGB, memLimitGB float64, cpuRequestMillicores, cpuLimitMillicores int, diskGB float64, diskClass DiskClass) Component {
+	monthlyCost := float64(replicas) * (float64(cpuLimitMillicores)/1000*costPerCPUCoreMonth +
+		memLimitGB*costPerMemGBMonth +
+		diskGB*costPerDiskGBMonth(diskClass))
+
+	return Component{
+		Name:                 name,
+		MachineName:          componentMachineNames[name],
+		Replicas:             replicas,
+		MemoryRequestGB:      memRequestGB,
+		MemoryLimitGB:        memLimitGB,
+		CPURequestMillicores: cpuRequestMillicores,
+		CPULimitMillicores:   cpuLimitMillicores,
+		DiskGB:               diskGB,
+		DiskClass:            diskClass,
+		MonthlyCostUSD:       monthlyCost,
+		Labels:               componentLabels(name),
+	}
+}
+
+// replicasFor returns the number of replicas needed to cover ingestMBPerSec
+// at perReplicaMBPerSec each, with a floor of one replica.
+func replicasFor(ingestMBPerSec, perReplicaMBPerSec float64) int {
+	replicas := int(ingestMBPerSec/perReplicaMBPerSec + 0.999999)
+	if replicas < 1 {
+		replicas = 1
+	}
+	return replicas
+}
+
+// objStorageBreakdown splits the object storage footprint accumulated over
+// the configured retention window into chunks and TSDB index files, the
+// latter sized as IndexOverheadFraction of the former.
+type objStorageBreakdown struct {
+	ChunksTB float64
+	IndexTB  float64
+}
+
+// TotalTB returns the combined chunks and index storage footprint.
+func (b objStorageBreakdown) TotalTB() float64 {
+	return b.ChunksTB + b.IndexTB
+}
+
+// ComputeObjectStorage estimates the object storage footprint accumulated
+// over the configured retention window, broken down into chunks and index
+// storage. Chunk storage is divided by the effective compression ratio
+// derived from in.IngestMix or in.CompressionRatio (see
+// effectiveCompressionRatio); leaving both unset assumes a ratio of 1,
+// i.e. no compression.
+func ComputeObjectStorage(in SizeInputs) objStorageBreakdown {
+	const secondsPerDay = 86400
+	totalMB := in.IngestMBPerSec * secondsPerDay * float64(in.RetentionDays)
+	rawTB := totalMB / 1e6 // MB -> TB
+	chunksTB := rawTB / effectiveCompressionRatio(in)
+	return objStorageBreakdown{
+		ChunksTB: chunksTB,
+		IndexTB:  chunksTB * in.IndexOverheadFraction,
+	}
+}
@@ -0,0 +1,113 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SizeCluster(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30})
+
+	require.NotEmpty(t, r.Components)
+	require.Greater(t, r.Nodes, 0)
+	require.Greater(t, r.MemoryGB, 0)
+	require.Greater(t, r.ObjStorageTB, 0.0)
+	require.Equal(t, AlgorithmVersion, r.Version)
+
+	var ingester Component
+	for _, c := range r.Components {
+		if c.Name == "ingester" {
+			ingester = c
+		}
+	}
+	require.Equal(t, "ingester", ingester.Name)
+	// 60 MB/s at 25 MB/s per replica needs 3 replicas.
+	require.Equal(t, 3, ingester.Replicas)
+	require.Greater(t, ingester.MonthlyCostUSD, 0.0)
+}
+
+func Test_SizeCluster_IndexOverhead(t *testing.T) {
+	t.Run("zero fraction sizes no index storage", func(t *testing.T) {
+		r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30})
+		require.Zero(t, r.IndexStorageTB)
+		require.Equal(t, r.ChunksStorageTB, r.ObjStorageTB)
+	})
+
+	t.Run("adds index storage on top of chunks", func(t *testing.T) {
+		r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30, IndexOverheadFraction: 0.05})
+		require.InDelta(t, r.ChunksStorageTB*0.05, r.IndexStorageTB, 1e-9)
+		require.InDelta(t, r.ChunksStorageTB+r.IndexStorageTB, r.ObjStorageTB, 1e-9)
+	})
+}
+
+func Test_replicasFor(t *testing.T) {
+	require.Equal(t, 1, replicasFor(0, 25))
+	require.Equal(t, 1, replicasFor(10, 25))
+	require.Equal(t, 2, replicasFor(26, 25))
+	require.Equal(t, 4, replicasFor(100, 25))
+}
+
+func Test_SizeCluster_QuerierAutoscaling(t *testing.T) {
+	t.Run("unset without peak QPS", func(t *testing.T) {
+		r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30})
+		require.False(t, r.QuerierAutoscalingConfigured())
+		require.Zero(t, r.QuerierMinReplicas)
+		require.Zero(t, r.QuerierMaxReplicas)
+	})
+
+	t.Run("derived from min and peak QPS", func(t *testing.T) {
+		r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30, MinQueriesPerSecond: 10, PeakQueriesPerSecond: 50})
+		require.True(t, r.QuerierAutoscalingConfigured())
+		// 10 QPS at 5 QPS/replica needs 2 replicas.
+		require.Equal(t, 2, r.QuerierMinReplicas)
+		// 50 QPS at 5 QPS/replica needs 10 replicas.
+		require.Equal(t, 10, r.QuerierMaxReplicas)
+	})
+
+	t.Run("floors min at the static querier replica count", func(t *testing.T) {
+		// 60 MB/s at 50 MB/s per replica needs 2 querier replicas even
+		// before considering query rate.
+		r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30, MinQueriesPerSecond: 1, PeakQueriesPerSecond: 5})
+		require.Equal(t, 2, r.QuerierMinReplicas)
+		require.Equal(t, 2, r.QuerierMaxReplicas)
+	})
+}
+
+// Test_ComponentMachineNames fails if SizeCluster ever emits a component
+// whose Name is missing from componentMachineNames, so JSON/Helm/k8s
+// consumers can always rely on MachineName being populated.
+func Test_ComponentMachineNames(t *testing.T) {
+	inputs := []SizeInputs{
+		{IngestMBPerSec: 10, RetentionDays: 30, CacheBackend: CacheBackendNone},
+		{IngestMBPerSec: 60, RetentionDays: 30, CacheBackend: CacheBackendMemcached, CacheWorkingSetGB: 16},
+		{IngestMBPerSec: 60, RetentionDays: 30, CacheBackend: CacheBackendEmbedded, CacheWorkingSetGB: 16},
+		{IngestMBPerSec: 200, RetentionDays: 30, EnableExperimentalComponents: true},
+	}
+
+	for _, in := range inputs {
+		for _, c := range SizeCluster(in).Components {
+			machineName, ok := componentMachineNames[c.Name]
+			require.True(t, ok, "component %q has no entry in componentMachineNames", c.Name)
+			require.Equal(t, machineName, c.MachineName)
+			require.NotEmpty(t, c.MachineName, "component %q has an empty machine name", c.Name)
+		}
+	}
+}
+
+func Test_ComponentLabels(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30})
+
+	var querier Component
+	for _, c := range r.Components {
+		if c.Name == "querier" {
+			querier = c
+		}
+	}
+
+	require.Equal(t, map[string]string{
+		"app.kubernetes.io/name":      "loki",
+		"app.kubernetes.io/component": "querier",
+		"app.kubernetes.io/part-of":   "loki",
+	}, querier.Labels)
+}
@@ -0,0 +1,100 @@
+package sizing
+
+import "fmt"
+
+// Default per-class compression ratios used by IngestMix when a class
+// doesn't override its ratio. Structured logs (JSON, logfmt) compress well
+// due to repeated keys and low-cardinality values; plain text less so;
+// already-compressed or high-entropy binary payloads barely compress at
+// all.
+const (
+	defaultStructuredCompressionRatio = 15.0
+	defaultTextCompressionRatio       = 8.0
+	defaultBinaryCompressionRatio     = 2.0
+)
+
+// defaultCompressionRatio is the ratio ComputeObjectStorage assumes when
+// neither SizeInputs.CompressionRatio nor SizeInputs.IngestMix is set. It's
+// 1.0 (no compression assumed) so that leaving both unset reproduces
+// exactly the storage estimate this package always produced, before either
+// input existed.
+const defaultCompressionRatio = 1.0
+
+// IngestMix describes a cluster's ingest volume as a percentage split
+// across three broad compressibility classes, so ComputeObjectStorage can
+// blend a single effective compression ratio instead of assuming one ratio
+// for all ingested bytes. StructuredPercent, TextPercent, and
+// BinaryPercent must sum to 100; see Validate.
+type IngestMix struct {
+	// StructuredPercent, TextPercent, and BinaryPercent are the share of
+	// ingest volume, in percent, made up of structured logs (JSON,
+	// logfmt), unstructured plain text, and already-compressed or
+	// high-entropy binary-ish payloads, respectively.
+	StructuredPercent float64
+	TextPercent       float64
+	BinaryPercent     float64
+
+	// StructuredRatio, TextRatio, and BinaryRatio override the default
+	// compression ratio assumed for their class. Left at zero, they
+	// default to defaultStructuredCompressionRatio,
+	// defaultTextCompressionRatio, and defaultBinaryCompressionRatio
+	// respectively.
+	StructuredRatio float64
+	TextRatio       float64
+	BinaryRatio     float64
+}
+
+// mixPercentTolerance absorbs floating-point rounding in a caller-supplied
+// mix that's meant to sum to 100.
+const mixPercentTolerance = 0.01
+
+// Validate returns an error unless StructuredPercent, TextPercent, and
+// BinaryPercent sum to 100.
+func (m IngestMix) Validate() error {
+	sum := m.StructuredPercent + m.TextPercent + m.BinaryPercent
+	if sum < 100-mixPercentTolerance || sum > 100+mixPercentTolerance {
+		return fmt.Errorf("ingest mix percentages must sum to 100, got %.2f", sum)
+	}
+	return nil
+}
+
+// EffectiveRatio blends the per-class compression ratios, weighted by each
+// class's share of ingest volume, into a single ratio. Compressed bytes
+// are additive across classes but a ratio isn't, so this is a weighted
+// harmonic mean rather than a weighted average: at overallRatio,
+// overallRatio's reciprocal (the compressed fraction of raw bytes) equals
+// the volume-weighted sum of each class's own compressed fraction.
+func (m IngestMix) EffectiveRatio() float64 {
+	structuredRatio := m.StructuredRatio
+	if structuredRatio <= 0 {
+		structuredRatio = defaultStructuredCompressionRatio
+	}
+	textRatio := m.TextRatio
+	if textRatio <= 0 {
+		textRatio = defaultTextCompressionRatio
+	}
+	binaryRatio := m.BinaryRatio
+	if binaryRatio <= 0 {
+		binaryRatio = defaultBinaryCompressionRatio
+	}
+
+	weightedInverse := m.StructuredPercent/structuredRatio + m.TextPercent/textRatio + m.BinaryPercent/binaryRatio
+	if weightedInverse <= 0 {
+		return defaultCompressionRatio
+	}
+	return 100 / weightedInverse
+}
+
+// effectiveCompressionRatio resolves the compression ratio ComputeObjectStorage
+// should apply: in.IngestMix's blended ratio when set, else
+// in.CompressionRatio when set, else defaultCompressionRatio so the
+// unconfigured path is unchanged.
+func effectiveCompressionRatio(in SizeInputs) float64 {
+	if in.IngestMix != nil {
+		return in.IngestMix.EffectiveRatio()
+	}
+	if in.CompressionRatio > 0 {
+		return in.CompressionRatio
+	}
+	return defaultCompressionRatio
+}
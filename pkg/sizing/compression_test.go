@@ -0,0 +1,86 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IngestMix_Validate(t *testing.T) {
+	require.NoError(t, IngestMix{StructuredPercent: 60, TextPercent: 30, BinaryPercent: 10}.Validate())
+	require.NoError(t, IngestMix{StructuredPercent: 100}.Validate())
+	require.Error(t, IngestMix{StructuredPercent: 60, TextPercent: 30, BinaryPercent: 5}.Validate())
+	require.Error(t, IngestMix{}.Validate())
+}
+
+func Test_IngestMix_EffectiveRatio(t *testing.T) {
+	t.Run("all structured matches the default structured ratio", func(t *testing.T) {
+		m := IngestMix{StructuredPercent: 100}
+		require.InDelta(t, defaultStructuredCompressionRatio, m.EffectiveRatio(), 1e-9)
+	})
+
+	t.Run("blends by volume share, not a simple average", func(t *testing.T) {
+		// Half structured (15x) and half binary (2x): the blended ratio
+		// is the harmonic mean, weighted 50/50, not (15+2)/2.
+		m := IngestMix{StructuredPercent: 50, BinaryPercent: 50}
+		want := 100 / (50/defaultStructuredCompressionRatio + 50/defaultBinaryCompressionRatio)
+		require.InDelta(t, want, m.EffectiveRatio(), 1e-9)
+		require.Less(t, m.EffectiveRatio(), (defaultStructuredCompressionRatio+defaultBinaryCompressionRatio)/2)
+	})
+
+	t.Run("per-class overrides take precedence over defaults", func(t *testing.T) {
+		m := IngestMix{StructuredPercent: 100, StructuredRatio: 20}
+		require.InDelta(t, 20, m.EffectiveRatio(), 1e-9)
+	})
+}
+
+func Test_ComputeObjectStorage_DefaultRatioUnchanged(t *testing.T) {
+	in := SizeInputs{IngestMBPerSec: 60, RetentionDays: 30}
+	storage := ComputeObjectStorage(in)
+	require.InDelta(t, 60*86400*30/1e6, storage.ChunksTB, 1e-9)
+}
+
+func Test_SizeCluster_CompressionRatio(t *testing.T) {
+	base := SizeInputs{IngestMBPerSec: 60, RetentionDays: 30}
+	uncompressed := SizeCluster(base)
+
+	withRatio := base
+	withRatio.CompressionRatio = 10
+	compressed := SizeCluster(withRatio)
+
+	require.InDelta(t, 1, uncompressed.EffectiveCompressionRatio, 1e-9)
+	require.InDelta(t, 10, compressed.EffectiveCompressionRatio, 1e-9)
+	require.InDelta(t, uncompressed.ChunksStorageTB/10, compressed.ChunksStorageTB, 1e-9)
+}
+
+func Test_SizeCluster_IngestMix(t *testing.T) {
+	base := SizeInputs{IngestMBPerSec: 60, RetentionDays: 30}
+	uncompressed := SizeCluster(base)
+
+	mixedIn := base
+	mixedIn.IngestMix = &IngestMix{StructuredPercent: 70, TextPercent: 20, BinaryPercent: 10}
+	mixed := SizeCluster(mixedIn)
+
+	require.InDelta(t, mixedIn.IngestMix.EffectiveRatio(), mixed.EffectiveCompressionRatio, 1e-9)
+	require.Less(t, mixed.ChunksStorageTB, uncompressed.ChunksStorageTB)
+
+	// A mix skewed toward the barely-compressible binary class should
+	// leave more storage provisioned than one skewed toward structured
+	// logs, for the same ingest volume.
+	binaryHeavyIn := base
+	binaryHeavyIn.IngestMix = &IngestMix{StructuredPercent: 10, TextPercent: 10, BinaryPercent: 80}
+	binaryHeavy := SizeCluster(binaryHeavyIn)
+	require.Greater(t, binaryHeavy.ChunksStorageTB, mixed.ChunksStorageTB)
+
+	// IngestMix takes precedence over a flat CompressionRatio when both
+	// are set.
+	bothIn := mixedIn
+	bothIn.CompressionRatio = 2
+	both := SizeCluster(bothIn)
+	require.InDelta(t, mixed.ChunksStorageTB, both.ChunksStorageTB, 1e-9)
+}
+
+func Test_ClusterResources_DescribeArchitecture_CompressionRatio(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30, CompressionRatio: 12})
+	require.Contains(t, r.DescribeArchitecture(), "Compression ratio")
+}
@@ -0,0 +1,98 @@
+package sizing
+
+import "fmt"
+
+// UnitCostInfo is the set of per-unit monthly cost assumptions SizeCluster
+// bakes into Component.MonthlyCostUSD; see costPerCPUCoreMonth,
+// costPerMemGBMonth, costPerSSDDiskGBMonth, and costPerStandardDiskGBMonth
+// in component.go.
+type UnitCostInfo struct {
+	CostPerCPU            float64
+	CostPerGBMem          float64
+	CostPerGBSSDDisk      float64
+	CostPerGBStandardDisk float64
+}
+
+// DefaultUnitCosts returns the per-unit costs SizeCluster currently uses.
+func DefaultUnitCosts() UnitCostInfo {
+	return UnitCostInfo{
+		CostPerCPU:            costPerCPUCoreMonth,
+		CostPerGBMem:          costPerMemGBMonth,
+		CostPerGBSSDDisk:      costPerSSDDiskGBMonth,
+		CostPerGBStandardDisk: costPerStandardDiskGBMonth,
+	}
+}
+
+// cloudProviderCostRange is the plausible monthly on-demand pricing band for
+// a single unit (one CPU core, one GB of memory, one GB of disk) observed
+// across common instance families for a cloud provider. These are
+// deliberately wide bands meant to catch a badly wrong cost assumption (an
+// order-of-magnitude typo, a cost quoted per-hour instead of per-month),
+// not to validate precise pricing.
+type cloudProviderCostRange struct {
+	minCostPerCPU, maxCostPerCPU             float64
+	minCostPerGBMem, maxCostPerGBMem         float64
+	minCostPerGBSSDDisk, maxCostPerGBSSDDisk float64
+	minCostPerGBStdDisk, maxCostPerGBStdDisk float64
+}
+
+var cloudProviderCostRanges = map[string]cloudProviderCostRange{
+	"aws": {
+		minCostPerCPU: 3, maxCostPerCPU: 60,
+		minCostPerGBMem: 0.5, maxCostPerGBMem: 10,
+		minCostPerGBSSDDisk: 0.05, maxCostPerGBSSDDisk: 0.5,
+		minCostPerGBStdDisk: 0.02, maxCostPerGBStdDisk: 0.2,
+	},
+	"gcp": {
+		minCostPerCPU: 3, maxCostPerCPU: 60,
+		minCostPerGBMem: 0.5, maxCostPerGBMem: 10,
+		minCostPerGBSSDDisk: 0.05, maxCostPerGBSSDDisk: 0.5,
+		minCostPerGBStdDisk: 0.02, maxCostPerGBStdDisk: 0.2,
+	},
+	"azure": {
+		minCostPerCPU: 3, maxCostPerCPU: 60,
+		minCostPerGBMem: 0.5, maxCostPerGBMem: 10,
+		minCostPerGBSSDDisk: 0.05, maxCostPerGBSSDDisk: 0.5,
+		minCostPerGBStdDisk: 0.02, maxCostPerGBStdDisk: 0.2,
+	},
+}
+
+// ValidateCosts compares costs against the known plausible pricing range for
+// provider and returns a warning string for each value that falls outside
+// it. provider is matched case-sensitively against the keys of
+// cloudProviderCostRanges ("aws", "gcp", "azure"); an unrecognized provider
+// returns a single warning saying plausibility couldn't be checked, rather
+// than silently passing.
+func ValidateCosts(provider string, costs UnitCostInfo) []string {
+	r, ok := cloudProviderCostRanges[provider]
+	if !ok {
+		return []string{fmt.Sprintf("unknown cloud provider %q: cost plausibility not checked", provider)}
+	}
+
+	var warnings []string
+	if costs.CostPerCPU < r.minCostPerCPU || costs.CostPerCPU > r.maxCostPerCPU {
+		warnings = append(warnings, fmt.Sprintf(
+			"cost per CPU core ($%.2f/mo) is outside the plausible range for %s ($%.2f-$%.2f/mo)",
+			costs.CostPerCPU, provider, r.minCostPerCPU, r.maxCostPerCPU,
+		))
+	}
+	if costs.CostPerGBMem < r.minCostPerGBMem || costs.CostPerGBMem > r.maxCostPerGBMem {
+		warnings = append(warnings, fmt.Sprintf(
+			"cost per GB memory ($%.2f/mo) is outside the plausible range for %s ($%.2f-$%.2f/mo)",
+			costs.CostPerGBMem, provider, r.minCostPerGBMem, r.maxCostPerGBMem,
+		))
+	}
+	if costs.CostPerGBSSDDisk < r.minCostPerGBSSDDisk || costs.CostPerGBSSDDisk > r.maxCostPerGBSSDDisk {
+		warnings = append(warnings, fmt.Sprintf(
+			"cost per GB SSD disk ($%.2f/mo) is outside the plausible range for %s ($%.2f-$%.2f/mo)",
+			costs.CostPerGBSSDDisk, provider, r.minCostPerGBSSDDisk, r.maxCostPerGBSSDDisk,
+		))
+	}
+	if costs.CostPerGBStandardDisk < r.minCostPerGBStdDisk || costs.CostPerGBStandardDisk > r.maxCostPerGBStdDisk {
+		warnings = append(warnings, fmt.Sprintf(
+			"cost per GB standard disk ($%.2f/mo) is outside the plausible range for %s ($%.2f-$%.2f/mo)",
+			costs.CostPerGBStandardDisk, provider, r.minCostPerGBStdDisk, r.maxCostPerGBStdDisk,
+		))
+	}
+	return warnings
+}
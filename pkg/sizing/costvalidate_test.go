@@ -0,0 +1,37 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ValidateCosts_PlausibleCostsProduceNoWarnings(t *testing.T) {
+	warnings := ValidateCosts("aws", UnitCostInfo{CostPerCPU: 20, CostPerGBMem: 3.5, CostPerGBSSDDisk: 0.10, CostPerGBStandardDisk: 0.04})
+	require.Empty(t, warnings)
+}
+
+func Test_ValidateCosts_ImplausibleCostsAreFlagged(t *testing.T) {
+	warnings := ValidateCosts("aws", UnitCostInfo{CostPerCPU: 900, CostPerGBMem: 0.001, CostPerGBSSDDisk: 0.10, CostPerGBStandardDisk: 0.04})
+	require.Len(t, warnings, 2)
+}
+
+func Test_ValidateCosts_ImplausibleStandardDiskCostIsFlagged(t *testing.T) {
+	warnings := ValidateCosts("aws", UnitCostInfo{CostPerCPU: 20, CostPerGBMem: 3.5, CostPerGBSSDDisk: 0.10, CostPerGBStandardDisk: 5.0})
+	require.Len(t, warnings, 1)
+	require.Contains(t, warnings[0], "standard disk")
+}
+
+func Test_ValidateCosts_UnknownProviderWarnsInsteadOfPassing(t *testing.T) {
+	warnings := ValidateCosts("digitalocean", DefaultUnitCosts())
+	require.Len(t, warnings, 1)
+	require.Contains(t, warnings[0], "digitalocean")
+}
+
+func Test_DefaultUnitCosts_MatchesModelConstants(t *testing.T) {
+	costs := DefaultUnitCosts()
+	require.Equal(t, costPerCPUCoreMonth, costs.CostPerCPU)
+	require.Equal(t, costPerMemGBMonth, costs.CostPerGBMem)
+	require.Equal(t, costPerSSDDiskGBMonth, costs.CostPerGBSSDDisk)
+	require.Equal(t, costPerStandardDiskGBMonth, costs.CostPerGBStandardDisk)
+}
@@ -0,0 +1,30 @@
+package sizing
+
+// DiskClass distinguishes the disk performance tier a component's
+// DiskGB needs, since a single per-GB disk cost can't tell apart the
+// SSD-class IOPS the ingester WAL and compactor scratch space need from
+// the cheaper disk the index-gateway's on-disk cache can live on.
+type DiskClass string
+
+const (
+	// DiskClassSSD is high-IOPS disk, for WAL and compaction scratch space.
+	DiskClassSSD DiskClass = "ssd"
+	// DiskClassStandard is cheaper, lower-IOPS disk, for read-through
+	// caches that tolerate higher latency.
+	DiskClassStandard DiskClass = "standard"
+)
+
+// storageClassName is the Kubernetes StorageClass name cluster-plan's
+// Kubernetes output suggests for a component's DiskClass. It's a
+// starting-point hint, not a guarantee any given cluster has a
+// StorageClass by this name.
+func (d DiskClass) storageClassName() string {
+	switch d {
+	case DiskClassSSD:
+		return "fast-ssd"
+	case DiskClassStandard:
+		return "standard"
+	default:
+		return "standard"
+	}
+}
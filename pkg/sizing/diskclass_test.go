@@ -0,0 +1,53 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DiskClass_storageClassName(t *testing.T) {
+	require.Equal(t, "fast-ssd", DiskClassSSD.storageClassName())
+	require.Equal(t, "standard", DiskClassStandard.storageClassName())
+	require.Equal(t, "standard", DiskClass("").storageClassName())
+}
+
+// Test_costPerDiskGBMonth pins the cost split that
+// AndreZiviani/loki#synth-431 asked for: SSD-class disk costs more per GB
+// than standard disk, and every component is costed at its own DiskClass's
+// rate rather than a single blanket rate.
+func Test_costPerDiskGBMonth(t *testing.T) {
+	require.Equal(t, costPerSSDDiskGBMonth, costPerDiskGBMonth(DiskClassSSD))
+	require.Equal(t, costPerStandardDiskGBMonth, costPerDiskGBMonth(DiskClassStandard))
+	require.Greater(t, costPerSSDDiskGBMonth, costPerStandardDiskGBMonth)
+}
+
+func Test_SizeCluster_DiskClassSplit(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30})
+
+	require.Greater(t, r.SSDDiskTB, 0.0)
+	require.Greater(t, r.StandardDiskTB, 0.0)
+	require.InDelta(t, r.DiskTB, r.SSDDiskTB+r.StandardDiskTB, 1e-9)
+
+	var ingester, indexGateway Component
+	for _, c := range r.Components {
+		switch c.Name {
+		case "ingester":
+			ingester = c
+		case "index-gateway":
+			indexGateway = c
+		}
+	}
+
+	require.Equal(t, DiskClassSSD, ingester.DiskClass)
+	require.Equal(t, DiskClassStandard, indexGateway.DiskClass)
+	require.Equal(t, "fast-ssd", ingester.StorageClassName())
+	require.Equal(t, "standard", indexGateway.StorageClassName())
+
+	// The same per-GB disk footprint costs less on standard disk than on
+	// SSD, so an index-gateway-shaped component costed as SSD would be
+	// more expensive than it actually is.
+	asSSD := newComponent("index-gateway", indexGateway.Replicas, indexGateway.MemoryRequestGB, indexGateway.MemoryLimitGB,
+		indexGateway.CPURequestMillicores, indexGateway.CPULimitMillicores, indexGateway.DiskGB, DiskClassSSD)
+	require.Less(t, indexGateway.MonthlyCostUSD, asSSD.MonthlyCostUSD)
+}
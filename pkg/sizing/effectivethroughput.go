@@ -0,0 +1,51 @@
+package sizing
+
+import "fmt"
+
+// EffectiveThroughput is the workload a single replica of a component
+// actually carries, once SizeCluster's replica-count math (including any
+// auto-split adjustment from checkReplicaBounds) is applied. It's derived
+// by dividing the input workload the component was sized for by its final
+// Component.Replicas, so operators can compare it against what their real
+// deployment achieves and calibrate the sizing tables.
+type EffectiveThroughput struct {
+	Component  string  `json:"component"`
+	PerReplica float64 `json:"per_replica"`
+	Unit       string  `json:"unit"`
+}
+
+// String formats t for verbose text output.
+func (t EffectiveThroughput) String() string {
+	return fmt.Sprintf("%s: %.2f %s/replica", t.Component, t.PerReplica, t.Unit)
+}
+
+// effectiveThroughputs derives per-replica workload figures for the
+// write-path distributor and ingester components (MB/s, always available)
+// and the querier (queries/s, only once querierMaxReplicas has been derived
+// from SizeInputs.PeakQueriesPerSecond). It deliberately doesn't cover
+// streams-per-ingester yet, since SizeInputs has no cardinality model to
+// derive that from.
+func effectiveThroughputs(in SizeInputs, components []Component, querierMaxReplicas int) []EffectiveThroughput {
+	var out []EffectiveThroughput
+	for _, c := range components {
+		switch c.Name {
+		case "distributor", "ingester":
+			if c.Replicas > 0 {
+				out = append(out, EffectiveThroughput{
+					Component:  c.Name,
+					PerReplica: in.IngestMBPerSec / float64(c.Replicas),
+					Unit:       "MB/s",
+				})
+			}
+		case "querier":
+			if querierMaxReplicas > 0 {
+				out = append(out, EffectiveThroughput{
+					Component:  c.Name,
+					PerReplica: in.PeakQueriesPerSecond / float64(querierMaxReplicas),
+					Unit:       "queries/s",
+				})
+			}
+		}
+	}
+	return out
+}
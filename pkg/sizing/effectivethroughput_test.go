@@ -0,0 +1,61 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SizeCluster_EffectiveThroughputs(t *testing.T) {
+	t.Run("distributor and ingester, no querier without peak QPS", func(t *testing.T) {
+		r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30})
+
+		byComponent := map[string]EffectiveThroughput{}
+		for _, et := range r.EffectiveThroughputs {
+			byComponent[et.Component] = et
+		}
+		require.Len(t, byComponent, 2)
+
+		for _, c := range r.Components {
+			et, ok := byComponent[c.Name]
+			if c.Name != "distributor" && c.Name != "ingester" {
+				require.False(t, ok)
+				continue
+			}
+			require.True(t, ok)
+			require.InDelta(t, 60, et.PerReplica*float64(c.Replicas), 1e-9)
+		}
+	})
+
+	t.Run("includes querier once peak QPS is set", func(t *testing.T) {
+		r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30, MinQueriesPerSecond: 10, PeakQueriesPerSecond: 50})
+
+		var querier EffectiveThroughput
+		var found bool
+		for _, et := range r.EffectiveThroughputs {
+			if et.Component == "querier" {
+				querier = et
+				found = true
+			}
+		}
+		require.True(t, found)
+		require.InDelta(t, 50, querier.PerReplica*float64(r.QuerierMaxReplicas), 1e-9)
+	})
+
+	t.Run("still holds when auto-split increases replicas", func(t *testing.T) {
+		r := SizeCluster(SizeInputs{IngestMBPerSec: 6000, RetentionDays: 30})
+
+		for _, et := range r.EffectiveThroughputs {
+			if et.Component != "distributor" && et.Component != "ingester" {
+				continue
+			}
+			var replicas int
+			for _, c := range r.Components {
+				if c.Name == et.Component {
+					replicas = c.Replicas
+				}
+			}
+			require.InDelta(t, 6000, et.PerReplica*float64(replicas), 1e-6)
+		}
+	})
+}
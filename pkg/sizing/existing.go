@@ -0,0 +1,139 @@
+package sizing
+
+import "math"
+
+// ExistingCluster is the current hardware footprint of a Loki cluster
+// already running in production, as supplied by the operator to
+// EstimateHeadroom. It mirrors the aggregate fields of ClusterResources,
+// not the per-component breakdown, since an operator sizing headroom
+// against real hardware usually knows totals rather than a per-component
+// split.
+type ExistingCluster struct {
+	Nodes        int     `yaml:"nodes"`
+	CPUs         int     `yaml:"cpus"`
+	MemoryGB     int     `yaml:"memory_gb"`
+	DiskTB       float64 `yaml:"disk_tb"`
+	ObjStorageTB float64 `yaml:"obj_storage_tb"`
+}
+
+// BindingConstraint identifies which resource of an ExistingCluster runs
+// out first as ingest rate grows, as reported by EstimateHeadroom.
+type BindingConstraint string
+
+const (
+	BindingConstraintCPU        BindingConstraint = "cpu"
+	BindingConstraintMemory     BindingConstraint = "memory"
+	BindingConstraintDisk       BindingConstraint = "disk"
+	BindingConstraintObjStorage BindingConstraint = "obj_storage"
+)
+
+// HeadroomAssessment is the result of inverting SizeCluster against an
+// ExistingCluster: the maximum ingest rate the existing hardware can
+// sustain, and how that compares against the ingest rate the operator is
+// currently running.
+type HeadroomAssessment struct {
+	// CurrentMBPerSec is the SizeInputs.IngestMBPerSec EstimateHeadroom was
+	// called with.
+	CurrentMBPerSec float64
+	// MaxSustainableMBPerSec is the highest ingest rate at which SizeCluster
+	// still fits within the ExistingCluster's footprint.
+	MaxSustainableMBPerSec float64
+	// HeadroomMBPerSec is MaxSustainableMBPerSec minus CurrentMBPerSec. It's
+	// negative when the existing cluster is already undersized for
+	// CurrentMBPerSec.
+	HeadroomMBPerSec float64
+	// BindingConstraint is the resource that would run out first if ingest
+	// grew past MaxSustainableMBPerSec.
+	BindingConstraint BindingConstraint
+}
+
+// headroomSearchIterations bounds the binary search in EstimateHeadroom to
+// a fixed number of steps rather than a target precision, since
+// SizeCluster's replica counts (and therefore its resource totals) change
+// in discrete steps as ingest rate crosses each component's per-replica
+// threshold; 40 iterations resolves well past the granularity any of those
+// thresholds could need.
+const headroomSearchIterations = 40
+
+// EstimateHeadroom searches for the maximum sustainable ingest rate that
+// SizeCluster fits within existing's footprint, holding every other
+// SizeInputs field (retention, cache, rollout headroom, query
+// autoscaling) fixed to in. It's a simple binary search rather than a
+// closed-form inversion, since SizeCluster's resource totals are a
+// monotonically non-decreasing but otherwise irregular step function of
+// IngestMBPerSec.
+func EstimateHeadroom(in SizeInputs, existing ExistingCluster) HeadroomAssessment {
+	fits := func(mbPerSec float64) bool {
+		trial := in
+		trial.IngestMBPerSec = mbPerSec
+		return fitsExisting(SizeCluster(trial), existing)
+	}
+
+	lo := 0.0
+	hi := 1.0
+	for fits(hi) {
+		hi *= 2
+	}
+	for i := 0; i < headroomSearchIterations; i++ {
+		mid := (lo + hi) / 2
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	maxSized := in
+	maxSized.IngestMBPerSec = lo
+
+	return HeadroomAssessment{
+		CurrentMBPerSec:        in.IngestMBPerSec,
+		MaxSustainableMBPerSec: lo,
+		HeadroomMBPerSec:       lo - in.IngestMBPerSec,
+		BindingConstraint:      bindingConstraint(SizeCluster(maxSized), existing),
+	}
+}
+
+// fitsExisting reports whether sized's resource totals all fit within
+// existing's footprint.
+func fitsExisting(sized ClusterResources, existing ExistingCluster) bool {
+	return sized.CPUs <= existing.CPUs &&
+		sized.MemoryGB <= existing.MemoryGB &&
+		sized.DiskTB <= existing.DiskTB &&
+		sized.ObjStorageTB <= existing.ObjStorageTB
+}
+
+// bindingConstraint returns the resource of existing that sized comes
+// closest to exhausting, i.e. the one that will run out first if ingest
+// grows further. Ties are broken in the order CPU, memory, disk, object
+// storage.
+func bindingConstraint(sized ClusterResources, existing ExistingCluster) BindingConstraint {
+	utilization := func(used, available float64) float64 {
+		if available <= 0 {
+			if used > 0 {
+				return math.Inf(1)
+			}
+			return 0
+		}
+		return used / available
+	}
+
+	constraint := BindingConstraintCPU
+	highest := utilization(float64(sized.CPUs), float64(existing.CPUs))
+
+	for _, c := range []struct {
+		utilization float64
+		constraint  BindingConstraint
+	}{
+		{utilization(float64(sized.MemoryGB), float64(existing.MemoryGB)), BindingConstraintMemory},
+		{utilization(sized.DiskTB, existing.DiskTB), BindingConstraintDisk},
+		{utilization(sized.ObjStorageTB, existing.ObjStorageTB), BindingConstraintObjStorage},
+	} {
+		if c.utilization > highest {
+			highest = c.utilization
+			constraint = c.constraint
+		}
+	}
+
+	return constraint
+}
@@ -0,0 +1,78 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EstimateHeadroom_CPUBound(t *testing.T) {
+	in := SizeInputs{IngestMBPerSec: 10, RetentionDays: 30}
+	sized := SizeCluster(in)
+
+	existing := ExistingCluster{
+		Nodes:        sized.Nodes,
+		CPUs:         sized.CPUs,
+		MemoryGB:     sized.MemoryGB * 100,
+		DiskTB:       sized.DiskTB * 100,
+		ObjStorageTB: sized.ObjStorageTB * 100,
+	}
+
+	a := EstimateHeadroom(in, existing)
+
+	require.Equal(t, BindingConstraintCPU, a.BindingConstraint)
+	require.InDelta(t, in.IngestMBPerSec, a.MaxSustainableMBPerSec, in.IngestMBPerSec*0.1)
+	require.InDelta(t, 0, a.HeadroomMBPerSec, in.IngestMBPerSec*0.1)
+}
+
+func Test_EstimateHeadroom_MemoryBound(t *testing.T) {
+	in := SizeInputs{IngestMBPerSec: 10, RetentionDays: 30}
+	sized := SizeCluster(in)
+
+	existing := ExistingCluster{
+		Nodes:        sized.Nodes,
+		CPUs:         sized.CPUs * 100,
+		MemoryGB:     sized.MemoryGB,
+		DiskTB:       sized.DiskTB * 100,
+		ObjStorageTB: sized.ObjStorageTB * 100,
+	}
+
+	a := EstimateHeadroom(in, existing)
+
+	require.Equal(t, BindingConstraintMemory, a.BindingConstraint)
+	require.InDelta(t, in.IngestMBPerSec, a.MaxSustainableMBPerSec, in.IngestMBPerSec*0.1)
+}
+
+func Test_EstimateHeadroom_Headroom(t *testing.T) {
+	in := SizeInputs{IngestMBPerSec: 10, RetentionDays: 30}
+
+	existing := ExistingCluster{
+		Nodes:        1000,
+		CPUs:         1000,
+		MemoryGB:     10000,
+		DiskTB:       1000,
+		ObjStorageTB: 1000,
+	}
+
+	a := EstimateHeadroom(in, existing)
+
+	require.Greater(t, a.MaxSustainableMBPerSec, in.IngestMBPerSec)
+	require.Greater(t, a.HeadroomMBPerSec, 0.0)
+}
+
+func Test_EstimateHeadroom_Undersized(t *testing.T) {
+	in := SizeInputs{IngestMBPerSec: 200, RetentionDays: 30}
+
+	existing := ExistingCluster{
+		Nodes:        1,
+		CPUs:         1,
+		MemoryGB:     1,
+		DiskTB:       0.01,
+		ObjStorageTB: 0.01,
+	}
+
+	a := EstimateHeadroom(in, existing)
+
+	require.Equal(t, 0.0, a.MaxSustainableMBPerSec)
+	require.Less(t, a.HeadroomMBPerSec, 0.0)
+}
@@ -0,0 +1,34 @@
+package sizing
+
+// bloomBuilderMBPerSecPerReplica and bloomGatewayQPSPerReplica size the
+// experimental Bloom filter components the same way the rest of the model
+// sizes fixed-capacity replicas: a per-replica throughput ceiling fed
+// through replicasFor.
+const (
+	bloomBuilderMBPerSecPerReplica = 50
+	bloomGatewayQPSPerReplica      = 10
+)
+
+// experimentalComponents returns the Bloom filter builder and gateway
+// components gated behind SizeInputs.EnableExperimentalComponents: the
+// builder scales with ingest rate, like the other write-path components,
+// and the gateway scales with query load, like the querier. Both are
+// marked Component.Experimental so callers can call out their incremental
+// cost separately; see ClusterResources.TotalExperimentalMonthlyCostUSD.
+func experimentalComponents(in SizeInputs) []Component {
+	builder := newComponent("bloom-builder", replicasFor(in.IngestMBPerSec, bloomBuilderMBPerSecPerReplica), 2, 4, 1000, 2000, 20, DiskClassSSD)
+	gateway := newComponent("bloom-gateway", replicasFor(bloomGatewayQueryLoad(in), bloomGatewayQPSPerReplica), 1, 2, 500, 1000, 0, DiskClassStandard)
+	builder.Experimental = true
+	gateway.Experimental = true
+	return []Component{builder, gateway}
+}
+
+// bloomGatewayQueryLoad picks the query rate to size the Bloom gateway
+// against: PeakQueriesPerSecond when a query range was supplied, otherwise
+// a single-replica floor since there's no declared load to size from.
+func bloomGatewayQueryLoad(in SizeInputs) float64 {
+	if in.PeakQueriesPerSecond > 0 {
+		return in.PeakQueriesPerSecond
+	}
+	return bloomGatewayQPSPerReplica
+}
@@ -0,0 +1,70 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SizeCluster_ExperimentalComponentsDisabledByDefault(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30})
+
+	for _, c := range r.Components {
+		require.False(t, c.Experimental)
+		require.NotEqual(t, "bloom-builder", c.Name)
+		require.NotEqual(t, "bloom-gateway", c.Name)
+	}
+	require.Equal(t, 0.0, r.TotalExperimentalMonthlyCostUSD())
+}
+
+func Test_SizeCluster_ExperimentalComponentsEnabled(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30, EnableExperimentalComponents: true})
+
+	var builder, gateway *Component
+	for i, c := range r.Components {
+		switch c.Name {
+		case "bloom-builder":
+			builder = &r.Components[i]
+		case "bloom-gateway":
+			gateway = &r.Components[i]
+		}
+	}
+	require.NotNil(t, builder, "bloom-builder should be present when enabled")
+	require.NotNil(t, gateway, "bloom-gateway should be present when enabled")
+	require.True(t, builder.Experimental)
+	require.True(t, gateway.Experimental)
+}
+
+func Test_SizeCluster_ExperimentalSubtotalIncludedInTotal(t *testing.T) {
+	without := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30})
+	with := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30, EnableExperimentalComponents: true})
+
+	exp := with.TotalExperimentalMonthlyCostUSD()
+	require.Greater(t, exp, 0.0)
+	require.InDelta(t, without.TotalMonthlyCostUSD()+exp, with.TotalMonthlyCostUSD(), 0.001)
+}
+
+func Test_experimentalComponents_ScalesWithIngestAndQueryLoad(t *testing.T) {
+	low := experimentalComponents(SizeInputs{IngestMBPerSec: 10, PeakQueriesPerSecond: 5})
+	high := experimentalComponents(SizeInputs{IngestMBPerSec: 1000, PeakQueriesPerSecond: 500})
+
+	builderReplicas := func(components []Component) int {
+		for _, c := range components {
+			if c.Name == "bloom-builder" {
+				return c.Replicas
+			}
+		}
+		return 0
+	}
+	gatewayReplicas := func(components []Component) int {
+		for _, c := range components {
+			if c.Name == "bloom-gateway" {
+				return c.Replicas
+			}
+		}
+		return 0
+	}
+
+	require.Greater(t, builderReplicas(high), builderReplicas(low))
+	require.Greater(t, gatewayReplicas(high), gatewayReplicas(low))
+}
@@ -0,0 +1,95 @@
+package sizing
+
+import (
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// update regenerates the golden fixtures under testdata/golden from the
+// current SizeCluster output instead of checking against them. Only ever
+// pass this alongside an intentional, version-bumped change to the sizing
+// model: `go test ./pkg/sizing/... -run Golden -update`, or `make
+// sizing-goldens`.
+var update = flag.Bool("update", false, "regenerate sizing golden fixtures instead of comparing against them")
+
+// Test_Golden runs every fixture under testdata/golden through SizeCluster
+// and checks the result against what's recorded in the fixture. A fixture
+// failing here after a deliberate change to the sizing model means
+// AlgorithmVersion needs bumping and the fixture needs regenerating with
+// -update; a fixture failing for any other reason is a regression.
+func Test_Golden(t *testing.T) {
+	paths, err := filepath.Glob("testdata/golden/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths, "no golden fixtures found")
+
+	for _, path := range paths {
+		path := path
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			if *update {
+				existing, err := os.Open(path)
+				require.NoError(t, err)
+				plan, err := LoadPlan(existing)
+				existing.Close()
+				require.NoError(t, err)
+
+				f, err := os.Create(path)
+				require.NoError(t, err)
+				defer f.Close()
+
+				require.NoError(t, NewPlan(plan.Inputs).Save(f))
+				return
+			}
+
+			f, err := os.Open(path)
+			require.NoError(t, err)
+			defer f.Close()
+
+			want, err := LoadPlan(f)
+			require.NoError(t, err)
+
+			got := NewPlan(want.Inputs)
+			require.Equal(t, round(want.Resources), round(got.Resources))
+		})
+	}
+}
+
+// round truncates every float64 in r to a fixed precision so that fixtures
+// written by hand, or by an older version of Go's float formatting, don't
+// spuriously fail on floating-point noise in the last few bits.
+func round(r ClusterResources) ClusterResources {
+	r.DiskTB = roundFloat(r.DiskTB)
+	r.SSDDiskTB = roundFloat(r.SSDDiskTB)
+	r.StandardDiskTB = roundFloat(r.StandardDiskTB)
+	r.ObjStorageTB = roundFloat(r.ObjStorageTB)
+	r.ChunksStorageTB = roundFloat(r.ChunksStorageTB)
+	r.IndexStorageTB = roundFloat(r.IndexStorageTB)
+	for i := range r.Components {
+		r.Components[i].MemoryRequestGB = roundFloat(r.Components[i].MemoryRequestGB)
+		r.Components[i].MemoryLimitGB = roundFloat(r.Components[i].MemoryLimitGB)
+		r.Components[i].DiskGB = roundFloat(r.Components[i].DiskGB)
+		r.Components[i].MonthlyCostUSD = roundFloat(r.Components[i].MonthlyCostUSD)
+	}
+	if r.IngesterRolloutHeadroom != nil {
+		h := *r.IngesterRolloutHeadroom
+		h.MemoryGB = roundFloat(h.MemoryGB)
+		h.DiskGB = roundFloat(h.DiskGB)
+		h.MonthlyCostUSD = roundFloat(h.MonthlyCostUSD)
+		r.IngesterRolloutHeadroom = &h
+	}
+	if r.HASpares != nil {
+		s := *r.HASpares
+		s.MonthlyCostUSD = roundFloat(s.MonthlyCostUSD)
+		r.HASpares = &s
+	}
+	return r
+}
+
+func roundFloat(f float64) float64 {
+	const precision = 1e6
+	return math.Round(f*precision) / precision
+}
@@ -0,0 +1,45 @@
+package sizing
+
+import "fmt"
+
+// HASpares is the extra ingester replicas (and their cost) that
+// SizeInputs.HASpares added on top of the steady-state count SizeCluster
+// would otherwise size, broken out separately so it's visible rather than
+// silently folded into the ingester's totals. See applyHASpares.
+type HASpares struct {
+	IngesterReplicas int
+	MonthlyCostUSD   float64
+}
+
+// applyHASpares returns a copy of ingester with spares additional replicas,
+// so the cluster keeps its steady-state effective capacity even after
+// losing spares ingesters at once. Ingesters are active-active (every
+// replica serves live writes and reads), so a spare is an ordinary replica
+// indistinguishable from the rest, not a passive standby; callers should
+// apply this after checkReplicaBounds has already sized and, if needed,
+// auto-split the ingester component, so spares stack on top of the final
+// per-replica size rather than being redistributed by a later split.
+func applyHASpares(ingester Component, spares int) (padded Component, info HASpares) {
+	if spares <= 0 {
+		return ingester, HASpares{}
+	}
+
+	padded = ingester
+	padded.Replicas = ingester.Replicas + spares
+	padded.MonthlyCostUSD = float64(padded.Replicas) * (float64(ingester.CPULimitMillicores)/1000*costPerCPUCoreMonth +
+		ingester.MemoryLimitGB*costPerMemGBMonth +
+		ingester.DiskGB*costPerDiskGBMonth(ingester.DiskClass))
+
+	info = HASpares{
+		IngesterReplicas: spares,
+		MonthlyCostUSD:   padded.MonthlyCostUSD - ingester.MonthlyCostUSD,
+	}
+	return padded, info
+}
+
+// compactorHASparesNote warns that HASpares wasn't applied to the compactor
+// as extra replicas, since compactor runs as a single active coordinator;
+// see SizeCluster.
+func compactorHASparesNote(spares int) string {
+	return fmt.Sprintf("compactor: -ha-spares (%d) adds no compactor replicas; compactor runs as a single active instance, run a passive standby out-of-band if you need failover", spares)
+}
@@ -0,0 +1,90 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyHASpares(t *testing.T) {
+	ingester := newComponent("ingester", 2, 4, 8, 1000, 2000, 50, DiskClassSSD)
+
+	padded, info := applyHASpares(ingester, 1)
+
+	require.Equal(t, 3, padded.Replicas)
+	require.Equal(t, 1, info.IngesterReplicas)
+	require.Greater(t, info.MonthlyCostUSD, 0.0)
+	require.InDelta(t, padded.MonthlyCostUSD-ingester.MonthlyCostUSD, info.MonthlyCostUSD, 0.001)
+
+	// Per-replica size is untouched by HA spares; only the count grows.
+	require.Equal(t, ingester.MemoryLimitGB, padded.MemoryLimitGB)
+	require.Equal(t, ingester.DiskGB, padded.DiskGB)
+}
+
+func Test_applyHASpares_Zero(t *testing.T) {
+	ingester := newComponent("ingester", 2, 4, 8, 1000, 2000, 50, DiskClassSSD)
+
+	padded, info := applyHASpares(ingester, 0)
+
+	require.Equal(t, ingester, padded)
+	require.Equal(t, HASpares{}, info)
+}
+
+func Test_SizeCluster_HASpares(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 10, RetentionDays: 30, HASpares: 1})
+
+	require.NotNil(t, r.HASpares)
+	require.Equal(t, 1, r.HASpares.IngesterReplicas)
+	require.Greater(t, r.HASpares.MonthlyCostUSD, 0.0)
+
+	var ingester Component
+	for _, c := range r.Components {
+		if c.Name == "ingester" {
+			ingester = c
+		}
+	}
+	require.Equal(t, replicasFor(10, ingesterMBPerSecPerReplica)+1, ingester.Replicas)
+
+	require.Condition(t, func() bool {
+		for _, w := range r.Warnings {
+			if w == compactorHASparesNote(1) {
+				return true
+			}
+		}
+		return false
+	}, "expected a compactor HA spares note in warnings")
+}
+
+func Test_SizeCluster_NoHASpares(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 10, RetentionDays: 30})
+
+	require.Nil(t, r.HASpares)
+}
+
+func Test_SizeCluster_HASparesStackOnTopOfAutoSplit(t *testing.T) {
+	// A single ingester replica sized way over the default bounds forces
+	// checkReplicaBounds to auto-split it before HA spares are applied; the
+	// spares should add to the post-split count, not be folded into it.
+	r := SizeCluster(SizeInputs{
+		IngestMBPerSec: ingesterMBPerSecPerReplica * 40,
+		RetentionDays:  30,
+		HASpares:       2,
+	})
+
+	var withoutSpares Component
+	for _, c := range SizeCluster(SizeInputs{IngestMBPerSec: ingesterMBPerSecPerReplica * 40, RetentionDays: 30}).Components {
+		if c.Name == "ingester" {
+			withoutSpares = c
+		}
+	}
+
+	var ingester Component
+	for _, c := range r.Components {
+		if c.Name == "ingester" {
+			ingester = c
+		}
+	}
+
+	require.Greater(t, withoutSpares.Replicas, 1, "expected auto-split to increase the ingester's replica count")
+	require.Equal(t, withoutSpares.Replicas+2, ingester.Replicas)
+}
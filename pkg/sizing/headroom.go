@@ -0,0 +1,43 @@
+package sizing
+
+// During a rolling restart, an ingester replays its WAL before it starts
+// serving again, and briefly holds both its old and new in-memory chunks
+// plus the WAL segments backing them. Sizing the ingester component from
+// steady-state alone leaves no room for that, so clusters sized exactly to
+// SizeCluster's steady-state numbers can OOM or fill their disks on every
+// deploy. applyRolloutHeadroom pads the ingester's memory and disk limits
+// (not its requests) to cover that, and is applied by default.
+const (
+	rolloutMemoryHeadroomFactor = 1.3
+	rolloutDiskHeadroomFactor   = 1.5
+)
+
+// RolloutHeadroom is the extra memory, disk, and cost that applyRolloutHeadroom
+// added to the ingester component on top of its steady-state numbers, broken
+// out separately so it's visible rather than silently folded into the
+// ingester's totals.
+type RolloutHeadroom struct {
+	MemoryGB       float64
+	DiskGB         float64
+	MonthlyCostUSD float64
+}
+
+// applyRolloutHeadroom returns a copy of ingester with its memory and disk
+// limits (and the cost derived from them) padded by the rollout headroom
+// factors, along with the portion of each that the padding contributed.
+func applyRolloutHeadroom(ingester Component) (padded Component, headroom RolloutHeadroom) {
+	padded = ingester
+	padded.MemoryLimitGB = ingester.MemoryLimitGB * rolloutMemoryHeadroomFactor
+	padded.DiskGB = ingester.DiskGB * rolloutDiskHeadroomFactor
+	padded.MonthlyCostUSD = float64(ingester.Replicas) * (float64(ingester.CPULimitMillicores)/1000*costPerCPUCoreMonth +
+		padded.MemoryLimitGB*costPerMemGBMonth +
+		padded.DiskGB*costPerDiskGBMonth(ingester.DiskClass))
+
+	replicas := float64(ingester.Replicas)
+	headroom = RolloutHeadroom{
+		MemoryGB:       replicas * (padded.MemoryLimitGB - ingester.MemoryLimitGB),
+		DiskGB:         replicas * (padded.DiskGB - ingester.DiskGB),
+		MonthlyCostUSD: padded.MonthlyCostUSD - ingester.MonthlyCostUSD,
+	}
+	return padded, headroom
+}
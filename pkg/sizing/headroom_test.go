@@ -0,0 +1,56 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SizeCluster_RolloutHeadroom(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30})
+
+	require.NotNil(t, r.IngesterRolloutHeadroom)
+	require.Greater(t, r.IngesterRolloutHeadroom.MemoryGB, 0.0)
+	require.Greater(t, r.IngesterRolloutHeadroom.DiskGB, 0.0)
+
+	var ingester Component
+	for _, c := range r.Components {
+		if c.Name == "ingester" {
+			ingester = c
+		}
+	}
+	require.InDelta(t, 8*rolloutMemoryHeadroomFactor, ingester.MemoryLimitGB, 0.001)
+	require.InDelta(t, 50*rolloutDiskHeadroomFactor, ingester.DiskGB, 0.001)
+}
+
+func Test_SizeCluster_NoRolloutHeadroom(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30, DisableRolloutHeadroom: true})
+
+	require.Nil(t, r.IngesterRolloutHeadroom)
+
+	var ingester Component
+	for _, c := range r.Components {
+		if c.Name == "ingester" {
+			ingester = c
+		}
+	}
+	require.Equal(t, 8.0, ingester.MemoryLimitGB)
+	require.Equal(t, 50.0, ingester.DiskGB)
+}
+
+func Test_applyRolloutHeadroom(t *testing.T) {
+	ingester := newComponent("ingester", 2, 4, 8, 1000, 2000, 50, DiskClassSSD)
+
+	padded, headroom := applyRolloutHeadroom(ingester)
+
+	require.InDelta(t, 10.4, padded.MemoryLimitGB, 0.001)
+	require.InDelta(t, 75, padded.DiskGB, 0.001)
+	require.InDelta(t, 2*(10.4-8), headroom.MemoryGB, 0.001)
+	require.InDelta(t, 2*(75-50), headroom.DiskGB, 0.001)
+	require.Greater(t, headroom.MonthlyCostUSD, 0.0)
+
+	// Requests, replicas, and CPU limits are untouched by rollout headroom.
+	require.Equal(t, ingester.MemoryRequestGB, padded.MemoryRequestGB)
+	require.Equal(t, ingester.Replicas, padded.Replicas)
+	require.Equal(t, ingester.CPULimitMillicores, padded.CPULimitMillicores)
+}
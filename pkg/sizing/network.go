@@ -0,0 +1,115 @@
+package sizing
+
+import "fmt"
+
+// defaultReplicationFactor is the ingester ring replication factor assumed
+// when SizeInputs.ReplicationFactor is left unset, matching Loki's own
+// default ingester ring replication factor.
+const defaultReplicationFactor = 3
+
+// querierMBPerQuery is the object storage/ingester read volume a single
+// query is assumed to pull, used to translate SizeInputs.PeakQueriesPerSecond
+// into NetworkThroughput.QueryReadMBPerSec. There's no query-shape input to
+// derive this from yet (see NetworkThroughput.QueryReadMBPerSec), so it's a
+// single flat assumption rather than a per-replica sizing table like
+// querierQPSPerReplica.
+const querierMBPerQuery = 5.0
+
+// secondsPerMonth is used to turn a sustained MB/s rate into a monthly GB
+// transfer volume for MonthlyInterAZTransferGB. It assumes a 30-day month,
+// matching ComputeObjectStorage's use of a flat day count for retention.
+const secondsPerMonth = 86400 * 30
+
+// NetworkThroughput is the network bandwidth a sized cluster implies,
+// derived entirely from SizeInputs rather than measured. It's meant to give
+// operators a starting point for NIC and inter-AZ transfer capacity
+// planning, not a guarantee of real traffic.
+type NetworkThroughput struct {
+	// IngestMBPerSec is the sustained bandwidth clients push into the
+	// distributors, i.e. SizeInputs.IngestMBPerSec unchanged.
+	IngestMBPerSec float64
+	// ReplicationMBPerSec is the bandwidth distributors push on to
+	// ingesters: ingest replicated ReplicationFactor times.
+	ReplicationMBPerSec float64
+	// FlushMBPerSec is the bandwidth ingesters flush to object storage,
+	// i.e. ingest divided by the effective compression ratio (see
+	// effectiveCompressionRatio) applied once: every replica ingests the
+	// same bytes, but chunks are only flushed once each.
+	FlushMBPerSec float64
+	// QueryReadMBPerSec is the expected read bandwidth queriers pull from
+	// ingesters and object storage to serve queries, sized from
+	// SizeInputs.PeakQueriesPerSecond. Zero when PeakQueriesPerSecond
+	// wasn't set, since there's no declared query rate to size from.
+	QueryReadMBPerSec float64
+
+	// ReplicationFactor and AvailabilityZones echo the SizeInputs (with
+	// ReplicationFactor's zero value resolved to defaultReplicationFactor)
+	// that produced this estimate, since MonthlyInterAZTransferGB only
+	// makes sense alongside both.
+	ReplicationFactor int
+	AvailabilityZones int
+	// MonthlyInterAZTransferGB estimates the replication traffic that
+	// crosses an availability zone boundary in a typical month, assuming
+	// replicas are spread evenly across AvailabilityZones so
+	// (AvailabilityZones-1)/AvailabilityZones of replication traffic
+	// leaves the writer's zone. Zero when AvailabilityZones is 1 or less,
+	// since a single zone has no inter-AZ traffic.
+	MonthlyInterAZTransferGB float64
+	// MonthlyInterAZTransferCostUSD prices MonthlyInterAZTransferGB at
+	// SizeInputs.CostPerGBTransfer. Zero when either MonthlyInterAZTransferGB
+	// or CostPerGBTransfer is zero.
+	MonthlyInterAZTransferCostUSD float64
+}
+
+// estimateNetworkThroughput derives the network bandwidth implied by in,
+// using effectiveCompressionRatio(in) to size flush bandwidth the same way
+// ComputeObjectStorage sizes chunk storage.
+func estimateNetworkThroughput(in SizeInputs) NetworkThroughput {
+	rf := in.ReplicationFactor
+	if rf <= 0 {
+		rf = defaultReplicationFactor
+	}
+
+	var queryReadMBPerSec float64
+	if in.PeakQueriesPerSecond > 0 {
+		queryReadMBPerSec = in.PeakQueriesPerSecond * querierMBPerQuery
+	}
+
+	nt := NetworkThroughput{
+		IngestMBPerSec:      in.IngestMBPerSec,
+		ReplicationMBPerSec: in.IngestMBPerSec * float64(rf),
+		FlushMBPerSec:       in.IngestMBPerSec / effectiveCompressionRatio(in),
+		QueryReadMBPerSec:   queryReadMBPerSec,
+		ReplicationFactor:   rf,
+		AvailabilityZones:   in.AvailabilityZones,
+	}
+
+	if in.AvailabilityZones > 1 {
+		crossZoneFraction := float64(in.AvailabilityZones-1) / float64(in.AvailabilityZones)
+		nt.MonthlyInterAZTransferGB = nt.ReplicationMBPerSec * secondsPerMonth * crossZoneFraction / 1024
+		nt.MonthlyInterAZTransferCostUSD = nt.MonthlyInterAZTransferGB * in.CostPerGBTransfer
+	}
+
+	return nt
+}
+
+// String formats nt for DescribeArchitecture's verbose output.
+func (nt NetworkThroughput) String() string {
+	s := fmt.Sprintf(
+		"    Ingest (client -> distributor):        %.2f MB/s\n"+
+			"    Replication (distributor -> ingester): %.2f MB/s (x%d replication factor)\n"+
+			"    Flush (ingester -> object storage):    %.2f MB/s\n",
+		nt.IngestMBPerSec, nt.ReplicationMBPerSec, nt.ReplicationFactor, nt.FlushMBPerSec,
+	)
+	if nt.QueryReadMBPerSec > 0 {
+		s += fmt.Sprintf("    Query read (querier <- storage):       %.2f MB/s\n", nt.QueryReadMBPerSec)
+	}
+	if nt.AvailabilityZones > 1 {
+		s += fmt.Sprintf("    Inter-AZ transfer (%d zones):           ~%.0f GB/mo", nt.AvailabilityZones, nt.MonthlyInterAZTransferGB)
+		if nt.MonthlyInterAZTransferCostUSD > 0 {
+			s += fmt.Sprintf(", $%.2f/mo", nt.MonthlyInterAZTransferCostUSD)
+		}
+		s += "\n"
+	}
+	return s
+}
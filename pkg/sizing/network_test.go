@@ -0,0 +1,82 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EstimateNetworkThroughput(t *testing.T) {
+	t.Run("defaults replication factor when unset", func(t *testing.T) {
+		nt := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100})
+		require.Equal(t, defaultReplicationFactor, nt.ReplicationFactor)
+		require.InDelta(t, 100, nt.IngestMBPerSec, 1e-9)
+		require.InDelta(t, 300, nt.ReplicationMBPerSec, 1e-9)
+	})
+
+	t.Run("honors an explicit replication factor", func(t *testing.T) {
+		nt := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100, ReplicationFactor: 5})
+		require.Equal(t, 5, nt.ReplicationFactor)
+		require.InDelta(t, 500, nt.ReplicationMBPerSec, 1e-9)
+	})
+
+	t.Run("flush bandwidth divides by the effective compression ratio", func(t *testing.T) {
+		nt := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100, CompressionRatio: 10})
+		require.InDelta(t, 10, nt.FlushMBPerSec, 1e-9)
+	})
+
+	t.Run("flush bandwidth assumes no compression when unset", func(t *testing.T) {
+		nt := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100})
+		require.InDelta(t, 100, nt.FlushMBPerSec, 1e-9)
+	})
+
+	t.Run("no query read bandwidth without a peak query rate", func(t *testing.T) {
+		nt := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100})
+		require.Zero(t, nt.QueryReadMBPerSec)
+	})
+
+	t.Run("query read bandwidth scales with peak queries per second", func(t *testing.T) {
+		nt := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100, PeakQueriesPerSecond: 20})
+		require.InDelta(t, 20*querierMBPerQuery, nt.QueryReadMBPerSec, 1e-9)
+	})
+
+	t.Run("no inter-AZ transfer with a single zone", func(t *testing.T) {
+		nt := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100, AvailabilityZones: 1})
+		require.Zero(t, nt.MonthlyInterAZTransferGB)
+		require.Zero(t, nt.MonthlyInterAZTransferCostUSD)
+	})
+
+	t.Run("no inter-AZ transfer when zones are unset", func(t *testing.T) {
+		nt := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100})
+		require.Zero(t, nt.MonthlyInterAZTransferGB)
+	})
+
+	t.Run("inter-AZ transfer scales with zone count and replication factor", func(t *testing.T) {
+		two := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100, ReplicationFactor: 3, AvailabilityZones: 2})
+		three := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100, ReplicationFactor: 3, AvailabilityZones: 3})
+
+		require.Greater(t, two.MonthlyInterAZTransferGB, 0.0)
+		// The cross-zone fraction (zones-1)/zones grows with zone count, so
+		// more zones leave more of the same replication traffic crossing a
+		// zone boundary.
+		require.Greater(t, three.MonthlyInterAZTransferGB, two.MonthlyInterAZTransferGB)
+	})
+
+	t.Run("inter-AZ transfer is priced only when a transfer cost is set", func(t *testing.T) {
+		unpriced := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100, AvailabilityZones: 2})
+		require.Zero(t, unpriced.MonthlyInterAZTransferCostUSD)
+
+		priced := estimateNetworkThroughput(SizeInputs{IngestMBPerSec: 100, AvailabilityZones: 2, CostPerGBTransfer: 0.02})
+		require.InDelta(t, priced.MonthlyInterAZTransferGB*0.02, priced.MonthlyInterAZTransferCostUSD, 1e-9)
+		require.Greater(t, priced.MonthlyInterAZTransferCostUSD, 0.0)
+	})
+}
+
+func Test_SizeCluster_NetworkThroughput(t *testing.T) {
+	r := SizeCluster(SizeInputs{IngestMBPerSec: 100, RetentionDays: 30, AvailabilityZones: 3, CostPerGBTransfer: 0.01})
+
+	require.InDelta(t, 100, r.NetworkThroughput.IngestMBPerSec, 1e-9)
+	require.Greater(t, r.NetworkThroughput.MonthlyInterAZTransferGB, 0.0)
+	require.InDelta(t, r.TotalMonthlyCostUSD(), r.MonthlyCosts().ComputeUSD, 1e-9)
+	require.InDelta(t, r.NetworkThroughput.MonthlyInterAZTransferCostUSD, r.MonthlyCosts().NetworkUSD, 1e-9)
+}
@@ -0,0 +1,84 @@
+package sizing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Plan is a saved sizing result: the inputs that produced it alongside the
+// resulting ClusterResources. Saving a Plan (rather than just the
+// ClusterResources) lets a later run re-derive or re-diff it against a
+// fresh SizeCluster call over the same SizeInputs.
+type Plan struct {
+	Inputs    SizeInputs       `json:"inputs"`
+	Resources ClusterResources `json:"resources"`
+}
+
+// NewPlan sizes a cluster from in and wraps the result into a Plan.
+func NewPlan(in SizeInputs) Plan {
+	return Plan{Inputs: in, Resources: SizeCluster(in)}
+}
+
+// Save writes p to w as JSON.
+func (p Plan) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// LoadPlan reads a Plan previously written by Plan.Save.
+func LoadPlan(r io.Reader) (Plan, error) {
+	var p Plan
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return Plan{}, fmt.Errorf("decoding plan: %w", err)
+	}
+	return p, nil
+}
+
+// DiffPlans compares two plans and returns a human-readable line for each
+// notable difference in their resource totals. It's meant for spotting
+// drift between a saved plan and a freshly computed one, not for
+// programmatic consumption.
+//
+// If the two plans were produced by different versions of the sizing
+// model, the first line warns about that before any other differences are
+// reported, since the remaining differences may simply reflect the model
+// changing rather than the inputs.
+func DiffPlans(before, after Plan) []string {
+	var diffs []string
+
+	if before.Resources.Version != after.Resources.Version {
+		diffs = append(diffs, fmt.Sprintf(
+			"warning: comparing plans sized by different model versions (%s vs %s); differences below may be caused by the model, not the inputs",
+			valueOrUnknown(before.Resources.Version), valueOrUnknown(after.Resources.Version)))
+	}
+
+	if before.Resources.Nodes != after.Resources.Nodes {
+		diffs = append(diffs, fmt.Sprintf("nodes: %d -> %d", before.Resources.Nodes, after.Resources.Nodes))
+	}
+	if before.Resources.MemoryGB != after.Resources.MemoryGB {
+		diffs = append(diffs, fmt.Sprintf("memory: %dGB -> %dGB", before.Resources.MemoryGB, after.Resources.MemoryGB))
+	}
+	if before.Resources.CPUs != after.Resources.CPUs {
+		diffs = append(diffs, fmt.Sprintf("cpus: %d -> %d", before.Resources.CPUs, after.Resources.CPUs))
+	}
+	if before.Resources.DiskTB != after.Resources.DiskTB {
+		diffs = append(diffs, fmt.Sprintf("disk: %.2fTB -> %.2fTB", before.Resources.DiskTB, after.Resources.DiskTB))
+	}
+	if before.Resources.ObjStorageTB != after.Resources.ObjStorageTB {
+		diffs = append(diffs, fmt.Sprintf("obj-storage: %.2fTB -> %.2fTB", before.Resources.ObjStorageTB, after.Resources.ObjStorageTB))
+	}
+	if beforeCost, afterCost := before.Resources.TotalMonthlyCostUSD(), after.Resources.TotalMonthlyCostUSD(); beforeCost != afterCost {
+		diffs = append(diffs, fmt.Sprintf("monthly cost: $%.2f -> $%.2f", beforeCost, afterCost))
+	}
+
+	return diffs
+}
+
+func valueOrUnknown(version string) string {
+	if version == "" {
+		return "unknown"
+	}
+	return version
+}
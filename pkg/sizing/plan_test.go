@@ -0,0 +1,45 @@
+package sizing
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Plan_SaveLoad_RoundTrip(t *testing.T) {
+	want := NewPlan(SizeInputs{IngestMBPerSec: 40, RetentionDays: 30, CacheBackend: CacheBackendEmbedded, CacheWorkingSetGB: 8})
+
+	var buf bytes.Buffer
+	require.NoError(t, want.Save(&buf))
+
+	got, err := LoadPlan(&buf)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func Test_DiffPlans_NoDifference(t *testing.T) {
+	p := NewPlan(SizeInputs{IngestMBPerSec: 20, RetentionDays: 30})
+	require.Empty(t, DiffPlans(p, p))
+}
+
+func Test_DiffPlans_ReportsChanges(t *testing.T) {
+	before := NewPlan(SizeInputs{IngestMBPerSec: 20, RetentionDays: 30})
+	after := NewPlan(SizeInputs{IngestMBPerSec: 200, RetentionDays: 30})
+
+	diffs := DiffPlans(before, after)
+	require.NotEmpty(t, diffs)
+	for _, d := range diffs {
+		require.NotContains(t, d, "different model versions")
+	}
+}
+
+func Test_DiffPlans_WarnsOnVersionMismatch(t *testing.T) {
+	before := NewPlan(SizeInputs{IngestMBPerSec: 20, RetentionDays: 30})
+	after := before
+	after.Resources.Version = "0.9.0"
+
+	diffs := DiffPlans(before, after)
+	require.NotEmpty(t, diffs)
+	require.Contains(t, diffs[0], "different model versions")
+}
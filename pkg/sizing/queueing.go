@@ -0,0 +1,144 @@
+package sizing
+
+import (
+	"fmt"
+	"math"
+)
+
+// assumedQueryDurationSeconds is the average time a query is assumed to
+// hold a querier's query-engine goroutine, used to translate
+// querierQPSPerReplica into a recommended per-replica concurrency (Little's
+// law: concurrency = throughput * duration) and to derive the M/M/c service
+// rate for QueueingEstimate. There's no query-shape input to derive this
+// from yet (see querierMBPerQuery in network.go for the same caveat), so
+// it's a single flat assumption rather than a per-replica sizing table.
+const assumedQueryDurationSeconds = 2.0
+
+// queueWaitWarningThresholdSeconds is the p95 queue wait above which
+// SizeCluster warns that the scheduler queue is backing up and more
+// queriers are needed, rather than just reporting the estimate.
+const queueWaitWarningThresholdSeconds = 2.0
+
+// QueueingEstimate reports the querier concurrency SizeCluster recommends
+// for SizeInputs.PeakQueriesPerSecond, and the scheduler queue wait that
+// concurrency implies under an M/M/c queueing approximation. It's nil
+// unless SizeInputs.PeakQueriesPerSecond was set, since without a declared
+// query rate there's no load to queue against.
+type QueueingEstimate struct {
+	// RecommendedConcurrencyPerQuerier is the querier.max-concurrent value
+	// SizeCluster recommends for each querier replica, derived from
+	// querierQPSPerReplica and assumedQueryDurationSeconds via Little's law.
+	RecommendedConcurrencyPerQuerier int
+	// TotalQueryParallelism is RecommendedConcurrencyPerQuerier multiplied
+	// by ClusterResources.QuerierMaxReplicas, i.e. the number of queries
+	// the read path can execute at once at peak scale. This is the "c" in
+	// the M/M/c model below.
+	TotalQueryParallelism int
+	// UtilizationFraction is the offered load (SizeInputs.PeakQueriesPerSecond
+	// times assumedQueryDurationSeconds) divided by TotalQueryParallelism.
+	// A value at or above 1 means the queue grows without bound; see
+	// Unstable.
+	UtilizationFraction float64
+	// Unstable is true when UtilizationFraction is at or above 1, meaning
+	// TotalQueryParallelism can't keep up with
+	// SizeInputs.PeakQueriesPerSecond even before any queueing delay is
+	// considered. P95QueueWaitSeconds is left at zero in that case, since
+	// an unstable queue has no finite steady-state wait to report.
+	Unstable bool
+	// P95QueueWaitSeconds is the 95th percentile time a query is estimated
+	// to wait in the scheduler queue before a querier slot is free, under
+	// an M/M/c approximation with TotalQueryParallelism servers, arrival
+	// rate SizeInputs.PeakQueriesPerSecond, and service rate
+	// 1/assumedQueryDurationSeconds per server.
+	P95QueueWaitSeconds float64
+}
+
+// String formats e for DescribeArchitecture's verbose output.
+func (e QueueingEstimate) String() string {
+	if e.Unstable {
+		return fmt.Sprintf(
+			"    Recommended concurrency: %d/querier, %d total parallelism\n"+
+				"    Utilization: %.0f%% (unstable: TotalQueryParallelism can't sustain the peak query rate)\n",
+			e.RecommendedConcurrencyPerQuerier, e.TotalQueryParallelism, e.UtilizationFraction*100,
+		)
+	}
+	return fmt.Sprintf(
+		"    Recommended concurrency: %d/querier, %d total parallelism\n"+
+			"    Utilization: %.0f%%, p95 queue wait: %.2fs\n",
+		e.RecommendedConcurrencyPerQuerier, e.TotalQueryParallelism, e.UtilizationFraction*100, e.P95QueueWaitSeconds,
+	)
+}
+
+// estimateQueueing derives a QueueingEstimate from in and the querier
+// replica count SizeCluster settled on for peak load
+// (querierAutoscaleReplicas' max). It returns nil, "" when
+// in.PeakQueriesPerSecond isn't set, matching querierAutoscaleReplicas'
+// convention of leaving query-load-derived fields unset rather than
+// guessing. It returns a non-empty warning when the estimated queue wait
+// exceeds queueWaitWarningThresholdSeconds, or when the queue is unstable.
+func estimateQueueing(in SizeInputs, querierMaxReplicas int) (*QueueingEstimate, string) {
+	if in.PeakQueriesPerSecond <= 0 {
+		return nil, ""
+	}
+
+	concurrencyPerReplica := int(math.Ceil(querierQPSPerReplica * assumedQueryDurationSeconds))
+	totalParallelism := concurrencyPerReplica * querierMaxReplicas
+
+	serviceRate := 1 / assumedQueryDurationSeconds
+	offeredLoad := in.PeakQueriesPerSecond / serviceRate
+	utilization := offeredLoad / float64(totalParallelism)
+
+	estimate := &QueueingEstimate{
+		RecommendedConcurrencyPerQuerier: concurrencyPerReplica,
+		TotalQueryParallelism:            totalParallelism,
+		UtilizationFraction:              utilization,
+	}
+
+	if utilization >= 1 {
+		estimate.Unstable = true
+		return estimate, fmt.Sprintf(
+			"querier concurrency is undersized for the peak query rate: %d total parallelism can't sustain %.1f queries/s at an assumed %.1fs average query duration; add queriers",
+			totalParallelism, in.PeakQueriesPerSecond, assumedQueryDurationSeconds)
+	}
+
+	pWait := erlangC(totalParallelism, offeredLoad)
+	estimate.P95QueueWaitSeconds = queueWaitPercentile(pWait, totalParallelism, serviceRate, in.PeakQueriesPerSecond, 0.95)
+
+	if estimate.P95QueueWaitSeconds > queueWaitWarningThresholdSeconds {
+		return estimate, fmt.Sprintf(
+			"estimated p95 scheduler queue wait (%.2fs) exceeds %.2fs at %.1f queries/s with %d total querier parallelism; add queriers",
+			estimate.P95QueueWaitSeconds, queueWaitWarningThresholdSeconds, in.PeakQueriesPerSecond, totalParallelism)
+	}
+
+	return estimate, ""
+}
+
+// erlangC returns the Erlang C probability that an arriving query finds
+// every one of c servers busy and has to queue, for offered load a
+// (arrival rate / service rate). It's computed via the Erlang B recursion
+// (Erlang C(c,a) = c*B(c,a) / (c - a*(1-B(c,a)))), which stays numerically
+// stable for large c where computing a^c/c! directly would overflow.
+func erlangC(c int, a float64) float64 {
+	b := 1.0 // Erlang B(0, a)
+	for n := 1; n <= c; n++ {
+		b = (a * b) / (float64(n) + a*b)
+	}
+	return float64(c) * b / (float64(c) - a*(1-b))
+}
+
+// queueWaitPercentile returns the wait time t such that P(wait > t) =
+// 1-percentile, given the Erlang C probability of queueing pWait. A query
+// that does queue waits an exponentially distributed time with rate
+// c*mu-lambda (the M/M/c result for the conditional wait distribution), so
+// overall P(wait > t) = pWait * exp(-(c*mu-lambda)*t). If that tail
+// probability is already below 1-percentile at t=0 (i.e. pWait itself is
+// small enough), the percentile falls in the "no wait at all" mass and the
+// wait is zero.
+func queueWaitPercentile(pWait float64, c int, mu, lambda float64, percentile float64) float64 {
+	tailTarget := 1 - percentile
+	if pWait <= tailTarget {
+		return 0
+	}
+	excessCapacity := float64(c)*mu - lambda
+	return math.Log(pWait/tailTarget) / excessCapacity
+}
@@ -0,0 +1,96 @@
+package sizing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ErlangC(t *testing.T) {
+	t.Run("single server reduces to plain utilization", func(t *testing.T) {
+		// For c=1, Erlang C's closed form is just the offered load itself:
+		// with one server, "found busy on arrival" is exactly the
+		// fraction of time that server is busy.
+		require.InDelta(t, 0.5, erlangC(1, 0.5), 1e-9)
+		require.InDelta(t, 0.8, erlangC(1, 0.8), 1e-9)
+	})
+
+	t.Run("matches a known three-server table value", func(t *testing.T) {
+		// c=3, a=2 erlangs is a standard textbook Erlang C example with the
+		// closed-form value 4/9.
+		require.InDelta(t, 4.0/9.0, erlangC(3, 2), 1e-9)
+	})
+}
+
+func Test_QueueWaitPercentile(t *testing.T) {
+	t.Run("zero wait when the queueing probability is already below the tail target", func(t *testing.T) {
+		require.Zero(t, queueWaitPercentile(0.03, 1, 1, 0.5, 0.95))
+	})
+
+	t.Run("matches the closed-form M/M/c conditional-wait exponential", func(t *testing.T) {
+		// P(wait > t) = pWait * exp(-(c*mu-lambda)*t); solving for the t at
+		// which that tail equals 1-percentile gives
+		// t = ln(pWait/(1-percentile)) / (c*mu-lambda).
+		got := queueWaitPercentile(0.5, 1, 1, 0.5, 0.95)
+		require.InDelta(t, 4.605170186, got, 1e-6) // 2*ln(10)
+	})
+}
+
+func Test_EstimateQueueing(t *testing.T) {
+	t.Run("nil without a peak query rate", func(t *testing.T) {
+		estimate, warning := estimateQueueing(SizeInputs{}, 4)
+		require.Nil(t, estimate)
+		require.Empty(t, warning)
+	})
+
+	t.Run("recommends concurrency from Little's law and reports low utilization without a warning", func(t *testing.T) {
+		estimate, warning := estimateQueueing(SizeInputs{PeakQueriesPerSecond: 5}, 2)
+		require.NotNil(t, estimate)
+		require.Empty(t, warning)
+
+		require.Equal(t, 10, estimate.RecommendedConcurrencyPerQuerier) // ceil(5 * 2.0)
+		require.Equal(t, 20, estimate.TotalQueryParallelism)            // 10 * 2 replicas
+		require.InDelta(t, 0.5, estimate.UtilizationFraction, 1e-9)
+		require.False(t, estimate.Unstable)
+		require.Zero(t, estimate.P95QueueWaitSeconds)
+	})
+
+	t.Run("warns once the estimated p95 wait crosses the threshold", func(t *testing.T) {
+		estimate, warning := estimateQueueing(SizeInputs{PeakQueriesPerSecond: 9.5}, 2)
+		require.NotNil(t, estimate)
+		require.InDelta(t, 0.95, estimate.UtilizationFraction, 1e-9)
+		require.False(t, estimate.Unstable)
+		require.Greater(t, estimate.P95QueueWaitSeconds, queueWaitWarningThresholdSeconds)
+		require.NotEmpty(t, warning)
+	})
+
+	t.Run("flags an unstable queue instead of reporting a wait time", func(t *testing.T) {
+		estimate, warning := estimateQueueing(SizeInputs{PeakQueriesPerSecond: 11}, 2)
+		require.NotNil(t, estimate)
+		require.True(t, estimate.Unstable)
+		require.Zero(t, estimate.P95QueueWaitSeconds)
+		require.NotEmpty(t, warning)
+	})
+}
+
+func Test_SizeCluster_Queueing(t *testing.T) {
+	t.Run("unset without a peak query rate", func(t *testing.T) {
+		r := SizeCluster(SizeInputs{IngestMBPerSec: 100, RetentionDays: 30})
+		require.Nil(t, r.Queueing)
+	})
+
+	t.Run("populated and surfaced as a warning when peak query rate is set", func(t *testing.T) {
+		r := SizeCluster(SizeInputs{IngestMBPerSec: 100, RetentionDays: 30, MinQueriesPerSecond: 5, PeakQueriesPerSecond: 1000})
+		require.NotNil(t, r.Queueing)
+		require.True(t, r.Queueing.Unstable)
+
+		var found bool
+		for _, w := range r.Warnings {
+			if strings.Contains(w, "querier concurrency is undersized") {
+				found = true
+			}
+		}
+		require.True(t, found, "expected a warning about the undersized querier concurrency")
+	})
+}
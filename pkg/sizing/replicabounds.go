@@ -0,0 +1,95 @@
+package sizing
+
+import "fmt"
+
+// No cloud provider offers arbitrarily large single instances, so a
+// per-replica size sized to hold extreme inputs (for example a huge
+// embedded-cache working set spread across too few querier replicas) can
+// come out unschedulable. These are the default per-replica ceilings
+// SizeCluster checks components against; SizeInputs can override them.
+const (
+	defaultMaxCPUMillicoresPerReplica = 32000
+	defaultMaxMemoryGBPerReplica      = 256
+)
+
+// checkReplicaBounds compares c's per-replica CPU and memory limits against
+// maxCPUMillicores/maxMemoryGB (either of which disables that half of the
+// check when zero or negative). If c fits, it's returned unchanged with no
+// warning. If it doesn't and autoSplit is true, splitOversizedComponent
+// grows its replica count to bring it back under bound. If it doesn't and
+// autoSplit is false, c is returned unchanged alongside a warning so the
+// caller can flag the oversized replica instead of silently producing it.
+func checkReplicaBounds(c Component, maxCPUMillicores int, maxMemoryGB float64, autoSplit bool) (Component, string) {
+	overCPU := maxCPUMillicores > 0 && c.CPULimitMillicores > maxCPUMillicores
+	overMemory := maxMemoryGB > 0 && c.MemoryLimitGB > maxMemoryGB
+	if !overCPU && !overMemory {
+		return c, ""
+	}
+
+	if !autoSplit {
+		return c, fmt.Sprintf(
+			"%s: per-replica size (%d millicores, %.0fGB memory) exceeds the sanity bound (%d millicores, %.0fGB memory) and auto-split is disabled; this replica may not be schedulable",
+			c.Name, c.CPULimitMillicores, c.MemoryLimitGB, maxCPUMillicores, maxMemoryGB,
+		)
+	}
+
+	split, newReplicas := splitOversizedComponent(c, maxCPUMillicores, maxMemoryGB)
+	warning := fmt.Sprintf(
+		"%s: per-replica size (%d millicores, %.0fGB memory) exceeded the sanity bound (%d millicores, %.0fGB memory); increased replicas from %d to %d to compensate",
+		c.Name, c.CPULimitMillicores, c.MemoryLimitGB, maxCPUMillicores, maxMemoryGB, c.Replicas, newReplicas,
+	)
+	return split, warning
+}
+
+// splitOversizedComponent grows c's replica count until its per-replica CPU
+// and memory limits both fit under maxCPUMillicores/maxMemoryGB, holding
+// its total capacity (and therefore total cost) fixed by redistributing the
+// existing totals evenly across the new replica count.
+func splitOversizedComponent(c Component, maxCPUMillicores int, maxMemoryGB float64) (split Component, newReplicas int) {
+	totalCPURequest := c.CPURequestMillicores * c.Replicas
+	totalCPULimit := c.CPULimitMillicores * c.Replicas
+	totalMemRequest := c.MemoryRequestGB * float64(c.Replicas)
+	totalMemLimit := c.MemoryLimitGB * float64(c.Replicas)
+	totalDisk := c.DiskGB * float64(c.Replicas)
+
+	newReplicas = c.Replicas
+	if maxCPUMillicores > 0 {
+		if need := ceilDivInt(totalCPULimit, maxCPUMillicores); need > newReplicas {
+			newReplicas = need
+		}
+	}
+	if maxMemoryGB > 0 {
+		if need := ceilDivFloat(totalMemLimit, maxMemoryGB); need > newReplicas {
+			newReplicas = need
+		}
+	}
+
+	split = c
+	split.Replicas = newReplicas
+	split.CPURequestMillicores = totalCPURequest / newReplicas
+	split.CPULimitMillicores = totalCPULimit / newReplicas
+	split.MemoryRequestGB = totalMemRequest / float64(newReplicas)
+	split.MemoryLimitGB = totalMemLimit / float64(newReplicas)
+	split.DiskGB = totalDisk / float64(newReplicas)
+	// Total cost is unaffected: it's the same aggregate CPU/memory/disk
+	// spread across more, smaller replicas.
+	split.MonthlyCostUSD = c.MonthlyCostUSD
+	return split, newReplicas
+}
+
+// ceilDivInt returns total/bound rounded up.
+func ceilDivInt(total, bound int) int {
+	return (total + bound - 1) / bound
+}
+
+// ceilDivFloat returns total/bound rounded up to the nearest whole replica.
+func ceilDivFloat(total, bound float64) int {
+	n := int(total / bound)
+	if float64(n)*bound < total {
+		n++
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
@@ -0,0 +1,89 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkReplicaBounds_WithinBounds(t *testing.T) {
+	c := newComponent("querier", 2, 2, 4, 1000, 2000, 0, DiskClassStandard)
+
+	adjusted, warning := checkReplicaBounds(c, defaultMaxCPUMillicoresPerReplica, defaultMaxMemoryGBPerReplica, true)
+
+	require.Empty(t, warning)
+	require.Equal(t, c, adjusted)
+}
+
+func Test_checkReplicaBounds_AutoSplitPreservesTotalCapacity(t *testing.T) {
+	// A single querier replica sized way over the default bounds, as
+	// happens with a huge embedded-cache working set and few replicas.
+	c := newComponent("querier", 1, 32, 700, 4000, 96000, 0, DiskClassStandard)
+
+	adjusted, warning := checkReplicaBounds(c, defaultMaxCPUMillicoresPerReplica, defaultMaxMemoryGBPerReplica, true)
+
+	require.NotEmpty(t, warning)
+	require.Greater(t, adjusted.Replicas, c.Replicas)
+	require.LessOrEqual(t, adjusted.CPULimitMillicores, defaultMaxCPUMillicoresPerReplica)
+	require.LessOrEqual(t, adjusted.MemoryLimitGB, defaultMaxMemoryGBPerReplica)
+
+	// Total capacity and cost are preserved across the split.
+	require.InDelta(t, c.MemoryLimitGB*float64(c.Replicas), adjusted.MemoryLimitGB*float64(adjusted.Replicas), 0.001)
+	require.Equal(t, c.CPULimitMillicores*c.Replicas, adjusted.CPULimitMillicores*adjusted.Replicas)
+	require.Equal(t, c.MonthlyCostUSD, adjusted.MonthlyCostUSD)
+}
+
+func Test_checkReplicaBounds_NoAutoSplitWarnsOnly(t *testing.T) {
+	c := newComponent("querier", 1, 32, 700, 4000, 96000, 0, DiskClassStandard)
+
+	adjusted, warning := checkReplicaBounds(c, defaultMaxCPUMillicoresPerReplica, defaultMaxMemoryGBPerReplica, false)
+
+	require.NotEmpty(t, warning)
+	require.Equal(t, c, adjusted)
+}
+
+func Test_SizeCluster_AutoSplitsOversizedQuerier(t *testing.T) {
+	r := SizeCluster(SizeInputs{
+		IngestMBPerSec:    10,
+		RetentionDays:     30,
+		CacheBackend:      CacheBackendEmbedded,
+		CacheWorkingSetGB: 2000,
+	})
+
+	var querier Component
+	for _, c := range r.Components {
+		if c.Name == "querier" {
+			querier = c
+		}
+	}
+	require.LessOrEqual(t, querier.MemoryLimitGB, defaultMaxMemoryGBPerReplica)
+	require.Greater(t, querier.Replicas, 1)
+	require.Condition(t, func() bool {
+		for _, w := range r.Warnings {
+			if len(w) > 0 {
+				return true
+			}
+		}
+		return false
+	}, "expected a warning about the auto-split")
+}
+
+func Test_SizeCluster_NoAutoSplitWarnsWithoutChangingReplicas(t *testing.T) {
+	r := SizeCluster(SizeInputs{
+		IngestMBPerSec:    10,
+		RetentionDays:     30,
+		CacheBackend:      CacheBackendEmbedded,
+		CacheWorkingSetGB: 2000,
+		DisableAutoSplit:  true,
+	})
+
+	var querier Component
+	for _, c := range r.Components {
+		if c.Name == "querier" {
+			querier = c
+		}
+	}
+	require.Greater(t, querier.MemoryLimitGB, defaultMaxMemoryGBPerReplica)
+	require.Equal(t, 1, querier.Replicas)
+	require.NotEmpty(t, r.Warnings)
+}
@@ -0,0 +1,67 @@
+package sizing
+
+// ResourceTotals is a bundle of resource quantities that scale linearly
+// with replica count: memory, CPU, and disk (broken down by DiskClass,
+// since SizeCluster prices and reports SSD- and standard-class disk
+// separately). It exists so SizeCluster's aggregate totals can be built by
+// summing Component.Resources() with Add rather than a hand-written loop
+// that adds one field per call site — the latter is what let the totals
+// loop silently miss a field in the past when Component grew one.
+//
+// It's named ResourceTotals rather than Resources to avoid colliding with
+// the Helm values Resources type in helm.go, which shapes Kubernetes
+// requests/limits rather than cluster-wide sums.
+type ResourceTotals struct {
+	MemoryGB       float64
+	CPUMillicores  int
+	SSDDiskGB      float64
+	StandardDiskGB float64
+}
+
+// Add returns the element-wise sum of r and other.
+func (r ResourceTotals) Add(other ResourceTotals) ResourceTotals {
+	return ResourceTotals{
+		MemoryGB:       r.MemoryGB + other.MemoryGB,
+		CPUMillicores:  r.CPUMillicores + other.CPUMillicores,
+		SSDDiskGB:      r.SSDDiskGB + other.SSDDiskGB,
+		StandardDiskGB: r.StandardDiskGB + other.StandardDiskGB,
+	}
+}
+
+// Scale returns r with every field multiplied by factor, rounding
+// CPUMillicores to the nearest millicore. Used to turn a component's
+// per-replica ResourceTotals into its total across all of its replicas.
+func (r ResourceTotals) Scale(factor float64) ResourceTotals {
+	return ResourceTotals{
+		MemoryGB:       r.MemoryGB * factor,
+		CPUMillicores:  int(float64(r.CPUMillicores)*factor + 0.5),
+		SSDDiskGB:      r.SSDDiskGB * factor,
+		StandardDiskGB: r.StandardDiskGB * factor,
+	}
+}
+
+// Resources returns c's total resource contribution across all of its
+// replicas (its per-replica limits scaled by Replicas), for summing into a
+// cluster-wide ResourceTotals with Add.
+func (c Component) Resources() ResourceTotals {
+	perReplica := ResourceTotals{
+		MemoryGB:      c.MemoryLimitGB,
+		CPUMillicores: c.CPULimitMillicores,
+	}
+	if c.DiskClass == DiskClassStandard {
+		perReplica.StandardDiskGB = c.DiskGB
+	} else {
+		perReplica.SSDDiskGB = c.DiskGB
+	}
+	return perReplica.Scale(float64(c.Replicas))
+}
+
+// totalResources sums Resources() across every component, for
+// SizeCluster's aggregate ClusterResources fields.
+func totalResources(components []Component) ResourceTotals {
+	var totals ResourceTotals
+	for _, c := range components {
+		totals = totals.Add(c.Resources())
+	}
+	return totals
+}
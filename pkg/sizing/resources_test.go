@@ -0,0 +1,81 @@
+package sizing
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ResourceTotals_Add(t *testing.T) {
+	a := ResourceTotals{MemoryGB: 4, CPUMillicores: 1000, SSDDiskGB: 50, StandardDiskGB: 20}
+	b := ResourceTotals{MemoryGB: 2, CPUMillicores: 500, SSDDiskGB: 10, StandardDiskGB: 5}
+
+	require.Equal(t, ResourceTotals{MemoryGB: 6, CPUMillicores: 1500, SSDDiskGB: 60, StandardDiskGB: 25}, a.Add(b))
+}
+
+func Test_ResourceTotals_Scale(t *testing.T) {
+	r := ResourceTotals{MemoryGB: 4, CPUMillicores: 1000, SSDDiskGB: 50, StandardDiskGB: 20}
+
+	require.Equal(t, ResourceTotals{MemoryGB: 12, CPUMillicores: 3000, SSDDiskGB: 150, StandardDiskGB: 60}, r.Scale(3))
+}
+
+func Test_Component_Resources(t *testing.T) {
+	ssd := newComponent("ingester", 3, 4, 8, 1000, 2000, 50, DiskClassSSD)
+	require.Equal(t, ResourceTotals{MemoryGB: 24, CPUMillicores: 6000, SSDDiskGB: 150}, ssd.Resources())
+
+	standard := newComponent("querier", 2, 2, 4, 1000, 2000, 0, DiskClassStandard)
+	require.Equal(t, ResourceTotals{MemoryGB: 8, CPUMillicores: 4000, StandardDiskGB: 0}, standard.Resources())
+}
+
+// Test_totalResources_MatchesManualSum is a property-based test checking
+// that totalResources, which builds a cluster-wide ResourceTotals out of
+// Component.Resources and Add, agrees with a naive per-replica
+// accumulation over the same random components, for any mix of replica
+// counts, resource sizes, and disk classes.
+func Test_totalResources_MatchesManualSum(t *testing.T) {
+	const eps = 1e-6
+
+	f := func(seed uint32) bool {
+		rng := rand.New(rand.NewSource(int64(seed)))
+		components := make([]Component, rng.Intn(10)+1)
+		for i := range components {
+			diskClass := DiskClassSSD
+			if rng.Intn(2) == 0 {
+				diskClass = DiskClassStandard
+			}
+			components[i] = Component{
+				Replicas:           rng.Intn(10) + 1,
+				MemoryLimitGB:      rng.Float64() * 100,
+				CPULimitMillicores: rng.Intn(8000),
+				DiskGB:             rng.Float64() * 500,
+				DiskClass:          diskClass,
+			}
+		}
+
+		var want ResourceTotals
+		for _, c := range components {
+			perReplica := ResourceTotals{MemoryGB: c.MemoryLimitGB, CPUMillicores: c.CPULimitMillicores}
+			if c.DiskClass == DiskClassStandard {
+				perReplica.StandardDiskGB = c.DiskGB
+			} else {
+				perReplica.SSDDiskGB = c.DiskGB
+			}
+			for r := 0; r < c.Replicas; r++ {
+				want = want.Add(perReplica)
+			}
+		}
+
+		got := totalResources(components)
+		return math.Abs(want.MemoryGB-got.MemoryGB) < eps &&
+			want.CPUMillicores == got.CPUMillicores &&
+			math.Abs(want.SSDDiskGB-got.SSDDiskGB) < eps &&
+			math.Abs(want.StandardDiskGB-got.StandardDiskGB) < eps
+	}
+
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,129 @@
+package sizing
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// summarySigFigs is the number of significant figures HumanizedSummary and
+// MachineSummaryJSON round every figure to. A fixed number of decimal
+// places would make a small plan's numbers look falsely precise (e.g.
+// "0.03TB") and a large plan's numbers unreadable (e.g. "1234567.89"), so
+// figures are rounded by magnitude instead.
+const summarySigFigs = 2
+
+// summaryFigures holds the headline resource and cost figures shared by
+// HumanizedSummary and MachineSummaryJSON, rounded exactly once so the two
+// outputs can never report different numbers for the same Plan.
+type summaryFigures struct {
+	IngestMBPerSec float64 `json:"ingest_mb_per_sec"`
+	RetentionDays  int     `json:"retention_days"`
+	Nodes          float64 `json:"nodes"`
+	CPUs           float64 `json:"cpus"`
+	MemoryGB       float64 `json:"memory_gb"`
+	DiskTB         float64 `json:"disk_tb"`
+	ObjStorageTB   float64 `json:"obj_storage_tb"`
+	MonthlyCostUSD float64 `json:"monthly_cost_usd"`
+}
+
+func (p Plan) summarize() summaryFigures {
+	return summaryFigures{
+		IngestMBPerSec: roundSigFigs(p.Inputs.IngestMBPerSec, summarySigFigs),
+		RetentionDays:  p.Inputs.RetentionDays,
+		Nodes:          roundSigFigs(float64(p.Resources.Nodes), summarySigFigs),
+		CPUs:           roundSigFigs(float64(p.Resources.CPUs), summarySigFigs),
+		MemoryGB:       roundSigFigs(float64(p.Resources.MemoryGB), summarySigFigs),
+		DiskTB:         roundSigFigs(p.Resources.DiskTB, summarySigFigs),
+		ObjStorageTB:   roundSigFigs(p.Resources.ObjStorageTB, summarySigFigs),
+		MonthlyCostUSD: roundSigFigs(p.Resources.TotalMonthlyCostUSD(), summarySigFigs),
+	}
+}
+
+// HumanizedSummary returns a single paragraph summarizing the plan's
+// headline resource and cost figures, meant for pasting into Slack or a PR
+// description in place of the whole report. See MachineSummaryJSON for the
+// same figures in a script-friendly form; both are derived from the same
+// rounded summaryFigures, so their numbers can't diverge.
+func (p Plan) HumanizedSummary() string {
+	f := p.summarize()
+	return fmt.Sprintf(
+		"To ingest %s MB/s with %d-day retention you need ~%s nodes (%s CPU, %s GB RAM), %s TB of disk and %s TB of object storage, costing about $%s/month at peak.",
+		formatSigFig(f.IngestMBPerSec), f.RetentionDays, formatSigFig(f.Nodes), formatSigFig(f.CPUs),
+		formatSigFig(f.MemoryGB), formatSigFig(f.DiskTB), formatSigFig(f.ObjStorageTB), formatWithCommas(f.MonthlyCostUSD),
+	)
+}
+
+// MachineSummaryJSON returns the same figures as HumanizedSummary, rounded
+// identically, as a single-line JSON object for chatops tooling that wants
+// to parse the numbers rather than scrape the sentence.
+func (p Plan) MachineSummaryJSON() (string, error) {
+	b, err := json.Marshal(p.summarize())
+	if err != nil {
+		return "", fmt.Errorf("marshaling plan summary: %w", err)
+	}
+	return string(b), nil
+}
+
+// roundSigFigs rounds x to n significant figures, e.g. roundSigFigs(4321.87,
+// 2) is 4300 and roundSigFigs(0.0347, 2) is 0.035.
+func roundSigFigs(x float64, n int) float64 {
+	if x == 0 || math.IsNaN(x) || math.IsInf(x, 0) {
+		return x
+	}
+
+	neg := x < 0
+	if neg {
+		x = -x
+	}
+
+	magnitude := math.Pow(10, float64(n)-math.Ceil(math.Log10(x)))
+	rounded := math.Round(x*magnitude) / magnitude
+
+	if neg {
+		rounded = -rounded
+	}
+	return rounded
+}
+
+// formatSigFig formats a value already rounded by roundSigFigs, dropping a
+// trailing ".0" for whole numbers (e.g. "9", "220") but keeping the decimal
+// otherwise (e.g. "1.2").
+func formatSigFig(x float64) string {
+	if x == math.Trunc(x) {
+		return strconv.FormatFloat(x, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
+
+// formatWithCommas formats a value already rounded by roundSigFigs with
+// thousands separators, the way a dollar figure is conventionally written
+// (e.g. "4,300").
+func formatWithCommas(x float64) string {
+	s := formatSigFig(x)
+
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+
+	var grouped strings.Builder
+	for i, d := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(d)
+	}
+
+	out := grouped.String()
+	if hasFrac {
+		out += "." + fracPart
+	}
+	if neg {
+		out = "-" + out
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package sizing
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_roundSigFigs(t *testing.T) {
+	tests := map[string]struct {
+		x    float64
+		n    int
+		want float64
+	}{
+		"example from the request":  {4321.87, 2, 4300},
+		"small fraction":            {0.0347, 2, 0.035},
+		"already exact":             {64, 2, 64},
+		"single digit rounds up":    {8.7, 2, 8.7},
+		"power of ten":              {1000, 2, 1000},
+		"negative value":            {-4321.87, 2, -4300},
+		"zero is unchanged":         {0, 2, 0},
+		"three significant figures": {123456, 3, 123000},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.InDelta(t, tt.want, roundSigFigs(tt.x, tt.n), 1e-9)
+		})
+	}
+}
+
+func Test_formatSigFig(t *testing.T) {
+	require.Equal(t, "9", formatSigFig(9))
+	require.Equal(t, "220", formatSigFig(220))
+	require.Equal(t, "1.2", formatSigFig(1.2))
+}
+
+func Test_formatWithCommas(t *testing.T) {
+	require.Equal(t, "4,300", formatWithCommas(4300))
+	require.Equal(t, "64", formatWithCommas(64))
+	require.Equal(t, "1,200,000", formatWithCommas(1200000))
+	require.Equal(t, "-4,300", formatWithCommas(-4300))
+	require.Equal(t, "4,300.5", formatWithCommas(4300.5))
+}
+
+// Test_PlanSummary_ConsistentAcrossMagnitudes checks HumanizedSummary and
+// MachineSummaryJSON against each other, rather than against a hand-picked
+// expected sentence, since the exact figures depend on the sizing model's
+// internals. What matters for a chatops summary is that the two output
+// modes can never disagree, at every order of magnitude a plan can be.
+func Test_PlanSummary_ConsistentAcrossMagnitudes(t *testing.T) {
+	tests := map[string]SizeInputs{
+		"tiny cluster":  {IngestMBPerSec: 0.5, RetentionDays: 7},
+		"small cluster": {IngestMBPerSec: 5, RetentionDays: 14},
+		"medium cluster (request example)": {IngestMBPerSec: 45, RetentionDays: 30},
+		"large cluster": {IngestMBPerSec: 5000, RetentionDays: 90},
+		"huge cluster":  {IngestMBPerSec: 500000, RetentionDays: 365},
+	}
+
+	for name, in := range tests {
+		t.Run(name, func(t *testing.T) {
+			plan := NewPlan(in)
+
+			sentence := plan.HumanizedSummary()
+			require.True(t, strings.HasPrefix(sentence, "To ingest "))
+			require.Contains(t, sentence, "nodes")
+			require.Contains(t, sentence, "costing about $")
+
+			machine, err := plan.MachineSummaryJSON()
+			require.NoError(t, err)
+			require.False(t, strings.Contains(machine, "\n"), "machine summary must be a single line")
+
+			var got summaryFigures
+			require.NoError(t, json.Unmarshal([]byte(machine), &got))
+
+			require.Contains(t, sentence, formatSigFig(got.IngestMBPerSec))
+			require.Contains(t, sentence, formatSigFig(got.Nodes))
+			require.Contains(t, sentence, formatSigFig(got.CPUs))
+			require.Contains(t, sentence, formatSigFig(got.MemoryGB))
+			require.Contains(t, sentence, formatSigFig(got.DiskTB))
+			require.Contains(t, sentence, formatSigFig(got.ObjStorageTB))
+			require.Contains(t, sentence, formatWithCommas(got.MonthlyCostUSD))
+
+			// Regenerating the summary from the same inputs must reproduce
+			// the exact same figures, so a chatops bot posting -summary
+			// twice for the same plan never shows conflicting numbers.
+			again := NewPlan(in)
+			machineAgain, err := again.MachineSummaryJSON()
+			require.NoError(t, err)
+			require.Equal(t, machine, machineAgain)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package sizing
+
+import "math"
+
+// tenantOverheadCoefficient scales how strongly SizeInputs.TenantCount
+// grows the ingester and compactor memory footprint; see
+// tenantOverheadFactor. Chosen empirically from a handful of multi-tenant
+// clusters we've sized by hand: overhead is dominated by the marginal
+// TSDB index and per-tenant rate-limit bookkeeping added by each new
+// tenant, which flattens out quickly rather than growing linearly, hence
+// the log10 curve rather than a per-tenant additive cost.
+const tenantOverheadCoefficient = 0.01
+
+// tenantOverheadFactor returns the memory multiplier SizeCluster applies
+// to the ingester and compactor components to account for per-tenant
+// overhead: separate TSDB indexes, per-tenant rate limit tracking, and
+// more frequent compaction as tenant count grows. It's 1 (no overhead)
+// for a single-tenant cluster or an unset tenantCount, and grows as
+// 1 + tenantOverheadCoefficient * log10(tenantCount) beyond that, so
+// doubling the tenant count from 10 to 20 adds much less overhead than
+// going from 1 to 10 did.
+func tenantOverheadFactor(tenantCount int) float64 {
+	if tenantCount <= 1 {
+		return 1
+	}
+	return 1 + tenantOverheadCoefficient*math.Log10(float64(tenantCount))
+}
+
+// applyTenantOverhead returns a copy of c with its memory limits/requests
+// (and the cost derived from them) scaled by factor, along with the
+// portion of the memory increase factor contributed. It's a no-op when
+// factor is 1.
+func applyTenantOverhead(c Component, factor float64) Component {
+	if factor == 1 {
+		return c
+	}
+	scaled := c
+	scaled.MemoryRequestGB = c.MemoryRequestGB * factor
+	scaled.MemoryLimitGB = c.MemoryLimitGB * factor
+	scaled.MonthlyCostUSD = float64(c.Replicas) * (float64(c.CPULimitMillicores)/1000*costPerCPUCoreMonth +
+		scaled.MemoryLimitGB*costPerMemGBMonth +
+		c.DiskGB*costPerDiskGBMonth(c.DiskClass))
+	return scaled
+}
@@ -0,0 +1,57 @@
+package sizing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tenantOverheadFactor(t *testing.T) {
+	require.Equal(t, 1.0, tenantOverheadFactor(0))
+	require.Equal(t, 1.0, tenantOverheadFactor(1))
+	require.InDelta(t, 1+tenantOverheadCoefficient*math.Log10(10), tenantOverheadFactor(10), 0.0001)
+	require.InDelta(t, 1+tenantOverheadCoefficient*math.Log10(100), tenantOverheadFactor(100), 0.0001)
+
+	// Overhead flattens out as tenant count grows: doubling from 50 to 100
+	// tenants adds less overhead than going from 1 to 50 did.
+	require.Less(t, tenantOverheadFactor(100)-tenantOverheadFactor(50), tenantOverheadFactor(50)-tenantOverheadFactor(1))
+}
+
+func Test_applyTenantOverhead(t *testing.T) {
+	c := newComponent("compactor", 1, 4, 8, 1000, 2000, 100, DiskClassSSD)
+
+	scaled := applyTenantOverhead(c, tenantOverheadFactor(10))
+
+	require.InDelta(t, 8*tenantOverheadFactor(10), scaled.MemoryLimitGB, 0.0001)
+	require.InDelta(t, 4*tenantOverheadFactor(10), scaled.MemoryRequestGB, 0.0001)
+	require.Greater(t, scaled.MonthlyCostUSD, c.MonthlyCostUSD)
+
+	// Disk, replicas, and CPU are untouched by tenant overhead.
+	require.Equal(t, c.DiskGB, scaled.DiskGB)
+	require.Equal(t, c.Replicas, scaled.Replicas)
+	require.Equal(t, c.CPULimitMillicores, scaled.CPULimitMillicores)
+
+	require.Equal(t, c, applyTenantOverhead(c, 1))
+}
+
+func Test_SizeCluster_TenantOverhead(t *testing.T) {
+	base := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30})
+	withTenants := SizeCluster(SizeInputs{IngestMBPerSec: 60, RetentionDays: 30, TenantCount: 50})
+
+	componentByName := func(r ClusterResources, name string) Component {
+		for _, c := range r.Components {
+			if c.Name == name {
+				return c
+			}
+		}
+		t.Fatalf("no %s component in plan", name)
+		return Component{}
+	}
+
+	require.Greater(t, componentByName(withTenants, "ingester").MemoryLimitGB, componentByName(base, "ingester").MemoryLimitGB)
+	require.Greater(t, componentByName(withTenants, "compactor").MemoryLimitGB, componentByName(base, "compactor").MemoryLimitGB)
+
+	// Other components are unaffected by TenantCount.
+	require.Equal(t, componentByName(base, "distributor"), componentByName(withTenants, "distributor"))
+}
@@ -0,0 +1,42 @@
+package sizing
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TFVarsJSON returns p as a flat JSON tfvars document: node_count,
+// object_storage_gb, and per-component <name>_replica_count and
+// <name>_volume_size_gb variables, for a Terraform module that takes node
+// counts, instance types, and volume sizes as input. Terraform's
+// tfvars.json format is a flat map of variable name to value, not a nested
+// object, hence the map return type here rather than a struct like
+// summaryFigures.
+//
+// Every variable name is prefixed with varPrefix (e.g. "loki_" produces
+// "loki_node_count"), so a caller can namespace the generated variables
+// against whatever else the target module already defines. Unlike
+// HumanizedSummary and MachineSummaryJSON, values here aren't rounded to a
+// handful of significant figures: a Terraform module provisions exactly
+// what it's told, so tfvars carries the plan's raw numbers.
+//
+// instance_type isn't emitted: SizeCluster has no node-shape/instance-type
+// recommendation model to source one from.
+func (p Plan) TFVarsJSON(varPrefix string) (string, error) {
+	vars := map[string]interface{}{
+		varPrefix + "node_count":        p.Resources.Nodes,
+		varPrefix + "object_storage_gb": p.Resources.ObjStorageTB * 1024,
+	}
+	for _, c := range p.Resources.Components {
+		name := strings.ReplaceAll(c.Name, "-", "_")
+		vars[varPrefix+name+"_replica_count"] = c.Replicas
+		vars[varPrefix+name+"_volume_size_gb"] = c.DiskGB
+	}
+
+	b, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling tfvars: %w", err)
+	}
+	return string(b), nil
+}
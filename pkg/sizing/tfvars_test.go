@@ -0,0 +1,44 @@
+package sizing
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Plan_TFVarsJSON(t *testing.T) {
+	plan := NewPlan(SizeInputs{IngestMBPerSec: 45, RetentionDays: 30, CacheBackend: CacheBackendEmbedded, CacheWorkingSetGB: 8})
+
+	doc, err := plan.TFVarsJSON("")
+	require.NoError(t, err)
+
+	var vars map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(doc), &vars))
+
+	require.InDelta(t, float64(plan.Resources.Nodes), vars["node_count"], 1e-9)
+	require.InDelta(t, plan.Resources.ObjStorageTB*1024, vars["object_storage_gb"], 1e-9)
+	require.NotContains(t, vars, "instance_type")
+
+	require.NotEmpty(t, plan.Resources.Components)
+	for _, c := range plan.Resources.Components {
+		name := strings.ReplaceAll(c.Name, "-", "_")
+		require.InDelta(t, float64(c.Replicas), vars[name+"_replica_count"], 1e-9)
+		require.InDelta(t, c.DiskGB, vars[name+"_volume_size_gb"], 1e-9)
+	}
+}
+
+func Test_Plan_TFVarsJSON_VarPrefix(t *testing.T) {
+	plan := NewPlan(SizeInputs{IngestMBPerSec: 45, RetentionDays: 30})
+
+	doc, err := plan.TFVarsJSON("loki_")
+	require.NoError(t, err)
+
+	var vars map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(doc), &vars))
+
+	require.Contains(t, vars, "loki_node_count")
+	require.Contains(t, vars, "loki_object_storage_gb")
+	require.NotContains(t, vars, "node_count")
+}
@@ -0,0 +1,18 @@
+package sizing
+
+// AlgorithmVersion is the semantic version of the sizing model implemented
+// by SizeCluster: the component list, the per-component resource formulas,
+// and the cost constants in component.go and cache.go. It is embedded in
+// every ClusterResources so that a plan produced today can be told apart
+// from one produced under a different model.
+//
+// Bump the minor version for a change that alters the numbers SizeCluster
+// returns for existing inputs (a new cost constant, a different replica
+// formula, a new component). Bump the major version if SizeInputs or
+// ClusterResources change shape in a way that breaks callers. Bump the
+// patch version for changes that can't affect SizeCluster's output at all
+// (comments, refactors, new fields that default to the old behavior).
+//
+// Any bump that changes SizeCluster's output requires regenerating the
+// golden fixtures under testdata/golden via `make sizing-goldens`.
+const AlgorithmVersion = "1.4.3"